@@ -0,0 +1,54 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The database-server command serves a database cache that can be shared by
+// many gapis instances with the --shared-database flag.
+package main
+
+import (
+	"context"
+	"flag"
+	"net"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/app/auth"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/net/grpcutil"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/database/remote"
+	"google.golang.org/grpc"
+)
+
+var (
+	rpc    = flag.String("rpc", "localhost:8092", "TCP host:port of the server's RPC listener")
+	dir    = flag.String("dir", "shared-database", "Directory used to hold the shared database")
+	sizeMB = flag.Int("size", 16384, "Maximum size in megabytes of the database directory; 0 for unbounded")
+	token  = flag.String("write-token", "", "Token that gapis instances must present to share resolved values; leave empty to only share content-addressed records")
+)
+
+func main() {
+	app.ShortHelp = "database-server serves a database cache shared between gapis instances"
+	app.Run(run)
+}
+
+func run(ctx context.Context) error {
+	c, err := database.NewDiskCache(ctx, *dir, int64(*sizeMB)<<20)
+	if err != nil {
+		return err
+	}
+	log.I(ctx, "Serving on %s", *rpc)
+	return grpcutil.Serve(ctx, *rpc, func(ctx context.Context, listener net.Listener, server *grpc.Server) error {
+		return remote.Serve(ctx, server, c, auth.Token(*token))
+	})
+}
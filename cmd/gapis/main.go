@@ -37,7 +37,9 @@ import (
 	"github.com/google/gapid/core/os/file"
 	"github.com/google/gapid/core/text"
 	"github.com/google/gapid/gapir/client"
+	"github.com/google/gapid/gapis/capture"
 	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/database/remote"
 	"github.com/google/gapid/gapis/replay"
 	"github.com/google/gapid/gapis/server"
 	"github.com/google/gapid/gapis/service"
@@ -62,6 +64,11 @@ var (
 	adbPath          = flag.String("adb", "", "Path to the adb executable; leave empty to search the environment")
 	enableLocalFiles = flag.Bool("enable-local-files", false, "Allow clients to access local .gfxtrace files by path")
 	remoteSSHConfig  = flag.String("ssh-config", "", "_Path to an ssh config file for remote devices")
+	databaseDir      = flag.String("database-dir", "", "Directory used to persist resolved data between sessions; leave empty to hold data in memory only")
+	databaseSizeMB   = flag.Int("database-size", 4096, "Maximum size in megabytes of the database directory; 0 for unbounded")
+	streamingMB      = flag.Int("streaming-threshold", 512, "Size in megabytes of capture data above which commands are decoded on demand; 0 to always hold all commands in memory")
+	sharedDatabase   = flag.String("shared-database", "", "TCP host:port of a database-server to share resolved data with other gapis instances")
+	sharedDBToken    = flag.String("shared-database-token", "", "Write token of the database-server; leave empty to only share content-addressed records")
 )
 
 func main() {
@@ -94,12 +101,19 @@ func run(ctx context.Context) error {
 		adb.ADB = file.Abs(*adbPath)
 	}
 
+	capture.StreamingThreshold = int64(*streamingMB) << 20
+
 	r := bind.NewRegistry()
 	ctx = bind.PutRegistry(ctx, r)
 	m := replay.New(ctx)
 	ctx = replay.PutManager(ctx, m)
 	ctx = trace.PutManager(ctx, trace.New(ctx))
-	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	db, closeDB, err := newDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	ctx = database.Put(ctx, db)
 
 	grpclog.SetLogger(log.From(ctx))
 
@@ -152,6 +166,46 @@ func run(ctx context.Context) error {
 	})
 }
 
+// newDatabase returns the database to use for the server, backed by the disk
+// and shared caches requested by the command line flags, along with a function
+// to release the database's resources.
+func newDatabase(ctx context.Context) (database.Database, func(), error) {
+	caches := []database.Cache{}
+	closers := []func(){}
+	closeAll := func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+
+	// The caches are consulted in order, so the local disk goes first.
+	if *databaseDir != "" {
+		c, err := database.NewDiskCache(ctx, *databaseDir, int64(*databaseSizeMB)<<20)
+		if err != nil {
+			return nil, nil, err
+		}
+		caches = append(caches, c)
+	}
+	if *sharedDatabase != "" {
+		c, close, err := remote.Dial(ctx, *sharedDatabase, auth.Token(*sharedDBToken))
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		caches = append(caches, c)
+		closers = append(closers, close)
+	}
+
+	switch len(caches) {
+	case 0:
+		return database.NewInMemory(ctx), closeAll, nil
+	case 1:
+		return database.NewCached(ctx, caches[0]), closeAll, nil
+	default:
+		return database.NewCached(ctx, database.NewTieredCache(caches...)), closeAll, nil
+	}
+}
+
 func monitorAndroidDevices(ctx context.Context, r *bind.Registry, scanDone func()) {
 	// Populate the registry with all the existing devices.
 	func() {
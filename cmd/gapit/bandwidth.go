@@ -0,0 +1,89 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type bandwidthVerb struct{ BandwidthFlags }
+
+func init() {
+	verb := &bandwidthVerb{
+		BandwidthFlags: BandwidthFlags{
+			Top:       5,
+			Resources: 20,
+		},
+	}
+	app.AddVerb(&app.Verb{
+		Name:      "bandwidth",
+		ShortHelp: "Prints the commands and resources of a capture that upload the most data",
+		Action:    verb,
+	})
+}
+
+func (verb *bandwidthVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if verb.Top < 0 || verb.Resources < 0 {
+		app.Usage(ctx, "Top and Resources must not be negative")
+		return nil
+	}
+
+	client, capture, err := loadCapture(ctx, flags, verb.Gapis)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	boxedVal, err := client.Get(ctx, (&path.Bandwidth{
+		Capture: capture,
+		Top:     uint32(verb.Top),
+	}).Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to analyze the capture's bandwidth")
+	}
+	bandwidth := boxedVal.(*service.Bandwidth)
+	if verb.Resources > 0 && len(bandwidth.Resources) > verb.Resources {
+		bandwidth.Resources = bandwidth.Resources[:verb.Resources]
+	}
+
+	if verb.Format != TextFormat {
+		return verb.Format.writeMessage(os.Stdout, bandwidth)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Frame\tCommand\tName\tBytes\tObserved bytes")
+	for _, f := range bandwidth.Frames {
+		fmt.Fprintf(w, "%v\t\t\t%v\t%v\n", f.Frame, f.Bytes, f.ObservedBytes)
+		for _, c := range f.Commands {
+			fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\n", c.Command.Indices, c.Name, c.Bytes, c.ObservedBytes)
+		}
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "Kind\tResource\tBytes\tUploads")
+	for _, r := range bandwidth.Resources {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", r.Kind, r.Name, r.Bytes, r.Uploads)
+	}
+	return w.Flush()
+}
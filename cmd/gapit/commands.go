@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/event/task"
@@ -93,6 +94,37 @@ func (verb *commandsVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 
 	tree := boxedTree.(*service.CommandTree)
 
+	var start uint64
+	if verb.Timing {
+		boxedCapture, err := client.Get(ctx, c.Path(), nil)
+		if err != nil {
+			return log.Err(ctx, err, "Failed to load the capture")
+		}
+		start = boxedCapture.(*service.Capture).StartTime
+	}
+
+	out := messageStream{w: os.Stdout, format: verb.Format}
+	printNode := func(n *service.CommandTreeNode, prefix string) error {
+		if verb.Format != TextFormat {
+			// Groups and commands are written as a flat stream, in
+			// pre-order. Their command ranges give the tree structure.
+			if err := out.writeValue(n); err != nil || n.Group != "" {
+				return err
+			}
+			cmd, err := getCommand(ctx, client, n.Commands.First())
+			if err != nil {
+				return err
+			}
+			return out.writeValue(cmd)
+		}
+		fmt.Fprintf(os.Stdout, prefix)
+		if n.Group != "" {
+			fmt.Fprintln(os.Stdout, n.Group)
+			return nil
+		}
+		return verb.printCommand(ctx, client, n.Commands.First(), start)
+	}
+
 	if verb.Name != "" {
 		req := &service.FindRequest{
 			From:    &service.FindRequest_CommandTreeNode{CommandTreeNode: tree.Root},
@@ -105,25 +137,30 @@ func (verb *commandsVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 			if err != nil {
 				return err
 			}
-			n := boxedNode.(*service.CommandTreeNode)
-
-			if n.Group != "" {
-				fmt.Fprintln(os.Stdout, n.Group)
-				return nil
-			}
-			return getAndPrintCommand(ctx, client, n.Commands.First(), verb.Observations)
+			return printNode(boxedNode.(*service.CommandTreeNode), "")
 		})
 		return nil
 	}
 
-	return traverseCommandTree(ctx, client, tree.Root, func(n *service.CommandTreeNode, prefix string) error {
-		fmt.Fprintf(os.Stdout, prefix)
-		if n.Group != "" {
-			fmt.Fprintln(os.Stdout, n.Group)
-			return nil
+	return traverseCommandTree(ctx, client, tree.Root, printNode, "", true)
+}
+
+// printCommand prints the command at p, preceded by its timing if requested.
+// The times are printed relative to the start of the trace.
+func (verb *commandsVerb) printCommand(ctx context.Context, client service.Service, p *path.Command, start uint64) error {
+	cmd, err := getCommand(ctx, client, p)
+	if err != nil {
+		return err
+	}
+	if verb.Timing {
+		if t := cmd.Timing; t != nil {
+			fmt.Fprintf(os.Stdout, "[%v +%v thread %d] ",
+				time.Duration(t.Begin-start), time.Duration(t.End-t.Begin), t.Thread)
+		} else {
+			fmt.Fprintf(os.Stdout, "[untimed] ")
 		}
-		return getAndPrintCommand(ctx, client, n.Commands.First(), verb.Observations)
-	}, "", true)
+	}
+	return printCommand(ctx, client, p, cmd, verb.Observations)
 }
 
 func traverseCommandTree(
@@ -37,6 +37,7 @@ import (
 	"github.com/google/gapid/gapis/memory"
 	"github.com/google/gapid/gapis/service"
 	"github.com/google/gapid/gapis/service/path"
+	"github.com/google/gapid/gapis/stringtable"
 )
 
 func (f CommandFilterFlags) commandFilter(ctx context.Context, client service.Service, p *path.Capture) (*path.CommandFilter, error) {
@@ -257,6 +258,24 @@ func getEvents(ctx context.Context, client service.Service, p *path.Events) ([]*
 	return b.(*service.Events).List, nil
 }
 
+// getStringTable returns the first string table available on the server, or
+// nil if there are none.
+func getStringTable(ctx context.Context, client service.Service) (*stringtable.StringTable, error) {
+	stringTables, err := client.GetAvailableStringTables(ctx)
+	if err != nil {
+		return nil, log.Err(ctx, err, "Failed get list of string tables")
+	}
+	if len(stringTables) == 0 {
+		return nil, nil
+	}
+	// TODO: Let the user pick the string table.
+	stringTable, err := client.GetStringTable(ctx, stringTables[0])
+	if err != nil {
+		return nil, log.Err(ctx, err, "Failed get string table")
+	}
+	return stringTable, nil
+}
+
 func getCommand(ctx context.Context, client service.Service, p *path.Command) (*api.Command, error) {
 	boxedCmd, err := client.Get(ctx, p.Path(), nil)
 	if err != nil {
@@ -0,0 +1,112 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/data/pack"
+	"github.com/google/gapid/core/log"
+)
+
+type compressVerb struct{ CompressFlags }
+type decompressVerb struct{ DecompressFlags }
+
+func init() {
+	compress := &compressVerb{}
+	compress.Codec = pack.Deflate.Name()
+	compress.Out = "compressed.gfxtrace"
+	app.AddVerb(&app.Verb{
+		Name:      "compress",
+		ShortHelp: "Compress the chunks of a trace file",
+		Action:    compress,
+	})
+	decompress := &decompressVerb{}
+	decompress.Out = "decompressed.gfxtrace"
+	app.AddVerb(&app.Verb{
+		Name:      "decompress",
+		ShortHelp: "Decompress a compressed trace file, so it can be read by older tools",
+		Action:    decompress,
+	})
+}
+
+func (verb *compressVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	codec := pack.FindCodec(verb.Codec)
+	if codec == nil {
+		names := []string{}
+		for _, c := range pack.Codecs() {
+			names = append(names, c.Name())
+		}
+		app.Usage(ctx, "Unknown codec '%v'. Supported codecs: %v", verb.Codec, strings.Join(names, ", "))
+		return nil
+	}
+	return transcode(ctx, flags, verb.Out, codec)
+}
+
+func (verb *decompressVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	return transcode(ctx, flags, verb.Out, nil)
+}
+
+// transcode writes the trace file given by flags to the file out, with its
+// chunks compressed with codec, or uncompressed if codec is nil.
+func transcode(ctx context.Context, flags flag.FlagSet, out string, codec pack.Codec) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one trace file expected, got %d", flags.NArg())
+		return nil
+	}
+	in, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return log.Errf(ctx, err, "Finding file: %v", flags.Arg(0))
+	}
+	if out, err = filepath.Abs(out); err != nil {
+		return log.Errf(ctx, err, "Finding file: %v", out)
+	}
+	if in == out {
+		app.Usage(ctx, "The output file must differ from the input file")
+		return nil
+	}
+
+	r, err := os.Open(in)
+	if err != nil {
+		return log.Errf(ctx, err, "Failed to open '%v'", in)
+	}
+	defer r.Close()
+
+	f, err := os.Create(out)
+	if err != nil {
+		return log.Errf(ctx, err, "Failed to create '%v'", out)
+	}
+	w := bufio.NewWriter(f)
+	err = pack.Transcode(ctx, bufio.NewReader(r), w, codec)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(out)
+		return log.Errf(ctx, err, "Failed to write '%v'", out)
+	}
+
+	log.I(ctx, "Capture written to: %v", out)
+	return nil
+}
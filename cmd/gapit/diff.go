@@ -0,0 +1,115 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type diffVerb struct{ DiffFlags }
+
+func init() {
+	verb := &diffVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "diff",
+		ShortHelp: "Prints the differences between two capture files",
+		Action:    verb,
+	})
+}
+
+func (verb *diffVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 2 {
+		app.Usage(ctx, "Exactly two gfx trace files expected, got %d", flags.NArg())
+		return nil
+	}
+
+	client, err := getGapis(ctx, verb.Gapis, GapirFlags{})
+	if err != nil {
+		return log.Err(ctx, err, "Failed to connect to the GAPIS server")
+	}
+	defer client.Close()
+
+	captures := make([]*path.Capture, 2)
+	for i := range captures {
+		filepath, err := filepath.Abs(flags.Arg(i))
+		if err != nil {
+			return log.Errf(ctx, err, "Finding file: %v", flags.Arg(i))
+		}
+		captures[i], err = client.LoadCapture(ctx, filepath)
+		if err != nil {
+			return log.Errf(ctx, err, "LoadCapture(%v)", filepath)
+		}
+	}
+
+	diff, err := client.DiffCaptures(ctx, captures[0], captures[1], nil)
+	if err != nil {
+		return log.Err(ctx, err, "Couldn't diff captures")
+	}
+
+	verb.print(os.Stdout, flags.Arg(0), flags.Arg(1), diff)
+	return nil
+}
+
+func (verb *diffVerb) print(w io.Writer, a, b string, diff *service.CaptureDiff) {
+	fmt.Fprintf(w, "--- %v\n+++ %v\n", a, b)
+
+	changed := 0
+	for _, f := range diff.Frames {
+		if f.Kind == service.DiffKind_Unchanged && !verb.Unchanged {
+			continue
+		}
+		changed++
+		fmt.Fprintf(w, "Frame %d: %v\n", f.Frame, f.Kind)
+		fmt.Fprintf(w, "  Draw calls: %d -> %d (%+d)\n", f.DrawCallsA, f.DrawCallsB, f.DrawCallsDelta)
+		fmt.Fprintf(w, "  Triangles: %d -> %d (%+d)\n", f.TrianglesA, f.TrianglesB, f.TrianglesDelta)
+		for _, c := range f.Commands {
+			switch c.Kind {
+			case service.DiffKind_Added:
+				fmt.Fprintf(w, "  + %v %v\n", c.B.Indices, c.Name)
+			case service.DiffKind_Removed:
+				fmt.Fprintf(w, "  - %v %v\n", c.A.Indices, c.Name)
+			case service.DiffKind_Changed:
+				fmt.Fprintf(w, "  ~ %v -> %v %v\n", c.A.Indices, c.B.Indices, c.Name)
+				for _, p := range c.Parameters {
+					fmt.Fprintf(w, "      %v: %v -> %v\n", p.Name, p.A.Get(), p.B.Get())
+				}
+			}
+		}
+	}
+	if changed == 0 {
+		fmt.Fprintln(w, "No frame differences")
+	}
+
+	for _, r := range diff.Resources {
+		switch r.Kind {
+		case service.DiffKind_Added:
+			fmt.Fprintf(w, "+ %v %v (%v)\n", r.Type, r.Handle, r.B)
+		case service.DiffKind_Removed:
+			fmt.Fprintf(w, "- %v %v (%v)\n", r.Type, r.Handle, r.A)
+		case service.DiffKind_Changed:
+			fmt.Fprintf(w, "~ %v %v (%v -> %v)\n", r.Type, r.Handle, r.A, r.B)
+		}
+	}
+}
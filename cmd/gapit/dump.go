@@ -19,11 +19,14 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"math"
+	"os"
 	"path/filepath"
 
 	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
 )
 
 type dumpVerb struct{ DumpFlags }
@@ -66,12 +69,20 @@ func (verb *dumpVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	}
 	c := boxedCapture.(*service.Capture)
 
-	boxedCommands, err := client.Get(ctx, cp.Commands().Path(), nil)
+	last := uint64(math.MaxUint64)
+	if verb.Count > 0 {
+		last = verb.At + verb.Count - 1
+	}
+	boxedCommands, err := client.Get(ctx, cp.CommandRange(verb.At, last).Path(), nil)
 	if err != nil {
 		return log.Err(ctx, err, "Failed to acquire the capture's commands")
 	}
 	commands := boxedCommands.(*service.Commands).List
 
+	if verb.Format != TextFormat {
+		return verb.writeMessages(ctx, client, c, commands)
+	}
+
 	if verb.ShowDeviceInfo {
 		dev, err := json.MarshalIndent(c.Device, "", "  ")
 		if err != nil {
@@ -100,3 +111,23 @@ func (verb *dumpVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 
 	return nil
 }
+
+// writeMessages writes the capture, which holds the device and ABI
+// information, if they were requested, or else the commands, as a stream of
+// messages in the output format.
+func (verb *dumpVerb) writeMessages(ctx context.Context, client service.Service, c *service.Capture, commands []*path.Command) error {
+	out := messageStream{w: os.Stdout, format: verb.Format}
+	if verb.ShowDeviceInfo || verb.ShowABIInfo {
+		return out.writeValue(c)
+	}
+	for _, p := range commands {
+		cmd, err := getCommand(ctx, client, p)
+		if err != nil {
+			return err
+		}
+		if err := out.writeValue(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -82,6 +82,10 @@ func (verb *pipeVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		return log.Err(ctx, err, "Failed to get bound pipeline resource data")
 	}
 
+	if verb.Format != TextFormat {
+		return verb.Format.writeMessage(os.Stdout, pipelineData)
+	}
+
 	return verb.printPipelineData(ctx, client, pipelineData)
 }
 
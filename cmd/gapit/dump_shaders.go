@@ -15,16 +15,21 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"flag"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 
 	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/image"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/os/file"
 	"github.com/google/gapid/gapis/api"
 	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
 )
 
 type dumpShadersVerb struct{ DumpShadersFlags }
@@ -32,12 +37,13 @@ type dumpShadersVerb struct{ DumpShadersFlags }
 func init() {
 	verb := &dumpShadersVerb{
 		DumpShadersFlags{
-			At: -1,
+			At:        -1,
+			Container: "png",
 		},
 	}
 	app.AddVerb(&app.Verb{
 		Name:      "dump_resources",
-		ShortHelp: "Dump all shaders at a particular command from a .gfxtrace",
+		ShortHelp: "Dump all shaders and textures at a particular command from a .gfxtrace",
 		Action:    verb,
 	})
 }
@@ -48,6 +54,12 @@ func (verb *dumpShadersVerb) Run(ctx context.Context, flags flag.FlagSet) error
 		return nil
 	}
 
+	writeTexture, ok := textureContainers[verb.Container]
+	if !ok {
+		app.Usage(ctx, "Unknown texture container '%s'", verb.Container)
+		return nil
+	}
+
 	filepath, err := filepath.Abs(flags.Arg(0))
 	if err != nil {
 		return log.Errf(ctx, err, "Could not find capture file '%s'", flags.Arg(0))
@@ -103,7 +115,84 @@ func (verb *dumpShadersVerb) Run(ctx context.Context, flags flag.FlagSet) error
 				f.WriteString(shaderSource)
 			}
 		}
+		if types.Type == api.ResourceType_TextureResource {
+			for _, v := range types.GetResources() {
+				if !v.ID.IsValid() {
+					log.E(ctx, "Got resource with invalid ID!\n%+v", v)
+					continue
+				}
+				resourcePath := capture.Command(uint64(verb.At)).ResourceAfter(v.ID)
+				resourceData, err := client.Get(ctx, resourcePath.Path(), nil)
+				if err != nil {
+					log.E(ctx, "Could not get data for texture: %v %v", v, err)
+					continue
+				}
+				tex, err := resourceData.(*api.ResourceData).GetTexture().ImageTexture(
+					func(i *image.Info) ([]byte, error) {
+						data, err := client.Get(ctx, path.NewBlob(i.Bytes.ID()).Path(), nil)
+						if err != nil {
+							return nil, err
+						}
+						return data.([]byte), nil
+					})
+				if err != nil {
+					log.E(ctx, "Could not load texture: %v %v", v, err)
+					continue
+				}
+				filename := file.SanitizePath(v.GetHandle()) + "." + verb.Container
+				if err := verb.writeTexture(ctx, filename, tex, writeTexture); err != nil {
+					log.E(ctx, "Could not write texture %s: %v", filename, err)
+				}
+			}
+		}
 	}
 
 	return nil
 }
+
+// textureContainers maps the --container flag values to the function that
+// writes a texture in that container.
+var textureContainers = map[string]func(io.Writer, *image.Texture) error{
+	"png":  writeBaseImage(image.PNG),
+	"exr":  writeBaseImage(image.EXR),
+	"hdr":  writeBaseImage(image.HDR),
+	"ktx":  image.WriteKTX,
+	"ktx2": image.WriteKTX2,
+	"dds":  image.WriteDDS,
+}
+
+// writeBaseImage returns a function that writes the base image of a texture
+// converted to the image file format f.
+func writeBaseImage(f *image.Format) func(io.Writer, *image.Texture) error {
+	return func(w io.Writer, t *image.Texture) error {
+		data, err := t.Image(0, 0, 0).Convert(f)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data.Bytes)
+		return err
+	}
+}
+
+// writeTexture writes the texture to the file filename using write. If the
+// container cannot hold the texture's format, the texture is converted to
+// RGBA_U8_NORM before writing.
+func (verb *dumpShadersVerb) writeTexture(ctx context.Context, filename string, t *image.Texture,
+	write func(io.Writer, *image.Texture) error) error {
+
+	buf := &bytes.Buffer{}
+	err := write(buf, t)
+	if err == image.ErrUnsupportedContainerFormat {
+		log.W(ctx, "%s cannot hold format %v, converting %s to %v",
+			verb.Container, t.Format.Name, filename, image.RGBA_U8_NORM.Name)
+		if t, err = t.Convert(image.RGBA_U8_NORM); err != nil {
+			return err
+		}
+		buf.Reset()
+		err = write(buf, t)
+	}
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, buf.Bytes(), 0666)
+}
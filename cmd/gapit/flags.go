@@ -34,6 +34,12 @@ const (
 	SimpleList
 )
 
+const (
+	TextFormat OutputFormat = iota
+	JSONFormat
+	PbtxtFormat
+)
+
 type VideoType uint8
 
 var videoTypeNames = map[VideoType]string{
@@ -66,10 +72,28 @@ func (v PackagesOutput) String() string {
 	return packagesOutputNames[v]
 }
 
+type OutputFormat uint8
+
+var outputFormatNames = map[OutputFormat]string{
+	TextFormat:  "text",
+	JSONFormat:  "json",
+	PbtxtFormat: "pbtxt",
+}
+
+func (v *OutputFormat) Choose(c interface{}) {
+	*v = c.(OutputFormat)
+}
+func (v OutputFormat) String() string {
+	return outputFormatNames[v]
+}
+
 type (
 	CommandFilterFlags struct {
 		Context int `help:"Filter to the i'th context."`
 	}
+	FormatFlags struct {
+		Format OutputFormat `help:"output format: text, json or pbtxt"`
+	}
 	ObservationFlags struct {
 		Ranges bool `help:"if true then display the read and write ranges made by each command."`
 		Data   bool `help:"if true then display the bytes read and written by each command. Implies Ranges."`
@@ -104,7 +128,31 @@ type (
 		Gapir            GapirFlags
 		Out              string `help:"output report path"`
 		DisplayToSurface bool   `help:"display the frames rendered in the replay back to the surface"`
+		Baseline         string `help:"file of known issues to suppress, as written by --writebaseline"`
+		WriteBaseline    string `help:"write all the issues found to this baseline file"`
+		JUnit            string `help:"write the issues as a JUnit XML report to this file"`
+		Sarif            string `help:"write the issues as a SARIF log to this file"`
+		FailOnWarnings   bool   `help:"exit with a non-zero status for new warnings, not only for new errors"`
+		Lint             bool   `help:"also report the calls that have no effect on the state"`
+		Unused           bool   `help:"also report the resources that are never used by a draw call or a framebuffer"`
 		CommandFilterFlags
+		FormatFlags
+	}
+	LintFlags struct {
+		Gapis GapisFlags
+		Calls bool `help:"list each redundant call, not only the counts"`
+		CommandFilterFlags
+	}
+	BandwidthFlags struct {
+		Gapis     GapisFlags
+		Top       int `help:"number of commands listed per frame, 0 for all"`
+		Resources int `help:"number of resources listed, 0 for all"`
+		FormatFlags
+	}
+	ShadersFlags struct {
+		Gapis GapisFlags
+		Top   int `help:"number of shaders listed, 0 for all"`
+		FormatFlags
 	}
 	VideoFlags struct {
 		Gapis GapisFlags
@@ -127,17 +175,21 @@ type (
 		CommandFilterFlags
 	}
 	DumpShadersFlags struct {
-		Gapis GapisFlags
-		Gapir GapirFlags
-		At    int `help:"command index to dump the resources after"`
+		Gapis     GapisFlags
+		Gapir     GapirFlags
+		At        int    `help:"command index to dump the resources after"`
+		Container string `help:"file container for textures: png, exr or hdr (base level only), ktx, ktx2 or dds"`
 	}
 	DumpFlags struct {
 		Gapis          GapisFlags
 		Gapir          GapirFlags
-		Raw            bool `help:"if true then the value of constants, instead of their names, will be dumped."`
-		ShowDeviceInfo bool `help:"if true then show originating device information."`
-		ShowABIInfo    bool `help:"if true then show information of the ABI used for the trace."`
+		Raw            bool   `help:"if true then the value of constants, instead of their names, will be dumped."`
+		ShowDeviceInfo bool   `help:"if true then show originating device information."`
+		ShowABIInfo    bool   `help:"if true then show information of the ABI used for the trace."`
+		At             uint64 `help:"index of the first command to dump"`
+		Count          uint64 `help:"number of commands to dump: 0 for all commands from At."`
 		Observations   ObservationFlags
+		FormatFlags
 	}
 	CommandsFlags struct {
 		Gapis                  GapisFlags
@@ -153,8 +205,18 @@ type (
 		GroupByUserMarkers     bool   `help:"Group commands by user markers"`
 		IncludeNoContextGroups bool   `help:"_Include no context groups"`
 		AllowIncompleteFrame   bool   `help:"_Make a group for incomplete frames"`
+		Timing                 bool   `help:"Print the CPU time at which each command was called and its duration"`
 		Observations           ObservationFlags
 		CommandFilterFlags
+		FormatFlags
+	}
+	MeshFlags struct {
+		Gapis   GapisFlags
+		Gapir   GapirFlags
+		At      flags.U64Slice `help:"command/subcommand index of the draw call"`
+		Format  string         `help:"output format: gltf (binary .glb) or obj"`
+		Out     string         `help:"output file (default 'mesh.glb' or 'mesh.obj')"`
+		Faceted bool           `help:"split shared vertices and use per-face normals"`
 	}
 	ReplaceResourceFlags struct {
 		Gapis                GapisFlags
@@ -171,6 +233,7 @@ type (
 		At     flags.U64Slice    `help:"command/subcommand index to get the state after. Empty for last"`
 		Depth  int               `help: "How many nodes deep should the state tree be displayed. -1 for all"`
 		Filter flags.StringSlice `help: "Which path through the tree should we filter to, default All"`
+		FormatFlags
 	}
 	StressTestFlags struct {
 		Gapis GapisFlags
@@ -196,6 +259,7 @@ type (
 		}
 		Record struct {
 			Errors bool `help:"_record device error state"`
+			Timing bool `help:"record the CPU time spent in each command"`
 		}
 		Clear struct {
 			Cache bool `help:"clear package data before running it"`
@@ -233,7 +297,7 @@ type (
 		Gapir      GapirFlags
 		At         flags.U64Slice `help:"command/subcommand index for the screenshot"`
 		Frame      int64          `help:"frame index for the screenshot. Empty for last"`
-		Out        string         `help:"output image file (default 'screenshot.png')"`
+		Out        string         `help:"output image file (default 'screenshot.png'). Use a .exr or .hdr extension to keep the full precision of float framebuffers"`
 		NoOpt      bool           `help:"disables optimization of the replay stream"`
 		Attachment int            `help:"the color attachment to show (0-3)"`
 		Overdraw   bool           `help:"renders the overdraw instead of the colour framebuffer"`
@@ -245,6 +309,8 @@ type (
 	}
 	UnpackFlags struct {
 		Verbose bool `help:"if true, then output will not be truncated"`
+		At      int  `help:"index of the first root object or group to display"`
+		Count   int  `help:"number of root objects or groups to display: 0 for all from At"`
 	}
 	StatsFlags struct {
 		Gapis  GapisFlags
@@ -252,10 +318,56 @@ type (
 			Start int `help:"frame to start stats from"`
 			Count int `help:"number of frames after Start to process: -1 for all frames"`
 		}
+		PerFrame bool `help:"print the draw, geometry, state change and upload statistics of each frame"`
+		CSV      bool `help:"with perframe, print the statistics of each frame as CSV"`
+		FormatFlags
 	}
-	MemoryFlags struct {
+	DiffFlags struct {
+		Gapis     GapisFlags
+		Unchanged bool `help:"also print the frames that have not changed"`
+	}
+	SplitFlags struct {
+		Gapis  GapisFlags
+		Thread bool   `help:"split by thread instead of by context"`
+		Out    string `help:"directory to write the new traces to"`
+	}
+	MergeFlags struct {
 		Gapis GapisFlags
-		At    flags.U64Slice `help:"command/subcommand index to get the memory after. Empty for last"`
+		Out   string `help:"output trace file (default 'merged.gfxtrace')"`
+	}
+	TrimFlags struct {
+		Gapis  GapisFlags
+		Frames struct {
+			Start int `help:"first frame to keep"`
+			Count int `help:"number of frames to keep: 0 for all frames after Start"`
+		}
+		Out string `help:"output trace file (default 'trimmed.gfxtrace')"`
+	}
+	CompressFlags struct {
+		Codec string `help:"compression codec of the chunks: 'deflate' (default) or the faster 'snappy'"`
+		Out   string `help:"output trace file (default 'compressed.gfxtrace')"`
+	}
+	DecompressFlags struct {
+		Out string `help:"output trace file (default 'decompressed.gfxtrace')"`
+	}
+	ValidateFlags struct {
+		Gapis   GapisFlags
+		Salvage string `help:"if the trace is damaged, write its intact commands to this trace file"`
+	}
+	ShellFlags struct {
+		Gapis GapisFlags
+	}
+	QueryFlags struct {
+		Gapis        GapisFlags
+		Max          int `help:"maximum number of matching commands to print, 0 for all"`
+		Observations ObservationFlags
+		FormatFlags
+	}
+	MemoryFlags struct {
+		Gapis    GapisFlags
+		At       flags.U64Slice `help:"command/subcommand index to get the memory after. Empty for last"`
+		Timeline bool           `help:"print the timeline of the allocations and frees of the whole capture instead"`
+		FormatFlags
 	}
 	PipelineFlags struct {
 		Gapis GapisFlags
@@ -264,5 +376,6 @@ type (
 			Shaders bool `help:"print the disassembled shaders along with the bound descriptor values"`
 		}
 		Compute bool `help:"print out the most recently bound compute pipeline instead of graphics pipeline"`
+		FormatFlags
 	}
 )
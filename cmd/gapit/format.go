@@ -0,0 +1,67 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/gapis/service"
+)
+
+// writeMessage writes msg to w in the format f, which must not be TextFormat.
+// JSON uses the proto field names, and includes the fields with default
+// values, so that the output is stable across versions.
+func (f OutputFormat) writeMessage(w io.Writer, msg proto.Message) error {
+	return f.write(w, msg, true)
+}
+
+// messageStream writes a sequence of proto messages to an output in a
+// machine readable format, one message per line.
+type messageStream struct {
+	w      io.Writer
+	format OutputFormat
+}
+
+// writeValue writes v, wrapped in a service.Value so that the type of each
+// message of the stream is recorded by the name of the set field.
+func (s messageStream) writeValue(v interface{}) error {
+	return s.format.write(s.w, service.NewValue(v), false)
+}
+
+func (f OutputFormat) write(w io.Writer, msg proto.Message, indent bool) error {
+	switch f {
+	case JSONFormat:
+		m := jsonpb.Marshaler{OrigName: true, EmitDefaults: true}
+		if indent {
+			m.Indent = "  "
+		}
+		if err := m.Marshal(w, msg); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(w)
+		return err
+	case PbtxtFormat:
+		if indent {
+			return proto.MarshalText(w, msg)
+		}
+		_, err := fmt.Fprintln(w, proto.CompactTextString(msg))
+		return err
+	default:
+		return fmt.Errorf("Unsupported message format '%v'", f)
+	}
+}
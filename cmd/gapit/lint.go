@@ -0,0 +1,150 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type lintVerb struct{ LintFlags }
+
+func init() {
+	verb := &lintVerb{
+		LintFlags: LintFlags{
+			CommandFilterFlags: CommandFilterFlags{
+				Context: -1,
+			},
+		},
+	}
+	app.AddVerb(&app.Verb{
+		Name:      "lint",
+		ShortHelp: "Counts the calls of a capture that have no effect on the state, per command and per frame",
+		Action:    verb,
+	})
+}
+
+// redundantCallMessage is the identifier of the message of the report items
+// raised for redundant calls.
+const redundantCallMessage = "WARN_REDUNDANT_CALL"
+
+func (verb *lintVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	client, capture, err := loadCapture(ctx, flags, verb.Gapis)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	stringTable, err := getStringTable(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	filter, err := verb.commandFilter(ctx, client, capture)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to build the CommandFilter")
+	}
+
+	boxedReport, err := client.Get(ctx, (&path.Report{
+		Capture: capture,
+		Filter:  filter,
+		Lint:    true,
+	}).Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to acquire the capture's report")
+	}
+	report := boxedReport.(*service.Report)
+
+	events, err := getEvents(ctx, client, &path.Events{
+		Capture:      capture,
+		FirstInFrame: true,
+	})
+	if err != nil {
+		return err
+	}
+	frameStarts := make([]uint64, len(events))
+	for i, e := range events {
+		frameStarts[i] = e.Command.Indices[0]
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+	perCommand, perFrame, total := map[string]int{}, map[int]int{}, 0
+	for _, raw := range report.RawItems() {
+		if raw.Message.Identifier != redundantCallMessage || raw.Item.Command == nil {
+			continue
+		}
+		idx := raw.Item.Command.Indices[0]
+		frame := sort.Search(len(frameStarts), func(i int) bool { return frameStarts[i] > idx }) - 1
+		if frame < 0 {
+			frame = 0
+		}
+		name := raw.CommandName()
+		perCommand[name]++
+		perFrame[frame]++
+		total++
+		if verb.Calls {
+			fmt.Fprintf(w, "%v\t%v\t%v\n", raw.Item.Command.Indices, name, raw.Message.Text(stringTable))
+		}
+	}
+	if verb.Calls && total > 0 {
+		fmt.Fprintln(w)
+	}
+
+	if total == 0 {
+		w.Flush()
+		fmt.Println("No redundant calls found")
+		return nil
+	}
+
+	// Commands are listed from the most to the least redundant.
+	names := make([]string, 0, len(perCommand))
+	for name := range perCommand {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if perCommand[names[i]] != perCommand[names[j]] {
+			return perCommand[names[i]] > perCommand[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	fmt.Fprintln(w, "Command\tRedundant calls")
+	for _, name := range names {
+		fmt.Fprintf(w, "%v\t%v\n", name, perCommand[name])
+	}
+	fmt.Fprintln(w)
+
+	frames := make([]int, 0, len(perFrame))
+	for frame := range perFrame {
+		frames = append(frames, frame)
+	}
+	sort.Ints(frames)
+	fmt.Fprintln(w, "Frame\tRedundant calls")
+	for _, frame := range frames {
+		fmt.Fprintf(w, "%v\t%v\n", frame, perFrame[frame])
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "%d redundant calls found\n", total)
+	return w.Flush()
+}
@@ -63,6 +63,10 @@ func (verb *memoryVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		return log.Errf(ctx, err, "LoadCapture(%v)", filepath)
 	}
 
+	if verb.Timeline {
+		return verb.timeline(ctx, client, capture)
+	}
+
 	if len(verb.At) == 0 {
 		boxedCapture, err := client.Get(ctx, capture.Path(), nil)
 		if err != nil {
@@ -84,6 +88,10 @@ func (verb *memoryVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		return log.Errf(ctx, err, "Loaded metrics do not have memory breakdown")
 	}
 
+	if verb.Format != TextFormat {
+		return verb.Format.writeMessage(os.Stdout, mem)
+	}
+
 	allocationFlags := []*service.Constant{}
 	if mem.AllocationFlagsIndex != -1 {
 		boxedConstants, err := client.Get(ctx, (&path.ConstantSet{
@@ -276,3 +284,51 @@ func (bindings bindingSlice) computeAliasing() []alias {
 
 	return aliases
 }
+
+// timeline prints the memory timeline of the capture.
+func (verb *memoryVerb) timeline(ctx context.Context, client service.Service, capture *path.Capture) error {
+	boxedVal, err := client.Get(ctx, (&path.Metrics{
+		Command:        capture.Command(0),
+		MemoryTimeline: true,
+	}).Path(), nil)
+	if err != nil {
+		return log.Errf(ctx, err, "Failed to load metrics")
+	}
+
+	timeline := boxedVal.(*api.Metrics).MemoryTimeline
+	if timeline == nil {
+		return log.Errf(ctx, nil, "Loaded metrics do not have memory timeline")
+	}
+
+	if verb.Format != TextFormat {
+		return verb.Format.writeMessage(os.Stdout, timeline)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Command\tEvent\tKind\tName\tSize\tTotal")
+	for _, e := range timeline.Events {
+		var event string
+		switch e.Kind {
+		case api.MemoryEventKind_MemoryAllocated:
+			event = "Allocated"
+		case api.MemoryEventKind_MemoryResized:
+			event = "Resized"
+		case api.MemoryEventKind_MemoryFreed:
+			event = "Freed"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n",
+			e.Command, event, e.Object.Kind, e.Object.Name, e.Object.Size, e.TotalSize)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Peak usage: %v bytes after command %v\n", timeline.PeakSize, timeline.PeakCommand)
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "%v objects alive at the end of the capture\n", len(timeline.Leaked))
+	if len(timeline.Leaked) > 0 {
+		fmt.Fprintln(w, "Allocated\tKind\tName\tSize")
+		for _, o := range timeline.Leaked {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", o.Allocated, o.Kind, o.Name, o.Size)
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/app/flags"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type meshVerb struct{ MeshFlags }
+
+func init() {
+	verb := &meshVerb{
+		MeshFlags{
+			At:     flags.U64Slice{},
+			Format: "gltf",
+		},
+	}
+
+	app.AddVerb(&app.Verb{
+		Name:      "mesh",
+		ShortHelp: "Exports the mesh of a draw call in a .gfxtrace file",
+		Action:    verb,
+	})
+}
+
+// meshFormats maps the --format flag values to the default output file
+// extension and the function that writes the mesh in that format.
+var meshFormats = map[string]struct {
+	ext   string
+	write func(*api.Mesh, io.Writer) error
+}{
+	"gltf": {".glb", (*api.Mesh).WriteGLB},
+	"obj":  {".obj", (*api.Mesh).WriteOBJ},
+}
+
+func (verb *meshVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+	if len(verb.At) == 0 {
+		app.Usage(ctx, "The command index of a draw call must be specified with --at")
+		return nil
+	}
+	format, ok := meshFormats[verb.Format]
+	if !ok {
+		app.Usage(ctx, "Unknown mesh format '%s'", verb.Format)
+		return nil
+	}
+	out := verb.Out
+	if out == "" {
+		out = "mesh" + format.ext
+	}
+
+	filepath, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return log.Errf(ctx, err, "Could not find capture file '%s'", flags.Arg(0))
+	}
+
+	client, err := getGapis(ctx, verb.Gapis, verb.Gapir)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to connect to the GAPIS server")
+	}
+	defer client.Close()
+
+	capture, err := client.LoadCapture(ctx, filepath)
+	if err != nil {
+		return log.Errf(ctx, err, "Failed to load the capture file '%v'", filepath)
+	}
+
+	cmd := capture.Command(verb.At[0], verb.At[1:]...)
+	boxedMesh, err := client.Get(ctx, cmd.Mesh(path.NewMeshOptions(verb.Faceted)).Path(), nil)
+	if err != nil {
+		return log.Errf(ctx, err, "Failed to get the mesh of command %v", verb.At)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := format.write(boxedMesh.(*api.Mesh), buf); err != nil {
+		return log.Errf(ctx, err, "Failed to write the mesh as %s", verb.Format)
+	}
+	return ioutil.WriteFile(out, buf.Bytes(), 0666)
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+)
+
+type queryVerb struct{ QueryFlags }
+
+func init() {
+	verb := &queryVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "query",
+		ShortHelp: "Prints the commands of a .gfxtrace file matching a query",
+		Action:    verb,
+	})
+}
+
+func (verb *queryVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() < 2 {
+		app.Usage(ctx, "Expected a gfx trace file and a query, e.g. 'glDrawElements where count > 10000'")
+		return nil
+	}
+
+	filepath, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return log.Err(ctx, err, "Could not find capture file")
+	}
+
+	client, err := getGapis(ctx, verb.Gapis, GapirFlags{})
+	if err != nil {
+		return log.Err(ctx, err, "Failed to connect to the GAPIS server")
+	}
+	defer client.Close()
+
+	c, err := client.LoadCapture(ctx, filepath)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to load the capture file")
+	}
+
+	boxedTree, err := client.Get(ctx, c.CommandTree(nil).Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to load the command tree")
+	}
+
+	req := &service.FindRequest{
+		From:     &service.FindRequest_CommandTreeNode{CommandTreeNode: boxedTree.(*service.CommandTree).Root},
+		Query:    strings.Join(flags.Args()[1:], " "),
+		MaxItems: uint32(verb.Max),
+	}
+	out := messageStream{w: os.Stdout, format: verb.Format}
+	err = client.Find(ctx, req, func(r *service.FindResponse) error {
+		boxedNode, err := client.Get(ctx, r.GetCommandTreeNode().Path(), nil)
+		if err != nil {
+			return err
+		}
+		p := boxedNode.(*service.CommandTreeNode).Commands.First()
+		cmd, err := getCommand(ctx, client, p)
+		if err != nil {
+			return err
+		}
+		if verb.Format != TextFormat {
+			return out.writeValue(cmd)
+		}
+		return printCommand(ctx, client, p, cmd, verb.Observations)
+	})
+	if err != nil {
+		return log.Err(ctx, err, "Query failed")
+	}
+	return nil
+}
@@ -25,7 +25,6 @@ import (
 	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/gapis/service"
-	"github.com/google/gapid/gapis/stringtable"
 )
 
 type reportVerb struct{ ReportFlags }
@@ -56,24 +55,28 @@ func (verb *reportVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		log.Errf(ctx, err, "Could not find capture file: %v", flags.Arg(0))
 	}
 
+	baseline := service.NewReportBaseline()
+	if verb.Baseline != "" {
+		f, err := os.Open(verb.Baseline)
+		if err != nil {
+			return log.Err(ctx, err, "Failed to open the baseline file")
+		}
+		baseline, err = service.ReadReportBaseline(f)
+		f.Close()
+		if err != nil {
+			return log.Err(ctx, err, "Failed to read the baseline file")
+		}
+	}
+
 	client, err := getGapis(ctx, verb.Gapis, verb.Gapir)
 	if err != nil {
 		return log.Err(ctx, err, "Failed to connect to the GAPIS server")
 	}
 	defer client.Close()
 
-	stringTables, err := client.GetAvailableStringTables(ctx)
+	stringTable, err := getStringTable(ctx, client)
 	if err != nil {
-		return log.Err(ctx, err, "Failed get list of string tables")
-	}
-
-	var stringTable *stringtable.StringTable
-	if len(stringTables) > 0 {
-		// TODO: Let the user pick the string table.
-		stringTable, err = client.GetStringTable(ctx, stringTables[0])
-		if err != nil {
-			return log.Err(ctx, err, "Failed get string table")
-		}
+		return err
 	}
 
 	capturePath, err := client.LoadCapture(ctx, capture)
@@ -97,7 +100,10 @@ func (verb *reportVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	}
 	commands := boxedCommands.(*service.Commands).List
 
-	boxedReport, err := client.Get(ctx, capturePath.Report(device, filter, verb.DisplayToSurface).Path(), nil)
+	reportPath := capturePath.Report(device, filter, verb.DisplayToSurface)
+	reportPath.Lint = verb.Lint
+	reportPath.UnusedResources = verb.Unused
+	boxedReport, err := client.Get(ctx, reportPath.Path(), nil)
 	if err != nil {
 		return log.Err(ctx, err, "Failed to acquire the capture's report")
 	}
@@ -113,20 +119,101 @@ func (verb *reportVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	}
 
 	report := boxedReport.(*service.Report)
-	for _, e := range report.Items {
-		where := ""
-		if e.Command != nil {
-			where = fmt.Sprintf("%v %v ", e.Command.Indices, commands[e.Command.Indices[0]]) // TODO: Subcommands
+
+	issues := make([]reportIssue, len(report.Items))
+	newIssues, failures := 0, 0
+	for i, raw := range report.RawItems() {
+		issue := reportIssue{
+			raw:        raw,
+			text:       raw.Message.Text(stringTable),
+			suppressed: baseline.Suppresses(raw),
+		}
+		if e := raw.Item; e.Command != nil {
+			issue.index = e.Command.Indices
+			issue.where = fmt.Sprintf("%v %v ", e.Command.Indices, commands[e.Command.Indices[0]]) // TODO: Subcommands
 		}
-		msg := report.Msg(e.Message).Text(stringTable)
-		fmt.Fprintln(reportWriter, fmt.Sprintf("[%s] %s%s", e.Severity.String(), where, msg))
+		if !issue.suppressed {
+			newIssues++
+			if verb.fails(raw.Item) {
+				failures++
+			}
+		}
+		issues[i] = issue
 	}
 
-	if len(report.Items) == 0 {
-		fmt.Fprintln(reportWriter, "No issues found")
+	if verb.WriteBaseline != "" {
+		written := service.NewReportBaseline()
+		for _, issue := range issues {
+			if !issue.raw.IsKnownIssue() {
+				written.Add(issue.raw)
+			}
+		}
+		if err := writeFile(verb.WriteBaseline, written.Write); err != nil {
+			return log.Err(ctx, err, "Failed to write the baseline file")
+		}
+	}
+	if verb.JUnit != "" {
+		write := func(w io.Writer) error { return verb.writeJUnit(w, capture, issues) }
+		if err := writeFile(verb.JUnit, write); err != nil {
+			return log.Err(ctx, err, "Failed to write the JUnit report")
+		}
+	}
+	if verb.Sarif != "" {
+		write := func(w io.Writer) error { return writeSarif(w, capture, issues) }
+		if err := writeFile(verb.Sarif, write); err != nil {
+			return log.Err(ctx, err, "Failed to write the SARIF log")
+		}
+	}
+
+	if verb.Format != TextFormat {
+		if err := verb.Format.writeMessage(reportWriter, baseline.Filter(report)); err != nil {
+			return err
+		}
 	} else {
-		fmt.Fprintf(reportWriter, "%d issues found\n", len(report.Items))
+		for _, issue := range issues {
+			if !issue.suppressed {
+				fmt.Fprintln(reportWriter, fmt.Sprintf("[%s] %s%s", issue.raw.Item.Severity.String(), issue.where, issue.text))
+			}
+		}
+
+		suppressed := ""
+		if n := len(issues) - newIssues; n > 0 {
+			suppressed = fmt.Sprintf(" (%d suppressed by the baseline)", n)
+		}
+		if newIssues == 0 {
+			fmt.Fprintf(reportWriter, "No issues found%s\n", suppressed)
+		} else {
+			fmt.Fprintf(reportWriter, "%d issues found%s\n", newIssues, suppressed)
+		}
 	}
 
+	if failures > 0 {
+		panic(reportIssuesExit)
+	}
 	return nil
 }
+
+// reportIssuesExit is the exit code of the report verb when issues that are
+// not in the baseline fail the report.
+const reportIssuesExit = app.ExitCode(3)
+
+// fails returns true if the item, if not in the baseline, fails the report.
+func (verb *reportVerb) fails(item *service.ReportItem) bool {
+	if verb.FailOnWarnings {
+		return item.Severity >= service.Severity_WarningLevel
+	}
+	return item.Severity >= service.Severity_ErrorLevel
+}
+
+// writeFile creates the file at path and writes its content with write.
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
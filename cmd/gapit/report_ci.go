@@ -0,0 +1,234 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/gapid/gapis/service"
+)
+
+// reportIssue is a report item prepared for output.
+type reportIssue struct {
+	raw        *service.ReportItemRaw
+	index      []uint64 // The indices of the command, or nil.
+	where      string   // The description of the command, or an empty string.
+	text       string   // The message text.
+	suppressed bool     // True if the issue is in the baseline.
+}
+
+// location returns the command of the issue, as '[index] name'.
+func (i reportIssue) location() string {
+	if i.index == nil {
+		return "capture"
+	}
+	return fmt.Sprintf("%v %v", i.index, i.raw.CommandName())
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes the issues of the report of the capture as a JUnit XML
+// test suite, with a test case per issue. New issues that fail the report are
+// failures, and the issues in the baseline are skipped.
+func (verb *reportVerb) writeJUnit(w io.Writer, capture string, issues []reportIssue) error {
+	name := filepath.Base(capture)
+	suite := junitTestSuite{Name: "gapit report " + name}
+	for _, i := range issues {
+		c := junitTestCase{
+			Name:      fmt.Sprintf("%v: %v", i.location(), i.raw.Message.Identifier),
+			ClassName: name,
+		}
+		msg := &junitMessage{
+			Message: i.text,
+			Type:    i.raw.Item.Severity.String(),
+			Text:    fmt.Sprintf("[%v] %v%v", i.raw.Item.Severity, i.where, i.text),
+		}
+		switch {
+		case i.suppressed:
+			c.Skipped = msg
+			suite.Skipped++
+		case verb.fails(i.raw.Item):
+			c.Failure = msg
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	if len(suite.Cases) == 0 {
+		suite.Cases = append(suite.Cases, junitTestCase{Name: "report", ClassName: name})
+	}
+	suite.Tests = len(suite.Cases)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+	if err := e.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+const sarifSchema = "https://schemastore.azurewebsites.net/schemas/json/sarif-2.1.0.json"
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	Level        string             `json:"level"`
+	Message      sarifMessage       `json:"message"`
+	Locations    []sarifLocation    `json:"locations"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// sarifLevel returns the SARIF level of the severity s.
+func sarifLevel(s service.Severity) string {
+	switch {
+	case s >= service.Severity_ErrorLevel:
+		return "error"
+	case s == service.Severity_WarningLevel:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// writeSarif writes the issues of the report of the capture as a SARIF log,
+// with a rule per message identifier. The commands raising the issues are
+// logical locations in the capture file. The issues in the baseline are
+// marked as externally suppressed.
+func writeSarif(w io.Writer, capture string, issues []reportIssue) error {
+	uri := (&url.URL{Scheme: "file", Path: filepath.ToSlash(capture)}).String()
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: "gapit"}},
+		Results: []sarifResult{},
+	}
+	rules := map[string]struct{}{}
+	for _, i := range issues {
+		id := i.raw.Message.Identifier
+		rules[id] = struct{}{}
+		loc := sarifLocation{
+			PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: uri}},
+		}
+		if i.index != nil {
+			loc.LogicalLocations = []sarifLogicalLocation{{
+				Name:               i.raw.CommandName(),
+				FullyQualifiedName: i.location(),
+				Kind:               "function",
+			}}
+		}
+		r := sarifResult{
+			RuleID:    id,
+			Level:     sarifLevel(i.raw.Item.Severity),
+			Message:   sarifMessage{Text: i.text},
+			Locations: []sarifLocation{loc},
+		}
+		if i.suppressed {
+			r.Suppressions = []sarifSuppression{{Kind: "external", Justification: "In the baseline"}}
+		}
+		run.Results = append(run.Results, r)
+	}
+	for id := range rules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: id})
+	}
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(sarifLog{Version: "2.1.0", Schema: sarifSchema, Runs: []sarifRun{run}})
+}
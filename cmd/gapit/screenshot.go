@@ -20,8 +20,10 @@ import (
 	"fmt"
 	"image"
 	"image/png"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/app/flags"
@@ -35,6 +37,13 @@ import (
 
 type screenshotVerb struct{ ScreenshotFlags }
 
+// screenshotHDRFormats are the image formats, keyed by file extension, that
+// preserve the full range and precision of the framebuffer.
+var screenshotHDRFormats = map[string]*img.Format{
+	".exr": img.EXR,
+	".hdr": img.HDR,
+}
+
 func init() {
 	verb := &screenshotVerb{
 		ScreenshotFlags{
@@ -90,6 +99,12 @@ func (verb *screenshotVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		}
 	}
 
+	for ext, format := range screenshotHDRFormats {
+		if strings.HasSuffix(strings.ToLower(verb.Out), ext) {
+			return verb.writeHDRFrame(ctx, command, device, client, format)
+		}
+	}
+
 	if frame, err := verb.getSingleFrame(ctx, command, device, client); err == nil {
 		return verb.writeSingleFrame(flipImg(frame), verb.Out)
 	} else {
@@ -107,7 +122,40 @@ func (verb *screenshotVerb) writeSingleFrame(frame image.Image, fn string) error
 	return png.Encode(out, frame)
 }
 
+// writeHDRFrame writes the frame at cmd to verb.Out in the given format,
+// without first clamping it to 8 bits per channel.
+func (verb *screenshotVerb) writeHDRFrame(ctx context.Context, cmd *path.Command, device *path.Device, client service.Service, format *img.Format) error {
+	frame, err := verb.getFrameData(ctx, cmd, device, client)
+	if err != nil {
+		return err
+	}
+	frame, err = flipData(frame).Convert(format)
+	if err != nil {
+		return log.Errf(ctx, err, "Failed to convert frame to %v", format.Name)
+	}
+	return ioutil.WriteFile(verb.Out, frame.Bytes, 0666)
+}
+
 func (verb *screenshotVerb) getSingleFrame(ctx context.Context, cmd *path.Command, device *path.Device, client service.Service) (*image.NRGBA, error) {
+	frame, err := verb.getFrameData(ctx, cmd, device, client)
+	if err != nil {
+		return nil, err
+	}
+	w, h := int(frame.Width), int(frame.Height)
+	data, err := img.Convert(frame.Bytes, w, h, 1, frame.Format, img.RGBA_U8_NORM)
+	if err != nil {
+		return nil, log.Err(ctx, err, "Failed to convert frame to RGBA")
+	}
+	stride := w * 4
+	return &image.NRGBA{
+		Rect:   image.Rect(0, 0, w, h),
+		Stride: stride,
+		Pix:    data,
+	}, nil
+}
+
+// getFrameData returns the framebuffer image at cmd in its native format.
+func (verb *screenshotVerb) getFrameData(ctx context.Context, cmd *path.Command, device *path.Device, client service.Service) (*img.Data, error) {
 	ctx = log.V{"cmd": cmd.Indices}.Bind(ctx)
 	settings := &service.RenderSettings{MaxWidth: uint32(0xFFFFFFFF), MaxHeight: uint32(0xFFFFFFFF)}
 	if verb.Overdraw {
@@ -152,16 +200,18 @@ func (verb *screenshotVerb) getSingleFrame(ctx context.Context, cmd *path.Comman
 		format = img.Gray_U8_NORM
 		rescaleBytes(ctx, data, verb.Max.Overdraw)
 	}
-	data, err = img.Convert(data, w, h, 1, format, img.RGBA_U8_NORM)
-	if err != nil {
-		return nil, log.Err(ctx, err, "Failed to convert frame to RGBA")
+	return &img.Data{Format: format, Width: ii.Width, Height: ii.Height, Depth: 1, Bytes: data}, nil
+}
+
+// flipData returns a copy of the uncompressed image d with the order of its
+// rows reversed.
+func flipData(d *img.Data) *img.Data {
+	stride := d.Format.Size(int(d.Width), 1, 1)
+	out := make([]byte, len(d.Bytes))
+	for y, h := 0, int(d.Height); y < h; y++ {
+		copy(out[(h-y-1)*stride:(h-y)*stride], d.Bytes[y*stride:])
 	}
-	stride := w * 4
-	return &image.NRGBA{
-		Rect:   image.Rect(0, 0, w, h),
-		Stride: stride,
-		Pix:    data,
-	}, nil
+	return &img.Data{Format: d.Format, Width: d.Width, Height: d.Height, Depth: d.Depth, Bytes: out}
 }
 
 func (verb *screenshotVerb) frameCommand(ctx context.Context, capture *path.Capture, client service.Service) (*path.Command, error) {
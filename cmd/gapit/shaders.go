@@ -0,0 +1,101 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type shadersVerb struct{ ShadersFlags }
+
+func init() {
+	verb := &shadersVerb{
+		ShadersFlags: ShadersFlags{
+			Top: 20,
+		},
+	}
+	app.AddVerb(&app.Verb{
+		Name:      "shaders",
+		ShortHelp: "Prints the complexity and interface of the shaders of a capture, most expensive first",
+		Action:    verb,
+	})
+}
+
+func (verb *shadersVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if verb.Top < 0 {
+		app.Usage(ctx, "Top must not be negative")
+		return nil
+	}
+
+	client, capture, err := loadCapture(ctx, flags, verb.Gapis)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	boxedVal, err := client.Get(ctx, (&path.Shaders{Capture: capture}).Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to analyze the capture's shaders")
+	}
+	report := boxedVal.(*service.ShaderReport)
+	if verb.Top > 0 && len(report.Shaders) > verb.Top {
+		report.Shaders = report.Shaders[:verb.Top]
+	}
+
+	if verb.Format != TextFormat {
+		return verb.Format.writeMessage(os.Stdout, report)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Shader\tType\tDraws\tPrograms\tInstructions\tSamples\tBranches\tInputs\tUniforms")
+	for _, s := range report.Shaders {
+		ty := s.Type.String()
+		if s.Stage != api.StageType_UNKNOWN {
+			ty = fmt.Sprintf("%v (%v)", ty, s.Stage)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", shaderName(s), ty, s.Draws, s.Programs,
+			s.Instructions, s.TextureSamples, s.Branches,
+			strings.Join(s.Inputs, ", "), strings.Join(s.Uniforms, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, s := range report.Shaders {
+		if s.Error != "" {
+			fmt.Fprintf(os.Stdout, "%v: %v\n", shaderName(s), s.Error)
+		}
+	}
+	return nil
+}
+
+// shaderName returns the handles of the shader resources of s for display.
+func shaderName(s *service.ShaderCost) string {
+	if len(s.Handles) == 0 {
+		return "-" // Only known from the programs, the shader objects are gone.
+	}
+	return strings.Join(s.Handles, ", ")
+}
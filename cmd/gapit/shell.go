@@ -0,0 +1,726 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/client"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+type shellVerb struct{ ShellFlags }
+
+func init() {
+	verb := &shellVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "shell",
+		ShortHelp: "Starts an interactive shell to explore a .gfxtrace file",
+		Action:    verb,
+	})
+}
+
+func (verb *shellVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() > 1 {
+		app.Usage(ctx, "At most one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+
+	client, err := getGapis(ctx, verb.Gapis, GapirFlags{})
+	if err != nil {
+		return log.Err(ctx, err, "Failed to connect to the GAPIS server")
+	}
+	defer client.Close()
+
+	s := &session{client: client}
+	defer s.stopLoadingNames()
+	if flags.NArg() == 1 {
+		if err := s.load(ctx, flags.Arg(0)); err != nil {
+			return err
+		}
+	}
+	return s.run(ctx)
+}
+
+// shellCommand is a command of the shell.
+type shellCommand struct {
+	name string
+	args string
+	help string
+	run  func(s *session, ctx context.Context, args []string) error
+	// complete returns the candidate completions of the argument arg.
+	complete func(s *session, ctx context.Context, arg string) []string
+}
+
+var shellCommands []*shellCommand
+
+func init() {
+	// Initialized here as the help command refers to shellCommands.
+	shellCommands = []*shellCommand{
+		{"help", "", "Prints this help", (*session).help, nil},
+		{"load", "<file>", "Loads a capture, replacing the current one", (*session).loadCmd, nil},
+		{"cmd", "[index]", "Moves to the command with the given index, e.g. 12 or 12.0.3, and prints it", (*session).cmd, nil},
+		{"next", "[count]", "Moves count commands forward", (*session).next, nil},
+		{"prev", "[count]", "Moves count commands backward", (*session).prev, nil},
+		{"list", "[count]", "Prints count commands from the current command", (*session).list, nil},
+		{"find", "<regex>", "Prints the commands and groups matching regex", (*session).find, (*session).completeCommandName},
+		{"query", "<query>", "Prints the commands matching a query, e.g. glDraw.* where count > 100", (*session).query, (*session).completeCommandName},
+		{"state", "[path] [depth]", "Prints the state subtree at path after the current command, e.g. Contexts/0", (*session).state, (*session).completeStatePath},
+		{"follow", "<parameter|path>", "Follows the link of a parameter of the current command or of a state node", (*session).follow, (*session).completeFollow},
+		{"memory", "<address> [size]", "Prints the application memory after the current command", (*session).memory, nil},
+		{"quit", "", "Exits the shell", nil, nil},
+		{"exit", "", "Exits the shell", nil, nil},
+	}
+}
+
+func findShellCommand(name string) *shellCommand {
+	for _, c := range shellCommands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// session holds the state of an interactive shell session with a single
+// gapis server.
+type session struct {
+	client  client.Client
+	term    *terminal.Terminal
+	capture *path.Capture
+	count   uint64        // The number of commands of the capture.
+	at      *path.Command // The current command.
+	command *api.Command  // The current command, once loaded.
+
+	// The loaded nodes of the state tree after the current command, keyed by
+	// their '/' separated path of names.
+	nodes map[string]*stateNode
+
+	// The names of the commands of the capture, loaded in the background.
+	namesMutex  sync.Mutex
+	names       map[string]struct{}
+	cancelNames task.CancelFunc
+}
+
+// stateNode is a node of the state tree, with the names of its children once
+// they are loaded.
+type stateNode struct {
+	path     *path.StateTreeNode
+	node     *service.StateTreeNode
+	children []string
+}
+
+func (s *session) run(ctx context.Context) error {
+	readLine := s.lineReader(ctx)
+	for !task.Stopped(ctx) {
+		line, err := readLine()
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		}
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+		c := findShellCommand(args[0])
+		switch {
+		case c == nil:
+			fmt.Fprintf(os.Stdout, "Unknown command '%v'. Type 'help' for the list of commands.\n", args[0])
+		case c.run == nil:
+			return nil
+		default:
+			if err := c.run(s, ctx, args[1:]); err != nil {
+				fmt.Fprintf(os.Stdout, "Error: %v\n", err)
+			}
+		}
+	}
+	return task.StopReason(ctx)
+}
+
+// lineReader returns the function that reads the lines typed by the user.
+// When stdin is a terminal, lines are edited with history and completion,
+// otherwise they are read as is, so that the shell can be scripted.
+func (s *session) lineReader(ctx context.Context) func() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		scanner := bufio.NewScanner(os.Stdin)
+		return func() (string, error) {
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return "", err
+				}
+				return "", io.EOF
+			}
+			return scanner.Text(), nil
+		}
+	}
+
+	s.term = terminal.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "gapit> ")
+	s.term.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		return s.complete(ctx, line, pos, key)
+	}
+	return func() (string, error) {
+		// The terminal is only raw while a line is edited, so that the output
+		// of the commands is printed normally.
+		state, err := terminal.MakeRaw(fd)
+		if err != nil {
+			return "", err
+		}
+		defer terminal.Restore(fd, state)
+		if w, h, err := terminal.GetSize(fd); err == nil {
+			s.term.SetSize(w, h)
+		}
+		return s.term.ReadLine()
+	}
+}
+
+// complete implements the terminal AutoCompleteCallback, completing the word
+// before the cursor when tab is pressed.
+func (s *session) complete(ctx context.Context, line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	head := line[:pos]
+	words := strings.Fields(head)
+	partial := ""
+	if len(words) > 0 && !strings.HasSuffix(head, " ") {
+		partial, words = words[len(words)-1], words[:len(words)-1]
+	}
+
+	var candidates []string
+	switch {
+	case len(words) == 0:
+		for _, c := range shellCommands {
+			candidates = append(candidates, c.name)
+		}
+	case len(words) == 1:
+		if c := findShellCommand(words[0]); c != nil && c.complete != nil {
+			candidates = c.complete(s, ctx, partial)
+		}
+	}
+
+	matches := []string{}
+	for _, c := range candidates {
+		if strings.HasPrefix(c, partial) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+
+	completion := commonPrefix(matches)
+	switch {
+	case len(matches) == 1 && !strings.HasSuffix(completion, "/"):
+		completion += " "
+	case len(matches) > 1 && completion == partial:
+		fmt.Fprintln(s.term, strings.Join(matches, "  "))
+		return "", 0, false
+	}
+	head = head[:len(head)-len(partial)] + completion
+	return head + line[pos:], len(head), true
+}
+
+func commonPrefix(strs []string) string {
+	prefix := strs[0]
+	for _, s := range strs[1:] {
+		for !strings.HasPrefix(s, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// load loads the capture file and moves to its last command.
+func (s *session) load(ctx context.Context, file string) error {
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return log.Errf(ctx, err, "Could not find capture file: %v", file)
+	}
+	c, err := s.client.LoadCapture(ctx, file)
+	if err != nil {
+		return log.Errf(ctx, err, "LoadCapture(%v)", file)
+	}
+	boxedCapture, err := s.client.Get(ctx, c.Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to load the capture")
+	}
+	count := boxedCapture.(*service.Capture).NumCommands
+	if count == 0 {
+		return fmt.Errorf("Capture %v has no commands", file)
+	}
+
+	s.stopLoadingNames()
+	s.capture, s.count = c, count
+	s.moveTo(c.Command(count - 1))
+	s.startLoadingNames(ctx)
+	fmt.Fprintf(os.Stdout, "Loaded %v: %v commands\n", file, count)
+	return nil
+}
+
+// moveTo makes p the current command.
+func (s *session) moveTo(p *path.Command) {
+	s.at, s.command, s.nodes = p, nil, nil
+}
+
+// current returns the current command, loading it if needed.
+func (s *session) current(ctx context.Context) (*api.Command, error) {
+	if s.at == nil {
+		return nil, fmt.Errorf("No capture loaded")
+	}
+	if s.command == nil {
+		cmd, err := getCommand(ctx, s.client, s.at)
+		if err != nil {
+			return nil, err
+		}
+		s.command = cmd
+		s.addName(cmd.Name)
+	}
+	return s.command, nil
+}
+
+func (s *session) help(ctx context.Context, args []string) error {
+	for _, c := range shellCommands {
+		fmt.Fprintf(os.Stdout, "  %-30s %s\n", strings.TrimSpace(c.name+" "+c.args), c.help)
+	}
+	return nil
+}
+
+func (s *session) loadCmd(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("Expected one capture file")
+	}
+	return s.load(ctx, args[0])
+}
+
+func (s *session) cmd(ctx context.Context, args []string) error {
+	if s.at == nil {
+		return fmt.Errorf("No capture loaded")
+	}
+	if len(args) > 0 {
+		indices := []uint64{}
+		for _, i := range strings.Split(args[0], ".") {
+			v, err := strconv.ParseUint(i, 10, 64)
+			if err != nil {
+				return fmt.Errorf("Invalid command index '%v'", args[0])
+			}
+			indices = append(indices, v)
+		}
+		if indices[0] >= s.count {
+			return fmt.Errorf("Command index %v out of range [0..%v]", indices[0], s.count-1)
+		}
+		s.moveTo(s.capture.Command(indices[0], indices[1:]...))
+	}
+	return s.printCurrent(ctx)
+}
+
+func (s *session) next(ctx context.Context, args []string) error {
+	return s.step(ctx, args, 1)
+}
+
+func (s *session) prev(ctx context.Context, args []string) error {
+	return s.step(ctx, args, -1)
+}
+
+// step moves the current command by count commands in direction dir. Steps
+// are made over the top-level commands.
+func (s *session) step(ctx context.Context, args []string, dir int64) error {
+	if s.at == nil {
+		return fmt.Errorf("No capture loaded")
+	}
+	count, err := countArg(args, 1)
+	if err != nil {
+		return err
+	}
+	i := int64(s.at.Indices[0]) + dir*int64(count)
+	switch {
+	case i < 0:
+		i = 0
+	case i >= int64(s.count):
+		i = int64(s.count) - 1
+	}
+	s.moveTo(s.capture.Command(uint64(i)))
+	return s.printCurrent(ctx)
+}
+
+func (s *session) printCurrent(ctx context.Context) error {
+	cmd, err := s.current(ctx)
+	if err != nil {
+		return err
+	}
+	return printCommand(ctx, s.client, s.at, cmd, ObservationFlags{})
+}
+
+func (s *session) list(ctx context.Context, args []string) error {
+	if s.at == nil {
+		return fmt.Errorf("No capture loaded")
+	}
+	count, err := countArg(args, 10)
+	if err != nil {
+		return err
+	}
+	for i := s.at.Indices[0]; i < s.count && i < s.at.Indices[0]+count; i++ {
+		p := s.capture.Command(i)
+		cmd, err := getCommand(ctx, s.client, p)
+		if err != nil {
+			return err
+		}
+		s.addName(cmd.Name)
+		if err := printCommand(ctx, s.client, p, cmd, ObservationFlags{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func countArg(args []string, def uint64) (uint64, error) {
+	if len(args) == 0 {
+		return def, nil
+	}
+	count, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid count '%v'", args[0])
+	}
+	return count, nil
+}
+
+// maxFindResults is the maximum number of results printed by find.
+const maxFindResults = 50
+
+func (s *session) find(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Expected the text to find")
+	}
+	return s.printFound(ctx, &service.FindRequest{Text: strings.Join(args, " "), IsRegex: true})
+}
+
+func (s *session) query(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Expected the query")
+	}
+	return s.printFound(ctx, &service.FindRequest{Query: strings.Join(args, " ")})
+}
+
+// printFound prints the results of the search req over the command tree.
+func (s *session) printFound(ctx context.Context, req *service.FindRequest) error {
+	if s.capture == nil {
+		return fmt.Errorf("No capture loaded")
+	}
+	boxedTree, err := s.client.Get(ctx, s.capture.CommandTree(nil).Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to load the command tree")
+	}
+	req.From = &service.FindRequest_CommandTreeNode{CommandTreeNode: boxedTree.(*service.CommandTree).Root}
+	req.MaxItems = maxFindResults
+	found := 0
+	err = s.client.Find(ctx, req, func(r *service.FindResponse) error {
+		found++
+		boxedNode, err := s.client.Get(ctx, r.GetCommandTreeNode().Path(), nil)
+		if err != nil {
+			return err
+		}
+		n := boxedNode.(*service.CommandTreeNode)
+		if n.Group != "" {
+			fmt.Fprintf(os.Stdout, "%v %v\n", n.Commands.First().Indices, n.Group)
+			return nil
+		}
+		return getAndPrintCommand(ctx, s.client, n.Commands.First(), ObservationFlags{})
+	})
+	if err != nil {
+		return err
+	}
+	if found == maxFindResults {
+		fmt.Fprintf(os.Stdout, "Stopped after %v results\n", found)
+	}
+	return nil
+}
+
+func (s *session) state(ctx context.Context, args []string) error {
+	name, depth := "", 1
+	if len(args) > 0 {
+		name = args[0]
+	}
+	if len(args) > 1 {
+		d, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("Invalid depth '%v'", args[1])
+		}
+		depth = d
+	}
+	n, err := s.stateNode(ctx, name)
+	if err != nil {
+		return err
+	}
+	return traverseStateTree(ctx, s.client, n.path, depth, nil, func(n *service.StateTreeNode, prefix string) error {
+		name := n.Name + ":"
+		if n.Preview != nil {
+			v := n.Preview.Get()
+			if n.Constants != nil {
+				constants, err := getConstantSet(ctx, s.client, n.Constants)
+				if err != nil {
+					return log.Err(ctx, err, "Couldn't fetch constant set")
+				}
+				v = constants.Sprint(v)
+			}
+			fmt.Fprintln(os.Stdout, prefix, name, v)
+		} else {
+			fmt.Fprintln(os.Stdout, prefix, name)
+		}
+		return nil
+	}, "", true)
+}
+
+// stateNode returns the node of the state tree after the current command with
+// the given '/' separated path of names.
+func (s *session) stateNode(ctx context.Context, name string) (*stateNode, error) {
+	if s.at == nil {
+		return nil, fmt.Errorf("No capture loaded")
+	}
+	name = strings.Trim(name, "/")
+	if n, ok := s.nodes[name]; ok {
+		return n, nil
+	}
+	if name == "" {
+		boxedTree, err := s.client.Get(ctx, s.at.StateAfter().Tree().Path(), nil)
+		if err != nil {
+			return nil, log.Err(ctx, err, "Failed to load the state tree")
+		}
+		root := boxedTree.(*service.StateTree).Root
+		boxedNode, err := s.client.Get(ctx, root.Path(), nil)
+		if err != nil {
+			return nil, log.Errf(ctx, err, "Failed to load the node at: %v", root)
+		}
+		n := &stateNode{path: root, node: boxedNode.(*service.StateTreeNode)}
+		s.nodes = map[string]*stateNode{"": n}
+		return n, nil
+	}
+
+	parentName, childName := "", name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		parentName, childName = name[:i], name[i+1:]
+	}
+	parent, err := s.stateNode(ctx, parentName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.stateChildren(ctx, parentName, parent); err != nil {
+		return nil, err
+	}
+	if n, ok := s.nodes[name]; ok {
+		return n, nil
+	}
+	return nil, fmt.Errorf("No state node '%v' in '%v'", childName, parentName)
+}
+
+// stateChildren returns the names of the children of the state node n, with
+// the given path of names, loading them if needed.
+func (s *session) stateChildren(ctx context.Context, name string, n *stateNode) ([]string, error) {
+	if n.children != nil || n.node.NumChildren == 0 {
+		return n.children, nil
+	}
+	children := make([]string, n.node.NumChildren)
+	for i := range children {
+		p := n.path.Index(uint64(i))
+		boxedNode, err := s.client.Get(ctx, p.Path(), nil)
+		if err != nil {
+			return nil, log.Errf(ctx, err, "Failed to load the node at: %v", p)
+		}
+		child := boxedNode.(*service.StateTreeNode)
+		children[i] = child.Name
+		childName := child.Name
+		if name != "" {
+			childName = name + "/" + child.Name
+		}
+		s.nodes[childName] = &stateNode{path: p, node: child}
+	}
+	n.children = children
+	return children, nil
+}
+
+func (s *session) follow(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("Expected a parameter name or a state path")
+	}
+	cmd, err := s.current(ctx)
+	if err != nil {
+		return err
+	}
+	var p *path.Any
+	for _, param := range cmd.Parameters {
+		if param.Name == args[0] {
+			p = s.at.Parameter(param.Name).Path()
+		}
+	}
+	if p == nil {
+		n, err := s.stateNode(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		if n.node.ValuePath == nil {
+			return fmt.Errorf("State node '%v' has no value", args[0])
+		}
+		p = n.node.ValuePath
+	}
+
+	target, err := s.client.Follow(ctx, p, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "%v → %v\n", p, target)
+	v, err := s.client.Get(ctx, target, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "%+v\n", v)
+	return nil
+}
+
+func (s *session) memory(ctx context.Context, args []string) error {
+	if s.at == nil {
+		return fmt.Errorf("No capture loaded")
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("Expected the address of the memory")
+	}
+	addr, err := strconv.ParseUint(args[0], 0, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid address '%v'", args[0])
+	}
+	size := uint64(64)
+	if len(args) > 1 {
+		if size, err = strconv.ParseUint(args[1], 0, 64); err != nil {
+			return fmt.Errorf("Invalid size '%v'", args[1])
+		}
+	}
+	mp := s.at.MemoryAfter(0, addr, size)
+	mp.ExcludeObserved = true
+	boxedMemory, err := s.client.Get(ctx, mp.Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Couldn't fetch the memory")
+	}
+	data := boxedMemory.(*service.Memory).Data
+	for i := 0; i < len(data); i += 16 {
+		end := i + 16
+		if end > len(data) {
+			end = len(data)
+		}
+		fmt.Fprintf(os.Stdout, "0x%016x: % x\n", addr+uint64(i), data[i:end])
+	}
+	return nil
+}
+
+func (s *session) completeCommandName(ctx context.Context, arg string) []string {
+	s.namesMutex.Lock()
+	defer s.namesMutex.Unlock()
+	out := make([]string, 0, len(s.names))
+	for name := range s.names {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (s *session) completeStatePath(ctx context.Context, arg string) []string {
+	parentName := ""
+	if i := strings.LastIndex(arg, "/"); i >= 0 {
+		parentName = arg[:i]
+	}
+	parent, err := s.stateNode(ctx, parentName)
+	if err != nil {
+		return nil
+	}
+	children, err := s.stateChildren(ctx, parentName, parent)
+	if err != nil {
+		return nil
+	}
+	out := make([]string, len(children))
+	for i, c := range children {
+		name := c
+		if parentName != "" {
+			name = parentName + "/" + c
+		}
+		if n := s.nodes[name]; n != nil && n.node.NumChildren > 0 {
+			name += "/"
+		}
+		out[i] = name
+	}
+	return out
+}
+
+func (s *session) completeFollow(ctx context.Context, arg string) []string {
+	out := s.completeStatePath(ctx, arg)
+	if cmd, err := s.current(ctx); err == nil {
+		for _, p := range cmd.Parameters {
+			out = append(out, p.Name)
+		}
+	}
+	return out
+}
+
+func (s *session) addName(name string) {
+	s.namesMutex.Lock()
+	defer s.namesMutex.Unlock()
+	s.names[name] = struct{}{}
+}
+
+// startLoadingNames starts loading the names of all the commands of the
+// capture in the background, for completion.
+func (s *session) startLoadingNames(ctx context.Context) {
+	s.namesMutex.Lock()
+	s.names = map[string]struct{}{}
+	s.namesMutex.Unlock()
+
+	var cancel task.CancelFunc
+	ctx, cancel = task.WithCancel(ctx)
+	s.cancelNames = cancel
+	capture, count := s.capture, s.count
+	go func() {
+		for i := uint64(0); i < count && !task.Stopped(ctx); i++ {
+			boxedCmd, err := s.client.Get(ctx, capture.Command(i).Path(), nil)
+			if err != nil {
+				return
+			}
+			s.addName(boxedCmd.(*api.Command).Name)
+		}
+	}()
+}
+
+func (s *session) stopLoadingNames() {
+	if s.cancelNames != nil {
+		s.cancelNames()
+		s.cancelNames = nil
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type splitVerb struct{ SplitFlags }
+
+func init() {
+	verb := &splitVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "split",
+		ShortHelp: "Produce a new trace for each context or thread of a trace",
+		Action:    verb,
+	})
+}
+
+func (verb *splitVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	client, capture, err := loadCapture(ctx, flags, verb.Gapis)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return nil
+	}
+	defer client.Close()
+
+	mode := service.SplitMode_ByContext
+	if verb.Thread {
+		mode = service.SplitMode_ByThread
+	}
+
+	parts, err := client.SplitCapture(ctx, capture, mode)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to split the capture")
+	}
+
+	base := strings.TrimSuffix(filepath.Base(flags.Arg(0)), filepath.Ext(flags.Arg(0)))
+	for i, part := range parts {
+		out, err := filepath.Abs(filepath.Join(verb.Out, fmt.Sprintf("%v.%d.gfxtrace", base, i)))
+		if err != nil {
+			return log.Errf(ctx, err, "Finding output path for part %d", i)
+		}
+		if err := client.SaveCapture(ctx, part, out); err != nil {
+			return log.Errf(ctx, err, "Failed to write '%v'", out)
+		}
+		boxedCapture, err := client.Get(ctx, part.Path(), nil)
+		if err != nil {
+			return log.Errf(ctx, err, "Failed to load part %d", i)
+		}
+		c := boxedCapture.(*service.Capture)
+		fmt.Printf("%v: %v (%d commands)\n", out, c.Name, c.NumCommands)
+	}
+	return nil
+}
+
+type mergeVerb struct{ MergeFlags }
+
+func init() {
+	verb := &mergeVerb{}
+	verb.Out = "merged.gfxtrace"
+	app.AddVerb(&app.Verb{
+		Name:      "merge",
+		ShortHelp: "Produce a single trace from traces of independent contexts",
+		Action:    verb,
+	})
+}
+
+func (verb *mergeVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() < 2 {
+		app.Usage(ctx, "At least two gfx trace files expected, got %d", flags.NArg())
+		return nil
+	}
+
+	client, err := getGapis(ctx, verb.Gapis, GapirFlags{})
+	if err != nil {
+		return log.Err(ctx, err, "Failed to connect to the GAPIS server")
+	}
+	defer client.Close()
+
+	captures := make([]*path.Capture, flags.NArg())
+	for i := range captures {
+		filepath, err := filepath.Abs(flags.Arg(i))
+		if err != nil {
+			return log.Errf(ctx, err, "Finding file: %v", flags.Arg(i))
+		}
+		captures[i], err = client.LoadCapture(ctx, filepath)
+		if err != nil {
+			return log.Errf(ctx, err, "LoadCapture(%v)", filepath)
+		}
+	}
+
+	out, err := filepath.Abs(verb.Out)
+	if err != nil {
+		return log.Errf(ctx, err, "Finding file: %v", verb.Out)
+	}
+	merged, err := client.MergeCaptures(ctx, filepath.Base(out), captures)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to merge the captures")
+	}
+	if err := client.SaveCapture(ctx, merged, out); err != nil {
+		return log.Errf(ctx, err, "Failed to write '%v'", out)
+	}
+
+	log.I(ctx, "Capture written to: %v", out)
+	return nil
+}
@@ -86,6 +86,13 @@ func (verb *stateVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 
 	tree := boxedTree.(*service.StateTree)
 
+	if verb.Format != TextFormat {
+		out := messageStream{w: os.Stdout, format: verb.Format}
+		return traverseStateTree(ctx, client, tree.Root, verb.Depth, verb.Filter, func(n *service.StateTreeNode, prefix string) error {
+			return out.writeValue(n)
+		}, "", true)
+	}
+
 	return traverseStateTree(ctx, client, tree.Root, verb.Depth, verb.Filter, func(n *service.StateTreeNode, prefix string) error {
 		name := n.Name + ":"
 		if n.Preview != nil {
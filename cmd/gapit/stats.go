@@ -16,12 +16,15 @@ package main
 
 import (
 	"context"
+	"encoding/csv"
 	"flag"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/google/gapid/core/app"
@@ -115,32 +118,92 @@ func (verb *infoVerb) getEventsInRange(ctx context.Context, client service.Servi
 	return events[begin:end], nil
 }
 
-func (verb *infoVerb) drawCallStats(ctx context.Context, client client.Client, c *path.Capture) (int, sint.HistogramStats, error) {
+// stats returns the stats of the capture, limited to the requested frames.
+func (verb *infoVerb) stats(ctx context.Context, client client.Client, c *path.Capture) (*service.Stats, error) {
 	boxedVal, err := client.Get(ctx, (&path.Stats{
 		Capture:  c,
 		DrawCall: true,
+		PerFrame: verb.PerFrame,
 	}).Path(), nil)
 	if err != nil {
-		return 0, sint.HistogramStats{}, err
+		return nil, err
+	}
+	stats := boxedVal.(*service.Stats)
+	start, end := verb.frameRange(len(stats.DrawCalls))
+	stats.DrawCalls = stats.DrawCalls[start:end]
+	if stats.Frames != nil {
+		stats.Frames = stats.Frames[start:end]
 	}
-	data := boxedVal.(*service.Stats).DrawCalls
+	return stats, nil
+}
 
-	if verb.Frames.Start < len(data) {
-		data = data[verb.Frames.Start:]
-	} else {
-		data = []uint64{}
+// frameRange returns the range of the requested frames in a capture of count
+// frames.
+func (verb *infoVerb) frameRange(count int) (start, end int) {
+	start, end = verb.Frames.Start, count
+	if start > count {
+		start = count
 	}
-	if verb.Frames.Count >= 0 && verb.Frames.Count < len(data) {
-		data = data[:verb.Frames.Count]
+	if verb.Frames.Count >= 0 && start+verb.Frames.Count < end {
+		end = start + verb.Frames.Count
 	}
+	return start, end
+}
 
+// frameStatsColumns are the columns of the per-frame statistics table.
+var frameStatsColumns = []struct {
+	name  string
+	value func(*service.FrameStats) uint64
+}{
+	{"commands", (*service.FrameStats).GetCommands},
+	{"draw_calls", (*service.FrameStats).GetDrawCalls},
+	{"triangles", (*service.FrameStats).GetTriangles},
+	{"vertices", (*service.FrameStats).GetVertices},
+	{"state_changes", (*service.FrameStats).GetStateChanges},
+	{"buffer_upload_bytes", (*service.FrameStats).GetBufferUploadBytes},
+	{"texture_upload_bytes", (*service.FrameStats).GetTextureUploadBytes},
+	{"program_switches", (*service.FrameStats).GetProgramSwitches},
+	{"render_passes", (*service.FrameStats).GetRenderPasses},
+}
+
+// writeFrameStats writes a table of the per-frame statistics to w, either as
+// CSV or as aligned text.
+func (verb *infoVerb) writeFrameStats(w io.Writer, stats *service.Stats) error {
+	header := []string{"frame"}
+	for _, c := range frameStatsColumns {
+		header = append(header, c.name)
+	}
+	rows := [][]string{header}
+	for i, f := range stats.Frames {
+		row := []string{fmt.Sprint(verb.Frames.Start + i)}
+		for _, c := range frameStatsColumns {
+			row = append(row, fmt.Sprint(c.value(f)))
+		}
+		rows = append(rows, row)
+	}
+
+	if verb.CSV {
+		cw := csv.NewWriter(w)
+		cw.WriteAll(rows)
+		return cw.Error()
+	}
+
+	tw := tabwriter.NewWriter(w, 4, 4, 2, ' ', tabwriter.AlignRight)
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t")+"\t")
+	}
+	return tw.Flush()
+}
+
+func (verb *infoVerb) drawCallStats(stats *service.Stats) (int, sint.HistogramStats) {
+	data := stats.DrawCalls
 	hist := make(sint.Histogram, len(data))
 	totalDraws := 0
 	for i, dat := range data {
 		totalDraws += int(dat)
 		hist[i] = int(dat)
 	}
-	return totalDraws, hist.Stats(), nil
+	return totalDraws, hist.Stats()
 }
 
 func (verb *infoVerb) Run(ctx context.Context, flags flag.FlagSet) error {
@@ -150,6 +213,18 @@ func (verb *infoVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	}
 	defer client.Close()
 
+	stats, err := verb.stats(ctx, client, capture)
+	if err != nil {
+		return err
+	}
+
+	if verb.Format != TextFormat {
+		return verb.Format.writeMessage(os.Stdout, stats)
+	}
+	if verb.PerFrame {
+		return verb.writeFrameStats(os.Stdout, stats)
+	}
+
 	events, err := verb.getEventsInRange(ctx, client, capture)
 
 	if err != nil {
@@ -170,10 +245,7 @@ func (verb *infoVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		}
 	}
 	callStats := cmdsPerFrame.Stats()
-	totalDraws, drawStats, err := verb.drawCallStats(ctx, client, capture)
-	if err != nil {
-		return err
-	}
+	totalDraws, drawStats := verb.drawCallStats(stats)
 
 	w := tabwriter.NewWriter(os.Stdout, 4, 4, 0, ' ', 0)
 	fmt.Fprintf(w, "Commands: \t%v\n", counts[service.EventKind_AllCommands])
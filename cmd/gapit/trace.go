@@ -156,6 +156,7 @@ func (verb *traceVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		DeferStart:            verb.Start.Defer,
 		NoBuffer:              verb.No.Buffer,
 		HideUnknownExtensions: verb.Disable.Unknown.Extensions,
+		RecordTiming:          verb.Record.Timing,
 		ClearCache:            verb.Clear.Cache,
 		ServerLocalSavePath:   out,
 	}
@@ -0,0 +1,78 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+)
+
+type trimVerb struct{ TrimFlags }
+
+func init() {
+	verb := &trimVerb{}
+	verb.Frames.Count = 1
+	verb.Out = "trimmed.gfxtrace"
+	app.AddVerb(&app.Verb{
+		Name:      "trim",
+		ShortHelp: "Produce a new trace holding only a range of frames of a trace",
+		Action:    verb,
+	})
+}
+
+func (verb *trimVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if verb.Frames.Start < 0 {
+		app.Usage(ctx, "Negative start frame %v is invalid", verb.Frames.Start)
+		return nil
+	}
+	if verb.Frames.Count < 0 {
+		app.Usage(ctx, "Negative frame count %v is invalid", verb.Frames.Count)
+		return nil
+	}
+
+	client, capture, err := loadCapture(ctx, flags, verb.Gapis)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return nil
+	}
+	defer client.Close()
+
+	data, err := client.ExportCapture(ctx, capture, &service.FrameRange{
+		First: uint64(verb.Frames.Start),
+		Count: uint64(verb.Frames.Count),
+	})
+	if err != nil {
+		return log.Err(ctx, err, "Failed to trim the capture")
+	}
+
+	out, err := filepath.Abs(verb.Out)
+	if err != nil {
+		return log.Errf(ctx, err, "Finding file: %v", verb.Out)
+	}
+	if err := ioutil.WriteFile(out, data, 0666); err != nil {
+		return log.Errf(ctx, err, "Failed to write '%v'", out)
+	}
+
+	log.I(ctx, "Capture written to: %v", out)
+	return nil
+}
@@ -57,7 +57,25 @@ func (verb *unpackVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	}
 	defer r.Close()
 
-	return pack.Read(ctx, r, unpacker{verb.Verbose, map[uint64]int{}}, true)
+	u := unpacker{verb.Verbose, map[uint64]int{}}
+	if verb.At == 0 && verb.Count == 0 {
+		return pack.Read(ctx, r, u, true)
+	}
+
+	pr, err := pack.NewReader(ctx, r, true)
+	if err != nil {
+		return err
+	}
+	end := pr.Count()
+	if verb.Count > 0 && verb.At+verb.Count < end {
+		end = verb.At + verb.Count
+	}
+	for i := verb.At; i < end; i++ {
+		if err := pr.Read(ctx, i, u); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 type unpacker struct {
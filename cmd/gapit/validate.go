@@ -0,0 +1,109 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+)
+
+type validateVerb struct{ ValidateFlags }
+
+func init() {
+	verb := &validateVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "validate",
+		ShortHelp: "Checks the integrity of a trace file, optionally salvaging a damaged trace",
+		Action:    verb,
+	})
+}
+
+func (verb *validateVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+
+	filepath, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return log.Errf(ctx, err, "Finding file: %v", flags.Arg(0))
+	}
+
+	client, err := getGapis(ctx, verb.Gapis, GapirFlags{})
+	if err != nil {
+		return log.Err(ctx, err, "Failed to connect to the GAPIS server")
+	}
+	defer client.Close()
+
+	validation, err := client.ValidateCapture(ctx, filepath, verb.Salvage != "")
+	if err != nil {
+		return log.Errf(ctx, err, "ValidateCapture(%v)", filepath)
+	}
+
+	printValidation(os.Stdout, flags.Arg(0), validation)
+
+	if validation.Salvaged != nil {
+		data, err := client.ExportCapture(ctx, validation.Salvaged, nil)
+		if err != nil {
+			return log.Err(ctx, err, "Failed to export the salvaged capture")
+		}
+		if err := ioutil.WriteFile(verb.Salvage, data, 0666); err != nil {
+			return log.Errf(ctx, err, "Failed to write '%v'", verb.Salvage)
+		}
+		fmt.Fprintf(os.Stdout, "Salvaged %d commands to: %v\n", validation.IntactCommands, verb.Salvage)
+	}
+
+	if len(validation.Issues) > 0 {
+		return fmt.Errorf("Trace has %d issues", len(validation.Issues))
+	}
+	return nil
+}
+
+func printValidation(w io.Writer, name string, v *service.CaptureValidation) {
+	fmt.Fprintf(w, "%v:\n", name)
+	fmt.Fprintf(w, "  Version:  %d\n", v.Version)
+	fmt.Fprintf(w, "  Size:     %d bytes (%d intact)\n", v.Size, v.IntactSize)
+	fmt.Fprintf(w, "  Commands: %d intact", v.IntactCommands)
+	if v.LastIntactCommand != "" {
+		fmt.Fprintf(w, ", last: %v", v.LastIntactCommand)
+	}
+	fmt.Fprintln(w)
+	if len(v.Issues) == 0 {
+		fmt.Fprintln(w, "No issues found")
+		return
+	}
+	for _, i := range v.Issues {
+		fmt.Fprintf(w, "  %v: %v", i.Kind, i.Message)
+		switch {
+		case i.CommandComplete:
+			fmt.Fprintf(w, " (command %d %v)", i.Command, i.CommandName)
+		case i.CommandName != "":
+			fmt.Fprintf(w, " (incomplete command %v)", i.CommandName)
+		}
+		if i.Offset != 0 {
+			fmt.Fprintf(w, " at offset %d", i.Offset)
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -56,7 +56,8 @@ func run(ctx context.Context) error {
 		return err
 	}
 
-	p, err := capture.Import(ctx, name, in)
+	// All the commands need to be held in memory to be rewritten.
+	p, err := capture.ImportInMemory(ctx, name, in)
 	if err != nil {
 		return err
 	}
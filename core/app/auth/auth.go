@@ -95,33 +95,47 @@ func GenTokenFile() (path string, token Token) {
 // RPC calls for the given auth token.
 func ServerInterceptor(token Token) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		if token != NoAuth {
-			md, ok := metadata.FromContext(ctx)
-			if !ok {
-				return nil, ErrInvalidToken
-			}
-
-			got, ok := md[rpcHeader]
-			if !ok || len(got) != 1 || Token(got[0]) != token {
-				return nil, ErrInvalidToken
-			}
+		if err := Check(ctx, token); err != nil {
+			return nil, err
 		}
-
 		return handler(ctx, req)
 	}
 }
 
+// Check returns ErrInvalidToken if the incoming RPC context does not hold the
+// given auth token. Check can be used by streaming RPC handlers, which are not
+// covered by ServerInterceptor.
+func Check(ctx context.Context, token Token) error {
+	if token == NoAuth {
+		return nil
+	}
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return ErrInvalidToken
+	}
+	got, ok := md[rpcHeader]
+	if !ok || len(got) != 1 || Token(got[0]) != token {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
 // ClientInterceptor returns a grpc.UnaryClientInterceptor that adds the given
 // auth token to outgoing RPC calls.
 func ClientInterceptor(token Token) grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
-		if token != NoAuth {
-			if md, ok := metadata.FromContext(ctx); ok {
-				ctx = metadata.NewContext(ctx, metadata.Join(md, metadata.Pairs(rpcHeader, string(token))))
-			} else {
-				ctx = metadata.NewContext(ctx, metadata.Pairs(rpcHeader, string(token)))
-			}
-		}
-		return invoker(ctx, method, req, reply, cc, opts...)
+		return invoker(WithToken(ctx, token), method, req, reply, cc, opts...)
+	}
+}
+
+// WithToken returns a context that adds the given auth token to outgoing RPC
+// calls made with it.
+func WithToken(ctx context.Context, token Token) context.Context {
+	if token == NoAuth {
+		return ctx
+	}
+	if md, ok := metadata.FromContext(ctx); ok {
+		return metadata.NewContext(ctx, metadata.Join(md, metadata.Pairs(rpcHeader, string(token))))
 	}
+	return metadata.NewContext(ctx, metadata.Pairs(rpcHeader, string(token)))
 }
@@ -0,0 +1,122 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pack
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Codec is the interface implemented by the compression algorithms used for
+// the chunks of compressed pack files.
+// Codecs must be safe for concurrent use.
+type Codec interface {
+	// Name returns the name of the codec, which is stored in the header of the
+	// pack files compressed with it.
+	Name() string
+	// Compress appends the compressed src to dst and returns the result.
+	Compress(dst, src []byte) ([]byte, error)
+	// Decompress appends the decompressed src to dst and returns the result.
+	Decompress(dst, src []byte) ([]byte, error)
+}
+
+// ErrUnknownCodec is the error returned when reading a pack file compressed
+// with a codec that has not been registered.
+type ErrUnknownCodec struct{ Name string }
+
+func (e ErrUnknownCodec) Error() string { return fmt.Sprintf("Unknown pack codec '%s'", e.Name) }
+
+var registeredCodecs = map[string]Codec{}
+
+// RegisterCodec registers the codec c, so that pack files compressed with it
+// can be read. RegisterCodec is expected to be called from init functions.
+func RegisterCodec(c Codec) {
+	name := c.Name()
+	if _, found := registeredCodecs[name]; found {
+		panic(fmt.Errorf("Codec %s already registered", name))
+	}
+	registeredCodecs[name] = c
+}
+
+// FindCodec returns the registered codec with the given name, or nil if there
+// is no such codec.
+func FindCodec(name string) Codec {
+	return registeredCodecs[name]
+}
+
+// Codecs returns all the registered codecs, sorted by name.
+func Codecs() []Codec {
+	out := make([]Codec, 0, len(registeredCodecs))
+	for _, c := range registeredCodecs {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out
+}
+
+// Deflate is the codec that compresses chunks with the DEFLATE algorithm.
+var Deflate Codec = &deflate{}
+
+func init() {
+	RegisterCodec(Deflate)
+}
+
+type deflate struct {
+	writers sync.Pool // *flate.Writer
+	readers sync.Pool // io.ReadCloser implementing flate.Resetter
+}
+
+func (*deflate) Name() string { return "deflate" }
+
+func (d *deflate) Compress(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	w, _ := d.writers.Get().(*flate.Writer)
+	if w == nil {
+		var err error
+		if w, err = flate.NewWriter(buf, flate.DefaultCompression); err != nil {
+			return nil, err
+		}
+	} else {
+		w.Reset(buf)
+	}
+	defer d.writers.Put(w)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *deflate) Decompress(dst, src []byte) ([]byte, error) {
+	in := bytes.NewReader(src)
+	r, _ := d.readers.Get().(io.ReadCloser)
+	if r == nil {
+		r = flate.NewReader(in)
+	} else if err := r.(flate.Resetter).Reset(in, nil); err != nil {
+		return nil, err
+	}
+	defer d.readers.Put(r)
+	buf := bytes.NewBuffer(dst)
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -29,11 +29,17 @@ import (
 type Dynamic struct {
 	Desc   *descriptor.DescriptorProto
 	Fields map[string]interface{}
+	name   string
 	types  *types
 }
 
 func (Dynamic) ProtoMessage() {}
 
+// TypeName returns the fully qualified name of the message type.
+func (d *Dynamic) TypeName() string {
+	return d.name
+}
+
 func (d *Dynamic) Reset() {
 	d.Fields = map[string]interface{}{}
 }
@@ -64,10 +70,11 @@ func (d Dynamic) Format(f fmt.State, r rune) {
 	}
 }
 
-func newDynamic(desc *descriptor.DescriptorProto, types *types) *Dynamic {
+func newDynamic(name string, desc *descriptor.DescriptorProto, types *types) *Dynamic {
 	return &Dynamic{
 		Desc:   desc,
 		Fields: map[string]interface{}{},
+		name:   name,
 		types:  types,
 	}
 }
@@ -0,0 +1,323 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pack
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/google/gapid/core/fault"
+	"github.com/google/gapid/core/math/sint"
+)
+
+const (
+	// indexTrailerSize is the size of the encoded Index.offset field, which
+	// ends files that have an index.
+	indexTrailerSize = 9
+
+	// indexTrailerTag is the proto tag of the Index.offset field.
+	indexTrailerTag = 15<<3 | 1
+
+	// indexTypeName is the proto name of the Index message.
+	indexTypeName = "pack.Index"
+
+	// errRootRead is returned by rootEvents once the roots have been read.
+	errRootRead = fault.Const("Root read")
+)
+
+// Reader reads the root objects and groups of a pack file in any order.
+// Files written with an index are opened without reading the rest of the file,
+// other files are scanned once to build the index.
+// Reader is not safe for concurrent use.
+type Reader struct {
+	from  io.ReadSeeker
+	types *types
+	codec Codec // The codec of the chunks, or nil if they are not compressed.
+	start int64 // The offset of the first chunk.
+	roots []Checkpoint
+}
+
+// NewReader returns a Reader for the pack file read from the supplied stream.
+func NewReader(ctx context.Context, from io.ReadSeeker, forceDynamic bool) (*Reader, error) {
+	if _, err := from.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	r := newReader(from, nil, newTypes(forceDynamic))
+	if err := r.readPreamble(); err != nil {
+		return nil, err
+	}
+
+	out := &Reader{from: from, types: r.types, codec: r.codec, start: r.offset}
+	size, err := from.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	index, err := out.readIndex(size)
+	if err != nil {
+		return nil, err
+	}
+	if index != nil {
+		if err := out.loadIndex(index); err != nil {
+			return nil, err
+		}
+	} else if err := out.scan(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Count returns the number of root objects and groups in the file.
+func (r *Reader) Count() int {
+	return len(r.roots)
+}
+
+// Read reads the root object or group with the given index, calling the
+// methods of events for it and all its descendants. The chunks of other root
+// objects and groups are skipped.
+func (r *Reader) Read(ctx context.Context, index int, events Events) error {
+	return r.ReadRange(ctx, index, 1, events)
+}
+
+// ReadRange reads the count root objects and groups starting with the one with
+// the given index, calling the methods of events for them and all their
+// descendants in the order of the file. The chunks of other root objects and
+// groups are skipped. Unlike calling Read for each root, the chunks are read
+// sequentially.
+func (r *Reader) ReadRange(ctx context.Context, index, count int, events Events) error {
+	if index < 0 || count <= 0 || index+count > len(r.roots) {
+		return fmt.Errorf("Roots [%v..%v] out of range [0..%v]", index, index+count-1, len(r.roots)-1)
+	}
+	cp := r.roots[index]
+	if _, err := r.from.Seek(cp.Offset, io.SeekStart); err != nil {
+		return err
+	}
+	re := &rootEvents{events: events, remaining: count, open: map[uint64]struct{}{}}
+	rd := newReader(r.from, re, cp.types.prefix(cp.numTypes))
+	rd.id, rd.offset, rd.codec = cp.ID, cp.Offset, cp.codec
+	if err := rd.read(ctx); err != nil && err != errRootRead {
+		return err
+	}
+	return nil
+}
+
+// readIndex returns the index at the end of the file of the given size, or nil
+// if the file has no index.
+func (r *Reader) readIndex(size int64) (*Index, error) {
+	if size < r.start+indexTrailerSize {
+		return nil, nil
+	}
+	if _, err := r.from.Seek(size-indexTrailerSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+	trailer := make([]byte, indexTrailerSize)
+	if _, err := io.ReadFull(r.from, trailer); err != nil {
+		return nil, err
+	}
+	if trailer[0] != indexTrailerTag {
+		return nil, nil
+	}
+	offset := int64(binary.LittleEndian.Uint64(trailer[1:]))
+	if offset < r.start || offset >= size {
+		return nil, nil
+	}
+
+	// Check that the trailer does belong to an index chunk that ends the file.
+	if _, err := r.from.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	rd := newReader(r.from, nil, r.types)
+	rd.offset, rd.codec = offset, r.codec
+	chunkSize, err := rd.readChunk()
+	if err != nil || chunkSize <= 0 || rd.offset != size {
+		return nil, nil
+	}
+	if parent, err := rd.pb.DecodeZigzag64(); err != nil || parent != 0 {
+		return nil, nil
+	}
+	if _, err := rd.pb.DecodeZigzag64(); err != nil {
+		return nil, nil
+	}
+	index := &Index{}
+	if err := rd.pb.Unmarshal(index); err != nil || index.Offset != uint64(offset) {
+		return nil, nil
+	}
+	return index, nil
+}
+
+// loadIndex reads the types declared by the file and builds the list of
+// roots from the index.
+func (r *Reader) loadIndex(index *Index) error {
+	if len(index.RootIds) != len(index.RootOffsets) || len(index.RootTypes) != len(index.RootOffsets) {
+		return fmt.Errorf("Corrupt pack file index")
+	}
+	for _, offset := range index.TypeOffsets {
+		if _, err := r.from.Seek(int64(offset), io.SeekStart); err != nil {
+			return err
+		}
+		rd := newReader(r.from, nil, r.types)
+		rd.codec = r.codec
+		size, err := rd.readChunk()
+		if err != nil {
+			return err
+		}
+		if size >= 0 {
+			return fmt.Errorf("Corrupt pack file index: no type at offset %v", offset)
+		}
+		if err := rd.readType(); err != nil {
+			return err
+		}
+	}
+	r.roots = make([]Checkpoint, len(index.RootOffsets))
+	for i := range r.roots {
+		if index.RootTypes[i] > r.types.count() {
+			return fmt.Errorf("Corrupt pack file index: root %v uses undeclared types", i)
+		}
+		r.roots[i] = Checkpoint{
+			Offset:   int64(index.RootOffsets[i]),
+			ID:       index.RootIds[i],
+			types:    r.types,
+			numTypes: index.RootTypes[i],
+			codec:    r.codec,
+		}
+	}
+	return nil
+}
+
+// scan builds the list of roots by reading all the chunks of the file,
+// without decoding the objects.
+func (r *Reader) scan() error {
+	if _, err := r.from.Seek(r.start, io.SeekStart); err != nil {
+		return err
+	}
+	rd := newReader(r.from, nil, r.types)
+	rd.offset, rd.codec = r.start, r.codec
+	for ; ; rd.id++ {
+		offset := rd.offset
+		size, err := rd.readChunk()
+		switch {
+		case err == io.EOF || err == io.ErrUnexpectedEOF:
+			return nil // Truncated files are read up to the last complete chunk.
+		case err != nil:
+			return err
+		case size < 0:
+			if err := rd.readType(); err != nil {
+				return err
+			}
+			continue
+		}
+		parent, err := rd.pb.DecodeZigzag64()
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		tyIdx, err := rd.pb.DecodeZigzag64()
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		if int64(parent) < 0 || tyIdx == 0 {
+			continue // Not a root.
+		}
+		if idx := uint64(sint.Abs(int(int64(tyIdx)))); idx < r.types.count() && r.types.entries[idx].name == indexTypeName {
+			continue
+		}
+		r.roots = append(r.roots, Checkpoint{
+			Offset:   offset,
+			ID:       rd.id,
+			types:    r.types,
+			numTypes: r.types.count(),
+			codec:    r.codec,
+		})
+	}
+}
+
+// readType reads the body of a type definition chunk and adds the type to the
+// registry.
+func (r *reader) readType() error {
+	name, err := r.pb.DecodeStringBytes()
+	if err != nil {
+		return err
+	}
+	desc := &descriptor.DescriptorProto{}
+	if err = r.pb.Unmarshal(desc); err != nil {
+		return err
+	}
+	r.types.add(name, desc)
+	return nil
+}
+
+// rootEvents forwards the events of the first remaining root objects and
+// groups, and of their descendants, to events.
+type rootEvents struct {
+	events    Events
+	remaining int                 // The number of roots still to be seen.
+	open      map[uint64]struct{} // The open groups of the roots.
+}
+
+// done returns errRootRead if all the roots have been read.
+func (e *rootEvents) done() error {
+	if e.remaining == 0 && len(e.open) == 0 {
+		return errRootRead
+	}
+	return nil
+}
+
+func (e *rootEvents) BeginGroup(ctx context.Context, msg proto.Message, id uint64) error {
+	if e.remaining == 0 {
+		return nil
+	}
+	e.remaining--
+	e.open[id] = struct{}{}
+	return e.events.BeginGroup(ctx, msg, id)
+}
+
+func (e *rootEvents) BeginChildGroup(ctx context.Context, msg proto.Message, id, parentID uint64) error {
+	if _, ok := e.open[parentID]; !ok {
+		return nil
+	}
+	e.open[id] = struct{}{}
+	return e.events.BeginChildGroup(ctx, msg, id, parentID)
+}
+
+func (e *rootEvents) EndGroup(ctx context.Context, id uint64) error {
+	if _, ok := e.open[id]; !ok {
+		return nil
+	}
+	delete(e.open, id)
+	if err := e.events.EndGroup(ctx, id); err != nil {
+		return err
+	}
+	return e.done()
+}
+
+func (e *rootEvents) Object(ctx context.Context, msg proto.Message) error {
+	if e.remaining == 0 {
+		return nil
+	}
+	e.remaining--
+	if err := e.events.Object(ctx, msg); err != nil {
+		return err
+	}
+	return e.done()
+}
+
+func (e *rootEvents) ChildObject(ctx context.Context, msg proto.Message, parentID uint64) error {
+	if _, ok := e.open[parentID]; !ok {
+		return nil
+	}
+	return e.events.ChildObject(ctx, msg, parentID)
+}
@@ -24,8 +24,16 @@ const (
 	// ErrIncorrectMagic is the error returned when the file header is not matched.
 	ErrIncorrectMagic = fault.Const("Incorrect pack magic header")
 
+	errIndexed = fault.Const("Cannot write to a pack file after its index")
+
 	initalBufferSize = 4096
 	maxVarintSize    = 10
+
+	// compressedMajorVersion is the first major version of compressed files.
+	compressedMajorVersion = 3
+
+	// minCompressSize is the size of the smallest chunk that is compressed.
+	minCompressSize = 64
 )
 
 var (
@@ -33,10 +41,14 @@ var (
 	MinMajorVersion = 2
 
 	// MaxMajorVersion is the current maximum supported major version of pack files.
-	MaxMajorVersion = 2
+	MaxMajorVersion = 3
 
 	// header is the header written by this package including the version.
 	header = []byte("ProtoPack\r\n2.0\n\x00")
+
+	// compressedHeader is the header written by this package for compressed
+	// files, which is followed by the name of the codec.
+	compressedHeader = []byte("ProtoPack\r\n3.0\n\x00")
 )
 
 type Version struct {
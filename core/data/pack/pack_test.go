@@ -17,6 +17,8 @@ package pack_test
 import (
 	"bytes"
 	"context"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
@@ -148,3 +150,233 @@ func TestReaderWriter(t *testing.T) {
 	err = pack.Read(ctx, bytes.NewBuffer(buf.Bytes()), &got, true)
 	assert.For(ctx, "Read (force-dynamic)").ThatError(err).Succeeded()
 }
+
+type checkpoints struct {
+	events
+	list []pack.Checkpoint
+	seen []int // Number of events read at each checkpoint.
+}
+
+func (c *checkpoints) Checkpoint(ctx context.Context, cp pack.Checkpoint) error {
+	c.list = append(c.list, cp)
+	c.seen = append(c.seen, len(c.events))
+	return nil
+}
+
+func TestResume(t *testing.T) {
+	ctx := log.Testing(t)
+	buf := &bytes.Buffer{}
+
+	var id0 uint64
+	written := events{
+		eventObject{&testprotos.MsgA{F32: 1, U32: 2, S32: 3, Str: "four"}},
+		eventBeginGroup{&testprotos.MsgB{F64: 2, U64: 3, S64: 4, Bool: false}, &id0},
+		eventChildObject{&testprotos.MsgA{F32: 3, U32: 4, S32: 5, Str: "six"}, &id0},
+		eventEndGroup{&id0},
+		eventObject{&testprotos.MsgB{F64: 4, U64: 5, S64: 6, Bool: true}},
+		eventObject{&testprotos.MsgA{F32: 5, U32: 6, S32: 7, Str: "eight"}},
+	}
+
+	w, err := pack.NewWriter(buf)
+	assert.For(ctx, "NewWriter").ThatError(err).Succeeded()
+	for _, e := range written {
+		e.write(ctx, w)
+	}
+
+	got := &checkpoints{}
+	err = pack.Read(ctx, bytes.NewBuffer(buf.Bytes()), got, false)
+	assert.For(ctx, "Read").ThatError(err).Succeeded()
+
+	assert.For(ctx, "checkpoints").That(len(got.list) > 0).Equals(true)
+
+	// There must be no checkpoint while the group is open.
+	for i, n := range got.seen {
+		assert.For(ctx, "checkpoint %v in group", i).That(n == 2 || n == 3).Equals(false)
+	}
+
+	for i, cp := range got.list {
+		resumed := events{}
+		err := pack.Resume(ctx, bytes.NewBuffer(buf.Bytes()[cp.Offset:]), &resumed, cp)
+		assert.For(ctx, "Resume %v", i).ThatError(err).Succeeded()
+		assert.For(ctx, "events %v", i).ThatSlice(resumed).DeepEquals(got.events[got.seen[i]:])
+	}
+}
+
+func TestIndex(t *testing.T) {
+	ctx := log.Testing(t)
+
+	var id0, id1, id2, id3 uint64
+	written := events{
+		eventObject{&testprotos.MsgA{F32: 1, U32: 2, S32: 3, Str: "four"}},
+		eventObject{&testprotos.MsgB{F64: 2, U64: 3, S64: 4, Bool: false}},
+		eventBeginGroup{&testprotos.MsgA{F32: 5, U32: 6, S32: 10, Str: "eleven"}, &id0},
+		eventBeginGroup{&testprotos.MsgB{F64: 6, U64: 7, S64: 11, Bool: false}, &id1},
+		eventChildObject{&testprotos.MsgA{F32: 7, U32: 8, S32: 12, Str: "thirteen"}, &id0},
+		eventBeginChildGroup{&testprotos.MsgB{F64: 8, U64: 9, S64: 13, Bool: true}, &id2, &id0},
+		eventEndGroup{&id2},
+		eventEndGroup{&id0},
+		eventObject{&testprotos.MsgC{Entries: []*testprotos.MsgC_Entry{
+			&testprotos.MsgC_Entry{Value: 1},
+		}}},
+		eventBeginChildGroup{&testprotos.MsgA{F32: 9, U32: 10, S32: 11, Str: "twelve"}, &id3, &id1},
+		eventEndGroup{&id3},
+		eventEndGroup{&id1},
+	}
+	roots := []events{
+		{written[0]},
+		{written[1]},
+		{written[2], written[4], written[5], written[6], written[7]},
+		{written[3], written[9], written[10], written[11]},
+		{written[8]},
+	}
+
+	write := func(index bool) []byte {
+		id0, id1, id2, id3 = 0, 0, 0, 0
+		buf := &bytes.Buffer{}
+		w, err := pack.NewWriter(buf)
+		assert.For(ctx, "NewWriter").ThatError(err).Succeeded()
+		for _, e := range written {
+			e.write(ctx, w)
+		}
+		if index {
+			assert.For(ctx, "WriteIndex").ThatError(w.WriteIndex(ctx)).Succeeded()
+			err := w.Object(ctx, &testprotos.MsgA{})
+			assert.For(ctx, "Object after index").ThatError(err).Failed()
+		}
+		return buf.Bytes()
+	}
+
+	for _, index := range []bool{true, false} {
+		data := write(index)
+
+		// The index must be invisible to sequential reads.
+		got := events{}
+		err := pack.Read(ctx, bytes.NewBuffer(data), &got, false)
+		assert.For(ctx, "Read (index: %v)", index).ThatError(err).Succeeded()
+		assert.For(ctx, "events (index: %v)", index).ThatSlice(got).DeepEquals(written)
+
+		r, err := pack.NewReader(ctx, bytes.NewReader(data), false)
+		if !assert.For(ctx, "NewReader (index: %v)", index).ThatError(err).Succeeded() {
+			continue
+		}
+		assert.For(ctx, "Count (index: %v)", index).That(r.Count()).Equals(len(roots))
+		for i := len(roots) - 1; i >= 0; i-- {
+			got := events{}
+			err := r.Read(ctx, i, &got)
+			assert.For(ctx, "Read root %v (index: %v)", i, index).ThatError(err).Succeeded()
+			assert.For(ctx, "root %v (index: %v)", i, index).ThatSlice(got).DeepEquals(roots[i])
+		}
+
+		// Ranges of roots skip the chunks of the roots outside the range.
+		got = events{}
+		err = r.ReadRange(ctx, 2, 2, &got)
+		assert.For(ctx, "ReadRange (index: %v)", index).ThatError(err).Succeeded()
+		want := append(append(events{}, written[2:8]...), written[9:]...)
+		assert.For(ctx, "range (index: %v)", index).ThatSlice(got).DeepEquals(want)
+		err = r.ReadRange(ctx, 4, 2, &got)
+		assert.For(ctx, "ReadRange out of range (index: %v)", index).ThatError(err).Failed()
+	}
+}
+
+func TestCompressed(t *testing.T) {
+	ctx := log.Testing(t)
+
+	long := strings.Repeat("compressible ", 100)
+	var id0 uint64
+	written := events{
+		eventObject{&testprotos.MsgA{F32: 1, U32: 2, S32: 3, Str: long}},
+		eventBeginGroup{&testprotos.MsgB{F64: 2, U64: 3, S64: 4, Bool: false}, &id0},
+		eventChildObject{&testprotos.MsgA{F32: 3, U32: 4, S32: 5, Str: long}, &id0},
+		eventEndGroup{&id0},
+		eventObject{&testprotos.MsgA{F32: 5, U32: 6, S32: 7, Str: "short"}},
+	}
+
+	write := func(codec pack.Codec) []byte {
+		id0 = 0
+		buf := &bytes.Buffer{}
+		w, err := pack.NewCompressedWriter(buf, codec)
+		assert.For(ctx, "NewCompressedWriter").ThatError(err).Succeeded()
+		for _, e := range written {
+			e.write(ctx, w)
+		}
+		assert.For(ctx, "WriteIndex").ThatError(w.WriteIndex(ctx)).Succeeded()
+		return buf.Bytes()
+	}
+	check := func(name string, data []byte) {
+		got := events{}
+		err := pack.Read(ctx, bytes.NewBuffer(data), &got, false)
+		assert.For(ctx, "Read %v", name).ThatError(err).Succeeded()
+		assert.For(ctx, "events %v", name).ThatSlice(got).DeepEquals(written)
+
+		r, err := pack.NewReader(ctx, bytes.NewReader(data), false)
+		if !assert.For(ctx, "NewReader %v", name).ThatError(err).Succeeded() {
+			return
+		}
+		assert.For(ctx, "Count %v", name).That(r.Count()).Equals(3)
+		got = events{}
+		err = r.Read(ctx, 1, &got)
+		assert.For(ctx, "Read root %v", name).ThatError(err).Succeeded()
+		assert.For(ctx, "root %v", name).ThatSlice(got).DeepEquals(written[1:4])
+	}
+
+	plain, compressed := write(nil), write(pack.Deflate)
+	check("plain", plain)
+	for _, codec := range pack.Codecs() {
+		data := write(codec)
+		assert.For(ctx, "%v compressed size", codec.Name()).That(len(data) < len(plain)).Equals(true)
+		check(codec.Name(), data)
+	}
+
+	buf := &bytes.Buffer{}
+	err := pack.Transcode(ctx, bytes.NewReader(plain), buf, pack.Deflate)
+	assert.For(ctx, "Transcode compress").ThatError(err).Succeeded()
+	check("transcoded", buf.Bytes())
+
+	decompressed := &bytes.Buffer{}
+	err = pack.Transcode(ctx, bytes.NewReader(buf.Bytes()), decompressed, nil)
+	assert.For(ctx, "Transcode decompress").ThatError(err).Succeeded()
+	assert.For(ctx, "decompressed").ThatSlice(decompressed.Bytes()).Equals(plain)
+
+	unknown := append([]byte{}, compressed...)
+	copy(unknown[17:], "inflate")
+	err = pack.Read(ctx, bytes.NewBuffer(unknown), &events{}, false)
+	assert.For(ctx, "Read unknown codec").ThatError(err).Equals(pack.ErrUnknownCodec{Name: "inflate"})
+}
+
+func TestCodecs(t *testing.T) {
+	ctx := log.Testing(t)
+	rng := rand.New(rand.NewSource(1))
+	random := make([]byte, 100000)
+	rng.Read(random)
+	mixed := []byte{}
+	for i := 0; i < 1000; i++ {
+		// Repeats of earlier data at near and far offsets, between literals.
+		mixed = append(mixed, random[:rng.Intn(100)]...)
+		mixed = append(mixed, random[rng.Intn(1000):][:rng.Intn(200)]...)
+	}
+	inputs := map[string][]byte{
+		"empty":  {},
+		"short":  []byte("abc"),
+		"run":    bytes.Repeat([]byte{7}, 10000),
+		"text":   []byte(strings.Repeat("The quick brown fox jumps over the lazy dog. ", 500)),
+		"random": random,
+		"mixed":  mixed,
+	}
+	for _, codec := range pack.Codecs() {
+		for name, data := range inputs {
+			ctx := log.Enter(ctx, codec.Name()+" "+name)
+			compressed, err := codec.Compress([]byte("prefix"), data)
+			if !assert.For(ctx, "Compress").ThatError(err).Succeeded() {
+				continue
+			}
+			got, err := codec.Decompress([]byte("prefix"), compressed[len("prefix"):])
+			if assert.For(ctx, "Decompress").ThatError(err).Succeeded() {
+				assert.For(ctx, "data").ThatSlice(got).Equals(append([]byte("prefix"), data...))
+			}
+			if len(compressed) > 1 {
+				_, err = codec.Decompress(nil, compressed[len("prefix"):len(compressed)-1])
+				assert.For(ctx, "Decompress truncated").ThatError(err).Failed()
+			}
+		}
+	}
+}
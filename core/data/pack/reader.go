@@ -20,7 +20,6 @@ import (
 	"io"
 
 	"github.com/golang/protobuf/proto"
-	"github.com/golang/protobuf/protoc-gen-go/descriptor"
 	"github.com/google/gapid/core/event/task"
 	"github.com/google/gapid/core/math/sint"
 	"github.com/pkg/errors"
@@ -35,19 +34,64 @@ func (e ErrUnknownType) Error() string { return fmt.Sprintf("Unknown proto type
 // Read reads the pack file from the supplied stream.
 // This function will read the header from the stream, adjusting it's position.
 // It may read extra bytes from the stream into an internal buffer.
+// If events implements Checkpointer, then it is notified of each position in
+// the stream where reading can be resumed with Resume.
 func Read(ctx context.Context, from io.Reader, events Events, forceDynamic bool) error {
+	r := newReader(from, events, newTypes(forceDynamic))
+	if err := r.readPreamble(); err != nil {
+		return err
+	}
+	return r.read(ctx)
+}
+
+// Resume reads the pack file from the supplied stream, which must be
+// positioned at the checkpoint cp of the same pack file. The stream must not
+// include the pack file header.
+// Reading stops at the end of the stream, so the stream can be limited to read
+// the chunks between two checkpoints.
+func Resume(ctx context.Context, from io.Reader, events Events, cp Checkpoint) error {
+	if cp.types == nil {
+		return fmt.Errorf("Invalid checkpoint")
+	}
+	r := newReader(from, events, cp.types.prefix(cp.numTypes))
+	r.id, r.offset, r.codec = cp.ID, cp.Offset, cp.codec
+	return r.read(ctx)
+}
+
+// Checkpoint is a position between two chunks of a pack file where no groups
+// are open, from which reading can be resumed with Resume.
+type Checkpoint struct {
+	// Offset is the byte offset of the next chunk from the start of the file.
+	Offset int64
+	// ID is the identifier of the next chunk.
+	ID uint64
+
+	types    *types // The type registry of the reader that made the checkpoint.
+	numTypes uint64 // The number of types declared before the checkpoint.
+	codec    Codec  // The codec of the chunks, or nil if they are not compressed.
+}
+
+// Checkpointer is the optional interface implemented by Events that want to be
+// notified of the checkpoints of the stream being read.
+type Checkpointer interface {
+	// Checkpoint is called after each chunk that leaves no groups open.
+	Checkpoint(ctx context.Context, cp Checkpoint) error
+}
+
+func newReader(from io.Reader, events Events, types *types) *reader {
 	r := &reader{
-		types:  newTypes(forceDynamic),
+		types:  types,
 		from:   from,
 		buf:    make([]byte, 0, initalBufferSize),
 		events: events,
+		open:   map[uint64]struct{}{},
 	}
+	r.checkpointer, _ = events.(Checkpointer)
 	r.pb = proto.NewBuffer(r.buf)
-	if version, err := r.readHeader(); err != nil {
-		return err
-	} else if !(MinMajorVersion <= version.Major && version.Major <= MaxMajorVersion) {
-		return ErrUnsupportedVersion{Version: version}
-	}
+	return r
+}
+
+func (r *reader) read(ctx context.Context) error {
 	for ; !task.Stopped(ctx); r.id++ {
 		if err := r.unmarshal(ctx); err != nil {
 			cause := errors.Cause(err)
@@ -56,6 +100,18 @@ func Read(ctx context.Context, from io.Reader, events Events, forceDynamic bool)
 			}
 			return err
 		}
+		if r.checkpointer != nil && len(r.open) == 0 {
+			cp := Checkpoint{
+				Offset:   r.offset,
+				ID:       r.id + 1,
+				types:    r.types,
+				numTypes: r.types.count(),
+				codec:    r.codec,
+			}
+			if err := r.checkpointer.Checkpoint(ctx, cp); err != nil {
+				return err
+			}
+		}
 	}
 	return task.StopReason(ctx)
 }
@@ -63,13 +119,18 @@ func Read(ctx context.Context, from io.Reader, events Events, forceDynamic bool)
 // reader is the type for a pack file reader.
 // They should only be constructed by NewReader.
 type reader struct {
-	types     *types
-	events    Events
-	id        uint64
-	buf       []byte
-	bufOffset int
-	pb        *proto.Buffer
-	from      io.Reader
+	types        *types
+	events       Events
+	checkpointer Checkpointer
+	id           uint64
+	offset       int64               // Offset of the next chunk in the file.
+	open         map[uint64]struct{} // Groups that have not been ended.
+	codec        Codec               // The codec of the chunks, if compressed.
+	buf          []byte
+	bufOffset    int
+	raw          []byte // The decompressed data of the current chunk.
+	pb           *proto.Buffer
+	from         io.Reader
 }
 
 func (r *reader) unmarshal(ctx context.Context) (err error) {
@@ -80,16 +141,7 @@ func (r *reader) unmarshal(ctx context.Context) (err error) {
 
 	// Negated size means this is type definition chunk.
 	if size < 0 {
-		name, err := r.pb.DecodeStringBytes()
-		if err != nil {
-			return err
-		}
-		desc := &descriptor.DescriptorProto{}
-		if err = r.pb.Unmarshal(desc); err != nil {
-			return err
-		}
-		r.types.add(name, desc)
-		return nil
+		return r.readType()
 	}
 
 	// Read first two fields of object instance. If missing, they are implicitly set to 0.
@@ -107,6 +159,7 @@ func (r *reader) unmarshal(ctx context.Context) (err error) {
 
 	if tyIdx == 0 { // Null-terminator
 		if hasParent {
+			delete(r.open, r.id+parent)
 			if err := r.events.EndGroup(ctx, r.id+parent); err != nil {
 				return err
 			}
@@ -119,10 +172,16 @@ func (r *reader) unmarshal(ctx context.Context) (err error) {
 			return fmt.Errorf("Unknown type index: %v. Type count: %v.", tyIdx, r.types.count())
 		}
 		ty := *r.types.entries[tyIdx]
+		if ty.name == indexTypeName && !hasParent {
+			return nil // The index is only used by Reader.
+		}
 		msg := ty.create()
 		if err := r.pb.Unmarshal(msg); err != nil {
 			return err
 		}
+		if hasChildren {
+			r.open[r.id] = struct{}{}
+		}
 		if !hasParent {
 			if hasChildren {
 				err = r.events.BeginGroup(ctx, msg, r.id)
@@ -163,6 +222,41 @@ func (r *reader) readHeader() (Version, error) {
 	return Version{}, ErrIncorrectMagic
 }
 
+// readPreamble reads the header of the file, and the name of the codec of
+// compressed files, leaving the reader at the first chunk.
+func (r *reader) readPreamble() error {
+	version, err := r.readHeader()
+	if err != nil {
+		return err
+	}
+	if !(MinMajorVersion <= version.Major && version.Major <= MaxMajorVersion) {
+		return ErrUnsupportedVersion{Version: version}
+	}
+	r.offset = int64(len(header))
+	if version.Major < compressedMajorVersion {
+		return nil
+	}
+
+	if err := r.readN(maxVarintSize); err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	data := r.pb.Bytes()
+	size, n := proto.DecodeVarint(data)
+	if n == 0 {
+		return io.ErrUnexpectedEOF
+	}
+	r.bufOffset -= len(data) - n
+	if err := r.readN(int(size)); err != nil {
+		return err
+	}
+	name := string(r.pb.Bytes())
+	if r.codec = FindCodec(name); r.codec == nil {
+		return ErrUnknownCodec{Name: name}
+	}
+	r.offset += int64(n) + int64(size)
+	return nil
+}
+
 func (r *reader) readChunk() (chunkSize int64, err error) {
 	// Make sure we have enough bytes for the maxiumum a varint could be, but don't
 	// fail if the eof is within that range
@@ -178,7 +272,36 @@ func (r *reader) readChunk() (chunkSize int64, err error) {
 		return 0, io.EOF
 	}
 	size = (size >> 1) ^ uint64((int64(size&1)<<63)>>63) // Decode zig-zag encoding
-	return int64(size), r.readN(sint.Abs(int(size)))
+	r.offset += int64(n + sint.Abs(int(size)))
+	if err := r.readN(sint.Abs(int(size))); err != nil || r.codec == nil {
+		return int64(size), err
+	}
+	return int64(size), r.decompress()
+}
+
+// decompress replaces the data of the current chunk of a compressed file with
+// its decompressed data. Compressed chunks start with the size of their
+// decompressed data, or 0 if the chunk is stored uncompressed.
+func (r *reader) decompress() error {
+	data := r.pb.Bytes()
+	size, n := proto.DecodeVarint(data)
+	if n == 0 {
+		return fmt.Errorf("Corrupt compressed chunk")
+	}
+	if size == 0 {
+		r.pb.SetBuf(data[n:])
+		return nil
+	}
+	raw, err := r.codec.Decompress(r.raw[:0], data[n:])
+	if err != nil {
+		return err
+	}
+	if uint64(len(raw)) != size {
+		return fmt.Errorf("Decompressed chunk has %v bytes, expected %v", len(raw), size)
+	}
+	r.raw = raw
+	r.pb.SetBuf(raw)
+	return nil
 }
 
 // readN makes sure there is size bytes available in the buffer if possible
@@ -0,0 +1,188 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pack
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Snappy is the codec that compresses chunks in the Snappy block format.
+// It trades compression ratio for speed, which makes it better suited than
+// Deflate for captures that are compressed while they are written.
+// See https://github.com/google/snappy/blob/master/format_description.txt
+var Snappy Codec = snappy{}
+
+func init() {
+	RegisterCodec(Snappy)
+}
+
+const (
+	snappyTableBits = 14        // Number of bits of the match hash table index.
+	snappyMaxOffset = 1<<16 - 1 // The maximum offset of the copies written.
+	snappyMinMatch  = 4         // The minimum length of the copies written.
+)
+
+// Element tags of the Snappy block format.
+const (
+	snappyLiteral = 0
+	snappyCopy1   = 1
+	snappyCopy2   = 2
+	snappyCopy4   = 3
+)
+
+type snappy struct{}
+
+func (snappy) Name() string { return "snappy" }
+
+func (snappy) Compress(dst, src []byte) ([]byte, error) {
+	var tmp [binary.MaxVarintLen64]byte
+	dst = append(dst, tmp[:binary.PutUvarint(tmp[:], uint64(len(src)))]...)
+
+	// table holds the position+1 of the last sequence of snappyMinMatch bytes
+	// with each hash, or 0 for none.
+	var table [1 << snappyTableBits]int32
+	lit, s := 0, 0 // The start of the pending literal, and the current position.
+	for s+snappyMinMatch <= len(src) {
+		v := binary.LittleEndian.Uint32(src[s:])
+		h := (v * 0x1e35a7bd) >> (32 - snappyTableBits)
+		c := int(table[h]) - 1
+		table[h] = int32(s + 1)
+		if c < 0 || s-c > snappyMaxOffset || binary.LittleEndian.Uint32(src[c:]) != v {
+			// Skip faster through data that does not compress.
+			s += 1 + (s-lit)>>5
+			continue
+		}
+		dst = snappyEmitLiteral(dst, src[lit:s])
+		start := s
+		s, c = s+snappyMinMatch, c+snappyMinMatch
+		for s < len(src) && src[s] == src[c] {
+			s, c = s+1, c+1
+		}
+		dst = snappyEmitCopy(dst, s-c, s-start)
+		lit = s
+	}
+	return snappyEmitLiteral(dst, src[lit:]), nil
+}
+
+func snappyEmitLiteral(dst, lit []byte) []byte {
+	if len(lit) == 0 {
+		return dst
+	}
+	switch n := uint32(len(lit) - 1); {
+	case n < 60:
+		dst = append(dst, byte(n)<<2|snappyLiteral)
+	case n < 1<<8:
+		dst = append(dst, 60<<2|snappyLiteral, byte(n))
+	case n < 1<<16:
+		dst = append(dst, 61<<2|snappyLiteral, byte(n), byte(n>>8))
+	case n < 1<<24:
+		dst = append(dst, 62<<2|snappyLiteral, byte(n), byte(n>>8), byte(n>>16))
+	default:
+		dst = append(dst, 63<<2|snappyLiteral, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+	}
+	return append(dst, lit...)
+}
+
+func snappyEmitCopy(dst []byte, offset, length int) []byte {
+	// Copies with a 2 byte offset hold at most 64 bytes. Split long copies so
+	// that the last one holds at least snappyMinMatch bytes.
+	for length >= 68 {
+		dst = append(dst, 63<<2|snappyCopy2, byte(offset), byte(offset>>8))
+		length -= 64
+	}
+	if length > 64 {
+		dst = append(dst, 59<<2|snappyCopy2, byte(offset), byte(offset>>8))
+		length -= 60
+	}
+	if length >= 12 || offset >= 1<<11 {
+		return append(dst, byte(length-1)<<2|snappyCopy2, byte(offset), byte(offset>>8))
+	}
+	return append(dst, byte(offset>>8)<<5|byte(length-4)<<2|snappyCopy1, byte(offset))
+}
+
+func (snappy) Decompress(dst, src []byte) ([]byte, error) {
+	size, n := binary.Uvarint(src)
+	if n <= 0 {
+		return nil, fmt.Errorf("Invalid snappy block length")
+	}
+	src = src[n:]
+	start := len(dst)
+	for len(src) > 0 {
+		tag := src[0]
+		offset, length := 0, 0
+		switch tag & 3 {
+		case snappyLiteral:
+			n := int(tag >> 2)
+			src = src[1:]
+			if n >= 60 {
+				bytes := n - 59
+				if len(src) < bytes {
+					return nil, fmt.Errorf("Truncated snappy literal")
+				}
+				n = 0
+				for i := 0; i < bytes; i++ {
+					n |= int(src[i]) << uint(8*i)
+				}
+				src = src[bytes:]
+			}
+			if n++; n <= 0 || n > len(src) {
+				return nil, fmt.Errorf("Truncated snappy literal")
+			}
+			if uint64(len(dst)-start+n) > size {
+				return nil, fmt.Errorf("Snappy block longer than %v bytes", size)
+			}
+			dst = append(dst, src[:n]...)
+			src = src[n:]
+			continue
+		case snappyCopy1:
+			if len(src) < 2 {
+				return nil, fmt.Errorf("Truncated snappy copy")
+			}
+			length = 4 + int(tag>>2&7)
+			offset = int(tag>>5)<<8 | int(src[1])
+			src = src[2:]
+		case snappyCopy2:
+			if len(src) < 3 {
+				return nil, fmt.Errorf("Truncated snappy copy")
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[1:]))
+			src = src[3:]
+		case snappyCopy4:
+			if len(src) < 5 {
+				return nil, fmt.Errorf("Truncated snappy copy")
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[1:]))
+			src = src[5:]
+		}
+		if offset <= 0 || offset > len(dst)-start {
+			return nil, fmt.Errorf("Invalid snappy copy offset %v", offset)
+		}
+		if uint64(len(dst)-start+length) > size {
+			return nil, fmt.Errorf("Snappy block longer than %v bytes", size)
+		}
+		// The source and destination of the copy may overlap, so copy byte
+		// by byte.
+		for i := 0; i < length; i++ {
+			dst = append(dst, dst[len(dst)-offset])
+		}
+	}
+	if got := uint64(len(dst) - start); got != size {
+		return nil, fmt.Errorf("Decompressed %v bytes of snappy block, expected %v", got, size)
+	}
+	return dst, nil
+}
@@ -0,0 +1,83 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pack
+
+import (
+	"context"
+	"io"
+
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/math/sint"
+)
+
+// Transcode copies the pack file read from the supplied stream to the writer
+// to, compressing its chunks with codec, or leaving them uncompressed if codec
+// is nil. The chunks are copied without decoding their messages, so the types
+// of the file do not need to be known. The written file always ends with an
+// index, which replaces the index of the file read, if any.
+func Transcode(ctx context.Context, from io.Reader, to io.Writer, codec Codec) error {
+	r := newReader(from, nil, newTypes(true))
+	if err := r.readPreamble(); err != nil {
+		return err
+	}
+	w, err := NewCompressedWriter(to, codec)
+	if err != nil {
+		return err
+	}
+	for ; !task.Stopped(ctx); r.id++ {
+		size, err := r.readChunk()
+		switch {
+		case err == io.EOF:
+			return w.WriteIndex(ctx)
+		case err != nil:
+			return err
+		}
+		data := r.pb.Bytes()
+
+		if size < 0 {
+			if err := r.readType(); err != nil {
+				return err
+			}
+			// Keep the writer's types in sync, so that the index type is only
+			// declared once.
+			t := r.types.entries[r.types.count()-1]
+			w.types.add(t.name, t.desc)
+			if err := w.writeChunk(data, true); err != nil {
+				return err
+			}
+			continue
+		}
+
+		parent, err := r.pb.DecodeZigzag64()
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		tyIdx, err := r.pb.DecodeZigzag64()
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		if int64(parent) >= 0 && tyIdx != 0 {
+			idx := uint64(sint.Abs(int(int64(tyIdx))))
+			if idx < r.types.count() && r.types.entries[idx].name == indexTypeName {
+				continue // The index is always last, and is rewritten.
+			}
+			w.addRoot()
+		}
+		if err := w.writeChunk(data, false); err != nil {
+			return err
+		}
+	}
+	return task.StopReason(ctx)
+}
@@ -105,6 +105,19 @@ func (t *types) addForType(ctx context.Context, typ reflect.Type, cb func(t *ty)
 	return ty, nil
 }
 
+// prefix returns a new registry holding the first n types of t.
+func (t *types) prefix(n uint64) *types {
+	out := &types{
+		entries:      append([]*ty{}, t.entries[:n]...),
+		byName:       map[string]*ty{},
+		forceDynamic: t.forceDynamic,
+	}
+	for _, e := range out.entries[1:] {
+		out.byName[e.name] = e
+	}
+	return out
+}
+
 // count returns the number of types in the registry.
 func (t *types) count() uint64 {
 	return uint64(len(t.entries))
@@ -112,7 +125,7 @@ func (t *types) count() uint64 {
 
 // add adds a type by name and descriptor.
 func (t *types) add(name string, desc *descriptor.DescriptorProto) *ty {
-	create := func() proto.Message { return newDynamic(desc, t) }
+	create := func() proto.Message { return newDynamic(name, desc, t) }
 	if !t.forceDynamic {
 		if ty := proto.MessageType(name); ty != nil {
 			create = func() proto.Message { return reflect.New(ty.Elem()).Interface().(proto.Message) }
@@ -27,8 +27,13 @@ import (
 type Writer struct {
 	types   *types
 	id      uint64
+	offset  int64 // Offset of the next chunk in the file.
+	index   Index // Index of the chunks written so far.
+	indexed bool  // True once the index has been written.
+	codec   Codec // The codec of the chunks, or nil if they are not compressed.
 	buf     *proto.Buffer
 	sizebuf *proto.Buffer
+	zbuf    []byte // The compressed data of the current chunk.
 	to      io.Writer
 }
 
@@ -37,15 +42,34 @@ type Writer struct {
 // This method will write the packfile magic and header to the underlying
 // stream.
 func NewWriter(to io.Writer) (*Writer, error) {
+	return NewCompressedWriter(to, nil)
+}
+
+// NewCompressedWriter constructs and returns a new Writer that writes to the
+// supplied output stream, compressing the chunks with the given codec.
+// If codec is nil then the chunks are not compressed, as with NewWriter.
+// Compressed files can only be read by readers that support the codec, and
+// only with version 3 or later of this package.
+func NewCompressedWriter(to io.Writer, codec Codec) (*Writer, error) {
 	w := &Writer{
 		types:   newTypes(false),
+		codec:   codec,
 		buf:     proto.NewBuffer(make([]byte, 0, initalBufferSize)),
 		sizebuf: proto.NewBuffer(make([]byte, 0, maxVarintSize)),
 		to:      to,
 	}
-	if _, err := w.to.Write(header); err != nil {
+	preamble := header
+	if codec != nil {
+		// The header of compressed files is followed by the codec name.
+		name := codec.Name()
+		preamble = append([]byte{}, compressedHeader...)
+		preamble = append(preamble, proto.EncodeVarint(uint64(len(name)))...)
+		preamble = append(preamble, name...)
+	}
+	if _, err := w.to.Write(preamble); err != nil {
 		return nil, err
 	}
+	w.offset = int64(len(preamble))
 	return w, nil
 }
 
@@ -65,6 +89,9 @@ func (w *Writer) BeginChildGroup(ctx context.Context, msg proto.Message, parentI
 // EndGroup should be closed immediately after the last child has been added
 // to the group.
 func (w *Writer) EndGroup(ctx context.Context, id uint64) error {
+	if w.indexed {
+		return errIndexed
+	}
 	if err := w.writeParentID(id); err != nil {
 		return err
 	}
@@ -84,7 +111,36 @@ func (w *Writer) ChildObject(ctx context.Context, msg proto.Message, parentID ui
 	return err
 }
 
+// WriteIndex writes an index of the root objects and groups written so far as
+// the last chunk of the file, which allows the file to be read in any order
+// with a Reader. Nothing can be written after the index.
+// Readers that do not support indices skip the index chunk.
+func (w *Writer) WriteIndex(ctx context.Context) error {
+	if w.indexed {
+		return errIndexed
+	}
+	ty, err := w.types.addForMessage(ctx, &w.index, func(t *ty) error { return w.writeType(t) })
+	if err != nil {
+		return err
+	}
+	w.index.Offset = uint64(w.offset)
+	if err := w.buf.EncodeZigzag64(0); err != nil {
+		return err
+	}
+	if err := w.buf.EncodeZigzag64(ty.index); err != nil {
+		return err
+	}
+	if err := w.buf.Marshal(&w.index); err != nil {
+		return err
+	}
+	w.indexed = true
+	return w.flushChunk(false)
+}
+
 func (w *Writer) writeMessage(ctx context.Context, msg proto.Message, isGroup bool, parentID *uint64) (id uint64, err error) {
+	if w.indexed {
+		return 0, errIndexed
+	}
 
 	ty, err := w.types.addForMessage(ctx, msg, func(t *ty) error { return w.writeType(t) })
 	if err != nil {
@@ -92,6 +148,7 @@ func (w *Writer) writeMessage(ctx context.Context, msg proto.Message, isGroup bo
 	}
 
 	if parentID == nil {
+		w.addRoot()
 		if err := w.buf.EncodeZigzag64(0); err != nil {
 			return 0, err
 		}
@@ -125,6 +182,13 @@ func (w *Writer) writeParentID(id uint64) error {
 	return w.buf.EncodeZigzag64(id - w.id)
 }
 
+// addRoot adds the next chunk to the index as a root object or group.
+func (w *Writer) addRoot() {
+	w.index.RootOffsets = append(w.index.RootOffsets, uint64(w.offset))
+	w.index.RootIds = append(w.index.RootIds, w.id)
+	w.index.RootTypes = append(w.index.RootTypes, w.types.count())
+}
+
 func (w *Writer) writeType(t *ty) error {
 	if err := w.buf.EncodeStringBytes(t.name); err != nil {
 		return err
@@ -136,7 +200,24 @@ func (w *Writer) writeType(t *ty) error {
 }
 
 func (w *Writer) flushChunk(isTypeDef bool) error {
-	size := len(w.buf.Bytes())
+	err := w.writeChunk(w.buf.Bytes(), isTypeDef)
+	w.buf.Reset()
+	return err
+}
+
+// writeChunk writes a chunk holding data, compressing it if the file is
+// compressed.
+func (w *Writer) writeChunk(data []byte, isTypeDef bool) error {
+	if isTypeDef {
+		w.index.TypeOffsets = append(w.index.TypeOffsets, uint64(w.offset))
+	}
+	if w.codec != nil {
+		var err error
+		if data, err = w.compress(data); err != nil {
+			return err
+		}
+	}
+	size := len(data)
 	if isTypeDef {
 		size = -size
 	}
@@ -144,12 +225,32 @@ func (w *Writer) flushChunk(isTypeDef bool) error {
 		return err
 	}
 	_, err := w.to.Write(w.sizebuf.Bytes())
+	w.offset += int64(len(w.sizebuf.Bytes()))
 	w.sizebuf.Reset()
 	if err != nil {
 		return err
 	}
-	_, err = w.to.Write(w.buf.Bytes())
-	w.buf.Reset()
+	_, err = w.to.Write(data)
+	w.offset += int64(len(data))
 	w.id++
 	return err
 }
+
+// compress returns the data of a chunk of a compressed file, prefixed with the
+// size of the decompressed data. Small chunks, chunks that do not compress and
+// the index are stored uncompressed with a size of 0, which keeps the index
+// trailer at the end of the file.
+func (w *Writer) compress(data []byte) ([]byte, error) {
+	if len(data) >= minCompressSize && !w.indexed {
+		out, err := w.codec.Compress(append(w.zbuf[:0], proto.EncodeVarint(uint64(len(data)))...), data)
+		if err != nil {
+			return nil, err
+		}
+		w.zbuf = out
+		if len(out) < len(data) {
+			return out, nil
+		}
+	}
+	w.zbuf = append(append(w.zbuf[:0], 0), data...)
+	return w.zbuf, nil
+}
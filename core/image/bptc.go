@@ -0,0 +1,633 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/gapid/core/data/binary"
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/math/f16"
+	"github.com/google/gapid/core/math/sint"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream"
+)
+
+var (
+	BPTC_RGBA_U8_NORM  = NewBPTC_RGBA_U8_NORM("BPTC_RGBA_U8_NORM")
+	BPTC_SRGBA_U8_NORM = NewBPTC_SRGBA_U8_NORM("BPTC_SRGBA_U8_NORM")
+	BPTC_RGB_SF16      = NewBPTC_RGB_SF16("BPTC_RGB_SF16")
+	BPTC_RGB_UF16      = NewBPTC_RGB_UF16("BPTC_RGB_UF16")
+)
+
+func init() {
+	RegisterConverter(BPTC_RGBA_U8_NORM, RGBA_U8_NORM, func(src []byte, w, h, d int) ([]byte, error) {
+		return decode4x4Blocks(src, w, h, d, decodeBC7)
+	})
+	RegisterConverter(BPTC_SRGBA_U8_NORM, SRGBA_U8_NORM, func(src []byte, w, h, d int) ([]byte, error) {
+		return decode4x4Blocks(src, w, h, d, decodeBC7)
+	})
+	RegisterConverter(BPTC_RGB_SF16, RGBA_F32, func(src []byte, w, h, d int) ([]byte, error) {
+		return decodeBC6H(src, w, h, d, true)
+	})
+	RegisterConverter(BPTC_RGB_UF16, RGBA_F32, func(src []byte, w, h, d int) ([]byte, error) {
+		return decodeBC6H(src, w, h, d, false)
+	})
+
+	// RGBA_F32 is not one of the intermediate formats tried by Convert, so
+	// register the 8-bit conversions of the HDR formats explicitly.
+	for _, f := range []*Format{BPTC_RGB_SF16, BPTC_RGB_UF16} {
+		f := f
+		RegisterConverter(f, RGBA_U8_NORM, func(src []byte, w, h, d int) ([]byte, error) {
+			rgba, err := Convert(src, w, h, d, f, RGBA_F32)
+			if err != nil {
+				return nil, err
+			}
+			return Convert(rgba, w, h, d, RGBA_F32, RGBA_U8_NORM)
+		})
+	}
+}
+
+// NewBPTC_RGBA_U8_NORM returns a format representing the
+// COMPRESSED_RGBA_BPTC_UNORM (BC7) block texture compression format.
+func NewBPTC_RGBA_U8_NORM(name string) *Format {
+	return &Format{Name: name, Format: &Format_BptcRgbaU8Norm{&FmtBPTC_RGBA_U8_NORM{}}}
+}
+
+// NewBPTC_SRGBA_U8_NORM returns a format representing the
+// COMPRESSED_SRGB_ALPHA_BPTC_UNORM (BC7) block texture compression format.
+func NewBPTC_SRGBA_U8_NORM(name string) *Format {
+	return &Format{Name: name, Format: &Format_BptcRgbaU8Norm{&FmtBPTC_RGBA_U8_NORM{Srgb: true}}}
+}
+
+func (f *FmtBPTC_RGBA_U8_NORM) key() interface{} {
+	if f.Srgb {
+		return "BPTC_SRGBA_U8_NORM"
+	}
+	return "BPTC_RGBA_U8_NORM"
+}
+func (*FmtBPTC_RGBA_U8_NORM) size(w, h, d int) int {
+	return d * (sint.Max(sint.AlignUp(w, 4), 4) * sint.Max(sint.AlignUp(h, 4), 4))
+}
+func (f *FmtBPTC_RGBA_U8_NORM) check(data []byte, w, h, d int) error {
+	return checkSize(data, f, w, h, d)
+}
+func (*FmtBPTC_RGBA_U8_NORM) channels() stream.Channels {
+	return stream.Channels{stream.Channel_Red, stream.Channel_Green, stream.Channel_Blue, stream.Channel_Alpha}
+}
+
+// NewBPTC_RGB_SF16 returns a format representing the
+// COMPRESSED_RGB_BPTC_SIGNED_FLOAT (BC6H) block texture compression format.
+func NewBPTC_RGB_SF16(name string) *Format {
+	return &Format{Name: name, Format: &Format_BptcRgbSf16{&FmtBPTC_RGB_SF16{}}}
+}
+
+func (f *FmtBPTC_RGB_SF16) key() interface{} {
+	return "BPTC_RGB_SF16"
+}
+func (*FmtBPTC_RGB_SF16) size(w, h, d int) int {
+	return d * (sint.Max(sint.AlignUp(w, 4), 4) * sint.Max(sint.AlignUp(h, 4), 4))
+}
+func (f *FmtBPTC_RGB_SF16) check(data []byte, w, h, d int) error {
+	return checkSize(data, f, w, h, d)
+}
+func (*FmtBPTC_RGB_SF16) channels() stream.Channels {
+	return stream.Channels{stream.Channel_Red, stream.Channel_Green, stream.Channel_Blue}
+}
+
+// NewBPTC_RGB_UF16 returns a format representing the
+// COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT (BC6H) block texture compression format.
+func NewBPTC_RGB_UF16(name string) *Format {
+	return &Format{Name: name, Format: &Format_BptcRgbUf16{&FmtBPTC_RGB_UF16{}}}
+}
+
+func (f *FmtBPTC_RGB_UF16) key() interface{} {
+	return "BPTC_RGB_UF16"
+}
+func (*FmtBPTC_RGB_UF16) size(w, h, d int) int {
+	return d * (sint.Max(sint.AlignUp(w, 4), 4) * sint.Max(sint.AlignUp(h, 4), 4))
+}
+func (f *FmtBPTC_RGB_UF16) check(data []byte, w, h, d int) error {
+	return checkSize(data, f, w, h, d)
+}
+func (*FmtBPTC_RGB_UF16) channels() stream.Channels {
+	return stream.Channels{stream.Channel_Red, stream.Channel_Green, stream.Channel_Blue}
+}
+
+// bptcBits reads little-endian bit fields from a 128-bit BPTC block.
+type bptcBits struct {
+	lo, hi uint64
+}
+
+func (b *bptcBits) read(count uint) int {
+	v := b.lo & (1<<count - 1)
+	b.lo = b.lo>>count | b.hi<<(64-count)
+	b.hi >>= count
+	return int(v)
+}
+
+// bptcPartitions2 holds the subset of each texel for the 64 two-subset
+// partitions, one bit per texel.
+var bptcPartitions2 = [64]uint16{
+	0xcccc, 0x8888, 0xeeee, 0xecc8, 0xc880, 0xfeec, 0xfec8, 0xec80,
+	0xc800, 0xffec, 0xfe80, 0xe800, 0xffe8, 0xff00, 0xfff0, 0xf000,
+	0xf710, 0x008e, 0x7100, 0x08ce, 0x008c, 0x7310, 0x3100, 0x8cce,
+	0x088c, 0x3110, 0x6666, 0x366c, 0x17e8, 0x0ff0, 0x718e, 0x399c,
+	0xaaaa, 0xf0f0, 0x5a5a, 0x33cc, 0x3c3c, 0x55aa, 0x9696, 0xa55a,
+	0x73ce, 0x13c8, 0x324c, 0x3bdc, 0x6996, 0xc33c, 0x9966, 0x0660,
+	0x0272, 0x04e4, 0x4e40, 0x2720, 0xc936, 0x936c, 0x39c6, 0x639c,
+	0x9336, 0x9cc6, 0x817e, 0xe718, 0xccf0, 0x0fcc, 0x7744, 0xee22,
+}
+
+// bptcPartitions3 holds the subset of each texel for the 64 three-subset
+// partitions, two bits per texel.
+var bptcPartitions3 = [64]uint32{
+	0xaa685050, 0x6a5a5040, 0x5a5a4200, 0x5450a0a8, 0xa5a50000, 0xa0a05050, 0x5555a0a0, 0x5a5a5050,
+	0xaa550000, 0xaa555500, 0xaaaa5500, 0x90909090, 0x94949494, 0xa4a4a4a4, 0xa9a59450, 0x2a0a4250,
+	0xa5945040, 0x0a425054, 0xa5a5a500, 0x55a0a0a0, 0xa8a85454, 0x6a6a4040, 0xa4a45000, 0x1a1a0500,
+	0x0050a4a4, 0xaaa59090, 0x14696914, 0x69691400, 0xa08585a0, 0xaa821414, 0x50a4a450, 0x6a5a0200,
+	0xa9a58000, 0x5090a0a8, 0xa8a09050, 0x24242424, 0x00aa5500, 0x24924924, 0x24499224, 0x50a50a50,
+	0x500aa550, 0xaaaa4444, 0x66660000, 0xa5a0a5a0, 0x50a050a0, 0x69286928, 0x44aaaa44, 0x66666600,
+	0xaa444444, 0x54a854a8, 0x95809580, 0x96969600, 0xa85454a8, 0x80959580, 0xaa141414, 0x96960000,
+	0xaaaa1414, 0xa05050a0, 0xa0a5a5a0, 0x96000000, 0x40804080, 0xa9a8a9a8, 0xaaaaaa44, 0x2a4a5254,
+}
+
+// bptcAnchors2 is the anchor texel of the second subset of each two-subset
+// partition.
+var bptcAnchors2 = [64]int{
+	15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15,
+	15, 2, 8, 2, 2, 8, 8, 15, 2, 8, 2, 2, 8, 8, 2, 2,
+	15, 15, 6, 8, 2, 8, 15, 15, 2, 8, 2, 2, 2, 15, 15, 6,
+	6, 2, 6, 8, 15, 15, 2, 2, 15, 15, 15, 15, 15, 2, 2, 15,
+}
+
+// bptcAnchors3 are the anchor texels of the second and third subsets of each
+// three-subset partition.
+var bptcAnchors3 = [2][64]int{
+	{
+		3, 3, 15, 15, 8, 3, 15, 15, 8, 8, 6, 6, 6, 5, 3, 3,
+		3, 3, 8, 15, 3, 3, 6, 10, 5, 8, 8, 6, 8, 5, 15, 15,
+		8, 15, 3, 5, 6, 10, 8, 15, 15, 3, 15, 5, 15, 15, 15, 15,
+		3, 15, 5, 5, 5, 8, 5, 10, 5, 10, 8, 13, 15, 12, 3, 3,
+	}, {
+		15, 8, 8, 3, 15, 15, 3, 8, 15, 15, 15, 15, 15, 15, 15, 8,
+		15, 8, 15, 3, 15, 8, 15, 8, 3, 15, 6, 10, 15, 15, 10, 8,
+		15, 3, 15, 10, 10, 8, 9, 10, 6, 15, 8, 15, 3, 6, 6, 8,
+		15, 3, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 3, 15, 15, 8,
+	},
+}
+
+// bptcSubset returns the subset of texel i in the given partition.
+func bptcSubset(subsets, partition, i int) int {
+	switch subsets {
+	case 2:
+		return int(bptcPartitions2[partition]>>uint(i)) & 1
+	case 3:
+		return int(bptcPartitions3[partition]>>uint(2*i)) & 3
+	default:
+		return 0
+	}
+}
+
+// bptcIsAnchor returns true if texel i is the anchor of its subset, and so
+// has its index stored with one fewer bit.
+func bptcIsAnchor(subsets, partition, i int) bool {
+	switch {
+	case i == 0:
+		return true
+	case subsets == 2:
+		return i == bptcAnchors2[partition]
+	case subsets == 3:
+		return i == bptcAnchors3[0][partition] || i == bptcAnchors3[1][partition]
+	default:
+		return false
+	}
+}
+
+// bptcWeights returns the interpolation weights for indices of the given bit
+// count.
+func bptcWeights(bits int) []int {
+	switch bits {
+	case 2:
+		return []int{0, 21, 43, 64}
+	case 3:
+		return []int{0, 9, 18, 27, 37, 46, 55, 64}
+	default:
+		return []int{0, 4, 9, 13, 17, 21, 26, 30, 34, 38, 43, 47, 51, 55, 60, 64}
+	}
+}
+
+func bptcInterpolate(e0, e1, w int) int {
+	return ((64-w)*e0 + w*e1 + 32) >> 6
+}
+
+type bc7Mode struct {
+	subsets        int
+	partitionBits  uint
+	rotationBits   uint
+	selectionBits  uint
+	colorBits      uint
+	alphaBits      uint
+	endpointPBits  bool
+	sharedPBits    bool
+	indexBits      uint
+	secondaryIndex uint
+}
+
+var bc7Modes = [8]bc7Mode{
+	{3, 4, 0, 0, 4, 0, true, false, 3, 0},
+	{2, 6, 0, 0, 6, 0, false, true, 3, 0},
+	{3, 6, 0, 0, 5, 0, false, false, 2, 0},
+	{2, 6, 0, 0, 7, 0, true, false, 2, 0},
+	{1, 0, 2, 1, 5, 6, false, false, 2, 3},
+	{1, 0, 2, 0, 7, 8, false, false, 2, 2},
+	{1, 0, 0, 0, 7, 7, true, false, 4, 0},
+	{2, 6, 0, 0, 5, 5, true, false, 2, 0},
+}
+
+// decodeBC7 decodes a single BC7 block.
+// See: https://www.khronos.org/registry/DataFormat/specs/1.1/dataformat.1.1.html#BPTC
+func decodeBC7(r binary.Reader, dst []pixel) {
+	b := bptcBits{r.Uint64(), r.Uint64()}
+
+	mode := 0
+	for mode < len(bc7Modes) && b.read(1) == 0 {
+		mode++
+	}
+	if mode == len(bc7Modes) {
+		// Reserved mode. Decodes to transparent black.
+		for i := range dst {
+			dst[i] = pixel{}
+		}
+		return
+	}
+	m := bc7Modes[mode]
+
+	partition := b.read(m.partitionBits)
+	rotation := b.read(m.rotationBits)
+	selection := b.read(m.selectionBits)
+
+	count := m.subsets * 2
+	endpoints := make([]pixel, count)
+	for i := range endpoints {
+		endpoints[i].r = b.read(m.colorBits)
+	}
+	for i := range endpoints {
+		endpoints[i].g = b.read(m.colorBits)
+	}
+	for i := range endpoints {
+		endpoints[i].b = b.read(m.colorBits)
+	}
+	for i := range endpoints {
+		endpoints[i].a = b.read(m.alphaBits)
+	}
+
+	colorBits, alphaBits := m.colorBits, m.alphaBits
+	if m.endpointPBits || m.sharedPBits {
+		pbits := make([]int, count)
+		if m.endpointPBits {
+			for i := range pbits {
+				pbits[i] = b.read(1)
+			}
+		} else {
+			for i := 0; i < count; i += 2 {
+				pbits[i] = b.read(1)
+				pbits[i+1] = pbits[i]
+			}
+		}
+		for i, p := range pbits {
+			e := &endpoints[i]
+			e.r, e.g, e.b = e.r<<1|p, e.g<<1|p, e.b<<1|p
+			if alphaBits > 0 {
+				e.a = e.a<<1 | p
+			}
+		}
+		colorBits++
+		if alphaBits > 0 {
+			alphaBits++
+		}
+	}
+
+	expand := func(v int, bits uint) int {
+		v <<= 8 - bits
+		return v | v>>bits
+	}
+	for i := range endpoints {
+		e := &endpoints[i]
+		e.r, e.g, e.b = expand(e.r, colorBits), expand(e.g, colorBits), expand(e.b, colorBits)
+		if alphaBits > 0 {
+			e.a = expand(e.a, alphaBits)
+		} else {
+			e.a = 255
+		}
+	}
+
+	var indices, secondary [16]int
+	for i := range indices {
+		bits := m.indexBits
+		if bptcIsAnchor(m.subsets, partition, i) {
+			bits--
+		}
+		indices[i] = b.read(bits)
+	}
+	if m.secondaryIndex > 0 {
+		for i := range secondary {
+			bits := m.secondaryIndex
+			if i == 0 {
+				bits--
+			}
+			secondary[i] = b.read(bits)
+		}
+	}
+
+	for i := range dst {
+		s := bptcSubset(m.subsets, partition, i)
+		e0, e1 := endpoints[2*s], endpoints[2*s+1]
+
+		colorIndex, colorWeights := indices[i], bptcWeights(int(m.indexBits))
+		alphaIndex, alphaWeights := colorIndex, colorWeights
+		if m.secondaryIndex > 0 {
+			alphaIndex, alphaWeights = secondary[i], bptcWeights(int(m.secondaryIndex))
+			if selection == 1 {
+				colorIndex, alphaIndex = alphaIndex, colorIndex
+				colorWeights, alphaWeights = alphaWeights, colorWeights
+			}
+		}
+
+		cw, aw := colorWeights[colorIndex], alphaWeights[alphaIndex]
+		p := pixel{
+			bptcInterpolate(e0.r, e1.r, cw),
+			bptcInterpolate(e0.g, e1.g, cw),
+			bptcInterpolate(e0.b, e1.b, cw),
+			bptcInterpolate(e0.a, e1.a, aw),
+		}
+		switch rotation {
+		case 1:
+			p.r, p.a = p.a, p.r
+		case 2:
+			p.g, p.a = p.a, p.g
+		case 3:
+			p.b, p.a = p.a, p.b
+		}
+		dst[i] = p
+	}
+}
+
+// bc6hBit places a single bit of the BC6H block header into an endpoint
+// component.
+type bc6hBit struct {
+	component int // endpoint*3 + channel
+	bit       uint
+}
+
+type bc6hMode struct {
+	transformed  bool
+	endpointBits uint
+	deltaBits    [3]uint
+	header       []bc6hBit
+}
+
+// parseBC6HLayout parses a header layout written in the notation of the
+// BC6H specification tables, for example "rw[9:0] gz[4]". The endpoint
+// components are named by channel (r, g, b) followed by the endpoint (w, x, y,
+// z). The bits of a range are stored starting with the right-hand bit.
+func parseBC6HLayout(layout string) []bc6hBit {
+	out := []bc6hBit{}
+	for _, field := range strings.Fields(layout) {
+		channel := strings.IndexByte("rgb", field[0])
+		endpoint := strings.IndexByte("wxyz", field[1])
+		if channel < 0 || endpoint < 0 || field[2] != '[' || field[len(field)-1] != ']' {
+			panic(fmt.Errorf("Invalid BC6H layout field '%s'", field))
+		}
+		bits := strings.Split(field[3:len(field)-1], ":")
+		first, err := strconv.Atoi(bits[len(bits)-1])
+		if err != nil {
+			panic(err)
+		}
+		last, err := strconv.Atoi(bits[0])
+		if err != nil {
+			panic(err)
+		}
+		step := 1
+		if last < first {
+			step = -1
+		}
+		for i := first; ; i += step {
+			out = append(out, bc6hBit{endpoint*3 + channel, uint(i)})
+			if i == last {
+				break
+			}
+		}
+	}
+	return out
+}
+
+// bc6hModes are the BC6H modes indexed by their mode bits.
+var bc6hModes = map[int]*bc6hMode{
+	0x00: {true, 10, [3]uint{5, 5, 5}, parseBC6HLayout(
+		"gy[4] by[4] bz[4] rw[9:0] gw[9:0] bw[9:0] rx[4:0] gz[4] gy[3:0] gx[4:0] bz[0] " +
+			"gz[3:0] bx[4:0] bz[1] by[3:0] ry[4:0] bz[2] rz[4:0] bz[3]")},
+	0x01: {true, 7, [3]uint{6, 6, 6}, parseBC6HLayout(
+		"gy[5] gz[4] gz[5] rw[6:0] bz[0] bz[1] by[4] gw[6:0] by[5] bz[2] gy[4] bw[6:0] " +
+			"bz[3] bz[5] bz[4] rx[5:0] gy[3:0] gx[5:0] gz[3:0] bx[5:0] by[3:0] ry[5:0] rz[5:0]")},
+	0x02: {true, 11, [3]uint{5, 4, 4}, parseBC6HLayout(
+		"rw[9:0] gw[9:0] bw[9:0] rx[4:0] rw[10] gy[3:0] gx[3:0] gw[10] bz[0] gz[3:0] " +
+			"bx[3:0] bw[10] bz[1] by[3:0] ry[4:0] bz[2] rz[4:0] bz[3]")},
+	0x06: {true, 11, [3]uint{4, 5, 4}, parseBC6HLayout(
+		"rw[9:0] gw[9:0] bw[9:0] rx[3:0] rw[10] gz[4] gy[3:0] gx[4:0] gw[10] gz[3:0] " +
+			"bx[3:0] bw[10] bz[1] by[3:0] ry[3:0] bz[0] bz[2] rz[3:0] gy[4] bz[3]")},
+	0x0a: {true, 11, [3]uint{4, 4, 5}, parseBC6HLayout(
+		"rw[9:0] gw[9:0] bw[9:0] rx[3:0] rw[10] by[4] gy[3:0] gx[3:0] gw[10] bz[0] " +
+			"gz[3:0] bx[4:0] bw[10] by[3:0] ry[3:0] bz[1] bz[2] rz[3:0] bz[4] bz[3]")},
+	0x0e: {true, 9, [3]uint{5, 5, 5}, parseBC6HLayout(
+		"rw[8:0] by[4] gw[8:0] gy[4] bw[8:0] bz[4] rx[4:0] gz[4] gy[3:0] gx[4:0] bz[0] " +
+			"gz[3:0] bx[4:0] bz[1] by[3:0] ry[4:0] bz[2] rz[4:0] bz[3]")},
+	0x12: {true, 8, [3]uint{6, 5, 5}, parseBC6HLayout(
+		"rw[7:0] gz[4] by[4] gw[7:0] bz[2] gy[4] bw[7:0] bz[3] bz[4] rx[5:0] gy[3:0] " +
+			"gx[4:0] bz[0] gz[3:0] bx[4:0] bz[1] by[3:0] ry[5:0] rz[5:0]")},
+	0x16: {true, 8, [3]uint{5, 6, 5}, parseBC6HLayout(
+		"rw[7:0] bz[0] by[4] gw[7:0] gy[5] gy[4] bw[7:0] gz[5] bz[4] rx[4:0] gz[4] " +
+			"gy[3:0] gx[5:0] gz[3:0] bx[4:0] bz[1] by[3:0] ry[4:0] bz[2] rz[4:0] bz[3]")},
+	0x1a: {true, 8, [3]uint{5, 5, 6}, parseBC6HLayout(
+		"rw[7:0] bz[1] by[4] gw[7:0] by[5] gy[4] bw[7:0] bz[5] bz[4] rx[4:0] gz[4] " +
+			"gy[3:0] gx[4:0] bz[0] gz[3:0] bx[5:0] by[3:0] ry[4:0] bz[2] rz[4:0] bz[3]")},
+	0x1e: {false, 6, [3]uint{6, 6, 6}, parseBC6HLayout(
+		"rw[5:0] gz[4] bz[0] bz[1] by[4] gw[5:0] gy[5] by[5] bz[2] gy[4] bw[5:0] gz[5] " +
+			"bz[3] bz[5] bz[4] rx[5:0] gy[3:0] gx[5:0] gz[3:0] bx[5:0] by[3:0] ry[5:0] rz[5:0]")},
+	0x03: {false, 10, [3]uint{10, 10, 10}, parseBC6HLayout(
+		"rw[9:0] gw[9:0] bw[9:0] rx[9:0] gx[9:0] bx[9:0]")},
+	0x07: {true, 11, [3]uint{9, 9, 9}, parseBC6HLayout(
+		"rw[9:0] gw[9:0] bw[9:0] rx[8:0] rw[10] gx[8:0] gw[10] bx[8:0] bw[10]")},
+	0x0b: {true, 12, [3]uint{8, 8, 8}, parseBC6HLayout(
+		"rw[9:0] gw[9:0] bw[9:0] rx[7:0] rw[10:11] gx[7:0] gw[10:11] bx[7:0] bw[10:11]")},
+	0x0f: {true, 16, [3]uint{4, 4, 4}, parseBC6HLayout(
+		"rw[9:0] gw[9:0] bw[9:0] rx[3:0] rw[10:15] gx[3:0] gw[10:15] bx[3:0] bw[10:15]")},
+}
+
+func signExtend(v int, bits uint) int {
+	shift := 32 - bits
+	return int(int32(v<<shift) >> shift)
+}
+
+// decodeBC6H decodes the BC6H blocks of src to RGBA_F32.
+// See: https://www.khronos.org/registry/DataFormat/specs/1.1/dataformat.1.1.html#BPTC
+func decodeBC6H(src []byte, width, height, depth int, signed bool) ([]byte, error) {
+	out := make([]rgbaF32, width*height*depth)
+	block := make([]rgbaF32, 16)
+	r := endian.Reader(bytes.NewReader(src), device.LittleEndian)
+	for z := 0; z < depth; z++ {
+		out := out[z*width*height:]
+		for y := 0; y < height; y += 4 {
+			for x := 0; x < width; x += 4 {
+				decodeBC6HBlock(r, block, signed)
+				for dy := 0; dy < 4 && y+dy < height; dy++ {
+					for dx := 0; dx < 4 && x+dx < width; dx++ {
+						out[(y+dy)*width+x+dx] = block[dy*4+dx]
+					}
+				}
+			}
+		}
+	}
+	if err := r.Error(); err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(out)*16))
+	w := endian.Writer(buf, device.LittleEndian)
+	for _, p := range out {
+		w.Float32(p.r)
+		w.Float32(p.g)
+		w.Float32(p.b)
+		w.Float32(p.a)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeBC6HBlock decodes a single BC6H block.
+func decodeBC6HBlock(r binary.Reader, dst []rgbaF32, signed bool) {
+	b := bptcBits{r.Uint64(), r.Uint64()}
+
+	mode := b.read(2)
+	if mode >= 2 {
+		mode |= b.read(3) << 2
+	}
+	m, ok := bc6hModes[mode]
+	if !ok {
+		// Reserved mode. Decodes to black.
+		for i := range dst {
+			dst[i] = rgbaF32{0, 0, 0, 1}
+		}
+		return
+	}
+
+	var endpoints [12]int // [endpoint*3 + channel]
+	for _, h := range m.header {
+		endpoints[h.component] |= b.read(1) << h.bit
+	}
+	subsets, partition := 1, 0
+	if mode&3 != 3 {
+		subsets, partition = 2, b.read(5)
+	}
+	count := subsets * 2
+
+	for c := 0; c < 3; c++ {
+		if signed {
+			endpoints[c] = signExtend(endpoints[c], m.endpointBits)
+		}
+		for e := 1; e < count; e++ {
+			i := e*3 + c
+			if signed || m.transformed {
+				endpoints[i] = signExtend(endpoints[i], m.deltaBits[c])
+			}
+			if m.transformed {
+				endpoints[i] = (endpoints[c] + endpoints[i]) & (1<<m.endpointBits - 1)
+				if signed {
+					endpoints[i] = signExtend(endpoints[i], m.endpointBits)
+				}
+			}
+		}
+	}
+
+	for i := range endpoints[:count*3] {
+		endpoints[i] = bc6hUnquantize(endpoints[i], m.endpointBits, signed)
+	}
+
+	indexBits := uint(3)
+	if subsets == 1 {
+		indexBits = 4
+	}
+	weights := bptcWeights(int(indexBits))
+	for i := range dst {
+		bits := indexBits
+		if bptcIsAnchor(subsets, partition, i) {
+			bits--
+		}
+		w := weights[b.read(bits)]
+		e := bptcSubset(subsets, partition, i) * 2
+		var rgb [3]float32
+		for c := range rgb {
+			v := bptcInterpolate(endpoints[e*3+c], endpoints[(e+1)*3+c], w)
+			rgb[c] = bc6hFinish(v, signed).Float32()
+		}
+		dst[i] = rgbaF32{rgb[0], rgb[1], rgb[2], 1}
+	}
+}
+
+// bc6hUnquantize expands an endpoint component of the given bit count to the
+// 16-bit (unsigned) or 15-bit plus sign (signed) interpolation range.
+func bc6hUnquantize(v int, bits uint, signed bool) int {
+	if !signed {
+		switch {
+		case bits >= 15, v == 0:
+			return v
+		case v == 1<<bits-1:
+			return 0xffff
+		default:
+			return (v<<16 + 0x8000) >> bits
+		}
+	}
+	if bits >= 16 {
+		return v
+	}
+	negative := v < 0
+	if negative {
+		v = -v
+	}
+	switch {
+	case v == 0:
+	case v >= 1<<(bits-1)-1:
+		v = 0x7fff
+	default:
+		v = (v<<15 + 0x4000) >> (bits - 1)
+	}
+	if negative {
+		v = -v
+	}
+	return v
+}
+
+// bc6hFinish scales an interpolated value to the bit pattern of a half float.
+func bc6hFinish(v int, signed bool) f16.Number {
+	if !signed {
+		return f16.Number(v * 31 >> 6)
+	}
+	if v < 0 {
+		return f16.Number(0x8000 | -v*31>>5)
+	}
+	return f16.Number(v * 31 >> 5)
+}
@@ -0,0 +1,193 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"fmt"
+
+	"github.com/google/gapid/core/stream/fmts"
+)
+
+// ErrUnsupportedContainerFormat is returned by the container writers when the
+// texture's format cannot be represented by the container.
+var ErrUnsupportedContainerFormat = fmt.Errorf("Format is not supported by the container")
+
+// glFormat describes a format using the OpenGL enums stored in KTX files.
+// Compressed formats have a zero type and format.
+type glFormat struct {
+	internalFormat     uint32
+	format             uint32
+	typ                uint32
+	typeSize           uint32
+	baseInternalFormat uint32
+}
+
+// containerFormat maps a Format to the identifiers used by the KTX, KTX2 and
+// DDS containers. A zero identifier means the container has no
+// representation for the format.
+type containerFormat struct {
+	format *Format
+	vk     uint32 // VkFormat, used by KTX2.
+	gl     glFormat
+	dxgi   uint32 // DXGI_FORMAT, used by DDS files with a DX10 header.
+	fourCC uint32 // Used by DDS files without a DX10 header.
+}
+
+// OpenGL enums used by containerFormats.
+const (
+	glByte           = 0x1400
+	glUnsignedByte   = 0x1401
+	glUnsignedShort  = 0x1403
+	glFloat          = 0x1406
+	glHalfFloat      = 0x140B
+	glDepthComponent = 0x1902
+	glRed            = 0x1903
+	glRGB            = 0x1907
+	glRGBA           = 0x1908
+	glBGRA           = 0x80E1
+	glRG             = 0x8227
+	glSRGB8Alpha8    = 0x8C43
+)
+
+func fourCC(s string) uint32 {
+	return uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24
+}
+
+func compressedGL(internalFormat, base uint32) glFormat {
+	return glFormat{internalFormat, 0, 0, 1, base}
+}
+
+// containerFormats is the list of formats that can be stored in a container.
+// When several formats share an identifier, reading picks the first.
+var containerFormats = []containerFormat{
+	// Uncompressed
+	{newUncompressed(fmts.R_U8_NORM), 9, glFormat{0x8229, glRed, glUnsignedByte, 1, glRed}, 61, 0},
+	{newUncompressed(fmts.R_S8_NORM), 10, glFormat{0x8F94, glRed, glByte, 1, glRed}, 63, 0},
+	{newUncompressed(fmts.RG_U8_NORM), 16, glFormat{0x822B, glRG, glUnsignedByte, 1, glRG}, 49, 0},
+	{newUncompressed(fmts.RG_S8_NORM), 17, glFormat{0x8F95, glRG, glByte, 1, glRG}, 51, 0},
+	{newUncompressed(fmts.RGB_U8_NORM), 23, glFormat{0x8051, glRGB, glUnsignedByte, 1, glRGB}, 0, 0},
+	{newUncompressed(fmts.SRGB_U8_NORM), 29, glFormat{0x8C41, glRGB, glUnsignedByte, 1, glRGB}, 0, 0},
+	{newUncompressed(fmts.RGBA_U8_NORM), 37, glFormat{0x8058, glRGBA, glUnsignedByte, 1, glRGBA}, 28, 0},
+	{newUncompressed(fmts.RGBA_S8_NORM), 38, glFormat{0x8F97, glRGBA, glByte, 1, glRGBA}, 31, 0},
+	{newUncompressed(fmts.SRGBA_U8_NORM), 43, glFormat{glSRGB8Alpha8, glRGBA, glUnsignedByte, 1, glRGBA}, 29, 0},
+	{newUncompressed(fmts.BGRA_U8_NORM), 44, glFormat{0x8058, glBGRA, glUnsignedByte, 1, glRGBA}, 87, 0},
+	{newUncompressed(fmts.BGRA_N_sRGBU8N_sRGBU8N_sRGBU8NU8), 50, glFormat{glSRGB8Alpha8, glBGRA, glUnsignedByte, 1, glRGBA}, 91, 0},
+	{newUncompressed(fmts.R_U16_NORM), 70, glFormat{0x822A, glRed, glUnsignedShort, 2, glRed}, 56, 0},
+	{newUncompressed(fmts.R_F16), 76, glFormat{0x822D, glRed, glHalfFloat, 2, glRed}, 54, 111},
+	{newUncompressed(fmts.RG_U16_NORM), 77, glFormat{0x822C, glRG, glUnsignedShort, 2, glRG}, 35, 0},
+	{newUncompressed(fmts.RG_F16), 83, glFormat{0x822F, glRG, glHalfFloat, 2, glRG}, 34, 112},
+	{newUncompressed(fmts.RGBA_U16_NORM), 91, glFormat{0x805B, glRGBA, glUnsignedShort, 2, glRGBA}, 11, 36},
+	{newUncompressed(fmts.RGBA_F16), 97, glFormat{0x881A, glRGBA, glHalfFloat, 2, glRGBA}, 10, 113},
+	{newUncompressed(fmts.R_F32), 100, glFormat{0x822E, glRed, glFloat, 4, glRed}, 41, 114},
+	{newUncompressed(fmts.RG_F32), 103, glFormat{0x8230, glRG, glFloat, 4, glRG}, 16, 115},
+	{newUncompressed(fmts.RGB_F32), 106, glFormat{0x8815, glRGB, glFloat, 4, glRGB}, 6, 0},
+	{newUncompressed(fmts.RGBA_F32), 109, glFormat{0x8814, glRGBA, glFloat, 4, glRGBA}, 2, 116},
+	{newUncompressed(fmts.D_U16_NORM), 124, glFormat{0x81A5, glDepthComponent, glUnsignedShort, 2, glDepthComponent}, 55, 0},
+	{newUncompressed(fmts.D_F32), 126, glFormat{0x8CAC, glDepthComponent, glFloat, 4, glDepthComponent}, 40, 0},
+
+	// S3TC / RGTC / BPTC
+	{S3_DXT1_RGBA, 133, compressedGL(0x83F1, glRGBA), 71, fourCC("DXT1")},
+	{S3_DXT1_RGB, 131, compressedGL(0x83F0, glRGB), 71, fourCC("DXT1")},
+	{S3_DXT3_RGBA, 135, compressedGL(0x83F2, glRGBA), 74, fourCC("DXT3")},
+	{S3_DXT5_RGBA, 137, compressedGL(0x83F3, glRGBA), 77, fourCC("DXT5")},
+	{RGTC1_BC4_R_U8_NORM, 139, compressedGL(0x8DBB, glRed), 80, fourCC("BC4U")},
+	{RGTC1_BC4_R_S8_NORM, 140, compressedGL(0x8DBC, glRed), 81, fourCC("BC4S")},
+	{RGTC2_BC5_RG_U8_NORM, 141, compressedGL(0x8DBD, glRG), 83, fourCC("BC5U")},
+	{RGTC2_BC5_RG_S8_NORM, 142, compressedGL(0x8DBE, glRG), 84, fourCC("BC5S")},
+	{BPTC_RGB_UF16, 143, compressedGL(0x8E8F, glRGB), 95, 0},
+	{BPTC_RGB_SF16, 144, compressedGL(0x8E8E, glRGB), 96, 0},
+	{BPTC_RGBA_U8_NORM, 145, compressedGL(0x8E8C, glRGBA), 98, 0},
+	{BPTC_SRGBA_U8_NORM, 146, compressedGL(0x8E8D, glRGBA), 99, 0},
+
+	// ETC / EAC
+	{ETC2_RGB_U8_NORM, 147, compressedGL(0x9274, glRGB), 0, 0},
+	{ETC2_SRGB_U8_NORM, 148, compressedGL(0x9275, glRGB), 0, 0},
+	{ETC2_RGBA_U8U8U8U1_NORM, 149, compressedGL(0x9276, glRGBA), 0, 0},
+	{ETC2_SRGBA_U8U8U8U1_NORM, 150, compressedGL(0x9277, glRGBA), 0, 0},
+	{ETC2_RGBA_U8_NORM, 151, compressedGL(0x9278, glRGBA), 0, 0},
+	{ETC2_SRGBA_U8_NORM, 152, compressedGL(0x9279, glRGBA), 0, 0},
+	{ETC2_R_U11_NORM, 153, compressedGL(0x9270, glRed), 0, 0},
+	{ETC2_R_S11_NORM, 154, compressedGL(0x9271, glRed), 0, 0},
+	{ETC2_RG_U11_NORM, 155, compressedGL(0x9272, glRG), 0, 0},
+	{ETC2_RG_S11_NORM, 156, compressedGL(0x9273, glRG), 0, 0},
+	{ETC1_RGB_U8_NORM, 147, compressedGL(0x8D64, glRGB), 0, 0},
+
+	// ASTC
+	{NewASTC("ASTC_RGBA_4x4", 4, 4, false), 157, compressedGL(0x93B0, glRGBA), 0, 0},
+	{NewASTC("ASTC_SRGBA_4x4", 4, 4, true), 158, compressedGL(0x93D0, glRGBA), 0, 0},
+	{NewASTC("ASTC_RGBA_5x4", 5, 4, false), 159, compressedGL(0x93B1, glRGBA), 0, 0},
+	{NewASTC("ASTC_SRGBA_5x4", 5, 4, true), 160, compressedGL(0x93D1, glRGBA), 0, 0},
+	{NewASTC("ASTC_RGBA_5x5", 5, 5, false), 161, compressedGL(0x93B2, glRGBA), 0, 0},
+	{NewASTC("ASTC_SRGBA_5x5", 5, 5, true), 162, compressedGL(0x93D2, glRGBA), 0, 0},
+	{NewASTC("ASTC_RGBA_6x5", 6, 5, false), 163, compressedGL(0x93B3, glRGBA), 0, 0},
+	{NewASTC("ASTC_SRGBA_6x5", 6, 5, true), 164, compressedGL(0x93D3, glRGBA), 0, 0},
+	{NewASTC("ASTC_RGBA_6x6", 6, 6, false), 165, compressedGL(0x93B4, glRGBA), 0, 0},
+	{NewASTC("ASTC_SRGBA_6x6", 6, 6, true), 166, compressedGL(0x93D4, glRGBA), 0, 0},
+	{NewASTC("ASTC_RGBA_8x5", 8, 5, false), 167, compressedGL(0x93B5, glRGBA), 0, 0},
+	{NewASTC("ASTC_SRGBA_8x5", 8, 5, true), 168, compressedGL(0x93D5, glRGBA), 0, 0},
+	{NewASTC("ASTC_RGBA_8x6", 8, 6, false), 169, compressedGL(0x93B6, glRGBA), 0, 0},
+	{NewASTC("ASTC_SRGBA_8x6", 8, 6, true), 170, compressedGL(0x93D6, glRGBA), 0, 0},
+	{NewASTC("ASTC_RGBA_8x8", 8, 8, false), 171, compressedGL(0x93B7, glRGBA), 0, 0},
+	{NewASTC("ASTC_SRGBA_8x8", 8, 8, true), 172, compressedGL(0x93D7, glRGBA), 0, 0},
+	{NewASTC("ASTC_RGBA_10x5", 10, 5, false), 173, compressedGL(0x93B8, glRGBA), 0, 0},
+	{NewASTC("ASTC_SRGBA_10x5", 10, 5, true), 174, compressedGL(0x93D8, glRGBA), 0, 0},
+	{NewASTC("ASTC_RGBA_10x6", 10, 6, false), 175, compressedGL(0x93B9, glRGBA), 0, 0},
+	{NewASTC("ASTC_SRGBA_10x6", 10, 6, true), 176, compressedGL(0x93D9, glRGBA), 0, 0},
+	{NewASTC("ASTC_RGBA_10x8", 10, 8, false), 177, compressedGL(0x93BA, glRGBA), 0, 0},
+	{NewASTC("ASTC_SRGBA_10x8", 10, 8, true), 178, compressedGL(0x93DA, glRGBA), 0, 0},
+	{NewASTC("ASTC_RGBA_10x10", 10, 10, false), 179, compressedGL(0x93BB, glRGBA), 0, 0},
+	{NewASTC("ASTC_SRGBA_10x10", 10, 10, true), 180, compressedGL(0x93DB, glRGBA), 0, 0},
+	{NewASTC("ASTC_RGBA_12x10", 12, 10, false), 181, compressedGL(0x93BC, glRGBA), 0, 0},
+	{NewASTC("ASTC_SRGBA_12x10", 12, 10, true), 182, compressedGL(0x93DC, glRGBA), 0, 0},
+	{NewASTC("ASTC_RGBA_12x12", 12, 12, false), 183, compressedGL(0x93BD, glRGBA), 0, 0},
+	{NewASTC("ASTC_SRGBA_12x12", 12, 12, true), 184, compressedGL(0x93DD, glRGBA), 0, 0},
+
+	// PVRTC
+	{PVRTC1_RGBA_2BPP, 1000054000, compressedGL(0x8C03, glRGBA), 0, 0},
+	{PVRTC1_RGBA_4BPP, 1000054001, compressedGL(0x8C02, glRGBA), 0, 0},
+	{PVRTC2_RGBA_2BPP, 1000054002, compressedGL(0x9137, glRGBA), 0, 0},
+	{PVRTC2_RGBA_4BPP, 1000054003, compressedGL(0x9138, glRGBA), 0, 0},
+	{PVRTC1_SRGBA_2BPP, 1000054004, compressedGL(0x8A56, glRGBA), 0, 0},
+	{PVRTC1_SRGBA_4BPP, 1000054005, compressedGL(0x8A57, glRGBA), 0, 0},
+	{PVRTC2_SRGBA_2BPP, 1000054006, compressedGL(0x93F0, glRGBA), 0, 0},
+	{PVRTC2_SRGBA_4BPP, 1000054007, compressedGL(0x93F1, glRGBA), 0, 0},
+	{PVRTC1_RGB_2BPP, 0, compressedGL(0x8C01, glRGB), 0, 0},
+	{PVRTC1_RGB_4BPP, 0, compressedGL(0x8C00, glRGB), 0, 0},
+	{PVRTC1_SRGB_2BPP, 0, compressedGL(0x8A54, glRGB), 0, 0},
+	{PVRTC1_SRGB_4BPP, 0, compressedGL(0x8A55, glRGB), 0, 0},
+
+	// ATC
+	{ATC_RGB_AMD, 0, compressedGL(0x8C92, glRGB), 0, fourCC("ATC ")},
+	{ATC_RGBA_EXPLICIT_ALPHA_AMD, 0, compressedGL(0x8C93, glRGBA), 0, fourCC("ATCA")},
+	{ATC_RGBA_INTERPOLATED_ALPHA_AMD, 0, compressedGL(0x87EE, glRGBA), 0, fourCC("ATCI")},
+}
+
+// findContainerFormat returns the first entry of containerFormats for which
+// pred returns true, or nil if there is no such entry.
+func findContainerFormat(pred func(*containerFormat) bool) *containerFormat {
+	for i := range containerFormats {
+		if f := &containerFormats[i]; pred(f) {
+			return f
+		}
+	}
+	return nil
+}
+
+// containerFormatOf returns the containerFormat for the format f, or nil if
+// the format cannot be stored in any container.
+func containerFormatOf(f *Format) *containerFormat {
+	key := f.Key()
+	return findContainerFormat(func(c *containerFormat) bool { return c.format.Key() == key })
+}
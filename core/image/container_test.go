@@ -0,0 +1,253 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/gapid/core/image"
+)
+
+// newTestTexture returns a texture filled with a distinct byte pattern for
+// every image.
+func newTestTexture(f *image.Format, w, h, d, layers, faces uint32, levels int) *image.Texture {
+	t := &image.Texture{
+		Format: f,
+		Width:  w,
+		Height: h,
+		Depth:  d,
+		Layers: layers,
+		Faces:  faces,
+		Levels: make([][]*image.Data, levels),
+	}
+	seed := byte(0)
+	for l := range t.Levels {
+		w, h, d := t.LevelSize(l)
+		t.Levels[l] = make([]*image.Data, t.ImagesPerLevel())
+		for i := range t.Levels[l] {
+			data := make([]byte, f.Size(int(w), int(h), int(d)))
+			for j := range data {
+				data[j] = seed + byte(j*7)
+			}
+			seed += 31
+			t.Levels[l][i] = &image.Data{Format: f, Width: w, Height: h, Depth: d, Bytes: data}
+		}
+	}
+	return t
+}
+
+func TestContainerRoundTrip(t *testing.T) {
+	type container struct {
+		name  string
+		write func(io.Writer, *image.Texture) error
+		read  func(io.Reader) (*image.Texture, error)
+	}
+	ktx := container{"KTX", image.WriteKTX, image.ReadKTX}
+	ktx2 := container{"KTX2", image.WriteKTX2, image.ReadKTX2}
+	dds := container{"DDS", image.WriteDDS, image.ReadDDS}
+
+	for _, test := range []struct {
+		name       string
+		tex        *image.Texture
+		containers []container
+	}{
+		{"2D RGBA8 mip-mapped", newTestTexture(image.RGBA_U8_NORM, 13, 7, 1, 0, 1, 4), []container{ktx, ktx2, dds}},
+		{"2D RGB8 unaligned rows", newTestTexture(image.RGB_U8_NORM, 5, 3, 1, 0, 1, 3), []container{ktx, ktx2}},
+		{"2D RGBA F32", newTestTexture(image.RGBA_F32, 4, 4, 1, 0, 1, 1), []container{ktx, ktx2, dds}},
+		{"3D RGBA8", newTestTexture(image.RGBA_U8_NORM, 4, 4, 4, 0, 1, 3), []container{ktx, ktx2, dds}},
+		{"2D array DXT5", newTestTexture(image.S3_DXT5_RGBA, 16, 8, 1, 3, 1, 5), []container{ktx, ktx2, dds}},
+		{"cube-map BC7", newTestTexture(image.BPTC_RGBA_U8_NORM, 8, 8, 1, 0, 6, 4), []container{ktx, ktx2, dds}},
+		{"cube-map array RGBA8", newTestTexture(image.RGBA_U8_NORM, 4, 4, 1, 2, 6, 3), []container{ktx, ktx2, dds}},
+		{"2D ETC2 sRGB", newTestTexture(image.ETC2_SRGBA_U8_NORM, 12, 12, 1, 0, 1, 4), []container{ktx, ktx2}},
+		{"2D ASTC 6x5", newTestTexture(image.NewASTC("astc", 6, 5, false), 20, 20, 1, 0, 1, 2), []container{ktx, ktx2}},
+		{"2D PVRTC1 4bpp mip-mapped", newTestTexture(image.PVRTC1_RGBA_4BPP, 16, 16, 1, 0, 1, 5), []container{ktx, ktx2}},
+		{"2D PVRTC2 2bpp", newTestTexture(image.PVRTC2_RGBA_2BPP, 32, 16, 1, 0, 1, 1), []container{ktx, ktx2}},
+		{"2D ATC", newTestTexture(image.ATC_RGBA_EXPLICIT_ALPHA_AMD, 8, 8, 1, 0, 1, 2), []container{ktx, dds}},
+	} {
+		for _, c := range test.containers {
+			buf := &bytes.Buffer{}
+			if err := c.write(buf, test.tex); err != nil {
+				t.Errorf("%s: Write%s returned error: %v", test.name, c.name, err)
+				continue
+			}
+			got, err := c.read(buf)
+			if err != nil {
+				t.Errorf("%s: Read%s returned error: %v", test.name, c.name, err)
+				continue
+			}
+			expected := test.tex
+			if got.Format.Key() != expected.Format.Key() {
+				t.Errorf("%s: Read%s returned format %v, expected %v", test.name, c.name, got.Format, expected.Format)
+				continue
+			}
+			if got.Width != expected.Width || got.Height != expected.Height || got.Depth != expected.Depth ||
+				got.Layers != expected.Layers || got.Faces != expected.Faces || len(got.Levels) != len(expected.Levels) {
+				t.Errorf("%s: Read%s returned a %dx%dx%d texture with %d layers, %d faces and %d levels. "+
+					"Expected %dx%dx%d with %d layers, %d faces and %d levels", test.name, c.name,
+					got.Width, got.Height, got.Depth, got.Layers, got.Faces, len(got.Levels),
+					expected.Width, expected.Height, expected.Depth, expected.Layers, expected.Faces, len(expected.Levels))
+				continue
+			}
+			for l := range expected.Levels {
+				for i := range expected.Levels[l] {
+					if !bytes.Equal(got.Levels[l][i].Bytes, expected.Levels[l][i].Bytes) {
+						t.Errorf("%s: Read%s returned different data for level %d image %d", test.name, c.name, l, i)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestContainerUnsupportedFormat(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		tex   *image.Texture
+		write func(io.Writer, *image.Texture) error
+	}{
+		{"KTX2 ATC", newTestTexture(image.ATC_RGB_AMD, 4, 4, 1, 0, 1, 1), image.WriteKTX2},
+		{"DDS ETC2", newTestTexture(image.ETC2_RGB_U8_NORM, 4, 4, 1, 0, 1, 1), image.WriteDDS},
+		{"DDS RGB8", newTestTexture(image.RGB_U8_NORM, 4, 4, 1, 0, 1, 1), image.WriteDDS},
+		{"KTX Gray8", newTestTexture(image.Gray_U8_NORM, 4, 4, 1, 0, 1, 1), image.WriteKTX},
+	} {
+		if err := test.write(&bytes.Buffer{}, test.tex); err != image.ErrUnsupportedContainerFormat {
+			t.Errorf("%s: Expected ErrUnsupportedContainerFormat, got %v", test.name, err)
+		}
+	}
+}
+
+func TestTextureCheck(t *testing.T) {
+	tex := newTestTexture(image.RGBA_U8_NORM, 8, 8, 1, 0, 6, 2)
+	if err := tex.Check(); err != nil {
+		t.Errorf("Check of a valid texture returned error: %v", err)
+	}
+	tex.Levels[1] = tex.Levels[1][:5]
+	if err := tex.Check(); err == nil {
+		t.Errorf("Check of a cube-map level with a missing face did not return an error")
+	}
+}
+
+func TestKTX2BlockSize(t *testing.T) {
+	for _, test := range []struct {
+		format   *image.Format
+		expected uint32
+	}{
+		{image.RGBA_U8_NORM, 4},
+		{image.S3_DXT5_RGBA, 16},
+		{image.PVRTC1_RGBA_4BPP, 8},
+		{image.PVRTC1_RGBA_2BPP, 8},
+	} {
+		buf := &bytes.Buffer{}
+		if err := image.WriteKTX2(buf, newTestTexture(test.format, 16, 16, 1, 0, 1, 1)); err != nil {
+			t.Errorf("WriteKTX2 of %v returned error: %v", test.format.Name, err)
+			continue
+		}
+		// The bytesPlane0 field of the basic descriptor block follows the
+		// dfdTotalSize and 4 other words of the data format descriptor.
+		data := buf.Bytes()
+		dfdOffset := binary.LittleEndian.Uint32(data[48:])
+		got := uint32(data[dfdOffset+20])
+		if got != test.expected {
+			t.Errorf("WriteKTX2 of %v wrote a block size of %d, expected %d", test.format.Name, got, test.expected)
+		}
+	}
+}
+
+func TestReadKTXFiles(t *testing.T) {
+	// Compare the textures read by ReadKTX with the images of loadKTX.
+	for _, f := range []*image.Format{
+		image.ETC2_RGB_U8_NORM,
+		image.ETC2_RGBA_U8_NORM,
+		image.ETC2_RGBA_U8U8U8U1_NORM,
+		image.ETC2_R_U11_NORM,
+		image.ETC2_RG_S11_NORM,
+	} {
+		path := filepath.Join("test_data", f.Name+".ktx")
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Errorf("Failed to read '%s': %v", path, err)
+			continue
+		}
+		expected, err := loadKTX(data)
+		if err != nil {
+			t.Errorf("Failed to load '%s': %v", path, err)
+			continue
+		}
+		tex, err := image.ReadKTX(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("ReadKTX of '%s' returned error: %v", path, err)
+			continue
+		}
+		if len(tex.Levels) != 1 || tex.ImagesPerLevel() != 1 {
+			t.Errorf("ReadKTX of '%s' returned %d levels of %d images, expected 1 level of 1 image",
+				path, len(tex.Levels), tex.ImagesPerLevel())
+			continue
+		}
+		got := tex.Image(0, 0, 0)
+		if got.Format.Key() != expected.Format.Key() || got.Width != expected.Width ||
+			got.Height != expected.Height || !bytes.Equal(got.Bytes, expected.Bytes) {
+			t.Errorf("ReadKTX of '%s' returned a %dx%d %v image, expected %dx%d %v", path,
+				got.Width, got.Height, got.Format.Name, expected.Width, expected.Height, expected.Format.Name)
+		}
+	}
+}
+
+func TestReadCorruptContainer(t *testing.T) {
+	tex := newTestTexture(image.RGBA_U8_NORM, 4, 4, 1, 0, 1, 1)
+	encode := func(write func(io.Writer, *image.Texture) error) []byte {
+		buf := &bytes.Buffer{}
+		if err := write(buf, tex); err != nil {
+			t.Fatalf("Failed to write the test texture: %v", err)
+		}
+		return buf.Bytes()
+	}
+	ktx, ktx2, dds := encode(image.WriteKTX), encode(image.WriteKTX2), encode(image.WriteDDS)
+
+	for _, test := range []struct {
+		name   string
+		file   []byte
+		read   func(io.Reader) (*image.Texture, error)
+		offset int    // Offset of the header field to corrupt.
+		value  uint32 // Value of the corrupted field.
+	}{
+		{"KTX key/value data", ktx, image.ReadKTX, 60, 0xffffffff},
+		{"KTX width", ktx, image.ReadKTX, 36, 0xffffffff},
+		{"KTX large width", ktx, image.ReadKTX, 36, 0x8000},
+		{"KTX layers", ktx, image.ReadKTX, 48, 0xffffffff},
+		{"KTX faces", ktx, image.ReadKTX, 52, 0xffffffff},
+		{"KTX levels", ktx, image.ReadKTX, 56, 0xffffffff},
+		{"KTX2 width", ktx2, image.ReadKTX2, 20, 0x8000},
+		{"KTX2 layers", ktx2, image.ReadKTX2, 32, 1 << 20},
+		{"KTX2 levels", ktx2, image.ReadKTX2, 40, 0xffffffff},
+		{"KTX2 level offset", ktx2, image.ReadKTX2, 80, 0xffffffff},
+		{"DDS width", dds, image.ReadDDS, 16, 0x8000},
+		{"DDS levels", dds, image.ReadDDS, 28, 0xffffffff},
+		{"DDS array size", dds, image.ReadDDS, 140, 0xffffffff},
+	} {
+		file := append([]byte{}, test.file...)
+		binary.LittleEndian.PutUint32(file[test.offset:], test.value)
+		if _, err := test.read(bytes.NewReader(file)); err == nil {
+			t.Errorf("%s: Reading the corrupt file did not return an error", test.name)
+		}
+		if _, err := test.read(bytes.NewReader(test.file[:len(test.file)/2])); err == nil {
+			t.Errorf("%s: Reading the truncated file did not return an error", test.name)
+		}
+	}
+}
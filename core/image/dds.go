@@ -0,0 +1,273 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/math/u32"
+	"github.com/google/gapid/core/os/device"
+)
+
+// DDS header flags and capabilities.
+// See: https://docs.microsoft.com/en-us/windows/desktop/direct3ddds/dds-header
+const (
+	ddsMagic      = 0x20534444 // "DDS "
+	ddsHeaderSize = 124
+	ddsPixFmtSize = 32
+
+	ddsdCaps        = 0x1
+	ddsdHeight      = 0x2
+	ddsdWidth       = 0x4
+	ddsdPitch       = 0x8
+	ddsdPixelFormat = 0x1000
+	ddsdMipMapCount = 0x20000
+	ddsdLinearSize  = 0x80000
+	ddsdDepth       = 0x800000
+
+	ddpfAlphaPixels = 0x1
+	ddpfFourCC      = 0x4
+	ddpfRGB         = 0x40
+
+	ddsCapsComplex = 0x8
+	ddsCapsTexture = 0x1000
+	ddsCapsMipMap  = 0x400000
+
+	ddsCaps2Cubemap         = 0x200
+	ddsCaps2CubemapAllFaces = 0xFC00
+	ddsCaps2Volume          = 0x200000
+
+	ddsDimensionTexture2D = 3
+	ddsDimensionTexture3D = 4
+	ddsMiscTextureCube    = 0x4
+)
+
+var ddsFourCCDX10 = fourCC("DX10")
+
+// ReadDDS reads a DDS texture from r.
+func ReadDDS(r io.Reader) (*Texture, error) {
+	file, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	remaining := bytes.NewReader(file)
+	in := endian.Reader(remaining, device.LittleEndian)
+
+	if magic := in.Uint32(); magic != ddsMagic {
+		if err := in.Error(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("Invalid DDS magic. Got: %x", magic)
+	}
+	if size := in.Uint32(); size != ddsHeaderSize {
+		return nil, fmt.Errorf("Invalid DDS header size %d", size)
+	}
+	flags := in.Uint32()
+	height := in.Uint32()
+	width := in.Uint32()
+	in.Uint32() // pitchOrLinearSize
+	depth := in.Uint32()
+	levels := in.Uint32()
+	in.Data(make([]byte, 11*4)) // reserved1
+	in.Uint32()                 // ddspf.size
+	pfFlags := in.Uint32()
+	pfFourCC := in.Uint32()
+	pfBitCount := in.Uint32()
+	var pfMasks [4]uint32
+	for i := range pfMasks {
+		pfMasks[i] = in.Uint32()
+	}
+	in.Uint32() // caps
+	caps2 := in.Uint32()
+	in.Data(make([]byte, 3*4)) // caps3, caps4, reserved2
+	if err := in.Error(); err != nil {
+		return nil, err
+	}
+
+	t := &Texture{
+		Width:  width,
+		Height: u32.Max(height, 1),
+		Depth:  1,
+		Faces:  1,
+	}
+	if flags&ddsdDepth != 0 && caps2&ddsCaps2Volume != 0 {
+		t.Depth = u32.Max(depth, 1)
+	}
+	if flags&ddsdMipMapCount == 0 {
+		levels = 1
+	}
+	levels = u32.Max(levels, 1)
+	if caps2&ddsCaps2Cubemap != 0 {
+		if caps2&ddsCaps2CubemapAllFaces != ddsCaps2CubemapAllFaces {
+			return nil, fmt.Errorf("Partial DDS cube-maps are not supported")
+		}
+		t.Faces = 6
+	}
+
+	var cf *containerFormat
+	switch {
+	case pfFlags&ddpfFourCC != 0 && pfFourCC == ddsFourCCDX10:
+		dxgi := in.Uint32()
+		in.Uint32() // resourceDimension
+		miscFlag := in.Uint32()
+		arraySize := in.Uint32()
+		in.Uint32() // miscFlags2
+		if err := in.Error(); err != nil {
+			return nil, err
+		}
+		if miscFlag&ddsMiscTextureCube != 0 {
+			t.Faces = 6
+		}
+		if arraySize > 1 {
+			t.Layers = arraySize
+		}
+		cf = findContainerFormat(func(c *containerFormat) bool { return c.dxgi == dxgi })
+		if dxgi == 0 || cf == nil {
+			return nil, fmt.Errorf("Unsupported DDS DXGI format %d", dxgi)
+		}
+	case pfFlags&ddpfFourCC != 0:
+		switch pfFourCC {
+		case fourCC("ATI1"):
+			pfFourCC = fourCC("BC4U")
+		case fourCC("ATI2"):
+			pfFourCC = fourCC("BC5U")
+		}
+		cf = findContainerFormat(func(c *containerFormat) bool { return c.fourCC == pfFourCC })
+		if cf == nil {
+			return nil, fmt.Errorf("Unsupported DDS FourCC 0x%x", pfFourCC)
+		}
+	case pfFlags&ddpfRGB != 0 && pfFlags&ddpfAlphaPixels != 0 && pfBitCount == 32:
+		var dxgi uint32
+		switch pfMasks {
+		case [4]uint32{0xff, 0xff00, 0xff0000, 0xff000000}:
+			dxgi = 28 // DXGI_FORMAT_R8G8B8A8_UNORM
+		case [4]uint32{0xff0000, 0xff00, 0xff, 0xff000000}:
+			dxgi = 87 // DXGI_FORMAT_B8G8R8A8_UNORM
+		default:
+			return nil, fmt.Errorf("Unsupported DDS pixel masks %x", pfMasks)
+		}
+		cf = findContainerFormat(func(c *containerFormat) bool { return c.dxgi == dxgi })
+	default:
+		return nil, fmt.Errorf("Unsupported DDS pixel format flags 0x%x", pfFlags)
+	}
+	t.Format = cf.format
+
+	if err := t.checkHeader(levels, remaining.Len()); err != nil {
+		return nil, err
+	}
+	t.Levels = make([][]*Data, levels)
+	for l := range t.Levels {
+		t.Levels[l] = make([]*Data, t.ImagesPerLevel())
+	}
+	for i := 0; i < t.ImagesPerLevel(); i++ {
+		for l := range t.Levels {
+			w, h, d := t.LevelSize(l)
+			data := make([]byte, t.Format.Size(int(w), int(h), int(d)))
+			in.Data(data)
+			t.Levels[l][i] = &Data{Format: t.Format, Width: w, Height: h, Depth: d, Bytes: data}
+		}
+	}
+	if err := in.Error(); err != nil {
+		return nil, err
+	}
+	return t, t.Check()
+}
+
+// WriteDDS writes the texture t to w as a DDS file.
+// Formats with a DXGI equivalent are written with a DX10 header extension.
+// If the texture's format cannot be stored in a DDS file, then
+// ErrUnsupportedContainerFormat is returned.
+func WriteDDS(w io.Writer, t *Texture) error {
+	if err := t.Check(); err != nil {
+		return err
+	}
+	cf := containerFormatOf(t.Format)
+	if cf == nil || (cf.dxgi == 0 && (cf.fourCC == 0 || t.Layers > 1)) {
+		return ErrUnsupportedContainerFormat
+	}
+	_, uncompressed := t.Format.Format.(*Format_Uncompressed)
+
+	flags := uint32(ddsdCaps | ddsdHeight | ddsdWidth | ddsdPixelFormat | ddsdMipMapCount)
+	pitchOrLinearSize := uint32(len(t.Levels[0][0].Bytes))
+	if uncompressed {
+		flags |= ddsdPitch
+		pitchOrLinearSize = uint32(t.Format.Size(int(t.Width), 1, 1))
+	} else {
+		flags |= ddsdLinearSize
+	}
+	caps, caps2, depth := uint32(ddsCapsTexture), uint32(0), uint32(0)
+	if len(t.Levels) > 1 {
+		caps |= ddsCapsComplex | ddsCapsMipMap
+	}
+	if t.Faces == 6 {
+		caps |= ddsCapsComplex
+		caps2 |= ddsCaps2Cubemap | ddsCaps2CubemapAllFaces
+	}
+	if t.Depth > 1 {
+		flags |= ddsdDepth
+		caps |= ddsCapsComplex
+		caps2 |= ddsCaps2Volume
+		depth = t.Depth
+	}
+
+	out := endian.Writer(w, device.LittleEndian)
+	out.Uint32(ddsMagic)
+	out.Uint32(ddsHeaderSize)
+	out.Uint32(flags)
+	out.Uint32(t.Height)
+	out.Uint32(t.Width)
+	out.Uint32(pitchOrLinearSize)
+	out.Uint32(depth)
+	out.Uint32(uint32(len(t.Levels)))
+	out.Data(make([]byte, 11*4)) // reserved1
+	out.Uint32(ddsPixFmtSize)
+	out.Uint32(ddpfFourCC)
+	if cf.dxgi != 0 {
+		out.Uint32(ddsFourCCDX10)
+	} else {
+		out.Uint32(cf.fourCC)
+	}
+	out.Data(make([]byte, 5*4)) // rgbBitCount and masks
+	out.Uint32(caps)
+	out.Uint32(caps2)
+	out.Data(make([]byte, 3*4)) // caps3, caps4, reserved2
+
+	if cf.dxgi != 0 {
+		dimension, miscFlag := uint32(ddsDimensionTexture2D), uint32(0)
+		if t.Depth > 1 {
+			dimension = ddsDimensionTexture3D
+		}
+		if t.Faces == 6 {
+			miscFlag = ddsMiscTextureCube
+		}
+		out.Uint32(cf.dxgi)
+		out.Uint32(dimension)
+		out.Uint32(miscFlag)
+		out.Uint32(u32.Max(t.Layers, 1))
+		out.Uint32(0) // miscFlags2
+	}
+
+	// DDS files store all the mip-levels of each layer and face together.
+	for i := 0; i < t.ImagesPerLevel(); i++ {
+		for _, images := range t.Levels {
+			out.Data(images[i].Bytes)
+		}
+	}
+	return out.Error()
+}
@@ -184,6 +184,11 @@ func TestDecompressors(t *testing.T) {
 		{image.RGTC1_BC4_R_S8_NORM, ".bin"},
 		{image.RGTC2_BC5_RG_U8_NORM, ".bin"},
 		{image.RGTC2_BC5_RG_S8_NORM, ".bin"},
+		{image.BPTC_RGBA_U8_NORM, ".bin"},
+		{image.BPTC_RGB_SF16, ".bin"},
+		{image.BPTC_RGB_UF16, ".bin"},
+		{image.PVRTC1_RGBA_2BPP, ".bin"},
+		{image.PVRTC1_RGBA_4BPP, ".bin"},
 	} {
 		name := test.fmt.Name
 		inPath := filepath.Join("test_data", name+test.ext)
@@ -294,6 +299,51 @@ func TestDecompressors(t *testing.T) {
 	}
 }
 
+// TestDecompressSRGB checks that the sRGB block formats decode to the same
+// (non-linearized) values as their linear counterparts when converted to
+// SRGBA_U8_NORM.
+func TestDecompressSRGB(t *testing.T) {
+	for _, test := range []struct {
+		linear, srgb *image.Format
+	}{
+		{image.ETC2_RGB_U8_NORM, image.ETC2_SRGB_U8_NORM},
+		{image.ETC2_RGBA_U8_NORM, image.ETC2_SRGBA_U8_NORM},
+		{image.ETC2_RGBA_U8U8U8U1_NORM, image.ETC2_SRGBA_U8U8U8U1_NORM},
+	} {
+		if test.linear.Key() == test.srgb.Key() {
+			t.Errorf("%v and %v have the same key", test.linear.Name, test.srgb.Name)
+			continue
+		}
+
+		inPath := filepath.Join("test_data", test.linear.Name+".ktx")
+		data, err := ioutil.ReadFile(inPath)
+		if err != nil {
+			t.Errorf("Failed to read '%s': %v", inPath, err)
+			continue
+		}
+		in, err := loadKTX(data)
+		if err != nil {
+			t.Errorf("Failed to read '%s': %v", inPath, err)
+			continue
+		}
+
+		linear, err := in.Convert(image.RGBA_U8_NORM)
+		if err != nil {
+			t.Errorf("Failed to convert '%s' from %v to %v: %v", inPath, test.linear, image.RGBA_U8_NORM, err)
+			continue
+		}
+		in.Format = test.srgb
+		srgb, err := in.Convert(image.SRGBA_U8_NORM)
+		if err != nil {
+			t.Errorf("Failed to convert '%s' from %v to %v: %v", inPath, test.srgb, image.SRGBA_U8_NORM, err)
+			continue
+		}
+		if !bytes.Equal(linear.Bytes, srgb.Bytes) {
+			t.Errorf("%v decoded differently to %v", test.srgb.Name, test.linear.Name)
+		}
+	}
+}
+
 func s16ToU8(src []byte, w, h, d int) ([]byte, error) {
 	pixels := w * h * d
 	channels := len(src) / (pixels * 2)
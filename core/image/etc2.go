@@ -50,6 +50,9 @@ func NewETC2_SRGB_U8_NORM(name string) *Format {
 }
 
 func (f *FmtETC2_RGB_U8_NORM) key() interface{} {
+	if f.Srgb {
+		return "ETC2_SRGB_U8_NORM"
+	}
 	return "ETC2_RGB_U8_NORM"
 }
 func (*FmtETC2_RGB_U8_NORM) size(w, h, d int) int {
@@ -75,6 +78,9 @@ func NewETC2_SRGBA_U8_NORM(name string) *Format {
 }
 
 func (f *FmtETC2_RGBA_U8_NORM) key() interface{} {
+	if f.Srgb {
+		return "ETC2_SRGBA_U8_NORM"
+	}
 	return "ETC2_RGBA_U8_NORM"
 }
 func (*FmtETC2_RGBA_U8_NORM) size(w, h, d int) int {
@@ -100,6 +106,9 @@ func NewETC2_SRGBA_U8U8U8U1_NORM(name string) *Format {
 }
 
 func (f *FmtETC2_RGBA_U8U8U8U1_NORM) key() interface{} {
+	if f.Srgb {
+		return "ETC2_SRGBA_U8U8U8U1_NORM"
+	}
 	return "ETC2_RGBA_U8U8U8U1_NORM"
 }
 func (*FmtETC2_RGBA_U8U8U8U1_NORM) size(w, h, d int) int {
@@ -0,0 +1,224 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream"
+)
+
+// EXR is a format representing an OpenEXR image file.
+var EXR = NewEXR("exr")
+
+// NewEXR returns a format representing an OpenEXR image file.
+func NewEXR(name string) *Format { return &Format{Name: name, Format: &Format_Exr{&FmtEXR{}}} }
+
+func (f *FmtEXR) key() interface{}                   { return "EXR" }
+func (*FmtEXR) size(w, h, d int) int                 { return -1 }
+func (*FmtEXR) check(data []byte, w, h, d int) error { return nil }
+func (*FmtEXR) channels() stream.Channels {
+	return nil
+}
+
+func init() {
+	// The HDR block-compressed formats would lose their range going via the
+	// default 8-bit intermediate formats.
+	for _, f := range []*Format{BPTC_RGB_SF16, BPTC_RGB_UF16} {
+		f := f
+		RegisterConverter(f, EXR, func(src []byte, w, h, d int) ([]byte, error) {
+			rgba, err := Convert(src, w, h, d, f, RGBA_F32)
+			if err != nil {
+				return nil, err
+			}
+			return Convert(rgba, w, h, d, RGBA_F32, EXR)
+		})
+	}
+}
+
+// OpenEXR channel pixel types.
+const (
+	exrUint  = 0
+	exrHalf  = 1
+	exrFloat = 2
+)
+
+// exrMagic is the little-endian magic number at the start of every OpenEXR
+// file.
+const exrMagic = 20000630
+
+// exrChannel describes a single channel of an OpenEXR image.
+type exrChannel struct {
+	name      string
+	pixelType int32
+	component *stream.Component
+}
+
+// exrChannels returns the OpenEXR channels, sorted by name, used to hold the
+// components of f. Color channels use the standard R, G, B, A and Y names.
+// Depth and stencil are stored as the separate 'depth' and 'stencil' layers.
+func exrChannels(f *stream.Format) []exrChannel {
+	out := []exrChannel{}
+	for _, c := range f.Components {
+		var name string
+		switch c.Channel {
+		case stream.Channel_Red:
+			name = "R"
+		case stream.Channel_Green:
+			name = "G"
+		case stream.Channel_Blue:
+			name = "B"
+		case stream.Channel_Alpha:
+			name = "A"
+		case stream.Channel_Gray, stream.Channel_Luminance:
+			name = "Y"
+		case stream.Channel_Depth:
+			name = "depth.Z"
+		case stream.Channel_Stencil:
+			name = "stencil.S"
+		default:
+			continue
+		}
+
+		ty := c.DataType
+		ch := exrChannel{name: name, pixelType: exrFloat}
+		switch {
+		case c.Channel == stream.Channel_Depth:
+			// Depth always needs the full precision of a FLOAT.
+		case ty.IsInteger() && !c.IsNormalized() && !ty.Signed:
+			ch.pixelType = exrUint
+		case ty.IsFloat() && ty.Bits() <= 16,
+			ty.IsInteger() && c.IsNormalized() && ty.Bits() <= 10:
+			ch.pixelType = exrHalf
+		}
+
+		switch ch.pixelType {
+		case exrUint:
+			ch.component = &stream.Component{DataType: &stream.U32, Sampling: stream.Linear, Channel: c.Channel}
+		case exrHalf:
+			ch.component = &stream.Component{DataType: &stream.F16, Sampling: stream.Linear, Channel: c.Channel}
+		case exrFloat:
+			ch.component = &stream.Component{DataType: &stream.F32, Sampling: stream.Linear, Channel: c.Channel}
+		}
+		out = append(out, ch)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out
+}
+
+// encodeEXR returns the image data of format f encoded as an uncompressed,
+// scanline OpenEXR file. All channels are stored as linear values without any
+// loss of precision.
+// See: https://www.openexr.com/documentation/openexrfilelayout.pdf
+func encodeEXR(f *stream.Format, data []byte, width, height, depth int) ([]byte, error) {
+	if depth != 1 {
+		return nil, fmt.Errorf("Cannot encode EXR with depth of %d", depth)
+	}
+	channels := exrChannels(f)
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("Format %v has no channels that can be stored in an EXR", f)
+	}
+
+	// Convert all the channels to their EXR pixel type in one pass.
+	dstFmt := &stream.Format{Components: make([]*stream.Component, len(channels))}
+	for i, c := range channels {
+		dstFmt.Components[i] = c.component
+	}
+	pixels, err := stream.Convert(dstFmt, f, data)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	w := endian.Writer(buf, device.LittleEndian)
+	attribute := func(name, ty string, size int) {
+		w.String(name)
+		w.String(ty)
+		w.Int32(int32(size))
+	}
+	box2i := func(name string) {
+		attribute(name, "box2i", 16)
+		w.Int32(0)
+		w.Int32(0)
+		w.Int32(int32(width - 1))
+		w.Int32(int32(height - 1))
+	}
+
+	w.Uint32(exrMagic)
+	w.Uint32(2) // Version 2, single-part scanline file.
+
+	chlistSize := 1
+	for _, c := range channels {
+		chlistSize += len(c.name) + 1 + 16
+	}
+	attribute("channels", "chlist", chlistSize)
+	for _, c := range channels {
+		w.String(c.name)
+		w.Int32(c.pixelType)
+		w.Uint8(0) // pLinear
+		w.Data([]byte{0, 0, 0})
+		w.Int32(1) // xSampling
+		w.Int32(1) // ySampling
+	}
+	w.Uint8(0)
+
+	attribute("compression", "compression", 1)
+	w.Uint8(0) // NO_COMPRESSION
+	box2i("dataWindow")
+	box2i("displayWindow")
+	attribute("lineOrder", "lineOrder", 1)
+	w.Uint8(0) // INCREASING_Y
+	attribute("pixelAspectRatio", "float", 4)
+	w.Float32(1)
+	attribute("screenWindowCenter", "v2f", 8)
+	w.Float32(0)
+	w.Float32(0)
+	attribute("screenWindowWidth", "float", 4)
+	w.Float32(1)
+	w.Uint8(0) // End of header.
+
+	// Each uncompressed chunk holds a single scanline of each channel in turn.
+	bitOffsets := dstFmt.BitOffsets()
+	stride := dstFmt.Stride()
+	chunkSize := 0
+	for _, c := range channels {
+		chunkSize += width * int(c.component.DataType.Bits()/8)
+	}
+	offset := uint64(buf.Len() + 8*height)
+	for y := 0; y < height; y++ {
+		w.Uint64(offset)
+		offset += uint64(8 + chunkSize)
+	}
+	for y := 0; y < height; y++ {
+		w.Int32(int32(y))
+		w.Int32(int32(chunkSize))
+		row := pixels[y*width*stride:]
+		for _, c := range channels {
+			start, size := int(bitOffsets[c.component]/8), int(c.component.DataType.Bits()/8)
+			for x := 0; x < width; x++ {
+				i := x*stride + start
+				w.Data(row[i : i+size])
+			}
+		}
+	}
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
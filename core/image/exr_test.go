@@ -0,0 +1,148 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/core/math/f16"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream/fmts"
+)
+
+// exrFile is a minimal decoding of an uncompressed, scanline OpenEXR file.
+type exrFile struct {
+	channels   []string
+	pixelTypes []int32
+	// rows holds the raw data of each scanline, indexed by channel.
+	rows [][][]byte
+}
+
+func readEXR(t *testing.T, data []byte, width, height int) *exrFile {
+	br := bytes.NewReader(data)
+	r := endian.Reader(br, device.LittleEndian)
+	if magic := r.Uint32(); magic != 20000630 {
+		t.Fatalf("Invalid EXR magic %d", magic)
+	}
+	r.Uint32() // version
+	out := &exrFile{}
+	for {
+		name := r.String()
+		if name == "" {
+			break
+		}
+		r.String() // type
+		size := r.Int32()
+		if name != "channels" {
+			r.Data(make([]byte, size))
+			continue
+		}
+		for c := r.String(); c != ""; c = r.String() {
+			out.channels = append(out.channels, c)
+			out.pixelTypes = append(out.pixelTypes, r.Int32())
+			r.Data(make([]byte, 12))
+		}
+	}
+	offsets := make([]uint64, height)
+	for y := range offsets {
+		offsets[y] = r.Uint64()
+	}
+	sizes := map[int32]int{0: 4, 1: 2, 2: 4}
+	for y := 0; y < height; y++ {
+		if pos := uint64(br.Size()) - uint64(br.Len()); offsets[y] != pos {
+			t.Fatalf("Offset of scanline %d was %d, expected %d", y, offsets[y], pos)
+		}
+		if got := r.Int32(); got != int32(y) {
+			t.Fatalf("Expected chunk for scanline %d, got %d", y, got)
+		}
+		r.Int32() // size
+		row := make([][]byte, len(out.channels))
+		for i := range row {
+			row[i] = make([]byte, width*sizes[out.pixelTypes[i]])
+			r.Data(row[i])
+		}
+		out.rows = append(out.rows, row)
+	}
+	if err := r.Error(); err != nil {
+		t.Fatalf("Failed to read EXR: %v", err)
+	}
+	return out
+}
+
+func TestEXR(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := endian.Writer(buf, device.LittleEndian)
+	for _, f := range []float32{
+		1, 2, 3, 0.5 /**/, 100, -1, 0, 1,
+		0, 0, 0, 0 /**/, 65504, 0.25, 1e-3, 0,
+	} {
+		w.Float32(f)
+	}
+	data, err := image.Convert(buf.Bytes(), 2, 2, 1, image.RGBA_F32, image.EXR)
+	if err != nil {
+		t.Fatalf("Convert to EXR returned error: %v", err)
+	}
+	exr := readEXR(t, data, 2, 2)
+	if expected := []string{"A", "B", "G", "R"}; !reflect.DeepEqual(exr.channels, expected) {
+		t.Errorf("EXR channels were %v, expected %v", exr.channels, expected)
+	}
+	if expected := []int32{2, 2, 2, 2}; !reflect.DeepEqual(exr.pixelTypes, expected) {
+		t.Errorf("EXR pixel types were %v, expected %v", exr.pixelTypes, expected)
+	}
+	red := endian.Reader(bytes.NewReader(exr.rows[1][3]), device.LittleEndian)
+	if got := []float32{red.Float32(), red.Float32()}; !reflect.DeepEqual(got, []float32{0, 65504}) {
+		t.Errorf("EXR red channel of scanline 1 was %v, expected [0 65504]", got)
+	}
+
+	// Depth and stencil are stored as separate layers.
+	ds := image.NewUncompressed("DS_F32U8", fmts.DS_F32U8)
+	buf.Reset()
+	w.Float32(0.75)
+	w.Uint8(42)
+	data, err = image.Convert(buf.Bytes(), 1, 1, 1, ds, image.EXR)
+	if err != nil {
+		t.Fatalf("Convert of depth-stencil to EXR returned error: %v", err)
+	}
+	exr = readEXR(t, data, 1, 1)
+	if expected := []string{"depth.Z", "stencil.S"}; !reflect.DeepEqual(exr.channels, expected) {
+		t.Errorf("EXR channels were %v, expected %v", exr.channels, expected)
+	}
+	if expected := []int32{2, 0}; !reflect.DeepEqual(exr.pixelTypes, expected) {
+		t.Errorf("EXR pixel types were %v, expected %v", exr.pixelTypes, expected)
+	}
+	if got := endian.Reader(bytes.NewReader(exr.rows[0][0]), device.LittleEndian).Float32(); got != 0.75 {
+		t.Errorf("EXR depth was %v, expected 0.75", got)
+	}
+	if got := endian.Reader(bytes.NewReader(exr.rows[0][1]), device.LittleEndian).Uint32(); got != 42 {
+		t.Errorf("EXR stencil was %v, expected 42", got)
+	}
+
+	data, err = image.Convert([]byte{0xff, 0x80, 0x00, 0xff}, 1, 1, 1, image.RGBA_U8_NORM, image.EXR)
+	if err != nil {
+		t.Fatalf("Convert of RGBA_U8_NORM to EXR returned error: %v", err)
+	}
+	exr = readEXR(t, data, 1, 1)
+	if expected := []int32{1, 1, 1, 1}; !reflect.DeepEqual(exr.pixelTypes, expected) {
+		t.Errorf("EXR pixel types were %v, expected %v", exr.pixelTypes, expected)
+	}
+	green := f16.Number(endian.Reader(bytes.NewReader(exr.rows[0][2]), device.LittleEndian).Uint16())
+	if got := green.Float32(); got < 0.501 || got > 0.503 {
+		t.Errorf("EXR green was %v, expected 128/255", got)
+	}
+}
@@ -53,6 +53,8 @@ type format interface {
 var _ = []format{
 	&FmtUncompressed{},
 	&FmtPNG{},
+	&FmtEXR{},
+	&FmtHDR{},
 	&FmtATC_RGB_AMD{},
 	&FmtATC_RGBA_EXPLICIT_ALPHA_AMD{},
 	&FmtATC_RGBA_INTERPOLATED_ALPHA_AMD{},
@@ -68,11 +70,15 @@ var _ = []format{
 	&FmtRGTC1_BC4_R_S8_NORM{},
 	&FmtRGTC2_BC5_RG_U8_NORM{},
 	&FmtRGTC2_BC5_RG_S8_NORM{},
+	&FmtBPTC_RGBA_U8_NORM{},
+	&FmtBPTC_RGB_SF16{},
+	&FmtBPTC_RGB_UF16{},
 	&FmtS3_DXT1_RGB{},
 	&FmtS3_DXT1_RGBA{},
 	&FmtS3_DXT3_RGBA{},
 	&FmtS3_DXT5_RGBA{},
 	&FmtASTC{},
+	&FmtPVRTC{},
 }
 
 // Check returns an error if the combination of data, image width, image
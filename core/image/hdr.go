@@ -0,0 +1,103 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream"
+	"github.com/google/gapid/core/stream/fmts"
+)
+
+// HDR is a format representing a Radiance RGBE (.hdr) image file.
+var HDR = NewHDR("hdr")
+
+// NewHDR returns a format representing a Radiance RGBE (.hdr) image file.
+func NewHDR(name string) *Format { return &Format{Name: name, Format: &Format_Hdr{&FmtHDR{}}} }
+
+func (f *FmtHDR) key() interface{}                   { return "HDR" }
+func (*FmtHDR) size(w, h, d int) int                 { return -1 }
+func (*FmtHDR) check(data []byte, w, h, d int) error { return nil }
+func (*FmtHDR) channels() stream.Channels {
+	return nil
+}
+
+func init() {
+	for _, f := range []*Format{BPTC_RGB_SF16, BPTC_RGB_UF16} {
+		f := f
+		RegisterConverter(f, HDR, func(src []byte, w, h, d int) ([]byte, error) {
+			rgba, err := Convert(src, w, h, d, f, RGBA_F32)
+			if err != nil {
+				return nil, err
+			}
+			return Convert(rgba, w, h, d, RGBA_F32, HDR)
+		})
+	}
+}
+
+// encodeHDR returns the image data of format f encoded as a Radiance RGBE
+// file. The color channels are stored as linear values with a shared 8-bit
+// exponent. Alpha, depth-only and stencil data is not preserved.
+// See: http://paulbourke.net/dataformats/pic/
+func encodeHDR(f *stream.Format, data []byte, width, height, depth int) ([]byte, error) {
+	if depth != 1 {
+		return nil, fmt.Errorf("Cannot encode HDR with depth of %d", depth)
+	}
+	rgb, err := stream.Convert(fmts.RGB_F32, f, data)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y %d +X %d\n", height, width)
+
+	// Scanlines are written flat, without run-length encoding.
+	r := endian.Reader(bytes.NewReader(rgb), device.LittleEndian)
+	for i, c := 0, width*height; i < c; i++ {
+		e := rgbe(r.Float32(), r.Float32(), r.Float32())
+		buf.Write(e[:])
+	}
+	if err := r.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rgbe returns the RGBE encoding of the linear color r, g, b.
+// Negative and NaN values are clamped to 0.
+func rgbe(r, g, b float32) [4]byte {
+	clamp := func(v float32) float64 {
+		if !(v > 0) {
+			return 0
+		}
+		return float64(v)
+	}
+	fr, fg, fb := clamp(r), clamp(g), clamp(b)
+	v := math.Max(fr, math.Max(fg, fb))
+	if v < 1e-32 {
+		return [4]byte{}
+	}
+	if math.IsInf(v, 0) {
+		v = math.MaxFloat32
+		fr, fg, fb = math.Min(fr, v), math.Min(fg, v), math.Min(fb, v)
+	}
+	m, e := math.Frexp(v)
+	scale := m * 256 / v
+	return [4]byte{byte(fr * scale), byte(fg * scale), byte(fb * scale), byte(e + 128)}
+}
@@ -0,0 +1,50 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/core/os/device"
+)
+
+func TestHDR(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := endian.Writer(buf, device.LittleEndian)
+	for _, f := range []float32{
+		1, 0.5, 0.25, 1 /**/, 0, 0, 0, 1 /**/, 1000, -1, 250, 0,
+	} {
+		w.Float32(f)
+	}
+	data, err := image.Convert(buf.Bytes(), 3, 1, 1, image.RGBA_F32, image.HDR)
+	if err != nil {
+		t.Fatalf("Convert to HDR returned error: %v", err)
+	}
+	header := "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 1 +X 3\n"
+	if !bytes.HasPrefix(data, []byte(header)) {
+		t.Fatalf("HDR header was %q, expected %q", data[:len(header)], header)
+	}
+	expected := []byte{
+		128, 64, 32, 129, // 1, 0.5, 0.25
+		0, 0, 0, 0, // black
+		250, 0, 62, 138, // 1000, 0, 250
+	}
+	if got := data[len(header):]; !bytes.Equal(got, expected) {
+		t.Errorf("HDR pixels were %v, expected %v", got, expected)
+	}
+}
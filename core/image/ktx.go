@@ -0,0 +1,186 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/math/sint"
+	"github.com/google/gapid/core/math/u32"
+	"github.com/google/gapid/core/os/device"
+)
+
+var ktxIdentifier = [12]byte{0xAB, 0x4B, 0x54, 0x58, 0x20, 0x31, 0x31, 0xBB, 0x0D, 0x0A, 0x1A, 0x0A}
+
+const ktxEndianness = 0x04030201
+
+// ReadKTX reads a KTX texture from r.
+// See: https://www.khronos.org/opengles/sdk/tools/KTX/file_format_spec/
+func ReadKTX(r io.Reader) (*Texture, error) {
+	file, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	remaining := bytes.NewReader(file)
+	in := endian.Reader(remaining, device.LittleEndian)
+
+	var ident [12]byte
+	in.Data(ident[:])
+	if err := in.Error(); err != nil {
+		return nil, err
+	}
+	if ident != ktxIdentifier {
+		return nil, fmt.Errorf("Invalid KTX identifier. Got: %x", ident)
+	}
+	if endianness := in.Uint32(); endianness != ktxEndianness {
+		return nil, fmt.Errorf("Unsupported KTX endianness 0x%x", endianness)
+	}
+
+	glType := in.Uint32()
+	in.Uint32() // glTypeSize
+	glFormat := in.Uint32()
+	glInternalFormat := in.Uint32()
+	in.Uint32() // glBaseInternalFormat
+	width := in.Uint32()
+	height := in.Uint32()
+	depth := in.Uint32()
+	layers := in.Uint32()
+	faces := in.Uint32()
+	levels := in.Uint32()
+	bytesOfKeyValueData := in.Uint32()
+	if err := in.Error(); err != nil {
+		return nil, err
+	}
+	if int64(bytesOfKeyValueData) > int64(remaining.Len()) {
+		return nil, fmt.Errorf("KTX key/value data of 0x%x bytes lies outside of the file", bytesOfKeyValueData)
+	}
+	in.Data(make([]byte, bytesOfKeyValueData))
+
+	cf := findContainerFormat(func(c *containerFormat) bool {
+		return c.gl.internalFormat == glInternalFormat &&
+			c.gl.format == glFormat && c.gl.typ == glType
+	})
+	if cf == nil {
+		return nil, fmt.Errorf("Unsupported KTX format: glInternalFormat=0x%x glFormat=0x%x glType=0x%x",
+			glInternalFormat, glFormat, glType)
+	}
+
+	t := &Texture{
+		Format: cf.format,
+		Width:  width,
+		Height: u32.Max(height, 1),
+		Depth:  u32.Max(depth, 1),
+		Layers: layers,
+		Faces:  faces,
+	}
+	levels = u32.Max(levels, 1)
+	if err := t.checkHeader(levels, remaining.Len()); err != nil {
+		return nil, err
+	}
+	t.Levels = make([][]*Data, levels)
+	for l := range t.Levels {
+		in.Uint32() // imageSize
+		w, h, d := t.LevelSize(l)
+		images := make([]*Data, t.ImagesPerLevel())
+		for i := range images {
+			data := make([]byte, t.Format.Size(int(w), int(h), int(d)))
+			ktxRows(t.Format, w, h, d, data, func(row []byte, padding int) {
+				in.Data(row)
+				in.Data(make([]byte, padding))
+			})
+			images[i] = &Data{Format: t.Format, Width: w, Height: h, Depth: d, Bytes: data}
+		}
+		if err := in.Error(); err != nil {
+			return nil, err
+		}
+		t.Levels[l] = images
+	}
+	return t, t.Check()
+}
+
+// WriteKTX writes the texture t to w as a KTX file.
+// If the texture's format cannot be stored in a KTX file, then
+// ErrUnsupportedContainerFormat is returned.
+func WriteKTX(w io.Writer, t *Texture) error {
+	if err := t.Check(); err != nil {
+		return err
+	}
+	cf := containerFormatOf(t.Format)
+	if cf == nil || cf.gl.internalFormat == 0 {
+		return ErrUnsupportedContainerFormat
+	}
+
+	out := endian.Writer(w, device.LittleEndian)
+	out.Data(ktxIdentifier[:])
+	out.Uint32(ktxEndianness)
+	out.Uint32(cf.gl.typ)
+	out.Uint32(cf.gl.typeSize)
+	out.Uint32(cf.gl.format)
+	out.Uint32(cf.gl.internalFormat)
+	out.Uint32(cf.gl.baseInternalFormat)
+	out.Uint32(t.Width)
+	out.Uint32(t.Height)
+	if t.Depth > 1 {
+		out.Uint32(t.Depth)
+	} else {
+		out.Uint32(0)
+	}
+	out.Uint32(t.Layers)
+	out.Uint32(t.Faces)
+	out.Uint32(uint32(len(t.Levels)))
+	out.Uint32(0) // bytesOfKeyValueData
+
+	// As rows are aligned to 4 bytes, the cube and mip-level padding is always
+	// empty.
+	for l, images := range t.Levels {
+		w, h, d := t.LevelSize(l)
+		imageSize := 0
+		ktxRows(t.Format, w, h, d, images[0].Bytes, func(row []byte, padding int) {
+			imageSize += len(row) + padding
+		})
+		if t.Faces != 6 || t.Layers != 0 {
+			// For everything but non-array cube-maps, imageSize is the size
+			// of the entire mip-level.
+			imageSize *= len(images)
+		}
+		out.Uint32(uint32(imageSize))
+		for _, img := range images {
+			ktxRows(t.Format, w, h, d, img.Bytes, func(row []byte, padding int) {
+				out.Data(row)
+				out.Data(make([]byte, padding))
+			})
+		}
+	}
+	return out.Error()
+}
+
+// ktxRows calls cb for each row of the uncompressed image data, along with the
+// number of padding bytes required to align the row to 4 bytes.
+// Compressed images are passed to cb in a single call.
+func ktxRows(f *Format, w, h, d uint32, data []byte, cb func(row []byte, padding int)) {
+	if _, ok := f.Format.(*Format_Uncompressed); !ok {
+		cb(data, sint.AlignUp(len(data), 4)-len(data))
+		return
+	}
+	stride := f.Size(int(w), 1, 1)
+	padding := sint.AlignUp(stride, 4) - stride
+	for i := 0; i < int(h*d); i++ {
+		cb(data[i*stride:(i+1)*stride], padding)
+	}
+}
@@ -0,0 +1,404 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/data/protoutil"
+	"github.com/google/gapid/core/math/sint"
+	"github.com/google/gapid/core/math/u32"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream"
+)
+
+var ktx2Identifier = [12]byte{0xAB, 0x4B, 0x54, 0x58, 0x20, 0x32, 0x30, 0xBB, 0x0D, 0x0A, 0x1A, 0x0A}
+
+const (
+	ktx2HeaderSize     = 80
+	ktx2LevelIndexSize = 24
+)
+
+// ReadKTX2 reads a KTX2 texture from r.
+// Supercompressed textures are not supported.
+// See: https://github.khronos.org/KTX-Specification/
+func ReadKTX2(r io.Reader) (*Texture, error) {
+	file, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	in := endian.Reader(bytes.NewReader(file), device.LittleEndian)
+
+	var ident [12]byte
+	in.Data(ident[:])
+	if err := in.Error(); err != nil {
+		return nil, err
+	}
+	if ident != ktx2Identifier {
+		return nil, fmt.Errorf("Invalid KTX2 identifier. Got: %x", ident)
+	}
+
+	vkFormat := in.Uint32()
+	in.Uint32() // typeSize
+	width := in.Uint32()
+	height := in.Uint32()
+	depth := in.Uint32()
+	layers := in.Uint32()
+	faces := in.Uint32()
+	levels := u32.Max(in.Uint32(), 1)
+	if scheme := in.Uint32(); scheme != 0 {
+		return nil, fmt.Errorf("Unsupported KTX2 supercompression scheme %d", scheme)
+	}
+	in.Data(make([]byte, 32)) // dfd, kvd and sgd offsets and lengths.
+	if err := in.Error(); err != nil {
+		return nil, err
+	}
+
+	cf := findContainerFormat(func(c *containerFormat) bool { return c.vk == vkFormat })
+	if vkFormat == 0 || cf == nil {
+		return nil, fmt.Errorf("Unsupported KTX2 vkFormat %d", vkFormat)
+	}
+
+	t := &Texture{
+		Format: cf.format,
+		Width:  width,
+		Height: u32.Max(height, 1),
+		Depth:  u32.Max(depth, 1),
+		Layers: layers,
+		Faces:  faces,
+	}
+	if err := t.checkHeader(levels, len(file)-ktx2HeaderSize); err != nil {
+		return nil, err
+	}
+	t.Levels = make([][]*Data, levels)
+
+	type levelIndex struct{ offset, length uint64 }
+	index := make([]levelIndex, levels)
+	for i := range index {
+		index[i].offset = in.Uint64()
+		index[i].length = in.Uint64()
+		in.Uint64() // uncompressedByteLength
+	}
+	if err := in.Error(); err != nil {
+		return nil, err
+	}
+	for l, idx := range index {
+		if idx.offset > uint64(len(file)) || idx.length > uint64(len(file))-idx.offset {
+			return nil, fmt.Errorf("KTX2 mip-level %d lies outside of the file", l)
+		}
+		data := file[idx.offset : idx.offset+idx.length]
+		w, h, d := t.LevelSize(l)
+		size := t.Format.Size(int(w), int(h), int(d))
+		images := make([]*Data, t.ImagesPerLevel())
+		if len(data) != size*len(images) {
+			return nil, fmt.Errorf("KTX2 mip-level %d has 0x%x bytes, expected 0x%x",
+				l, len(data), size*len(images))
+		}
+		for i := range images {
+			images[i] = &Data{
+				Format: t.Format,
+				Width:  w,
+				Height: h,
+				Depth:  d,
+				Bytes:  data[i*size : (i+1)*size],
+			}
+		}
+		t.Levels[l] = images
+	}
+	return t, t.Check()
+}
+
+// WriteKTX2 writes the texture t to w as a KTX2 file.
+// If the texture's format cannot be stored in a KTX2 file, then
+// ErrUnsupportedContainerFormat is returned.
+func WriteKTX2(w io.Writer, t *Texture) error {
+	if err := t.Check(); err != nil {
+		return err
+	}
+	cf := containerFormatOf(t.Format)
+	if cf == nil || cf.vk == 0 {
+		return ErrUnsupportedContainerFormat
+	}
+	dfd, blockSize := ktx2DFD(t.Format)
+
+	// Mip-levels are stored smallest first, each aligned to both the texel
+	// block size and 4 bytes.
+	alignment := blockSize
+	for alignment%4 != 0 {
+		alignment += blockSize
+	}
+	type levelIndex struct{ offset, length int }
+	index := make([]levelIndex, len(t.Levels))
+	dfdOffset := ktx2HeaderSize + ktx2LevelIndexSize*len(t.Levels)
+	offset := dfdOffset + len(dfd)
+	for l := len(t.Levels) - 1; l >= 0; l-- {
+		offset = sint.AlignUp(offset, alignment)
+		length := 0
+		for _, img := range t.Levels[l] {
+			length += len(img.Bytes)
+		}
+		index[l] = levelIndex{offset, length}
+		offset += length
+	}
+
+	out := endian.Writer(w, device.LittleEndian)
+	out.Data(ktx2Identifier[:])
+	out.Uint32(cf.vk)
+	out.Uint32(cf.gl.typeSize)
+	out.Uint32(t.Width)
+	out.Uint32(t.Height)
+	if t.Depth > 1 {
+		out.Uint32(t.Depth)
+	} else {
+		out.Uint32(0)
+	}
+	out.Uint32(t.Layers)
+	out.Uint32(t.Faces)
+	out.Uint32(uint32(len(t.Levels)))
+	out.Uint32(0) // supercompressionScheme
+	out.Uint32(uint32(dfdOffset))
+	out.Uint32(uint32(len(dfd)))
+	out.Uint32(0) // kvdByteOffset
+	out.Uint32(0) // kvdByteLength
+	out.Uint64(0) // sgdByteOffset
+	out.Uint64(0) // sgdByteLength
+	for _, idx := range index {
+		out.Uint64(uint64(idx.offset))
+		out.Uint64(uint64(idx.length))
+		out.Uint64(uint64(idx.length))
+	}
+	out.Data(dfd)
+
+	offset = dfdOffset + len(dfd)
+	for l := len(t.Levels) - 1; l >= 0; l-- {
+		out.Data(make([]byte, index[l].offset-offset))
+		for _, img := range t.Levels[l] {
+			out.Data(img.Bytes)
+		}
+		offset = index[l].offset + index[l].length
+	}
+	return out.Error()
+}
+
+// KTX2 data format descriptor constants.
+// See: https://www.khronos.org/registry/DataFormat/specs/1.3/dataformat.1.3.html
+const (
+	dfdModelRGBSDA = 1
+	dfdModelBC1A   = 128
+	dfdModelBC2    = 129
+	dfdModelBC3    = 130
+	dfdModelBC4    = 131
+	dfdModelBC5    = 132
+	dfdModelBC6H   = 133
+	dfdModelBC7    = 134
+	dfdModelETC1   = 160
+	dfdModelETC2   = 161
+	dfdModelASTC   = 162
+	dfdModelPVRTC  = 164
+	dfdModelPVRTC2 = 165
+
+	dfdChannelRed     = 0
+	dfdChannelGreen   = 1
+	dfdChannelBlue    = 2
+	dfdChannelStencil = 13
+	dfdChannelDepth   = 14
+	dfdChannelAlpha   = 15
+
+	dfdChannelBC1AAlphaPresent = 1
+	dfdChannelETC2Color        = 2
+
+	dfdQualifierLinear = 0x10
+	dfdQualifierSigned = 0x40
+	dfdQualifierFloat  = 0x80
+
+	dfdPrimariesBT709 = 1
+	dfdTransferLinear = 1
+	dfdTransferSRGB   = 2
+)
+
+type dfdSample struct {
+	bitOffset, bitLength uint32
+	channel              uint32
+	lower, upper         uint32
+}
+
+// ktx2DFD returns the data format descriptor for the format f, along with
+// the size in bytes of a single texel block.
+func ktx2DFD(f *Format) ([]byte, int) {
+	model, transfer := uint32(dfdModelRGBSDA), uint32(dfdTransferLinear)
+	blockW, blockH := 1, 1
+	var samples []dfdSample
+
+	unsigned := func(offset, length, channel uint32) dfdSample {
+		return dfdSample{offset, length, channel, 0, 0xffffffff}
+	}
+	signed := func(offset, length, channel uint32) dfdSample {
+		return dfdSample{offset, length, channel | dfdQualifierSigned, 0x80000000, 0x7fffffff}
+	}
+	srgb := func(b bool) uint32 {
+		if b {
+			return dfdTransferSRGB
+		}
+		return dfdTransferLinear
+	}
+
+	switch f := protoutil.OneOf(f.Format).(type) {
+	case *FmtUncompressed:
+		offset := uint32(0)
+		for _, c := range f.Format.Components {
+			bits := c.DataType.Bits()
+			s := dfdSample{bitOffset: offset, bitLength: bits}
+			switch c.Channel {
+			case stream.Channel_Red:
+				s.channel = dfdChannelRed
+			case stream.Channel_Green:
+				s.channel = dfdChannelGreen
+			case stream.Channel_Blue:
+				s.channel = dfdChannelBlue
+			case stream.Channel_Alpha:
+				s.channel = dfdChannelAlpha
+			case stream.Channel_Depth:
+				s.channel = dfdChannelDepth
+			case stream.Channel_Stencil:
+				s.channel = dfdChannelStencil
+			}
+			switch {
+			case c.DataType.IsFloat() && c.DataType.Signed:
+				s.channel |= dfdQualifierFloat | dfdQualifierSigned
+				s.lower, s.upper = 0xbf800000, 0x3f800000 // -1.0, 1.0
+			case c.DataType.IsFloat():
+				s.channel |= dfdQualifierFloat
+				s.lower, s.upper = 0, 0x3f800000 // 0.0, 1.0
+			case c.DataType.Signed:
+				s.channel |= dfdQualifierSigned
+				s.lower, s.upper = uint32(-int32(1<<(bits-1)-1)), 1<<(bits-1)-1
+			default:
+				s.lower, s.upper = 0, uint32(uint64(1)<<bits-1)
+			}
+			if c.Sampling.GetCurve() == stream.Curve_sRGB {
+				transfer = dfdTransferSRGB
+			} else if c.Channel == stream.Channel_Alpha {
+				s.channel |= dfdQualifierLinear
+			}
+			samples = append(samples, s)
+			offset += bits
+		}
+		if transfer != dfdTransferSRGB {
+			// The linear qualifier is only meaningful for sRGB formats.
+			for i := range samples {
+				samples[i].channel &^= dfdQualifierLinear
+			}
+		}
+	case *FmtS3_DXT1_RGB:
+		model, blockW, blockH = dfdModelBC1A, 4, 4
+		samples = []dfdSample{unsigned(0, 64, 0)}
+	case *FmtS3_DXT1_RGBA:
+		model, blockW, blockH = dfdModelBC1A, 4, 4
+		samples = []dfdSample{unsigned(0, 64, dfdChannelBC1AAlphaPresent)}
+	case *FmtS3_DXT3_RGBA:
+		model, blockW, blockH = dfdModelBC2, 4, 4
+		samples = []dfdSample{unsigned(0, 64, dfdChannelAlpha), unsigned(64, 64, 0)}
+	case *FmtS3_DXT5_RGBA:
+		model, blockW, blockH = dfdModelBC3, 4, 4
+		samples = []dfdSample{unsigned(0, 64, dfdChannelAlpha), unsigned(64, 64, 0)}
+	case *FmtRGTC1_BC4_R_U8_NORM:
+		model, blockW, blockH = dfdModelBC4, 4, 4
+		samples = []dfdSample{unsigned(0, 64, 0)}
+	case *FmtRGTC1_BC4_R_S8_NORM:
+		model, blockW, blockH = dfdModelBC4, 4, 4
+		samples = []dfdSample{signed(0, 64, 0)}
+	case *FmtRGTC2_BC5_RG_U8_NORM:
+		model, blockW, blockH = dfdModelBC5, 4, 4
+		samples = []dfdSample{unsigned(0, 64, dfdChannelRed), unsigned(64, 64, dfdChannelGreen)}
+	case *FmtRGTC2_BC5_RG_S8_NORM:
+		model, blockW, blockH = dfdModelBC5, 4, 4
+		samples = []dfdSample{signed(0, 64, dfdChannelRed), signed(64, 64, dfdChannelGreen)}
+	case *FmtBPTC_RGB_UF16:
+		model, blockW, blockH = dfdModelBC6H, 4, 4
+		samples = []dfdSample{{0, 128, dfdQualifierFloat, 0, 0x7f800000}}
+	case *FmtBPTC_RGB_SF16:
+		model, blockW, blockH = dfdModelBC6H, 4, 4
+		samples = []dfdSample{{0, 128, dfdQualifierFloat | dfdQualifierSigned, 0xff800000, 0x7f800000}}
+	case *FmtBPTC_RGBA_U8_NORM:
+		model, blockW, blockH, transfer = dfdModelBC7, 4, 4, srgb(f.Srgb)
+		samples = []dfdSample{unsigned(0, 128, 0)}
+	case *FmtETC1_RGB_U8_NORM:
+		model, blockW, blockH = dfdModelETC1, 4, 4
+		samples = []dfdSample{unsigned(0, 64, 0)}
+	case *FmtETC2_RGB_U8_NORM:
+		model, blockW, blockH, transfer = dfdModelETC2, 4, 4, srgb(f.Srgb)
+		samples = []dfdSample{unsigned(0, 64, dfdChannelETC2Color)}
+	case *FmtETC2_RGBA_U8U8U8U1_NORM:
+		model, blockW, blockH, transfer = dfdModelETC2, 4, 4, srgb(f.Srgb)
+		samples = []dfdSample{unsigned(0, 64, dfdChannelETC2Color), unsigned(0, 64, dfdChannelAlpha)}
+	case *FmtETC2_RGBA_U8_NORM:
+		model, blockW, blockH, transfer = dfdModelETC2, 4, 4, srgb(f.Srgb)
+		samples = []dfdSample{unsigned(0, 64, dfdChannelAlpha), unsigned(64, 64, dfdChannelETC2Color)}
+	case *FmtETC2_R_U11_NORM:
+		model, blockW, blockH = dfdModelETC2, 4, 4
+		samples = []dfdSample{unsigned(0, 64, dfdChannelRed)}
+	case *FmtETC2_R_S11_NORM:
+		model, blockW, blockH = dfdModelETC2, 4, 4
+		samples = []dfdSample{signed(0, 64, dfdChannelRed)}
+	case *FmtETC2_RG_U11_NORM:
+		model, blockW, blockH = dfdModelETC2, 4, 4
+		samples = []dfdSample{unsigned(0, 64, dfdChannelRed), unsigned(64, 64, dfdChannelGreen)}
+	case *FmtETC2_RG_S11_NORM:
+		model, blockW, blockH = dfdModelETC2, 4, 4
+		samples = []dfdSample{signed(0, 64, dfdChannelRed), signed(64, 64, dfdChannelGreen)}
+	case *FmtASTC:
+		model, blockW, blockH, transfer = dfdModelASTC, int(f.BlockWidth), int(f.BlockHeight), srgb(f.Srgb)
+		samples = []dfdSample{unsigned(0, 128, 0)}
+	case *FmtPVRTC:
+		model, blockW, blockH, transfer = dfdModelPVRTC, f.blockWidth(), 4, srgb(f.Srgb)
+		if f.Version == 2 {
+			model = dfdModelPVRTC2
+		}
+		samples = []dfdSample{unsigned(0, 64, 0)}
+	}
+
+	// The size of compressed blocks is taken from the samples, as the size of
+	// a single block may be rounded up, such as to 2x2 blocks for PVRTC1.
+	blockSize := f.Size(blockW, blockH, 1)
+	if blockW > 1 || blockH > 1 {
+		blockSize = 0
+		for _, s := range samples {
+			if end := int(s.bitOffset+s.bitLength) / 8; end > blockSize {
+				blockSize = end
+			}
+		}
+	}
+	buf := &bytes.Buffer{}
+	out := endian.Writer(buf, device.LittleEndian)
+	blockBytes := 24 + 16*len(samples)
+	out.Uint32(uint32(4 + blockBytes)) // dfdTotalSize
+	out.Uint32(0)                      // vendorId, descriptorType
+	out.Uint32(2 | uint32(blockBytes)<<16)
+	out.Uint32(model | dfdPrimariesBT709<<8 | transfer<<16)
+	out.Uint32(uint32(blockW-1) | uint32(blockH-1)<<8)
+	out.Uint32(uint32(blockSize)) // bytesPlane0-3
+	out.Uint32(0)                 // bytesPlane4-7
+	for _, s := range samples {
+		out.Uint32(s.bitOffset | (s.bitLength-1)<<16 | s.channel<<24)
+		out.Uint32(0) // samplePosition0-3
+		out.Uint32(s.lower)
+		out.Uint32(s.upper)
+	}
+	return buf.Bytes(), blockSize
+}
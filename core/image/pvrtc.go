@@ -0,0 +1,350 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/math/sint"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream"
+)
+
+var (
+	PVRTC1_RGB_2BPP   = NewPVRTC("PVRTC1_RGB_2BPP", 2, 1, false, false)
+	PVRTC1_RGB_4BPP   = NewPVRTC("PVRTC1_RGB_4BPP", 4, 1, false, false)
+	PVRTC1_RGBA_2BPP  = NewPVRTC("PVRTC1_RGBA_2BPP", 2, 1, true, false)
+	PVRTC1_RGBA_4BPP  = NewPVRTC("PVRTC1_RGBA_4BPP", 4, 1, true, false)
+	PVRTC1_SRGB_2BPP  = NewPVRTC("PVRTC1_SRGB_2BPP", 2, 1, false, true)
+	PVRTC1_SRGB_4BPP  = NewPVRTC("PVRTC1_SRGB_4BPP", 4, 1, false, true)
+	PVRTC1_SRGBA_2BPP = NewPVRTC("PVRTC1_SRGBA_2BPP", 2, 1, true, true)
+	PVRTC1_SRGBA_4BPP = NewPVRTC("PVRTC1_SRGBA_4BPP", 4, 1, true, true)
+	PVRTC2_RGBA_2BPP  = NewPVRTC("PVRTC2_RGBA_2BPP", 2, 2, true, false)
+	PVRTC2_RGBA_4BPP  = NewPVRTC("PVRTC2_RGBA_4BPP", 4, 2, true, false)
+	PVRTC2_SRGBA_2BPP = NewPVRTC("PVRTC2_SRGBA_2BPP", 2, 2, true, true)
+	PVRTC2_SRGBA_4BPP = NewPVRTC("PVRTC2_SRGBA_4BPP", 4, 2, true, true)
+)
+
+func init() {
+	for _, f := range []*Format{
+		PVRTC1_RGB_2BPP, PVRTC1_RGB_4BPP, PVRTC1_RGBA_2BPP, PVRTC1_RGBA_4BPP,
+		PVRTC1_SRGB_2BPP, PVRTC1_SRGB_4BPP, PVRTC1_SRGBA_2BPP, PVRTC1_SRGBA_4BPP,
+		PVRTC2_RGBA_2BPP, PVRTC2_RGBA_4BPP, PVRTC2_SRGBA_2BPP, PVRTC2_SRGBA_4BPP,
+	} {
+		pvrtc := f.GetPvrtc()
+		dst := RGBA_U8_NORM
+		if pvrtc.Srgb {
+			dst = SRGBA_U8_NORM
+		}
+		RegisterConverter(f, dst, func(src []byte, w, h, d int) ([]byte, error) {
+			return decodePVRTC(src, w, h, d, pvrtc)
+		})
+	}
+}
+
+// NewPVRTC returns a format representing the PVRTC block texture compression
+// format with the given bits per pixel (2 or 4) and version (1 or 2).
+func NewPVRTC(name string, bitsPerPixel, version uint32, alpha, srgb bool) *Format {
+	return &Format{Name: name, Format: &Format_Pvrtc{&FmtPVRTC{
+		BitsPerPixel: bitsPerPixel,
+		Version:      version,
+		Alpha:        alpha,
+		Srgb:         srgb,
+	}}}
+}
+
+func (f *FmtPVRTC) key() interface{} {
+	return f.String()
+}
+func (f *FmtPVRTC) size(w, h, d int) int {
+	bw, bh := f.blocks(w, h)
+	return d * bw * bh * 8
+}
+func (f *FmtPVRTC) check(data []byte, w, h, d int) error {
+	return checkSize(data, f, w, h, d)
+}
+func (f *FmtPVRTC) channels() stream.Channels {
+	if f.Alpha {
+		return stream.Channels{stream.Channel_Red, stream.Channel_Green, stream.Channel_Blue, stream.Channel_Alpha}
+	}
+	return stream.Channels{stream.Channel_Red, stream.Channel_Green, stream.Channel_Blue}
+}
+
+// blockWidth returns the width in texels of a single 64-bit block.
+func (f *FmtPVRTC) blockWidth() int {
+	if f.BitsPerPixel == 2 {
+		return 8
+	}
+	return 4
+}
+
+// blocks returns the number of blocks along each axis for a w x h image.
+// PVRTC1 textures are always at least 2x2 blocks in size.
+func (f *FmtPVRTC) blocks(w, h int) (int, int) {
+	bw := (w + f.blockWidth() - 1) / f.blockWidth()
+	bh := (h + 3) / 4
+	if f.Version < 2 {
+		bw, bh = sint.Max(bw, 2), sint.Max(bh, 2)
+	}
+	return bw, bh
+}
+
+// pvrtcBlock is a single unpacked PVRTC block.
+type pvrtcBlock struct {
+	a, b       pixel   // 5-bit RGB, 4-bit alpha
+	modulation [32]int // per texel modulation
+	modes      int     // modulation mode
+	hard       bool    // PVRTC2 hard transition flag
+}
+
+// pvrtcTwiddle returns the index of the block at (x, y) in a Morton ordered
+// grid of w x h blocks.
+func pvrtcTwiddle(w, h, x, y int) int {
+	minAxis, rest := h, x
+	if h > w {
+		minAxis, rest = w, y
+	}
+	out, shift := 0, uint(0)
+	for bit := 1; bit < minAxis; bit <<= 1 {
+		if y&bit != 0 {
+			out |= 1 << (2 * shift)
+		}
+		if x&bit != 0 {
+			out |= 2 << (2 * shift)
+		}
+		shift++
+	}
+	return out | (rest>>shift)<<(2*shift)
+}
+
+// decodePVRTC decodes the PVRTC data in src to 8-bit RGBA.
+// See: http://cdn.imgtec.com/sdk-documentation/PVRTC+%26+Texture+Compression.User+Guide.pdf
+//
+// The colors of a texel are interpolated between the four blocks P, Q, R and S
+// whose centres surround it. With PVRTC2, the hard transition flag of P turns
+// off this interpolation for the texels between the four centres: each texel
+// uses the colors of its own block, or with the 4bpp modulation mode flag also
+// set in P, the local palette of pvrtcLocalPalette.
+func decodePVRTC(src []byte, width, height, depth int, f *FmtPVRTC) ([]byte, error) {
+	bw, bh := f.blocks(width, height)
+	blockW := f.blockWidth()
+	v2 := f.Version >= 2
+	out := make([]byte, width*height*depth*4)
+	blocks := make([]pvrtcBlock, bw*bh)
+	r := endian.Reader(bytes.NewReader(src), device.LittleEndian)
+	for z := 0; z < depth; z++ {
+		for i := range blocks {
+			blocks[i] = unpackPVRTCBlock(r.Uint32(), r.Uint32(), f.BitsPerPixel, v2)
+		}
+		if err := r.Error(); err != nil {
+			return nil, err
+		}
+
+		block := func(x, y int) *pvrtcBlock {
+			x, y = (x+bw)%bw, (y+bh)%bh
+			return &blocks[pvrtcTwiddle(bw, bh, x, y)]
+		}
+		// modulation returns the stored (or direct) modulation value of the
+		// texel at (x, y), which may lie in a neighbouring block.
+		modulation := func(x, y int) int {
+			x, y = (x+bw*blockW)%(bw*blockW), (y+bh*4)%(bh*4)
+			return block(x/blockW, y/4).modulation[(y%4)*blockW+x%blockW]
+		}
+
+		out := out[z*width*height*4:]
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				own := block(x/blockW, y/4)
+
+				// Colors A and B are bilinearly interpolated between the
+				// centres of the four nearest blocks.
+				u, v := x-blockW/2, y-2
+				bx, by := (u+blockW)/blockW-1, (v+4)/4-1
+				fx, fy := u-bx*blockW, v-by*4
+				p, q, r, s := block(bx, by), block(bx+1, by), block(bx, by+1), block(bx+1, by+1)
+				tx, ty := x%blockW, y%4
+				mod, punchThrough := own.modulation[ty*blockW+tx], false
+
+				var a, b pixel
+				localPalette := p.hard && p.modes != 0 && f.BitsPerPixel == 4
+				switch {
+				case !p.hard:
+					a = pvrtcInterpolate(p.a, q.a, r.a, s.a, fx, fy, blockW)
+					b = pvrtcInterpolate(p.b, q.b, r.b, s.b, fx, fy, blockW)
+				case localPalette:
+					a, b, mod = pvrtcLocalPalette(p, q, r, s, fx, fy, mod)
+				default:
+					a, b = pvrtcExpand(own.a), pvrtcExpand(own.b)
+				}
+
+				switch {
+				case localPalette:
+					// The modulation selected the palette color.
+				case f.BitsPerPixel == 2:
+					mod = pvrtcRepVals[mod]
+					if own.modes != 0 && (tx^ty)&1 != 0 {
+						rep := func(x, y int) int { return pvrtcRepVals[modulation(x, y)] }
+						switch own.modes {
+						case 1: // H and V interpolation
+							mod = (rep(x, y-1) + rep(x, y+1) + rep(x-1, y) + rep(x+1, y) + 2) / 4
+						case 2: // H only
+							mod = (rep(x-1, y) + rep(x+1, y) + 1) / 2
+						default: // V only
+							mod = (rep(x, y-1) + rep(x, y+1) + 1) / 2
+						}
+					}
+				case own.modes != 0 && !own.hard:
+					// Punch-through alpha.
+					mod, punchThrough = [4]int{0, 4, 4, 8}[mod], mod == 2
+				default:
+					mod = pvrtcRepVals[mod]
+				}
+
+				i := (y*width + x) * 4
+				out[i+0] = sint.Byte((a.r*(8-mod) + b.r*mod) / 8)
+				out[i+1] = sint.Byte((a.g*(8-mod) + b.g*mod) / 8)
+				out[i+2] = sint.Byte((a.b*(8-mod) + b.b*mod) / 8)
+				switch {
+				case !f.Alpha:
+					out[i+3] = 255
+				case punchThrough:
+					out[i+3] = 0
+				default:
+					out[i+3] = sint.Byte((a.a*(8-mod) + b.a*mod) / 8)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+var pvrtcRepVals = [4]int{0, 3, 5, 8}
+
+// unpackPVRTCBlock unpacks the colors and per-texel modulation of a block.
+func unpackPVRTCBlock(modulation, color uint32, bpp uint32, v2 bool) pvrtcBlock {
+	out := pvrtcBlock{modes: int(color & 1)}
+
+	opaqueA, opaqueB := color&0x8000 != 0, color&0x80000000 != 0
+	if v2 {
+		opaqueA, out.hard = opaqueB, color&0x8000 != 0
+	}
+	c := int(color)
+	if opaqueA { // RGB 554
+		out.a = pixel{(c >> 10) & 0x1f, (c >> 5) & 0x1f, (c & 0x1e) | (c&0x1e)>>4, 0xf}
+	} else { // ARGB 3443
+		out.a = pixel{(c>>7)&0x1e | (c>>11)&0x1, (c>>3)&0x1e | (c>>7)&0x1, (c<<1)&0x1c | (c>>2)&0x3, (c >> 11) & 0xe}
+	}
+	if opaqueB { // RGB 555
+		out.b = pixel{(c >> 26) & 0x1f, (c >> 21) & 0x1f, (c >> 16) & 0x1f, 0xf}
+	} else { // ARGB 3444
+		out.b = pixel{(c>>23)&0x1e | (c>>27)&0x1, (c>>19)&0x1e | (c>>23)&0x1, (c>>15)&0x1e | (c>>19)&0x1, (c >> 27) & 0xe}
+	}
+
+	m := modulation
+	switch {
+	case bpp == 4:
+		for i := 0; i < 16; i++ {
+			out.modulation[i] = int(m & 3)
+			m >>= 2
+		}
+	case out.modes == 0:
+		// 1 bit per texel: 0 => 0, 1 => 3 (8 once mapped through pvrtcRepVals).
+		for i := 0; i < 32; i++ {
+			out.modulation[i] = int(m&1) * 3
+			m >>= 1
+		}
+	default:
+		// 2 bits per texel for half the texels in a checkerboard pattern.
+		// The remaining texels are interpolated from their neighbours.
+		if m&1 != 0 {
+			// The LSB of the centre texel selects between H-only and V-only
+			// interpolation.
+			if m&(1<<20) != 0 {
+				out.modes = 3
+			} else {
+				out.modes = 2
+			}
+			if m&(1<<21) != 0 {
+				m |= 1 << 20
+			} else {
+				m &^= 1 << 20
+			}
+		}
+		if m&2 != 0 {
+			m |= 1
+		} else {
+			m &^= 1
+		}
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 8; x++ {
+				if (x^y)&1 == 0 {
+					out.modulation[y*8+x] = int(m & 3)
+					m >>= 2
+				}
+			}
+		}
+	}
+	return out
+}
+
+// pvrtcLocalPalette returns the colors A and B and the modulation of the texel
+// at the offset (x, y) from the centre of block P, for the PVRTC2 4bpp local
+// palette mode. The texel at the centre of P uses the colors of P with the
+// standard modulation. Every other texel selects one of the A and B colors of
+// the two blocks whose centres are nearest to it, the nearest block first, with
+// its 2-bit modulation value.
+func pvrtcLocalPalette(p, q, r, s *pvrtcBlock, x, y, mod int) (pixel, pixel, int) {
+	if x == 0 && y == 0 {
+		return pvrtcExpand(p.a), pvrtcExpand(p.b), pvrtcRepVals[mod]
+	}
+	corners := [4]struct {
+		block *pvrtcBlock
+		x, y  int
+	}{{p, 0, 0}, {q, 4, 0}, {r, 0, 4}, {s, 4, 4}}
+	dist := func(i int) int {
+		dx, dy := corners[i].x-x, corners[i].y-y
+		return dx*dx + dy*dy
+	}
+	nearest := []int{0, 1, 2, 3}
+	sort.SliceStable(nearest, func(i, j int) bool { return dist(nearest[i]) < dist(nearest[j]) })
+	c := corners[nearest[mod/2]].block.a
+	if mod&1 != 0 {
+		c = corners[nearest[mod/2]].block.b
+	}
+	c = pvrtcExpand(c)
+	return c, c, 0
+}
+
+// pvrtcExpand returns the 8-bit color of the 5-bit color c, with 4-bit alpha.
+func pvrtcExpand(c pixel) pixel {
+	return pixel{c.r<<3 | c.r>>2, c.g<<3 | c.g>>2, c.b<<3 | c.b>>2, c.a<<4 | c.a}
+}
+
+// pvrtcInterpolate returns the 8-bit color bilinearly interpolated between
+// the 5-bit colors p, q, r and s at the texel offset (x, y).
+func pvrtcInterpolate(p, q, r, s pixel, x, y, blockW int) pixel {
+	lerp := func(p, q, r, s int) int {
+		top := p*blockW + x*(q-p)
+		bottom := r*blockW + x*(s-r)
+		return 4*top + y*(bottom-top)
+	}
+	c := pixel{lerp(p.r, q.r, r.r, s.r), lerp(p.g, q.g, r.g, s.g), lerp(p.b, q.b, r.b, s.b), lerp(p.a, q.a, r.a, s.a)}
+	if blockW == 8 {
+		return pixel{c.r>>7 + c.r>>2, c.g>>7 + c.g>>2, c.b>>7 + c.b>>2, c.a>>5 + c.a>>1}
+	}
+	return pixel{c.r>>6 + c.r>>1, c.g>>6 + c.g>>1, c.b>>6 + c.b>>1, c.a>>4 + c.a}
+}
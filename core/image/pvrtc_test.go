@@ -0,0 +1,176 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/image"
+)
+
+// pvrtc2Color is an opaque 5-bit RGB color of a PVRTC2 block.
+type pvrtc2Color struct{ r, g, b uint32 }
+
+var (
+	pvrtcBlack = pvrtc2Color{0, 0, 0}
+	pvrtcRed   = pvrtc2Color{31, 0, 0}
+	pvrtcGreen = pvrtc2Color{0, 31, 0}
+	pvrtcBlue  = pvrtc2Color{0, 0, 31}
+	pvrtcWhite = pvrtc2Color{31, 31, 31}
+)
+
+// pvrtc2Block is a PVRTC2 4bpp block with opaque colors.
+type pvrtc2Block struct {
+	a, b       pvrtc2Color
+	hard, mode bool
+	modulation [16]uint32 // 2-bit modulation of each texel, row by row.
+}
+
+func (b pvrtc2Block) encode() []byte {
+	mod := uint32(0)
+	for i, m := range b.modulation {
+		mod |= m << uint(2*i)
+	}
+	// Bit 31: opaque, bits 16-30: B as RGB 555, bit 15: hard transition,
+	// bits 1-14: A as RGB 554, bit 0: modulation mode.
+	color := uint32(1<<31) | b.b.r<<26 | b.b.g<<21 | b.b.b<<16 | b.a.r<<10 | b.a.g<<5 | (b.a.b>>1)<<1
+	if b.hard {
+		color |= 1 << 15
+	}
+	if b.mode {
+		color |= 1
+	}
+	out := []byte{}
+	for _, v := range []uint32{mod, color} {
+		out = append(out, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+	}
+	return out
+}
+
+// decodePVRTC2 decodes the 8x8 PVRTC2 4bpp image of the 2x2 blocks, given in
+// row order.
+func decodePVRTC2(t *testing.T, blocks [4]pvrtc2Block) []byte {
+	data := []byte{}
+	// Blocks are stored in Morton order: (0, 0), (0, 1), (1, 0), (1, 1).
+	for _, i := range []int{0, 2, 1, 3} {
+		data = append(data, blocks[i].encode()...)
+	}
+	out, err := image.Convert(data, 8, 8, 1, image.PVRTC2_RGBA_4BPP, image.RGBA_U8_NORM)
+	if err != nil {
+		t.Fatalf("Convert returned error: %v", err)
+	}
+	return out
+}
+
+func TestPVRTC2(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		blocks [4]pvrtc2Block
+		texels map[[2]int][4]byte
+	}{
+		{
+			// Without the hard transition flag, the colors are bilinearly
+			// interpolated between the block centres (2, 2), (6, 2), (2, 6)
+			// and (6, 6). At (3, 3) the weights of P, Q, R and S are 9/16,
+			// 3/16, 3/16 and 1/16. Red: 31 * 10 / 16 = 19.375 in 5 bits,
+			// 159 in 8 bits. Green and blue: 31 * 4 / 16 = 7.75 in 5 bits, 63
+			// in 8 bits.
+			name: "Interpolated",
+			blocks: [4]pvrtc2Block{
+				{a: pvrtcRed}, {a: pvrtcGreen},
+				{a: pvrtcBlue}, {a: pvrtcWhite},
+			},
+			texels: map[[2]int][4]byte{
+				{2, 2}: {255, 0, 0, 255},
+				{6, 6}: {255, 255, 255, 255},
+				{3, 3}: {159, 63, 63, 255},
+			},
+		},
+		{
+			// All the texels use the colors of their own block.
+			name: "Hard transition",
+			blocks: [4]pvrtc2Block{
+				{a: pvrtcRed, hard: true}, {a: pvrtcGreen, hard: true},
+				{a: pvrtcBlue, hard: true}, {a: pvrtcWhite, hard: true},
+			},
+			texels: map[[2]int][4]byte{
+				{2, 2}: {255, 0, 0, 255},
+				{3, 3}: {255, 0, 0, 255},
+				{4, 3}: {0, 255, 0, 255},
+				{3, 4}: {0, 0, 255, 255},
+				{5, 5}: {255, 255, 255, 255},
+				{0, 0}: {255, 0, 0, 255},
+			},
+		},
+		{
+			// The flag of the top-left block P of the four blocks applies.
+			// (3, 3) lies between the centres of blocks (0, 0) to (1, 1),
+			// and (7, 7) and (0, 0) between the centres of blocks (1, 1) to
+			// (0, 0) as the texture wraps.
+			name: "Hard transition of P only",
+			blocks: [4]pvrtc2Block{
+				{a: pvrtcRed}, {a: pvrtcGreen},
+				{a: pvrtcBlue}, {a: pvrtcWhite, hard: true},
+			},
+			texels: map[[2]int][4]byte{
+				{3, 3}: {159, 63, 63, 255},
+				{7, 7}: {255, 255, 255, 255},
+				{0, 0}: {255, 0, 0, 255},
+				{0, 7}: {0, 0, 255, 255},
+			},
+		},
+		{
+			// Texels (2, 2), (3, 2) and (5, 5) are at the offsets (0, 0),
+			// (1, 0) and (3, 3) from the centre of P. (2, 2) uses the colors
+			// of P with the modulation 3/8. The blocks nearest to (3, 2) are
+			// P then Q, so 2 selects the color A of Q. The blocks nearest
+			// to (5, 5) are S then Q, so 3 selects the color B of Q. (4, 4)
+			// is as near to all four, so 0 selects the color A of P.
+			name: "Local palette",
+			blocks: [4]pvrtc2Block{
+				{a: pvrtcRed, b: pvrtcBlack, hard: true, mode: true, modulation: [16]uint32{
+					0, 0, 0, 0,
+					0, 0, 0, 0,
+					0, 0, 1, 2,
+					0, 0, 0, 0,
+				}},
+				{a: pvrtcGreen, b: pvrtcBlue, hard: true, mode: true},
+				{a: pvrtcBlack, b: pvrtcBlack, hard: true, mode: true},
+				{a: pvrtcWhite, b: pvrtcBlack, hard: true, mode: true, modulation: [16]uint32{
+					0, 0, 0, 0,
+					0, 3, 0, 0,
+					0, 0, 0, 0,
+					0, 0, 0, 0,
+				}},
+			},
+			texels: map[[2]int][4]byte{
+				{2, 2}: {159, 0, 0, 255},
+				{3, 2}: {0, 255, 0, 255},
+				{5, 5}: {0, 0, 255, 255},
+				{4, 4}: {255, 0, 0, 255},
+			},
+		},
+	} {
+		out := decodePVRTC2(t, test.blocks)
+		for xy, expected := range test.texels {
+			i := (xy[1]*8 + xy[0]) * 4
+			var got [4]byte
+			copy(got[:], out[i:])
+			if got != expected {
+				t.Errorf("%v: texel %v was %v, expected %v", test.name, xy, got, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"fmt"
+
+	"github.com/google/gapid/core/math/u32"
+)
+
+// Limits of the textures read from containers.
+const (
+	maxTextureSize   = 1 << 16
+	maxTextureLayers = 1 << 12
+	maxTextureLevels = 17
+)
+
+// Texture is a complete texture with every mip-level, array layer and
+// cube-map face, all sharing a single format. Textures are what the KTX, KTX2
+// and DDS container readers return and writers accept.
+type Texture struct {
+	// Format is the format of every image in the texture.
+	Format *Format
+	// Width, Height and Depth are the dimensions of the base mip-level.
+	Width, Height, Depth uint32
+	// Layers is the number of array layers, or 0 if the texture is not an
+	// array texture.
+	Layers uint32
+	// Faces is 6 for cube-maps and cube-map arrays, otherwise 1.
+	Faces uint32
+	// Levels holds the images of each mip-level, starting with the base
+	// level. Each level holds one image for every layer and face, ordered
+	// layer-major. Cube-map faces are ordered +X, -X, +Y, -Y, +Z, -Z.
+	Levels [][]*Data
+}
+
+// LevelSize returns the dimensions of the given mip-level.
+func (t *Texture) LevelSize(level int) (w, h, d uint32) {
+	return u32.Max(t.Width>>uint(level), 1),
+		u32.Max(t.Height>>uint(level), 1),
+		u32.Max(t.Depth>>uint(level), 1)
+}
+
+// ImagesPerLevel returns the number of images held by each mip-level.
+func (t *Texture) ImagesPerLevel() int {
+	return int(u32.Max(t.Layers, 1) * t.Faces)
+}
+
+// Image returns the image for the given mip-level, layer and face.
+func (t *Texture) Image(level, layer, face int) *Data {
+	return t.Levels[level][layer*int(t.Faces)+face]
+}
+
+// Check returns an error if the texture's layout is not self-consistent, or
+// if any of its images do not hold the expected amount of data.
+func (t *Texture) Check() error {
+	if t.Format == nil {
+		return fmt.Errorf("Texture has no format")
+	}
+	if t.Width == 0 || t.Height == 0 || t.Depth == 0 {
+		return fmt.Errorf("Texture has zero dimensions %dx%dx%d", t.Width, t.Height, t.Depth)
+	}
+	if t.Faces != 1 && t.Faces != 6 {
+		return fmt.Errorf("Texture has %d faces, expected 1 or 6", t.Faces)
+	}
+	if t.Faces == 6 && (t.Width != t.Height || t.Depth != 1) {
+		return fmt.Errorf("Cube-map faces must be square, got %dx%dx%d", t.Width, t.Height, t.Depth)
+	}
+	if len(t.Levels) == 0 {
+		return fmt.Errorf("Texture has no mip-levels")
+	}
+	count := t.ImagesPerLevel()
+	for l, images := range t.Levels {
+		if len(images) != count {
+			return fmt.Errorf("Mip-level %d has %d images, expected %d", l, len(images), count)
+		}
+		w, h, d := t.LevelSize(l)
+		for i, img := range images {
+			if img == nil {
+				return fmt.Errorf("Mip-level %d is missing image %d", l, i)
+			}
+			if img.Width != w || img.Height != h || img.Depth != d {
+				return fmt.Errorf("Mip-level %d image %d is %dx%dx%d, expected %dx%dx%d",
+					l, i, img.Width, img.Height, img.Depth, w, h, d)
+			}
+			if img.Format.Key() != t.Format.Key() {
+				return fmt.Errorf("Mip-level %d image %d has format %v, expected %v",
+					l, i, img.Format.Name, t.Format.Name)
+			}
+			if err := t.Format.Check(img.Bytes, int(w), int(h), int(d)); err != nil {
+				return fmt.Errorf("Mip-level %d image %d: %v", l, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkHeader returns an error if the dimensions, layers or faces of a texture
+// read from a container, or its number of mip-levels, are out of range, or if
+// its images need more than the size bytes left in the container. Readers call
+// it before allocating the images of the texture.
+func (t *Texture) checkHeader(levels uint32, size int) error {
+	if t.Width == 0 || t.Height == 0 || t.Depth == 0 ||
+		t.Width > maxTextureSize || t.Height > maxTextureSize || t.Depth > maxTextureSize {
+		return fmt.Errorf("Invalid texture dimensions %dx%dx%d", t.Width, t.Height, t.Depth)
+	}
+	if t.Layers > maxTextureLayers {
+		return fmt.Errorf("Invalid texture layer count %d", t.Layers)
+	}
+	if t.Faces != 1 && t.Faces != 6 {
+		return fmt.Errorf("Invalid texture face count %d", t.Faces)
+	}
+	if levels == 0 || levels > maxTextureLevels {
+		return fmt.Errorf("Invalid texture mip-level count %d", levels)
+	}
+	images := t.ImagesPerLevel()
+	for l := 0; l < int(levels); l++ {
+		w, h, d := t.LevelSize(l)
+		levelSize := t.Format.Size(int(w), int(h), int(d))
+		if levelSize > size/images {
+			return fmt.Errorf("Texture mip-level %d is larger than the remaining 0x%x bytes", l, size)
+		}
+		size -= levelSize * images
+	}
+	return nil
+}
+
+// Convert returns a copy of the texture with every image converted to the
+// format to.
+func (t *Texture) Convert(to *Format) (*Texture, error) {
+	out := *t
+	out.Format = to
+	out.Levels = make([][]*Data, len(t.Levels))
+	for l, images := range t.Levels {
+		out.Levels[l] = make([]*Data, len(images))
+		for i, img := range images {
+			converted, err := img.Convert(to)
+			if err != nil {
+				return nil, err
+			}
+			out.Levels[l][i] = converted
+		}
+	}
+	return &out, nil
+}
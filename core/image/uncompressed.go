@@ -85,6 +85,10 @@ func (f *FmtUncompressed) convert(data []byte, w, h, d int, dstFmt *Format) ([]b
 	switch dstFmt := protoutil.OneOf(dstFmt.Format).(type) {
 	case *FmtUncompressed:
 		return stream.Convert(dstFmt.Format, f.Format, data)
+	case *FmtEXR:
+		return encodeEXR(f.Format, data, w, h, d)
+	case *FmtHDR:
+		return encodeHDR(f.Format, data, w, h, d)
 	default:
 		return nil, nil
 	}
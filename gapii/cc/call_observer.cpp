@@ -19,8 +19,10 @@
 
 #include "core/cc/thread.h"
 
+#include "gapis/api/gfxtrace.pb.h"
 #include "gapis/memory/memory_pb/memory.pb.h"
 
+#include <chrono>
 #include <tuple>
 
 using core::Interval;
@@ -123,6 +125,22 @@ void CallObserver::exit() {
   mEncoderStack.pop();
 }
 
+void CallObserver::encodeTiming(uint64_t begin, uint64_t end) {
+  if (!mShouldTrace) {
+    return;
+  }
+  api::CmdTiming timing;
+  timing.set_begin(begin);
+  timing.set_end(end);
+  timing.set_thread(mCurrentThread);
+  encoder()->object(&timing);
+}
+
+uint64_t CallObserver::now() {
+  auto t = std::chrono::steady_clock::now().time_since_epoch();
+  return std::chrono::duration_cast<std::chrono::nanoseconds>(t).count();
+}
+
 void CallObserver::encodeAndDelete(::google::protobuf::Message* cmd) {
   if (!mShouldTrace) {
     delete cmd;
@@ -174,6 +174,14 @@ class CallObserver : public context_t {
   // exit returns encoding to the group bound before calling enter().
   void exit();
 
+  // encodeTiming encodes an api::CmdTiming holding the given begin and end
+  // times of the call, and the current thread.
+  void encodeTiming(uint64_t begin, uint64_t end);
+
+  // now returns the current time in nanoseconds, on the monotonic clock used
+  // for the command timings and the start time of the capture header.
+  static uint64_t now();
+
   // observePending observes and encodes all the pending memory observations.
   // The list of pending memory observations is cleared on returning.
   void observePending();
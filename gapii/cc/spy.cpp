@@ -160,6 +160,8 @@ Spy::Spy()
       (header.mFlags & ConnectionHeader::FLAG_RECORD_ERROR_STATE) != 0;
   SpyBase::mHideUnknownExtensions =
       (header.mFlags & ConnectionHeader::FLAG_HIDE_UNKNOWN_EXTENSIONS) != 0;
+  SpyBase::mRecordTiming =
+      (header.mFlags & ConnectionHeader::FLAG_RECORD_TIMING) != 0;
   // This will be over-written if we also set the header flags
   mSuspendCaptureFrames = header.mStartFrame;
   mCaptureFrames = header.mNumFrames;
@@ -177,6 +179,7 @@ Spy::Spy()
              mDisablePrecompiledShaders ? "true" : "false");
   GAPID_INFO("Hide unknown extensions: %s",
              mHideUnknownExtensions ? "true" : "false");
+  GAPID_INFO("Record command timing: %s", mRecordTiming ? "true" : "false");
 
   mEncoder = gapii::PackEncoder::create(
       mConnection, header.mFlags & ConnectionHeader::FLAG_NO_BUFFER);
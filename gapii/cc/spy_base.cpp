@@ -23,7 +23,7 @@
 
 // CurrentCaptureVersion is incremented on breaking changes to the capture
 // format. NB: Also update equally named field in capture.go
-static const int CurrentCaptureVersion = 3;
+static const int CurrentCaptureVersion = 4;
 
 using core::Interval;
 
@@ -35,6 +35,7 @@ SpyBase::SpyBase()
       mMemoryTracker(),
 #endif  // TARGET_OS
       mHideUnknownExtensions(false),
+      mRecordTiming(false),
       mNullEncoder(PackEncoder::noop()),
       mDeviceInstance(nullptr),
       mCurrentABI(nullptr),
@@ -90,6 +91,9 @@ int64_t SpyBase::sendResource(uint8_t api, const void* data, size_t size) {
 bool SpyBase::writeHeader() {
   capture::Header file_header;
   file_header.set_version(CurrentCaptureVersion);
+  if (mRecordTiming) {
+    file_header.set_start_time(CallObserver::now());
+  }
   if (mDeviceInstance != nullptr) {
     device::Instance* t = new device::Instance(*device_instance());
     file_header.set_allocated_device(t);
@@ -188,6 +188,9 @@ class SpyBase {
   // If true, we will hide unknown extensions from the application
   bool mHideUnknownExtensions;
 
+  // If true, the CPU time spent in each command is recorded
+  bool mRecordTiming;
+
  private:
   template <class T>
   bool shouldObserve(const gapil::Slice<T>& slice) const;
@@ -51,6 +51,8 @@ const (
 	// HideUnkownExtensions will prevent any unknown extensions from being
 	// seen by the application
 	HideUnknownExtensions Flags = 0x00000040
+	// RecordTiming records the CPU time spent in each command as extras.
+	RecordTiming Flags = 0x00000080
 
 	// GlesAPI is hard-coded bit mask for GLES API, it needs to be kept in sync
 	// with the api_index in the gles.api file.
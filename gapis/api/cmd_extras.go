@@ -73,6 +73,17 @@ func (e *CmdExtras) Observations() *CmdObservations {
 	return nil
 }
 
+// Timing returns a pointer to the CmdTiming structure in the CmdExtras, or nil
+// if the command was not timed.
+func (e *CmdExtras) Timing() *CmdTiming {
+	for _, t := range e.All() {
+		if t, ok := t.(*CmdTiming); ok {
+			return t
+		}
+	}
+	return nil
+}
+
 // GetOrAppendObservations returns a pointer to the existing Observations
 // structure in the CmdExtras, or appends and returns a pointer to a new
 // observations structure if the CmdExtras does not already contain one.
@@ -0,0 +1,28 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "context"
+
+// CmdHandlesProvider is the interface implemented by APIs that can list the
+// object handles referenced by a command. It is used to detect captures whose
+// handles would collide when combined into a single capture.
+type CmdHandlesProvider interface {
+	// CmdHandles returns the keys of the object handles referenced by the
+	// command cmd. Keys returned for different captures that compare equal
+	// refer to the same handle value. The state s is the state before cmd is
+	// mutated.
+	CmdHandles(ctx context.Context, cmd Cmd, s *GlobalState) []interface{}
+}
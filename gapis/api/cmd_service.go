@@ -28,6 +28,7 @@ func CmdToService(c Cmd) (*Command, error) {
 	out := &Command{
 		Name:   c.CmdName(),
 		Thread: c.Thread(),
+		Timing: c.Extras().Timing(),
 	}
 
 	if api := c.API(); api != nil {
@@ -71,6 +72,9 @@ func ServiceToCmd(a arena.Arena, c *Command) (Cmd, error) {
 
 	cmd.SetCaller(CmdNoID) // TODO: Include this in the proto
 	cmd.SetThread(c.Thread)
+	if c.Timing != nil {
+		cmd.Extras().Add(c.Timing)
+	}
 
 	for _, s := range c.Parameters {
 		SetParameter(cmd, s.Name, s.Value.Get())
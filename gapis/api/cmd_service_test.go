@@ -36,3 +36,22 @@ func TestToServiceToCmd(t *testing.T) {
 		assert.For(ctx, "CmdToService(%v) -> ServiceToCmd", n).That(g).DeepEquals(cmd)
 	}
 }
+
+func TestTimingToServiceToCmd(t *testing.T) {
+	ctx := log.Testing(t)
+	cb := test.CommandBuilder{Arena: test.Cmds.Arena}
+	timing := &api.CmdTiming{Begin: 1000, End: 1500, Thread: 7}
+	cmd := api.WithExtras(cb.CmdTypeMix(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, false, test.Voidᵖ(0x10), 300), timing)
+
+	s, err := api.CmdToService(cmd)
+	if !assert.For(ctx, "CmdToService").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "Timing").That(s.Timing).Equals(timing)
+
+	g, err := api.ServiceToCmd(test.Cmds.Arena, s)
+	if !assert.For(ctx, "ServiceToCmd").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "Extras().Timing()").That(g.Extras().Timing()).Equals(timing)
+}
@@ -0,0 +1,56 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "context"
+
+// CmdStats holds the statistics of a single command that are specific to the
+// API of the command.
+type CmdStats struct {
+	StateChanges       uint64 // Number of pipeline state changes.
+	BufferUploadBytes  uint64 // Number of bytes uploaded to buffers.
+	TextureUploadBytes uint64 // Number of bytes uploaded to textures.
+	ProgramSwitches    uint64 // Number of changes of the bound program.
+	RenderPasses       uint64 // Number of render passes begun.
+	Triangles          uint64 // Number of triangles drawn.
+	Vertices           uint64 // Number of distinct vertices drawn.
+}
+
+// AddDraw adds a draw of count vertices of the primitive p to the statistics.
+// indices holds the vertex indices of indexed draws, and is nil for draws of
+// consecutive vertices.
+func (s *CmdStats) AddDraw(p DrawPrimitive, count uint32, indices []uint32) {
+	switch p {
+	case DrawPrimitive_Triangles, DrawPrimitive_TriangleStrip, DrawPrimitive_TriangleFan:
+		s.Triangles += uint64(p.Count(count))
+	}
+	if indices == nil {
+		s.Vertices += uint64(count)
+		return
+	}
+	unique := make(map[uint32]struct{}, len(indices))
+	for _, i := range indices {
+		unique[i] = struct{}{}
+	}
+	s.Vertices += uint64(len(unique))
+}
+
+// CmdStatsProvider is the interface implemented by APIs that can report the
+// per-command statistics used to build the per-frame statistics timeline.
+type CmdStatsProvider interface {
+	// CmdStats returns the statistics of the command cmd. The state s is the
+	// state before cmd is mutated, with the reads of cmd applied.
+	CmdStats(ctx context.Context, id CmdID, cmd Cmd, s *GlobalState) CmdStats
+}
@@ -0,0 +1,45 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/memory"
+)
+
+// CmdHandles implements the api.CmdHandlesProvider interface.
+// Object names of GLES are scoped to their context, so only the EGL handles
+// that are not commonly shared between processes are returned.
+func (API) CmdHandles(ctx context.Context, cmd api.Cmd, s *api.GlobalState) []interface{} {
+	out := []interface{}{}
+	add := func(p *api.Property) {
+		if p == nil {
+			return
+		}
+		switch v := p.Get().(type) {
+		case EGLContext, EGLSurface, EGLImageKHR, EGLSyncKHR:
+			if !v.(memory.Pointer).IsNullptr() {
+				out = append(out, v)
+			}
+		}
+	}
+	for _, p := range cmd.CmdParams() {
+		add(p)
+	}
+	add(cmd.CmdResult())
+	return out
+}
@@ -0,0 +1,152 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// CmdStats implements the api.CmdStatsProvider interface.
+func (API) CmdStats(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState) api.CmdStats {
+	out := api.CmdStats{}
+	c := GetContext(s, cmd.Thread())
+	if c.IsNil() {
+		return out
+	}
+
+	switch cmd := cmd.(type) {
+	case *GlBufferData:
+		if cmd.Data().Address() != 0 {
+			out.BufferUploadBytes = uint64(cmd.Size())
+		}
+	case *GlBufferSubData:
+		out.BufferUploadBytes = uint64(cmd.Size())
+
+	case *GlTexImage2D:
+		if texUploads(c, cmd.Data().Address()) {
+			out.TextureUploadBytes = texUploadSize(cmd.Format(), cmd.Type(), cmd.Width(), cmd.Height(), 1)
+		}
+	case *GlTexImage3D:
+		if texUploads(c, cmd.Data().Address()) {
+			out.TextureUploadBytes = texUploadSize(cmd.Format(), cmd.Type(), cmd.Width(), cmd.Height(), cmd.Depth())
+		}
+	case *GlTexImage3DOES:
+		if texUploads(c, cmd.Pixels().Address()) {
+			out.TextureUploadBytes = texUploadSize(cmd.Format(), cmd.Type(), cmd.Width(), cmd.Height(), cmd.Depth())
+		}
+	case *GlTexSubImage2D:
+		if texUploads(c, cmd.Data().Address()) {
+			out.TextureUploadBytes = texUploadSize(cmd.Format(), cmd.Type(), cmd.Width(), cmd.Height(), 1)
+		}
+	case *GlTexSubImage3D:
+		if texUploads(c, cmd.Data().Address()) {
+			out.TextureUploadBytes = texUploadSize(cmd.Format(), cmd.Type(), cmd.Width(), cmd.Height(), cmd.Depth())
+		}
+	case *GlTexSubImage3DOES:
+		if texUploads(c, cmd.Pixels().Address()) {
+			out.TextureUploadBytes = texUploadSize(cmd.Format(), cmd.Type(), cmd.Width(), cmd.Height(), cmd.Depth())
+		}
+	case *GlCompressedTexImage2D:
+		if texUploads(c, cmd.Data().Address()) {
+			out.TextureUploadBytes = uint64(cmd.ImageSize())
+		}
+	case *GlCompressedTexImage3D:
+		if texUploads(c, cmd.Data().Address()) {
+			out.TextureUploadBytes = uint64(cmd.ImageSize())
+		}
+	case *GlCompressedTexImage3DOES:
+		if texUploads(c, cmd.Data().Address()) {
+			out.TextureUploadBytes = uint64(cmd.ImageSize())
+		}
+	case *GlCompressedTexSubImage2D:
+		out.TextureUploadBytes = uint64(cmd.ImageSize())
+	case *GlCompressedTexSubImage3D:
+		out.TextureUploadBytes = uint64(cmd.ImageSize())
+	case *GlCompressedTexSubImage3DOES:
+		out.TextureUploadBytes = uint64(cmd.ImageSize())
+
+	case *GlUseProgram:
+		if cmd.Program() != c.Bound().Program().GetID() {
+			out.ProgramSwitches = 1
+		}
+
+	case *GlBindFramebuffer:
+		switch cmd.Target() {
+		case GLenum_GL_FRAMEBUFFER, GLenum_GL_DRAW_FRAMEBUFFER:
+			if cmd.Framebuffer() != c.Bound().DrawFramebuffer().GetID() {
+				out.RenderPasses = 1
+			}
+		default:
+			out.StateChanges = 1
+		}
+
+	case *GlEnable, *GlDisable, *GlEnablei, *GlDisablei,
+		*GlBlendColor, *GlBlendEquation, *GlBlendEquationSeparate,
+		*GlBlendFunc, *GlBlendFuncSeparate,
+		*GlColorMask, *GlCullFace, *GlFrontFace, *GlLineWidth, *GlPolygonOffset,
+		*GlDepthFunc, *GlDepthMask, *GlDepthRangef,
+		*GlStencilFunc, *GlStencilFuncSeparate, *GlStencilMask, *GlStencilMaskSeparate,
+		*GlStencilOp, *GlStencilOpSeparate,
+		*GlScissor, *GlViewport,
+		*GlActiveTexture, *GlBindTexture, *GlBindSampler, *GlBindBuffer,
+		*GlBindBufferBase, *GlBindBufferRange, *GlBindVertexArray,
+		*GlBindRenderbuffer, *GlVertexAttribPointer, *GlVertexAttribIPointer,
+		*GlEnableVertexAttribArray, *GlDisableVertexAttribArray:
+		out.StateChanges = 1
+
+	case drawCall:
+		dci, err := cmd.getIndices(ctx, c, s)
+		if err != nil {
+			break
+		}
+		dp, err := translateDrawPrimitive(dci.drawMode)
+		if err != nil {
+			break
+		}
+		count, indices := uint32(len(dci.indices)), dci.indices
+		if !dci.indexed {
+			indices = nil
+		}
+		out.AddDraw(dp, count, indices)
+
+	default:
+		// There are too many uniform commands to list.
+		name := cmd.CmdName()
+		if strings.HasPrefix(name, "glUniform") || strings.HasPrefix(name, "glProgramUniform") {
+			out.StateChanges = 1
+		}
+	}
+	return out
+}
+
+// texUploads returns true if a texture image command with the data pointer
+// data uploads texels, either from client memory or from the bound pixel
+// unpack buffer.
+func texUploads(c Contextʳ, data uint64) bool {
+	return data != 0 || !c.Bound().PixelUnpackBuffer().IsNil()
+}
+
+// texUploadSize returns the size in bytes of uncompressed texel data of the
+// given format, type and dimensions, or 0 if the format is not supported.
+func texUploadSize(format, ty GLenum, width, height, depth GLsizei) uint64 {
+	f, err := getImageFormat(format, ty)
+	if err != nil {
+		return 0
+	}
+	return uint64(f.Size(int(width), int(height), int(depth)))
+}
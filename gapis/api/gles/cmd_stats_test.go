@@ -0,0 +1,66 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/gles"
+	"github.com/google/gapid/gapis/memory"
+)
+
+func TestCmdStats(t *testing.T) {
+	ctx, a, s := newContextState(t)
+	defer a.Dispose()
+
+	ml := s.MemoryLayout
+	programInfo := gles.MakeLinkProgramExtra(a)
+	programInfo.SetLinkStatus(gles.GLboolean_GL_TRUE)
+
+	cb := gles.CommandBuilder{Thread: 0, Arena: a}
+	for _, test := range []struct {
+		cmd      api.Cmd
+		expected api.CmdStats
+	}{
+		{cb.GlBindBuffer(gles.GLenum_GL_ARRAY_BUFFER, 1), api.CmdStats{StateChanges: 1}},
+		{cb.GlBufferData(gles.GLenum_GL_ARRAY_BUFFER, 16, p(0x1000), gles.GLenum_GL_STATIC_DRAW).
+			AddRead(memory.Store(ctx, ml, p(0x1000), make([]byte, 16))), api.CmdStats{BufferUploadBytes: 16}},
+		{cb.GlBufferData(gles.GLenum_GL_ARRAY_BUFFER, 16, memory.Nullptr, gles.GLenum_GL_STATIC_DRAW), api.CmdStats{}},
+		{cb.GlBufferSubData(gles.GLenum_GL_ARRAY_BUFFER, 0, 8, p(0x1000)).
+			AddRead(memory.Store(ctx, ml, p(0x1000), make([]byte, 8))), api.CmdStats{BufferUploadBytes: 8}},
+		{cb.GlBindTexture(gles.GLenum_GL_TEXTURE_2D, 1), api.CmdStats{StateChanges: 1}},
+		{cb.GlTexImage2D(gles.GLenum_GL_TEXTURE_2D, 0, gles.GLint(gles.GLenum_GL_RGBA), 4, 4, 0, gles.GLenum_GL_RGBA, gles.GLenum_GL_UNSIGNED_BYTE, p(0x2000)).
+			AddRead(memory.Store(ctx, ml, p(0x2000), make([]byte, 64))), api.CmdStats{TextureUploadBytes: 64}},
+		{cb.GlTexImage2D(gles.GLenum_GL_TEXTURE_2D, 1, gles.GLint(gles.GLenum_GL_RGBA), 2, 2, 0, gles.GLenum_GL_RGBA, gles.GLenum_GL_UNSIGNED_BYTE, memory.Nullptr), api.CmdStats{}},
+		{cb.GlCreateProgram(1), api.CmdStats{}},
+		{api.WithExtras(cb.GlLinkProgram(1), programInfo), api.CmdStats{}},
+		{cb.GlUseProgram(1), api.CmdStats{ProgramSwitches: 1}},
+		{cb.GlUseProgram(1), api.CmdStats{}},
+		{cb.GlEnable(gles.GLenum_GL_DEPTH_TEST), api.CmdStats{StateChanges: 1}},
+		{cb.GlDrawArrays(gles.GLenum_GL_TRIANGLES, 0, 6), api.CmdStats{Triangles: 2, Vertices: 6}},
+		{cb.GlDrawArrays(gles.GLenum_GL_TRIANGLE_STRIP, 0, 5), api.CmdStats{Triangles: 3, Vertices: 5}},
+		{cb.GlDrawArrays(gles.GLenum_GL_LINES, 0, 4), api.CmdStats{Vertices: 4}},
+		{cb.GlDrawElements(gles.GLenum_GL_TRIANGLES, 6, gles.GLenum_GL_UNSIGNED_SHORT, p(0x3000)).
+			AddRead(memory.Store(ctx, ml, p(0x3000), []uint16{0, 1, 2, 2, 1, 3})), api.CmdStats{Triangles: 2, Vertices: 4}},
+	} {
+		test.cmd.Extras().Observations().ApplyReads(s.Memory.ApplicationPool())
+		got := gles.API{}.CmdStats(ctx, api.CmdNoID, test.cmd, s)
+		err := test.cmd.Mutate(ctx, api.CmdNoID, s, nil)
+		assert.For(ctx, "%v err", test.cmd).ThatError(err).Succeeded()
+		assert.For(ctx, "%v stats", test.cmd).That(got).Equals(test.expected)
+	}
+}
@@ -219,6 +219,22 @@ func TestDeadCommandRemoval(t *testing.T) {
 			dead(cb.GlTexImage2D(gles.GLenum_GL_TEXTURE_2D, 5, gles.GLint(gles.GLenum_GL_RGB), 2, 2, 0, gles.GLenum_GL_RGB, gles.GLenum_GL_UNSIGNED_SHORT_5_6_5, memory.Nullptr)),
 			live(cb.GlGenerateMipmap(gles.GLenum_GL_TEXTURE_2D)),
 		},
+		"Buffers read by unpacks, copies and indirect draws": {
+			cb.GlBindBuffer(gles.GLenum_GL_PIXEL_UNPACK_BUFFER, 5),
+			cb.GlBufferData(gles.GLenum_GL_PIXEL_UNPACK_BUFFER, 64, memory.Nullptr, gles.GLenum_GL_STATIC_DRAW),
+			cb.GlBindTexture(gles.GLenum_GL_TEXTURE_2D, 10),
+			cb.GlTexImage2D(gles.GLenum_GL_TEXTURE_2D, 0, gles.GLint(gles.GLenum_GL_RGBA), 4, 4, 0, gles.GLenum_GL_RGBA, gles.GLenum_GL_UNSIGNED_BYTE, memory.Nullptr),
+			cb.GlBindBuffer(gles.GLenum_GL_PIXEL_UNPACK_BUFFER, 0),
+			cb.GlBindBuffer(gles.GLenum_GL_COPY_READ_BUFFER, 6),
+			cb.GlBufferData(gles.GLenum_GL_COPY_READ_BUFFER, 16, memory.Nullptr, gles.GLenum_GL_STATIC_DRAW),
+			cb.GlBindBuffer(gles.GLenum_GL_DRAW_INDIRECT_BUFFER, 7),
+			cb.GlBufferData(gles.GLenum_GL_DRAW_INDIRECT_BUFFER, 16, memory.Nullptr, gles.GLenum_GL_STATIC_DRAW),
+			cb.GlCopyBufferSubData(gles.GLenum_GL_COPY_READ_BUFFER, gles.GLenum_GL_DRAW_INDIRECT_BUFFER, 0, 0, 16),
+			cb.GlBindVertexArray(1),
+			dead(cb.GlUniform4fv(0, 1, memory.Nullptr)),
+			cb.GlUniform4fv(0, 1, memory.Nullptr),
+			live(cb.GlDrawArraysIndirect(gles.GLenum_GL_TRIANGLES, memory.Nullptr)),
+		},
 	}
 
 	for name, testCmds := range tests {
@@ -266,3 +282,62 @@ func TestDeadCommandRemoval(t *testing.T) {
 		assert.For(ctx, "Test '%v'", name).ThatSlice(r.Cmds).Equals(expectedCmds)
 	}
 }
+
+func TestUnusedBuffers(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	a := arena.New()
+	defer a.Dispose()
+
+	programInfo := gles.MakeLinkProgramExtra(a)
+	programInfo.SetLinkStatus(gles.GLboolean_GL_TRUE)
+
+	ctxHandle := memory.BytePtr(1)
+	displayHandle := memory.BytePtr(2)
+	surfaceHandle := memory.BytePtr(3)
+	cb := gles.CommandBuilder{Thread: 0, Arena: a}
+	cmds := []api.Cmd{
+		cb.EglCreateContext(displayHandle, surfaceHandle, surfaceHandle, memory.Nullptr, ctxHandle),
+		api.WithExtras(
+			cb.EglMakeCurrent(displayHandle, surfaceHandle, surfaceHandle, ctxHandle, 0),
+			gles.NewStaticContextStateForTest(a), gles.NewDynamicContextStateForTest(a, 64, 64, false)),
+		cb.GlCreateProgram(1),
+		api.WithExtras(cb.GlLinkProgram(1), programInfo),
+		cb.GlUseProgram(1),
+		cb.GlBindVertexArray(1),
+		// Buffer 5 is only read by a copy and buffer 6 is the copy destination
+		// used by the indirect draw.
+		cb.GlBindBuffer(gles.GLenum_GL_COPY_READ_BUFFER, 5),
+		cb.GlBufferData(gles.GLenum_GL_COPY_READ_BUFFER, 16, memory.Nullptr, gles.GLenum_GL_STATIC_DRAW),
+		cb.GlBindBuffer(gles.GLenum_GL_DRAW_INDIRECT_BUFFER, 6),
+		cb.GlBufferData(gles.GLenum_GL_DRAW_INDIRECT_BUFFER, 16, memory.Nullptr, gles.GLenum_GL_STATIC_DRAW),
+		cb.GlCopyBufferSubData(gles.GLenum_GL_COPY_READ_BUFFER, gles.GLenum_GL_DRAW_INDIRECT_BUFFER, 0, 0, 16),
+		// Buffer 7 is never read.
+		cb.GlBindBuffer(gles.GLenum_GL_ARRAY_BUFFER, 7),
+		cb.GlBufferData(gles.GLenum_GL_ARRAY_BUFFER, 32, memory.Nullptr, gles.GLenum_GL_STATIC_DRAW),
+		cb.GlDrawArraysIndirect(gles.GLenum_GL_TRIANGLES, memory.Nullptr),
+	}
+
+	h := &capture.Header{ABI: device.WindowsX86_64}
+	capturePath, err := capture.New(ctx, a, "unused buffers", h, cmds)
+	if err != nil {
+		panic(err)
+	}
+	ctx = capture.Put(ctx, capturePath)
+
+	g, err := dependencygraph.GetDependencyGraph(ctx, nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	resources, err := g.UnusedResources(ctx, []api.CmdID{api.CmdID(len(cmds) - 1)})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	unused := []string{}
+	for _, u := range resources {
+		unused = append(unused, u.Name)
+	}
+	assert.For(ctx, "unused").ThatSlice(unused).Equals([]string{"Buffer 7"})
+}
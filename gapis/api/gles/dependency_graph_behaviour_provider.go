@@ -16,6 +16,7 @@ package gles
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/gapis/api"
@@ -107,6 +108,20 @@ type eglImageSizeKey struct {
 
 func (k eglImageSizeKey) Parent() dependencygraph.StateKey { return nil }
 
+// bufferDataKey and programKey are only used to find the unused resources.
+// They are written by commands that are always kept alive.
+type bufferDataKey struct {
+	buffer Bufferʳ
+}
+
+func (k bufferDataKey) Parent() dependencygraph.StateKey { return nil }
+
+type programKey struct {
+	program Programʳ
+}
+
+func (k programKey) Parent() dependencygraph.StateKey { return nil }
+
 type GlesDependencyGraphBehaviourProvider struct{}
 
 func newGlesDependencyGraphBehaviourProvider() *GlesDependencyGraphBehaviourProvider {
@@ -159,7 +174,11 @@ func (*GlesDependencyGraphBehaviourProvider) GetBehaviourForCommand(
 			b.Write(g, renderbufferDataKey{stencil})
 		} else if cmd.CmdFlags(ctx, id, s).IsDrawCall() {
 			b.Read(g, uniformGroupKey{c.Bound().Program()})
+			b.Read(g, programKey{c.Bound().Program()})
 			b.Read(g, vertexAttribGroupKey{c.Bound().VertexArray()})
+			for _, stateKey := range getAllUsedBufferData(cmd, c) {
+				b.Read(g, stateKey)
+			}
 			for _, stateKey := range getAllUsedTextureData(ctx, cmd, id, s, c) {
 				b.Read(g, stateKey)
 			}
@@ -195,6 +214,9 @@ func (*GlesDependencyGraphBehaviourProvider) GetBehaviourForCommand(
 				log.E(ctx, "Unknown clear command: %v", cmd)
 			}
 		} else {
+			if unpack := c.Bound().PixelUnpackBuffer(); usesPixelUnpackBuffer(cmd) && !unpack.IsNil() {
+				b.Read(g, bufferDataKey{unpack})
+			}
 			switch cmd := cmd.(type) {
 			case *GlCopyImageSubData:
 				// TODO: This assumes whole-image copy.  Handle sub-range copies.
@@ -261,6 +283,27 @@ func (*GlesDependencyGraphBehaviourProvider) GetBehaviourForCommand(
 				b.Write(g, uniformKey{c.Bound().Program(), cmd.Location(), cmd.Count()})
 			case *GlVertexAttribPointer:
 				b.Write(g, vertexAttribKey{c.Bound().VertexArray(), cmd.Location()})
+			case *GlBufferData:
+				writeBufferData(g, &b, c, cmd.Target())
+			case *GlBufferSubData:
+				writeBufferData(g, &b, c, cmd.Target())
+			case *GlCopyBufferSubData:
+				if handle, ok := boundBuffer(c, cmd.ReadTarget()); ok && handle != 0 {
+					b.Read(g, bufferDataKey{c.Objects().Buffers().Get(handle)})
+				}
+				writeBufferData(g, &b, c, cmd.WriteTarget())
+			case *GlDispatchCompute, *GlDispatchComputeIndirect:
+				b.Read(g, programKey{c.Bound().Program()})
+				for _, stateKey := range getAllUsedTextureData(ctx, cmd, id, s, c) {
+					b.Read(g, stateKey)
+				}
+				for _, stateKey := range getAllUsedBufferData(cmd, c) {
+					b.Read(g, stateKey)
+				}
+				b.KeepAlive = true // Writes untracked state
+			case *GlLinkProgram:
+				b.Write(g, programKey{c.Objects().Programs().Get(cmd.Program())})
+				b.KeepAlive = true // Changes untracked state
 			default:
 				// Force all unhandled commands to be kept alive.
 				b.KeepAlive = true
@@ -321,6 +364,72 @@ func getAllUsedTextureData(ctx context.Context, cmd api.Cmd, id api.CmdID, s *ap
 	return
 }
 
+// getAllUsedBufferData returns the state keys of the data of the buffers used
+// by the draw call or dispatch cmd: the vertex buffers, the index buffer, the
+// indirect buffer, the buffers bound to the indexed uniform, shader storage and
+// atomic counter buffer bindings and the active transform feedback buffers.
+func getAllUsedBufferData(cmd api.Cmd, c Contextʳ) (stateKeys []dependencygraph.StateKey) {
+	add := func(b Bufferʳ) {
+		if !b.IsNil() {
+			stateKeys = append(stateKeys, bufferDataKey{b})
+		}
+	}
+	switch cmd.(type) {
+	case *GlDrawArraysIndirect, *GlDrawElementsIndirect:
+		add(c.Bound().DrawIndirectBuffer())
+	case *GlDispatchComputeIndirect:
+		add(c.Bound().DispatchIndirectBuffer())
+	}
+	if va := c.Bound().VertexArray(); !va.IsNil() {
+		for _, binding := range va.VertexBufferBindings().All() {
+			if !binding.IsNil() {
+				add(binding.Buffer())
+			}
+		}
+		add(va.ElementArrayBuffer())
+	}
+	for _, binding := range c.Bound().UniformBuffers().All() {
+		add(binding.Binding())
+	}
+	for _, binding := range c.Bound().ShaderStorageBuffers().All() {
+		add(binding.Binding())
+	}
+	for _, binding := range c.Bound().AtomicCounterBuffers().All() {
+		add(binding.Binding())
+	}
+	// Transform feedback buffers are written by the draw call, which still
+	// counts as a use of the buffers.
+	if tf := c.Bound().TransformFeedback(); !tf.IsNil() && tf.Active() == GLboolean_GL_TRUE {
+		for _, binding := range tf.Buffers().All() {
+			add(binding.Binding())
+		}
+	}
+	return
+}
+
+// usesPixelUnpackBuffer returns true if cmd sources its texel data from the
+// bound pixel unpack buffer, when one is bound.
+func usesPixelUnpackBuffer(cmd api.Cmd) bool {
+	switch cmd.(type) {
+	case *GlTexImage2D, *GlTexImage3D, *GlTexImage3DOES,
+		*GlTexSubImage2D, *GlTexSubImage3D, *GlTexSubImage3DOES,
+		*GlCompressedTexImage2D, *GlCompressedTexImage3D, *GlCompressedTexImage3DOES,
+		*GlCompressedTexSubImage2D, *GlCompressedTexSubImage3D, *GlCompressedTexSubImage3DOES:
+		return true
+	}
+	return false
+}
+
+// writeBufferData adds the write of the data of the buffer bound to target.
+// Buffer data is only tracked to find the unused buffers, so the command is
+// kept alive.
+func writeBufferData(g *dependencygraph.DependencyGraph, b *dependencygraph.CmdBehaviour, c Contextʳ, target GLenum) {
+	if handle, ok := boundBuffer(c, target); ok && handle != 0 {
+		b.Write(g, bufferDataKey{c.Objects().Buffers().Get(handle)})
+	}
+	b.KeepAlive = true
+}
+
 func getTextureDataAndSize(
 	ctx context.Context,
 	cmd api.Cmd,
@@ -372,3 +481,55 @@ func (att FramebufferAttachment) dataAndSize(g *dependencygraph.DependencyGraph,
 	}
 	return
 }
+
+// ResourceOfKey implements the dependencygraph.ResourceKeyProvider interface.
+func (*GlesDependencyGraphBehaviourProvider) ResourceOfKey(key dependencygraph.StateKey) (dependencygraph.Resource, bool) {
+	switch key := key.(type) {
+	case textureDataKey:
+		return textureResource(key.texture)
+	case textureDataGroupKey:
+		return textureResource(key.texture)
+	case bufferDataKey:
+		if !key.buffer.IsNil() {
+			return dependencygraph.Resource{
+				Object: key.buffer,
+				Kind:   "Buffer",
+				Name:   fmt.Sprintf("Buffer %v", key.buffer.GetID()),
+				Size:   uint64(key.buffer.Size()),
+			}, true
+		}
+	case programKey:
+		if !key.program.IsNil() {
+			return programResource(key.program)
+		}
+	case uniformKey:
+		if !key.program.IsNil() {
+			return programResource(key.program)
+		}
+	case uniformGroupKey:
+		if !key.program.IsNil() {
+			return programResource(key.program)
+		}
+	}
+	return dependencygraph.Resource{}, false
+}
+
+func textureResource(t Textureʳ) (dependencygraph.Resource, bool) {
+	if t.IsNil() {
+		return dependencygraph.Resource{}, false
+	}
+	return dependencygraph.Resource{
+		Object: t,
+		Kind:   "Texture",
+		Name:   fmt.Sprintf("Texture %v", t.GetID()),
+		Size:   textureSize(t),
+	}, true
+}
+
+func programResource(p Programʳ) (dependencygraph.Resource, bool) {
+	return dependencygraph.Resource{
+		Object: p,
+		Kind:   "Program",
+		Name:   fmt.Sprintf("Program %v", p.GetID()),
+	}, true
+}
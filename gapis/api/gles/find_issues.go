@@ -66,10 +66,11 @@ func newFindIssues(ctx context.Context, c *capture.Capture, device *device.Insta
 func (t *findIssues) reportTo(r replay.Result) { t.res = append(t.res, r) }
 
 func (t *findIssues) onIssue(cmd api.Cmd, id api.CmdID, s service.Severity, e error) {
-	if s == service.Severity_FatalLevel && isIssueWhitelisted(cmd, e) {
+	known := s == service.Severity_FatalLevel && isIssueWhitelisted(cmd, e)
+	if known {
 		s = service.Severity_ErrorLevel
 	}
-	t.issues = append(t.issues, replay.Issue{Command: id, Severity: s, Error: e})
+	t.issues = append(t.issues, replay.Issue{Command: id, Severity: s, Error: e, Known: known})
 }
 
 // The value 0 is used for many enums - prefer GL_NO_ERROR in this case.
@@ -0,0 +1,60 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/gles"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/memory"
+)
+
+// newContextState returns a test context holding an empty capture, the arena
+// of the capture, and a state in which a GLES context is current on thread 0.
+// The caller must dispose the arena.
+func newContextState(t *testing.T) (context.Context, arena.Arena, *api.GlobalState) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	a := arena.New()
+	h := &capture.Header{ABI: device.AndroidARMv7a}
+	capturePath, err := capture.New(ctx, a, "test", h, []api.Cmd{})
+	if err != nil {
+		panic(err)
+	}
+	ctx = capture.Put(ctx, capturePath)
+
+	s := newState(ctx)
+	ctxHandle, displayHandle, surfaceHandle := p(1), p(2), p(3)
+	cb := gles.CommandBuilder{Thread: 0, Arena: a}
+	for _, cmd := range []api.Cmd{
+		cb.EglCreateContext(displayHandle, surfaceHandle, surfaceHandle, memory.Nullptr, ctxHandle),
+		api.WithExtras(
+			cb.EglMakeCurrent(displayHandle, surfaceHandle, surfaceHandle, ctxHandle, 0),
+			gles.NewStaticContextStateForTest(a), gles.NewDynamicContextStateForTest(a, 64, 64, false)),
+	} {
+		if err := cmd.Mutate(ctx, api.CmdNoID, s, nil); err != nil {
+			panic(err)
+		}
+	}
+	return ctx, a, s
+}
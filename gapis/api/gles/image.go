@@ -340,6 +340,42 @@ func getCompressedImageFormat(format GLenum) (*image.Format, error) {
 		return image.NewS3_DXT3_RGBA("GL_COMPRESSED_RGBA_S3TC_DXT3_EXT"), nil
 	case GLenum_GL_COMPRESSED_RGBA_S3TC_DXT5_EXT:
 		return image.NewS3_DXT5_RGBA("GL_COMPRESSED_RGBA_S3TC_DXT5_EXT"), nil
+
+	// BPTC
+	case GLenum_GL_COMPRESSED_RGBA_BPTC_UNORM:
+		return image.NewBPTC_RGBA_U8_NORM("GL_COMPRESSED_RGBA_BPTC_UNORM"), nil
+	case GLenum_GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM:
+		return image.NewBPTC_SRGBA_U8_NORM("GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM"), nil
+	case GLenum_GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT:
+		return image.NewBPTC_RGB_SF16("GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT"), nil
+	case GLenum_GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT:
+		return image.NewBPTC_RGB_UF16("GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT"), nil
+
+	// PVRTC
+	case GLenum_GL_COMPRESSED_RGB_PVRTC_2BPPV1_IMG:
+		return image.NewPVRTC("GL_COMPRESSED_RGB_PVRTC_2BPPV1_IMG", 2, 1, false, false), nil
+	case GLenum_GL_COMPRESSED_RGB_PVRTC_4BPPV1_IMG:
+		return image.NewPVRTC("GL_COMPRESSED_RGB_PVRTC_4BPPV1_IMG", 4, 1, false, false), nil
+	case GLenum_GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG:
+		return image.NewPVRTC("GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG", 2, 1, true, false), nil
+	case GLenum_GL_COMPRESSED_RGBA_PVRTC_4BPPV1_IMG:
+		return image.NewPVRTC("GL_COMPRESSED_RGBA_PVRTC_4BPPV1_IMG", 4, 1, true, false), nil
+	case GLenum_GL_COMPRESSED_SRGB_PVRTC_2BPPV1_EXT:
+		return image.NewPVRTC("GL_COMPRESSED_SRGB_PVRTC_2BPPV1_EXT", 2, 1, false, true), nil
+	case GLenum_GL_COMPRESSED_SRGB_PVRTC_4BPPV1_EXT:
+		return image.NewPVRTC("GL_COMPRESSED_SRGB_PVRTC_4BPPV1_EXT", 4, 1, false, true), nil
+	case GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV1_EXT:
+		return image.NewPVRTC("GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV1_EXT", 2, 1, true, true), nil
+	case GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV1_EXT:
+		return image.NewPVRTC("GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV1_EXT", 4, 1, true, true), nil
+	case GLenum_GL_COMPRESSED_RGBA_PVRTC_2BPPV2_IMG:
+		return image.NewPVRTC("GL_COMPRESSED_RGBA_PVRTC_2BPPV2_IMG", 2, 2, true, false), nil
+	case GLenum_GL_COMPRESSED_RGBA_PVRTC_4BPPV2_IMG:
+		return image.NewPVRTC("GL_COMPRESSED_RGBA_PVRTC_4BPPV2_IMG", 4, 2, true, false), nil
+	case GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV2_IMG:
+		return image.NewPVRTC("GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV2_IMG", 2, 2, true, true), nil
+	case GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV2_IMG:
+		return image.NewPVRTC("GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV2_IMG", 4, 2, true, true), nil
 	}
 
 	return nil, fmt.Errorf("Unsupported compressed format: %s", format)
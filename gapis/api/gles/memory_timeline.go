@@ -0,0 +1,136 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// Interface compliance test
+var (
+	_ = api.MemoryTimelineProvider(API{})
+)
+
+// memoryCmdPrefixes are the prefixes of the names of the commands that may
+// allocate, resize or free buffers, textures or renderbuffers.
+var memoryCmdPrefixes = []string{
+	"glBufferData",
+	"glBufferStorage",
+	"glTexImage",
+	"glTexStorage",
+	"glTexBuffer",
+	"glCompressedTexImage",
+	"glCopyTexImage",
+	"glGenerateMipmap",
+	"glEGLImageTargetTexture",
+	"glRenderbufferStorage",
+	"glDeleteBuffers",
+	"glDeleteTextures",
+	"glDeleteRenderbuffers",
+	"eglMakeCurrent",
+	"eglDestroyContext",
+}
+
+// ChangesMemoryObjects implements the api.MemoryTimelineProvider interface.
+func (API) ChangesMemoryObjects(cmd api.Cmd) bool {
+	name := cmd.CmdName()
+	for _, p := range memoryCmdPrefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryObjects implements the api.MemoryTimelineProvider interface. The
+// objects are keyed by their references, so that the objects shared by
+// several contexts are listed once. They are named after the first of these
+// contexts.
+func (API) MemoryObjects(s *api.GlobalState) map[interface{}]*api.MemoryObject {
+	contexts := []Contextʳ{}
+	for _, c := range GetState(s).EGLContexts().All() {
+		if !c.Other().Destroyed() {
+			contexts = append(contexts, c)
+		}
+	}
+	sort.Slice(contexts, func(i, j int) bool {
+		return contexts[i].Identifier() < contexts[j].Identifier()
+	})
+
+	apiPath := path.NewAPI(id.ID(ID))
+	out := map[interface{}]*api.MemoryObject{}
+	add := func(key interface{}, c Contextʳ, kind string, handle uint64, size uint64) {
+		out[key] = &api.MemoryObject{
+			API:    apiPath,
+			Kind:   kind,
+			Handle: handle,
+			Name:   objectName(c, kind, handle),
+			Size:   size,
+		}
+	}
+
+	for _, c := range contexts {
+		for handle, b := range c.Objects().Buffers().All() {
+			if _, ok := out[b]; !ok && !b.IsNil() {
+				add(b, c, "Buffer", uint64(handle), uint64(b.Size()))
+			}
+		}
+		for handle, t := range c.Objects().Textures().All() {
+			if _, ok := out[t]; !ok && !t.IsNil() {
+				add(t, c, "Texture", uint64(handle), textureSize(t))
+			}
+		}
+		for handle, r := range c.Objects().Renderbuffers().All() {
+			// Skip the virtual renderbuffers of the backbuffer.
+			if handle >= 0xf0000000 {
+				continue
+			}
+			if _, ok := out[r]; !ok && !r.IsNil() {
+				size := uint64(0)
+				if !r.Image().IsNil() {
+					size = r.Image().Data().Size()
+				}
+				add(r, c, "Renderbuffer", uint64(handle), size)
+			}
+		}
+	}
+	return out
+}
+
+// textureSize returns the estimated size of the texture, as the size of the
+// data of all its images.
+func textureSize(t Textureʳ) uint64 {
+	size := uint64(0)
+	for _, level := range t.Levels().All() {
+		for _, img := range level.Layers().All() {
+			if !img.IsNil() {
+				size += img.Data().Size()
+			}
+		}
+	}
+	return size
+}
+
+// objectName returns the name used for display of the object of the given
+// kind and handle in the context c.
+func objectName(c Contextʳ, kind string, handle uint64) string {
+	return fmt.Sprintf("%v %v of context %v", kind, handle, c.Identifier())
+}
@@ -0,0 +1,208 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+// alwaysUnchanged is returned by RedundantCall for calls that are known to
+// have no effect before they are mutated.
+func alwaysUnchanged() bool { return true }
+
+// uniformCmd is implemented by all the glUniform* and glProgramUniform*
+// commands.
+type uniformCmd interface {
+	api.Cmd
+	Location() UniformLocation
+}
+
+// programCmd is implemented by commands taking a program parameter, such as
+// glProgramUniform*.
+type programCmd interface {
+	Program() ProgramId
+}
+
+// RedundantCall implements the api.RedundantCallChecker interface.
+func (API) RedundantCall(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState) (string, func() bool) {
+	c := GetContext(s, cmd.Thread())
+	if c.IsNil() {
+		return "", nil
+	}
+
+	switch cmd := cmd.(type) {
+	case *GlBindTexture:
+		if bound, ok := boundTexture(c, cmd.Target()); ok && bound == cmd.Texture() {
+			return fmt.Sprintf("texture %v is already bound to %v", cmd.Texture(), cmd.Target()), alwaysUnchanged
+		}
+	case *GlBindBuffer:
+		if bound, ok := boundBuffer(c, cmd.Target()); ok && bound == cmd.Buffer() {
+			return fmt.Sprintf("buffer %v is already bound to %v", cmd.Buffer(), cmd.Target()), alwaysUnchanged
+		}
+	case *GlUseProgram:
+		if c.Bound().Program().GetID() == cmd.Program() {
+			return fmt.Sprintf("program %v is already in use", cmd.Program()), alwaysUnchanged
+		}
+	case *GlEnable:
+		if enabled, ok := capabilityEnabled(c, cmd.Capability()); ok && enabled {
+			return fmt.Sprintf("%v is already enabled", cmd.Capability()), alwaysUnchanged
+		}
+	case *GlDisable:
+		if enabled, ok := capabilityEnabled(c, cmd.Capability()); ok && !enabled {
+			return fmt.Sprintf("%v is already disabled", cmd.Capability()), alwaysUnchanged
+		}
+	case uniformCmd:
+		name := cmd.CmdName()
+		if !strings.HasPrefix(name, "glUniform") && !strings.HasPrefix(name, "glProgramUniform") {
+			break
+		}
+		program := c.Bound().Program()
+		if pc, ok := cmd.(programCmd); ok {
+			program = c.Objects().Programs().Get(pc.Program())
+		}
+		if program.IsNil() {
+			break
+		}
+		loc := cmd.Location()
+		// values returns the value of the uniform, or nil if the uniform does
+		// not exist or its value cannot be read.
+		values := func() []uint8 {
+			u, ok := program.UniformLocations().Lookup(loc)
+			if !ok {
+				return nil
+			}
+			v, err := u.Values().Read(ctx, cmd, s, nil)
+			if err != nil {
+				log.D(ctx, "Failed to read the uniform at location %v: %v", loc, err)
+				return nil
+			}
+			return v
+		}
+		before := values()
+		if before == nil {
+			break
+		}
+		return fmt.Sprintf("the uniform at location %v already has the same value", loc), func() bool {
+			after := values()
+			return after != nil && bytes.Equal(before, after)
+		}
+	}
+	return "", nil
+}
+
+// boundTexture returns the identifier of the texture bound to target on the
+// active texture unit of c.
+func boundTexture(c Contextʳ, target GLenum) (TextureId, bool) {
+	tu := c.Bound().TextureUnit()
+	if tu.IsNil() {
+		return 0, false
+	}
+	switch target {
+	case GLenum_GL_TEXTURE_2D:
+		return tu.Binding2d().GetID(), true
+	case GLenum_GL_TEXTURE_3D:
+		return tu.Binding3d().GetID(), true
+	case GLenum_GL_TEXTURE_2D_ARRAY:
+		return tu.Binding2dArray().GetID(), true
+	case GLenum_GL_TEXTURE_BUFFER:
+		return tu.BindingBuffer().GetID(), true
+	case GLenum_GL_TEXTURE_CUBE_MAP:
+		return tu.BindingCubeMap().GetID(), true
+	case GLenum_GL_TEXTURE_CUBE_MAP_ARRAY:
+		return tu.BindingCubeMapArray().GetID(), true
+	case GLenum_GL_TEXTURE_2D_MULTISAMPLE:
+		return tu.Binding2dMultisample().GetID(), true
+	case GLenum_GL_TEXTURE_2D_MULTISAMPLE_ARRAY:
+		return tu.Binding2dMultisampleArray().GetID(), true
+	case GLenum_GL_TEXTURE_EXTERNAL_OES:
+		return tu.BindingExternalOes().GetID(), true
+	default:
+		return 0, false
+	}
+}
+
+// boundBuffer returns the identifier of the buffer bound to target in c.
+func boundBuffer(c Contextʳ, target GLenum) (BufferId, bool) {
+	b := c.Bound()
+	switch target {
+	case GLenum_GL_ARRAY_BUFFER:
+		return b.ArrayBuffer().GetID(), true
+	case GLenum_GL_ELEMENT_ARRAY_BUFFER:
+		if b.VertexArray().IsNil() {
+			return 0, false
+		}
+		return b.VertexArray().ElementArrayBuffer().GetID(), true
+	case GLenum_GL_COPY_READ_BUFFER:
+		return b.CopyReadBuffer().GetID(), true
+	case GLenum_GL_COPY_WRITE_BUFFER:
+		return b.CopyWriteBuffer().GetID(), true
+	case GLenum_GL_PIXEL_PACK_BUFFER:
+		return b.PixelPackBuffer().GetID(), true
+	case GLenum_GL_PIXEL_UNPACK_BUFFER:
+		return b.PixelUnpackBuffer().GetID(), true
+	case GLenum_GL_UNIFORM_BUFFER:
+		return b.UniformBuffer().GetID(), true
+	case GLenum_GL_ATOMIC_COUNTER_BUFFER:
+		return b.AtomicCounterBuffer().GetID(), true
+	case GLenum_GL_DISPATCH_INDIRECT_BUFFER:
+		return b.DispatchIndirectBuffer().GetID(), true
+	case GLenum_GL_DRAW_INDIRECT_BUFFER:
+		return b.DrawIndirectBuffer().GetID(), true
+	case GLenum_GL_SHADER_STORAGE_BUFFER:
+		return b.ShaderStorageBuffer().GetID(), true
+	default:
+		return 0, false
+	}
+}
+
+// capabilityEnabled returns whether the non-indexed capability is enabled in
+// c. GL_BLEND is only reported as enabled or disabled if it is for all the
+// draw buffers.
+func capabilityEnabled(c Contextʳ, capability GLenum) (bool, bool) {
+	switch capability {
+	case GLenum_GL_BLEND:
+		enabled, found := false, false
+		for _, b := range c.Pixel().Blend().All() {
+			e := b.Enabled() == GLboolean_GL_TRUE
+			if found && e != enabled {
+				return false, false
+			}
+			enabled, found = e, true
+		}
+		return enabled, found
+	case GLenum_GL_CULL_FACE:
+		return c.Rasterization().CullFace() == GLboolean_GL_TRUE, true
+	case GLenum_GL_DEPTH_TEST:
+		return c.Pixel().Depth().Test() == GLboolean_GL_TRUE, true
+	case GLenum_GL_DITHER:
+		return c.Pixel().Dither() == GLboolean_GL_TRUE, true
+	case GLenum_GL_POLYGON_OFFSET_FILL:
+		return c.Rasterization().PolygonOffsetFill() == GLboolean_GL_TRUE, true
+	case GLenum_GL_RASTERIZER_DISCARD:
+		return c.Rasterization().RasterizerDiscard() == GLboolean_GL_TRUE, true
+	case GLenum_GL_SCISSOR_TEST:
+		return c.Pixel().Scissor().Test() == GLboolean_GL_TRUE, true
+	case GLenum_GL_STENCIL_TEST:
+		return c.Pixel().Stencil().Test() == GLboolean_GL_TRUE, true
+	default:
+		return false, false
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/gles"
+)
+
+func TestRedundantCalls(t *testing.T) {
+	ctx, a, s := newContextState(t)
+	defer a.Dispose()
+
+	uniform := gles.MakeProgramResourceʳ(a)
+	uniform.SetName("u")
+	uniform.SetType(gles.GLenum_GL_FLOAT)
+	uniform.SetArraySize(1)
+	uniform.SetLocations(gles.NewU32ːGLintᵐ(a).Add(0, 0))
+	resources := gles.MakeActiveProgramResourcesʳ(a)
+	resources.SetDefaultUniformBlock(gles.NewUniformIndexːProgramResourceʳᵐ(a).Add(0, uniform))
+	programInfo := gles.MakeLinkProgramExtra(a)
+	programInfo.SetLinkStatus(gles.GLboolean_GL_TRUE)
+	programInfo.SetActiveResources(resources)
+
+	cb := gles.CommandBuilder{Thread: 0, Arena: a}
+	for _, test := range []struct {
+		cmd       api.Cmd
+		redundant bool
+	}{
+		{cb.GlBindTexture(gles.GLenum_GL_TEXTURE_2D, 1), false},
+		{cb.GlBindTexture(gles.GLenum_GL_TEXTURE_2D, 1), true},
+		{cb.GlBindTexture(gles.GLenum_GL_TEXTURE_CUBE_MAP, 1), false},
+		{cb.GlBindTexture(gles.GLenum_GL_TEXTURE_2D, 2), false},
+		{cb.GlBindBuffer(gles.GLenum_GL_ARRAY_BUFFER, 1), false},
+		{cb.GlBindBuffer(gles.GLenum_GL_ARRAY_BUFFER, 1), true},
+		{cb.GlBindBuffer(gles.GLenum_GL_ELEMENT_ARRAY_BUFFER, 1), false},
+		{cb.GlEnable(gles.GLenum_GL_DEPTH_TEST), false},
+		{cb.GlEnable(gles.GLenum_GL_DEPTH_TEST), true},
+		{cb.GlDisable(gles.GLenum_GL_DEPTH_TEST), false},
+		{cb.GlDisable(gles.GLenum_GL_DEPTH_TEST), true},
+		{cb.GlCreateProgram(1), false},
+		{api.WithExtras(cb.GlLinkProgram(1), programInfo), false},
+		{cb.GlUseProgram(1), false},
+		{cb.GlUseProgram(1), true},
+		{cb.GlUniform1f(0, 1), false},
+		{cb.GlUniform1f(0, 1), true},
+		{cb.GlUniform1f(0, 2), false},
+		{cb.GlUseProgram(0), false},
+	} {
+		reason, unchanged := gles.API{}.RedundantCall(ctx, api.CmdNoID, test.cmd, s)
+		err := test.cmd.Mutate(ctx, api.CmdNoID, s, nil)
+		assert.For(ctx, "%v err", test.cmd).ThatError(err).Succeeded()
+		redundant := unchanged != nil && unchanged()
+		assert.For(ctx, "%v redundant (%v)", test.cmd, reason).That(redundant).Equals(test.redundant)
+	}
+}
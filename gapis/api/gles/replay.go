@@ -133,8 +133,6 @@ func (a API) Replay(
 
 	ctx = PutUnusedIDMap(ctx)
 
-	cmds := capture.Commands
-
 	// Gathers and reports any issues found.
 	var issues *findIssues
 
@@ -186,7 +184,12 @@ func (a API) Replay(
 			}
 			deadCodeElimination.Request(req.after)
 
-			thread := cmds[req.after].Thread()
+			cmd, release, err := capture.Cmd(ctx, req.after)
+			if err != nil {
+				return err
+			}
+			thread := cmd.Thread()
+			release()
 			switch req.attachment {
 			case api.FramebufferAttachment_Depth:
 				rf.depth(req.after, thread, req.fb, rr.Result)
@@ -243,7 +246,7 @@ func (a API) Replay(
 	transforms.Add(&destroyResourcesAtEOS{})
 
 	if config.DebugReplay {
-		log.I(ctx, "Replaying %d commands using transform chain:", len(cmds))
+		log.I(ctx, "Replaying %d commands using transform chain:", capture.NumCommands())
 		for i, t := range transforms {
 			log.I(ctx, "(%d) %#v", i, t)
 		}
@@ -268,7 +271,7 @@ func (a API) Replay(
 		transforms.Add(transform.NewCaptureLog(ctx, capture, "replay_log.gfxtrace"))
 	}
 
-	cmds = []api.Cmd{} // DeadCommandRemoval generates commands.
+	cmds := []api.Cmd{} // DeadCommandRemoval generates commands.
 	transforms.Transform(ctx, cmds, out)
 	return nil
 }
@@ -344,10 +344,16 @@ func (s Shaderʳ) SetResourceData(
 	}
 	for j := index; j >= 0; j-- {
 		i := resource.Accesses[j].Indices[0] // TODO: Subcommands
-		if a, ok := c.Commands[i].(*GlShaderSource); ok {
+		cmd, release, err := c.Cmd(ctx, api.CmdID(i))
+		if err != nil {
+			return err
+		}
+		if a, ok := cmd.(*GlShaderSource); ok {
 			edits(uint64(i), a.Replace(ctx, c, data))
+			release()
 			return nil
 		}
+		release()
 	}
 	return fmt.Errorf("No command to set data in")
 }
@@ -0,0 +1,127 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// Interface compliance test
+var (
+	_ = api.ShaderUsageProvider(API{})
+)
+
+// DrawPrograms implements the api.ShaderUsageProvider interface. Draws
+// without a bound program use the stage programs of the bound program
+// pipeline.
+func (API) DrawPrograms(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState) []api.Resource {
+	if !cmd.CmdFlags(ctx, id, s).IsDrawCall() {
+		return nil
+	}
+	c := GetContext(s, cmd.Thread())
+	if c.IsNil() {
+		return nil
+	}
+	if p := c.Bound().Program(); !p.IsNil() {
+		return []api.Resource{p}
+	}
+	pipe := c.Bound().Pipeline()
+	if pipe.IsNil() {
+		return nil
+	}
+	out := []api.Resource{}
+	seen := map[Programʳ]struct{}{}
+	for _, p := range []Programʳ{
+		pipe.VertexShader(),
+		pipe.TessControlShader(),
+		pipe.TessEvaluationShader(),
+		pipe.GeometryShader(),
+		pipe.FragmentShader(),
+	} {
+		if _, ok := seen[p]; !ok && !p.IsNil() {
+			seen[p] = struct{}{}
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// LinkedProgram implements the api.ShaderUsageProvider interface.
+func (API) LinkedProgram(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState) api.Resource {
+	c := GetContext(s, cmd.Thread())
+	if c.IsNil() {
+		return nil
+	}
+	var handle ProgramId
+	switch cmd := cmd.(type) {
+	case *GlLinkProgram:
+		handle = cmd.Program()
+	case *GlProgramBinary:
+		handle = cmd.Program()
+	case *GlProgramBinaryOES:
+		handle = cmd.Program()
+	default:
+		return nil
+	}
+	if p, ok := c.Objects().Programs().Lookup(handle); ok {
+		return p
+	}
+	return nil
+}
+
+// ProgramStages implements the api.ShaderUsageProvider interface. The stages
+// are the shaders of the last successful link of the program, as the shaders
+// are commonly detached once the program is linked.
+func (API) ProgramStages(ctx context.Context, r api.Resource, s *api.GlobalState) ([]*api.Stage, error) {
+	p, ok := r.(Programʳ)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a program", r.ResourceHandle())
+	}
+	link := p.SuccessfulLinkExtra()
+	if link.IsNil() {
+		return nil, fmt.Errorf("%v is not linked", p.ResourceHandle())
+	}
+	if !link.Binary().IsNil() {
+		return nil, fmt.Errorf("%v is linked from a program binary", p.ResourceHandle())
+	}
+	stages := make([]*api.Stage, 0, link.Shaders().Len())
+	for ty, shader := range link.Shaders().All() {
+		if !shader.Binary().IsNil() {
+			continue // The source of binary shaders is unavailable.
+		}
+		stage := &api.Stage{Shader: &api.Shader{Source: shader.Source()}}
+		switch ty {
+		case GLenum_GL_VERTEX_SHADER:
+			stage.Type, stage.Shader.Type = api.StageType_VERTEX, api.ShaderType_Vertex
+		case GLenum_GL_GEOMETRY_SHADER:
+			stage.Type, stage.Shader.Type = api.StageType_GEOMETRY, api.ShaderType_Geometry
+		case GLenum_GL_TESS_CONTROL_SHADER:
+			stage.Type, stage.Shader.Type = api.StageType_TESSELLATION_CONTROL, api.ShaderType_TessControl
+		case GLenum_GL_TESS_EVALUATION_SHADER:
+			stage.Type, stage.Shader.Type = api.StageType_TESSELLATION_EVALUATION, api.ShaderType_TessEvaluation
+		case GLenum_GL_FRAGMENT_SHADER:
+			stage.Type, stage.Shader.Type = api.StageType_FRAGMENT, api.ShaderType_Fragment
+		case GLenum_GL_COMPUTE_SHADER:
+			stage.Type, stage.Shader.Type = api.StageType_COMPUTE, api.ShaderType_Compute
+		default:
+			continue
+		}
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
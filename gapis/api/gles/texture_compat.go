@@ -359,6 +359,34 @@ func getExtensionTextureFormats(extension string) []GLenum {
 			GLenum_GL_COMPRESSED_RGBA_S3TC_DXT3_EXT,
 			GLenum_GL_COMPRESSED_RGBA_S3TC_DXT5_EXT,
 		}
+	case "GL_EXT_texture_compression_bptc", "GL_ARB_texture_compression_bptc":
+		return []GLenum{
+			GLenum_GL_COMPRESSED_RGBA_BPTC_UNORM,
+			GLenum_GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM,
+			GLenum_GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT,
+			GLenum_GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT,
+		}
+	case "GL_IMG_texture_compression_pvrtc":
+		return []GLenum{
+			GLenum_GL_COMPRESSED_RGB_PVRTC_2BPPV1_IMG,
+			GLenum_GL_COMPRESSED_RGB_PVRTC_4BPPV1_IMG,
+			GLenum_GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG,
+			GLenum_GL_COMPRESSED_RGBA_PVRTC_4BPPV1_IMG,
+		}
+	case "GL_IMG_texture_compression_pvrtc2":
+		return []GLenum{
+			GLenum_GL_COMPRESSED_RGBA_PVRTC_2BPPV2_IMG,
+			GLenum_GL_COMPRESSED_RGBA_PVRTC_4BPPV2_IMG,
+		}
+	case "GL_EXT_pvrtc_sRGB":
+		return []GLenum{
+			GLenum_GL_COMPRESSED_SRGB_PVRTC_2BPPV1_EXT,
+			GLenum_GL_COMPRESSED_SRGB_PVRTC_4BPPV1_EXT,
+			GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV1_EXT,
+			GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV1_EXT,
+			GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV2_IMG,
+			GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV2_IMG,
+		}
 	case "GL_KHR_texture_compression_astc_ldr":
 		return []GLenum{
 			GLenum_GL_COMPRESSED_RGBA_ASTC_4x4_KHR,
@@ -428,9 +456,18 @@ func isCompressedFormat(internalformat GLenum) bool {
 		GLenum_GL_COMPRESSED_RGBA_ASTC_8x5,
 		GLenum_GL_COMPRESSED_RGBA_ASTC_8x6,
 		GLenum_GL_COMPRESSED_RGBA_ASTC_8x8,
+		GLenum_GL_COMPRESSED_RGBA_BPTC_UNORM,
+		GLenum_GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG,
+		GLenum_GL_COMPRESSED_RGBA_PVRTC_2BPPV2_IMG,
+		GLenum_GL_COMPRESSED_RGBA_PVRTC_4BPPV1_IMG,
+		GLenum_GL_COMPRESSED_RGBA_PVRTC_4BPPV2_IMG,
 		GLenum_GL_COMPRESSED_RGBA_S3TC_DXT1_EXT,
 		GLenum_GL_COMPRESSED_RGBA_S3TC_DXT3_EXT,
 		GLenum_GL_COMPRESSED_RGBA_S3TC_DXT5_EXT,
+		GLenum_GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT,
+		GLenum_GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT,
+		GLenum_GL_COMPRESSED_RGB_PVRTC_2BPPV1_IMG,
+		GLenum_GL_COMPRESSED_RGB_PVRTC_4BPPV1_IMG,
 		GLenum_GL_COMPRESSED_RGB_S3TC_DXT1_EXT,
 		GLenum_GL_COMPRESSED_SIGNED_LUMINANCE_ALPHA_LATC2_EXT,
 		GLenum_GL_COMPRESSED_SIGNED_LUMINANCE_LATC1_EXT,
@@ -453,6 +490,13 @@ func isCompressedFormat(internalformat GLenum) bool {
 		GLenum_GL_COMPRESSED_SRGB8_ALPHA8_ETC2_EAC,
 		GLenum_GL_COMPRESSED_SRGB8_ETC2,
 		GLenum_GL_COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2,
+		GLenum_GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM,
+		GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV1_EXT,
+		GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV2_IMG,
+		GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV1_EXT,
+		GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV2_IMG,
+		GLenum_GL_COMPRESSED_SRGB_PVRTC_2BPPV1_EXT,
+		GLenum_GL_COMPRESSED_SRGB_PVRTC_4BPPV1_EXT,
 		GLenum_GL_ETC1_RGB8_OES:
 		return true
 	}
@@ -0,0 +1,133 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// Interface compliance test
+var (
+	_ = api.UploadProvider(API{})
+)
+
+// Uploads implements the api.UploadProvider interface. Data written to
+// buffers through mappings is attributed to the explicit flushes, or to the
+// unmap for mappings without explicit flushes.
+func (API) Uploads(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState) []api.Upload {
+	c := GetContext(s, cmd.Thread())
+	if c.IsNil() {
+		return nil
+	}
+
+	buffer := func(target GLenum, bytes uint64) []api.Upload {
+		b, ok := boundBuffer(c, target)
+		if !ok || b == 0 || bytes == 0 {
+			return nil
+		}
+		return []api.Upload{{Kind: "Buffer", Name: objectName(c, "Buffer", uint64(b)), Bytes: bytes}}
+	}
+	texture := func(target GLenum, bytes uint64) []api.Upload {
+		switch target {
+		case GLenum_GL_TEXTURE_CUBE_MAP_POSITIVE_X, GLenum_GL_TEXTURE_CUBE_MAP_NEGATIVE_X,
+			GLenum_GL_TEXTURE_CUBE_MAP_POSITIVE_Y, GLenum_GL_TEXTURE_CUBE_MAP_NEGATIVE_Y,
+			GLenum_GL_TEXTURE_CUBE_MAP_POSITIVE_Z, GLenum_GL_TEXTURE_CUBE_MAP_NEGATIVE_Z:
+			target = GLenum_GL_TEXTURE_CUBE_MAP
+		}
+		t, ok := boundTexture(c, target)
+		if !ok || t == 0 || bytes == 0 {
+			return nil
+		}
+		return []api.Upload{{Kind: "Texture", Name: objectName(c, "Texture", uint64(t)), Bytes: bytes}}
+	}
+	unmap := func(target GLenum) []api.Upload {
+		handle, ok := boundBuffer(c, target)
+		if !ok {
+			return nil
+		}
+		b := c.Objects().Buffers().Get(handle)
+		if b.IsNil() || b.Mapped() == GLboolean_GL_FALSE {
+			return nil
+		}
+		access := b.AccessFlags()
+		if access&GLbitfield_GL_MAP_WRITE_BIT == 0 || access&GLbitfield_GL_MAP_FLUSH_EXPLICIT_BIT != 0 {
+			return nil
+		}
+		return buffer(target, uint64(b.MapLength()))
+	}
+
+	switch cmd := cmd.(type) {
+	case *GlBufferData:
+		if cmd.Data().Address() != 0 {
+			return buffer(cmd.Target(), uint64(cmd.Size()))
+		}
+	case *GlBufferSubData:
+		return buffer(cmd.Target(), uint64(cmd.Size()))
+	case *GlFlushMappedBufferRange:
+		return buffer(cmd.Target(), uint64(cmd.Length()))
+	case *GlFlushMappedBufferRangeEXT:
+		return buffer(cmd.Target(), uint64(cmd.Length()))
+	case *GlUnmapBuffer:
+		return unmap(cmd.Target())
+	case *GlUnmapBufferOES:
+		return unmap(cmd.Target())
+
+	case *GlTexImage2D:
+		if texUploads(c, cmd.Data().Address()) {
+			return texture(cmd.Target(), texUploadSize(cmd.Format(), cmd.Type(), cmd.Width(), cmd.Height(), 1))
+		}
+	case *GlTexImage3D:
+		if texUploads(c, cmd.Data().Address()) {
+			return texture(cmd.Target(), texUploadSize(cmd.Format(), cmd.Type(), cmd.Width(), cmd.Height(), cmd.Depth()))
+		}
+	case *GlTexImage3DOES:
+		if texUploads(c, cmd.Pixels().Address()) {
+			return texture(cmd.Target(), texUploadSize(cmd.Format(), cmd.Type(), cmd.Width(), cmd.Height(), cmd.Depth()))
+		}
+	case *GlTexSubImage2D:
+		if texUploads(c, cmd.Data().Address()) {
+			return texture(cmd.Target(), texUploadSize(cmd.Format(), cmd.Type(), cmd.Width(), cmd.Height(), 1))
+		}
+	case *GlTexSubImage3D:
+		if texUploads(c, cmd.Data().Address()) {
+			return texture(cmd.Target(), texUploadSize(cmd.Format(), cmd.Type(), cmd.Width(), cmd.Height(), cmd.Depth()))
+		}
+	case *GlTexSubImage3DOES:
+		if texUploads(c, cmd.Pixels().Address()) {
+			return texture(cmd.Target(), texUploadSize(cmd.Format(), cmd.Type(), cmd.Width(), cmd.Height(), cmd.Depth()))
+		}
+	case *GlCompressedTexImage2D:
+		if texUploads(c, cmd.Data().Address()) {
+			return texture(cmd.Target(), uint64(cmd.ImageSize()))
+		}
+	case *GlCompressedTexImage3D:
+		if texUploads(c, cmd.Data().Address()) {
+			return texture(cmd.Target(), uint64(cmd.ImageSize()))
+		}
+	case *GlCompressedTexImage3DOES:
+		if texUploads(c, cmd.Data().Address()) {
+			return texture(cmd.Target(), uint64(cmd.ImageSize()))
+		}
+	case *GlCompressedTexSubImage2D:
+		return texture(cmd.Target(), uint64(cmd.ImageSize()))
+	case *GlCompressedTexSubImage3D:
+		return texture(cmd.Target(), uint64(cmd.ImageSize()))
+	case *GlCompressedTexSubImage3DOES:
+		return texture(cmd.Target(), uint64(cmd.ImageSize()))
+	}
+	return nil
+}
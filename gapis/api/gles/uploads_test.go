@@ -0,0 +1,63 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/gles"
+	"github.com/google/gapid/gapis/memory"
+)
+
+func TestUploads(t *testing.T) {
+	ctx, a, s := newContextState(t)
+	defer a.Dispose()
+
+	ml := s.MemoryLayout
+
+	buffer := func(bytes uint64) []api.Upload {
+		return []api.Upload{{Kind: "Buffer", Name: "Buffer 1 of context 0", Bytes: bytes}}
+	}
+	texture := func(bytes uint64) []api.Upload {
+		return []api.Upload{{Kind: "Texture", Name: "Texture 1 of context 0", Bytes: bytes}}
+	}
+
+	cb := gles.CommandBuilder{Thread: 0, Arena: a}
+	for _, test := range []struct {
+		cmd      api.Cmd
+		expected []api.Upload
+	}{
+		{cb.GlBindBuffer(gles.GLenum_GL_ARRAY_BUFFER, 1), nil},
+		{cb.GlBufferData(gles.GLenum_GL_ARRAY_BUFFER, 16, p(0x1000), gles.GLenum_GL_STATIC_DRAW).
+			AddRead(memory.Store(ctx, ml, p(0x1000), make([]byte, 16))), buffer(16)},
+		{cb.GlBufferData(gles.GLenum_GL_ARRAY_BUFFER, 16, memory.Nullptr, gles.GLenum_GL_STATIC_DRAW), nil},
+		{cb.GlBufferSubData(gles.GLenum_GL_ARRAY_BUFFER, 0, 8, p(0x1000)).
+			AddRead(memory.Store(ctx, ml, p(0x1000), make([]byte, 8))), buffer(8)},
+		{cb.GlBindTexture(gles.GLenum_GL_TEXTURE_2D, 1), nil},
+		{cb.GlTexImage2D(gles.GLenum_GL_TEXTURE_2D, 0, gles.GLint(gles.GLenum_GL_RGBA), 4, 4, 0, gles.GLenum_GL_RGBA, gles.GLenum_GL_UNSIGNED_BYTE, p(0x2000)).
+			AddRead(memory.Store(ctx, ml, p(0x2000), make([]byte, 64))), texture(64)},
+		{cb.GlTexImage2D(gles.GLenum_GL_TEXTURE_2D, 1, gles.GLint(gles.GLenum_GL_RGBA), 2, 2, 0, gles.GLenum_GL_RGBA, gles.GLenum_GL_UNSIGNED_BYTE, memory.Nullptr), nil},
+		{cb.GlTexSubImage2D(gles.GLenum_GL_TEXTURE_2D, 0, 0, 0, 2, 2, gles.GLenum_GL_RGBA, gles.GLenum_GL_UNSIGNED_BYTE, p(0x2000)).
+			AddRead(memory.Store(ctx, ml, p(0x2000), make([]byte, 16))), texture(16)},
+	} {
+		test.cmd.Extras().Observations().ApplyReads(s.Memory.ApplicationPool())
+		got := gles.API{}.Uploads(ctx, api.CmdNoID, test.cmd, s)
+		err := test.cmd.Mutate(ctx, api.CmdNoID, s, nil)
+		assert.For(ctx, "%v err", test.cmd).ThatError(err).Succeeded()
+		assert.For(ctx, "%v uploads", test.cmd).ThatSlice(got).Equals(test.expected)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+// MemoryTimelineProvider is the interface implemented by APIs that can list
+// the objects owning memory in their state, to build the memory timeline of a
+// capture.
+type MemoryTimelineProvider interface {
+	// ChangesMemoryObjects returns true if cmd may allocate, resize or free
+	// the objects returned by MemoryObjects.
+	ChangesMemoryObjects(cmd Cmd) bool
+	// MemoryObjects returns the live objects owning memory in the state,
+	// keyed by a comparable value identifying each object for its lifetime,
+	// such as the object itself. The Allocated field of the objects is
+	// ignored.
+	MemoryObjects(*GlobalState) map[interface{}]*MemoryObject
+}
@@ -223,3 +223,87 @@ func (dp DrawPrimitive) Count(vertices uint32) uint32 {
 		return 0
 	}
 }
+
+// vertexStream returns the vertex stream with the given semantic type and
+// index, or nil if the mesh has no such stream.
+func (m *Mesh) vertexStream(ty vertex.Semantic_Type, index uint32) *vertex.Stream {
+	if m.VertexBuffer == nil {
+		return nil
+	}
+	for _, s := range m.VertexBuffer.Streams {
+		if s.Semantic != nil && s.Semantic.Type == ty && s.Semantic.Index == index {
+			return s
+		}
+	}
+	return nil
+}
+
+// streamChannelSlots maps the vector and color channels to the index of their
+// component in the float32 vectors returned by streamFloats.
+var streamChannelSlots = map[stream.Channel]int{
+	stream.Channel_X: 0, stream.Channel_Red: 0, stream.Channel_U: 0,
+	stream.Channel_Y: 1, stream.Channel_Green: 1, stream.Channel_V: 1,
+	stream.Channel_Z: 2, stream.Channel_Blue: 2,
+	stream.Channel_W: 3, stream.Channel_Alpha: 3,
+}
+
+// streamFloats returns the components of each vertex in s as len(def)
+// float32s. Components are placed by their channel, so that BGRA colors are
+// returned as RGBA, and components of other channels by their position in the
+// stream's format. Missing components are filled from def.
+// Normalized integer components are converted to the range [0, 1] or [-1, 1].
+func streamFloats(s *vertex.Stream, def ...float32) ([]float32, error) {
+	src := s.Format
+	dst := &stream.Format{Components: make([]*stream.Component, len(src.Components))}
+	for i, c := range src.Components {
+		dst.Components[i] = &stream.Component{DataType: &stream.F32, Sampling: stream.Linear, Channel: c.Channel}
+	}
+	data, err := stream.Convert(dst, src, s.Data)
+	if err != nil {
+		return nil, err
+	}
+	count := len(data) / (4 * len(dst.Components))
+	r := endian.Reader(bytes.NewReader(data), device.LittleEndian)
+	out := make([]float32, count*len(def))
+	for i := 0; i < count; i++ {
+		v := out[i*len(def) : (i+1)*len(def)]
+		copy(v, def)
+		for j, c := range src.Components {
+			slot, ok := streamChannelSlots[c.Channel]
+			if !ok {
+				slot = j
+			}
+			if f := r.Float32(); slot < len(v) {
+				v[slot] = f
+			}
+		}
+	}
+	return out, r.Error()
+}
+
+// splitAtRestarts returns the runs of indices between the primitive restart
+// indices, without the empty runs. Primitive restart indices are the maximum
+// values of the 8, 16 and 32-bit index types that are out of bounds of the
+// count vertices. Any other index out of bounds returns an error.
+func splitAtRestarts(indices []uint32, count int) ([][]uint32, error) {
+	out := [][]uint32{}
+	start := 0
+	for i, idx := range indices {
+		if int(idx) < count {
+			continue
+		}
+		switch idx {
+		case 0xff, 0xffff, 0xffffffff:
+		default:
+			return nil, fmt.Errorf("Index %d is out of bounds of the %d vertices", idx, count)
+		}
+		if i > start {
+			out = append(out, indices[start:i])
+		}
+		start = i + 1
+	}
+	if len(indices) > start {
+		out = append(out, indices[start:])
+	}
+	return out, nil
+}
@@ -0,0 +1,295 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/math/sint"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/vertex"
+)
+
+// glTF 2.0 constants.
+// See: https://github.com/KhronosGroup/glTF/tree/master/specification/2.0
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbVersion   = 2
+	glbChunkJSON = 0x4E4F534A // "JSON"
+	glbChunkBIN  = 0x004E4942 // "BIN\0"
+
+	gltfFloat       = 5126
+	gltfUnsignedInt = 5125
+
+	gltfArrayBuffer        = 34962
+	gltfElementArrayBuffer = 34963
+)
+
+// gltfModes maps the draw primitives to the glTF primitive modes.
+var gltfModes = map[DrawPrimitive]int{
+	DrawPrimitive_Points:        0,
+	DrawPrimitive_Lines:         1,
+	DrawPrimitive_LineLoop:      2,
+	DrawPrimitive_LineStrip:     3,
+	DrawPrimitive_Triangles:     4,
+	DrawPrimitive_TriangleStrip: 5,
+	DrawPrimitive_TriangleFan:   6,
+}
+
+// gltfAttribute describes how a vertex stream semantic is stored as a glTF
+// vertex attribute.
+type gltfAttribute struct {
+	name string
+	typ  string
+	def  []float32
+}
+
+// gltfAttributes maps the vertex stream semantics to the glTF attributes.
+// glTF has no bitangent attribute, as readers derive it from the normal and
+// tangent.
+var gltfAttributes = map[vertex.Semantic_Type]gltfAttribute{
+	vertex.Semantic_Position: {"POSITION", "VEC3", []float32{0, 0, 0}},
+	vertex.Semantic_Normal:   {"NORMAL", "VEC3", []float32{0, 0, 0}},
+	vertex.Semantic_Tangent:  {"TANGENT", "VEC4", []float32{0, 0, 0, 1}},
+	vertex.Semantic_Texcoord: {"TEXCOORD_%d", "VEC2", []float32{0, 0}},
+	vertex.Semantic_Color:    {"COLOR_%d", "VEC4", []float32{0, 0, 0, 1}},
+}
+
+type gltfJSON struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNode struct {
+	Mesh int `json:"mesh"`
+}
+
+type gltfMesh struct {
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices,omitempty"`
+	Mode       int            `json:"mode"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target"`
+}
+
+type gltfBuffer struct {
+	ByteLength int `json:"byteLength"`
+}
+
+// WriteGLB writes the mesh to w as a binary glTF 2.0 (.glb) file holding a
+// single mesh. Primitive restart indices split the mesh into one primitive per
+// strip, as glTF does not support primitive restart.
+// The position, normal, tangent, texture coordinate and color vertex streams
+// are written as float vertex attributes. All other streams are ignored.
+func (m *Mesh) WriteGLB(w io.Writer) error {
+	mode, ok := gltfModes[m.DrawPrimitive]
+	if !ok {
+		return fmt.Errorf("Unsupported draw primitive %v", m.DrawPrimitive)
+	}
+	if m.vertexStream(vertex.Semantic_Position, 0) == nil {
+		return fmt.Errorf("Mesh has no position stream")
+	}
+
+	doc := gltfJSON{
+		Asset:  gltfAsset{Version: "2.0", Generator: "GAPID"},
+		Scenes: []gltfScene{{Nodes: []int{0}}},
+		Nodes:  []gltfNode{{Mesh: 0}},
+	}
+	prim := gltfPrimitive{Attributes: map[string]int{}, Mode: mode}
+	bin := &bytes.Buffer{}
+	binW := endian.Writer(bin, device.LittleEndian)
+
+	// addAccessor appends the data to the binary buffer, with a new buffer
+	// view and accessor. It returns the index of the new accessor.
+	addAccessor := func(acc gltfAccessor, target int, write func()) int {
+		offset := bin.Len()
+		write()
+		doc.BufferViews = append(doc.BufferViews, gltfBufferView{
+			ByteOffset: offset,
+			ByteLength: bin.Len() - offset,
+			Target:     target,
+		})
+		binW.Data(make([]byte, sint.AlignUp(bin.Len(), 4)-bin.Len()))
+		acc.BufferView = len(doc.BufferViews) - 1
+		doc.Accessors = append(doc.Accessors, acc)
+		return len(doc.Accessors) - 1
+	}
+
+	// Texture coordinate and color sets are numbered from 0 without gaps.
+	sets := map[*vertex.Stream]int{}
+	for _, ty := range []vertex.Semantic_Type{vertex.Semantic_Texcoord, vertex.Semantic_Color} {
+		streams := []*vertex.Stream{}
+		for _, s := range m.VertexBuffer.Streams {
+			if s.Semantic != nil && s.Semantic.Type == ty {
+				streams = append(streams, s)
+			}
+		}
+		sort.SliceStable(streams, func(i, j int) bool {
+			return streams[i].Semantic.Index < streams[j].Semantic.Index
+		})
+		for i, s := range streams {
+			sets[s] = i
+		}
+	}
+
+	vertexCount := -1
+	for _, s := range m.VertexBuffer.Streams {
+		if s.Semantic == nil {
+			continue
+		}
+		attr, ok := gltfAttributes[s.Semantic.Type]
+		if !ok {
+			continue
+		}
+		name := attr.name
+		switch s.Semantic.Type {
+		case vertex.Semantic_Texcoord, vertex.Semantic_Color:
+			name = fmt.Sprintf(name, sets[s])
+		default:
+			if s.Semantic.Index != 0 {
+				continue
+			}
+		}
+		data, err := streamFloats(s, attr.def...)
+		if err != nil {
+			return err
+		}
+		count := len(data) / len(attr.def)
+		if vertexCount >= 0 && count != vertexCount {
+			return fmt.Errorf("Vertex stream '%s' has %d vertices, expected %d", s.Name, count, vertexCount)
+		}
+		vertexCount = count
+
+		acc := gltfAccessor{ComponentType: gltfFloat, Count: count, Type: attr.typ}
+		if s.Semantic.Type == vertex.Semantic_Position {
+			// Positions must declare their bounds.
+			acc.Min, acc.Max = gltfBounds(data, len(attr.def))
+		}
+		prim.Attributes[name] = addAccessor(acc, gltfArrayBuffer, func() {
+			for _, f := range data {
+				binW.Float32(f)
+			}
+		})
+	}
+
+	if vertexCount == 0 {
+		return fmt.Errorf("Mesh has no vertices")
+	}
+
+	prims := []gltfPrimitive{prim}
+	if m.IndexBuffer != nil && len(m.IndexBuffer.Indices) > 0 {
+		strips, err := splitAtRestarts(m.IndexBuffer.Indices, vertexCount)
+		if err != nil {
+			return err
+		}
+		if len(strips) == 0 {
+			return fmt.Errorf("Mesh has no primitives")
+		}
+		prims = prims[:0]
+		for _, indices := range strips {
+			acc := gltfAccessor{ComponentType: gltfUnsignedInt, Count: len(indices), Type: "SCALAR"}
+			i := addAccessor(acc, gltfElementArrayBuffer, func() {
+				for _, i := range indices {
+					binW.Uint32(i)
+				}
+			})
+			strip := prim
+			strip.Indices = &i
+			prims = append(prims, strip)
+		}
+	}
+	if err := binW.Error(); err != nil {
+		return err
+	}
+
+	doc.Meshes = []gltfMesh{{Primitives: prims}}
+	doc.Buffers = []gltfBuffer{{ByteLength: bin.Len()}}
+	js, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	// The JSON chunk is padded with spaces, the binary chunk with zeros.
+	js = append(js, bytes.Repeat([]byte(" "), sint.AlignUp(len(js), 4)-len(js))...)
+
+	out := endian.Writer(w, device.LittleEndian)
+	out.Uint32(glbMagic)
+	out.Uint32(glbVersion)
+	out.Uint32(uint32(12 + 8 + len(js) + 8 + bin.Len()))
+	out.Uint32(uint32(len(js)))
+	out.Uint32(glbChunkJSON)
+	out.Data(js)
+	out.Uint32(uint32(bin.Len()))
+	out.Uint32(glbChunkBIN)
+	out.Data(bin.Bytes())
+	return out.Error()
+}
+
+// gltfBounds returns the per-component minimum and maximum of the vectors of
+// n components in data.
+func gltfBounds(data []float32, n int) (min, max []float32) {
+	min, max = make([]float32, n), make([]float32, n)
+	for i := range min {
+		min[i], max[i] = math.MaxFloat32, -math.MaxFloat32
+	}
+	for i, f := range data {
+		c := i % n
+		if f < min[c] {
+			min[c] = f
+		}
+		if f > max[c] {
+			max[c] = f
+		}
+	}
+	return min, max
+}
@@ -0,0 +1,145 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/google/gapid/gapis/vertex"
+)
+
+// WriteOBJ writes the mesh to w as a Wavefront OBJ file.
+// The position, normal and first texture coordinate streams are written, along
+// with the first color stream as the non-standard vertex color extension.
+// Triangle strips and fans are written as separate triangles, and line strips
+// and loops as separate line segments. Primitive restart indices start a new
+// strip, fan or loop.
+func (m *Mesh) WriteOBJ(w io.Writer) error {
+	pos := m.vertexStream(vertex.Semantic_Position, 0)
+	if pos == nil {
+		return fmt.Errorf("Mesh has no position stream")
+	}
+	positions, err := streamFloats(pos, 0, 0, 0)
+	if err != nil {
+		return err
+	}
+	count := len(positions) / 3
+
+	// optional returns the data of the first stream with the given semantic,
+	// or nil if the mesh has no such stream.
+	optional := func(ty vertex.Semantic_Type, def ...float32) ([]float32, error) {
+		s := m.vertexStream(ty, 0)
+		if s == nil {
+			return nil, nil
+		}
+		data, err := streamFloats(s, def...)
+		if err != nil {
+			return nil, err
+		}
+		if c := len(data) / len(def); c != count {
+			return nil, fmt.Errorf("Vertex stream '%s' has %d vertices, expected %d", s.Name, c, count)
+		}
+		return data, nil
+	}
+	normals, err := optional(vertex.Semantic_Normal, 0, 0, 0)
+	if err != nil {
+		return err
+	}
+	texcoords, err := optional(vertex.Semantic_Texcoord, 0, 0)
+	if err != nil {
+		return err
+	}
+	colors, err := optional(vertex.Semantic_Color, 0, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	indices := []uint32{}
+	if m.IndexBuffer != nil && len(m.IndexBuffer.Indices) > 0 {
+		indices = m.IndexBuffer.Indices
+	} else {
+		for i := 0; i < count; i++ {
+			indices = append(indices, uint32(i))
+		}
+	}
+	strips, err := splitAtRestarts(indices, count)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(w)
+	for i := 0; i < count; i++ {
+		p := positions[i*3:]
+		if colors != nil {
+			c := colors[i*3:]
+			fmt.Fprintf(out, "v %g %g %g %g %g %g\n", p[0], p[1], p[2], c[0], c[1], c[2])
+		} else {
+			fmt.Fprintf(out, "v %g %g %g\n", p[0], p[1], p[2])
+		}
+	}
+	for i := 0; i < len(texcoords); i += 2 {
+		fmt.Fprintf(out, "vt %g %g\n", texcoords[i], texcoords[i+1])
+	}
+	for i := 0; i < len(normals); i += 3 {
+		fmt.Fprintf(out, "vn %g %g %g\n", normals[i], normals[i+1], normals[i+2])
+	}
+
+	// ref returns the OBJ vertex reference for the index i.
+	ref := func(i uint32) string {
+		i++ // OBJ indices are 1-based.
+		switch {
+		case texcoords != nil && normals != nil:
+			return fmt.Sprintf("%d/%d/%d", i, i, i)
+		case texcoords != nil:
+			return fmt.Sprintf("%d/%d", i, i)
+		case normals != nil:
+			return fmt.Sprintf("%d//%d", i, i)
+		default:
+			return fmt.Sprint(i)
+		}
+	}
+
+	for _, indices := range strips {
+		switch m.DrawPrimitive {
+		case DrawPrimitive_Points:
+			for _, i := range indices {
+				fmt.Fprintf(out, "p %s\n", ref(i))
+			}
+		case DrawPrimitive_Lines, DrawPrimitive_LineStrip, DrawPrimitive_LineLoop:
+			lines := int(m.DrawPrimitive.Count(uint32(len(indices))))
+			for l := 0; l < lines; l++ {
+				var a, b uint32
+				switch m.DrawPrimitive {
+				case DrawPrimitive_Lines:
+					a, b = indices[l*2], indices[l*2+1]
+				default:
+					a, b = indices[l], indices[(l+1)%len(indices)]
+				}
+				fmt.Fprintf(out, "l %s %s\n", ref(a), ref(b))
+			}
+		case DrawPrimitive_Triangles, DrawPrimitive_TriangleStrip, DrawPrimitive_TriangleFan:
+			tris := &Mesh{DrawPrimitive: m.DrawPrimitive, IndexBuffer: &IndexBuffer{Indices: indices}}
+			for t, n := 0, tris.TriangleCount(); t < n; t++ {
+				a, b, c := tris.Triangle(t)
+				fmt.Fprintf(out, "f %s %s %s\n", ref(a), ref(b), ref(c))
+			}
+		default:
+			return fmt.Errorf("Unsupported draw primitive %v", m.DrawPrimitive)
+		}
+	}
+	return out.Flush()
+}
@@ -0,0 +1,241 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream"
+	"github.com/google/gapid/core/stream/fmts"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/vertex"
+)
+
+// newTestQuad returns a quad mesh drawn as a triangle strip.
+func newTestQuad() *api.Mesh {
+	floats := func(v ...float32) []byte {
+		buf := &bytes.Buffer{}
+		w := endian.Writer(buf, device.LittleEndian)
+		for _, f := range v {
+			w.Float32(f)
+		}
+		return buf.Bytes()
+	}
+	s := func(name string, ty vertex.Semantic_Type, f *stream.Format, data []byte) *vertex.Stream {
+		return &vertex.Stream{Name: name, Data: data, Format: f, Semantic: &vertex.Semantic{Type: ty}}
+	}
+	return &api.Mesh{
+		DrawPrimitive: api.DrawPrimitive_TriangleStrip,
+		VertexBuffer: &vertex.Buffer{Streams: []*vertex.Stream{
+			s("position", vertex.Semantic_Position, fmts.XYZ_F32, floats(0, 0, 0, 0, 1, 0, 1, 0, 0, 1, 1, 0)),
+			s("normal", vertex.Semantic_Normal, fmts.XYZ_F32, floats(0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1)),
+			s("uv", vertex.Semantic_Texcoord, fmts.XY_F32, floats(0, 0, 0, 1, 1, 0, 1, 1)),
+			s("color", vertex.Semantic_Color, fmts.XYZW_U8_NORM, []byte{
+				255, 0, 0, 255, 0, 255, 0, 255, 0, 0, 255, 255, 255, 255, 255, 0,
+			}),
+			s("unknown", vertex.Semantic_Unknown, fmts.XY_F32, floats(0, 0, 0, 0, 0, 0, 0, 0)),
+		}},
+		IndexBuffer: &api.IndexBuffer{Indices: []uint32{0, 1, 2, 3}},
+	}
+}
+
+func TestMeshWriteOBJ(t *testing.T) {
+	ctx := log.Testing(t)
+
+	buf := &bytes.Buffer{}
+	err := newTestQuad().WriteOBJ(buf)
+	if !assert.For(ctx, "WriteOBJ").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "OBJ").ThatString(buf.String()).Equals(
+		"v 0 0 0 1 0 0\n" +
+			"v 0 1 0 0 1 0\n" +
+			"v 1 0 0 0 0 1\n" +
+			"v 1 1 0 1 1 1\n" +
+			"vt 0 0\n" +
+			"vt 0 1\n" +
+			"vt 1 0\n" +
+			"vt 1 1\n" +
+			"vn 0 0 1\n" +
+			"vn 0 0 1\n" +
+			"vn 0 0 1\n" +
+			"vn 0 0 1\n" +
+			"f 1/1/1 2/2/2 3/3/3\n" +
+			"f 4/4/4 3/3/3 2/2/2\n")
+}
+
+func TestMeshWriteGLB(t *testing.T) {
+	ctx := log.Testing(t)
+
+	buf := &bytes.Buffer{}
+	err := newTestQuad().WriteGLB(buf)
+	if !assert.For(ctx, "WriteGLB").ThatError(err).Succeeded() {
+		return
+	}
+
+	r := endian.Reader(bytes.NewReader(buf.Bytes()), device.LittleEndian)
+	assert.For(ctx, "magic").That(r.Uint32()).Equals(uint32(0x46546C67))
+	assert.For(ctx, "version").That(r.Uint32()).Equals(uint32(2))
+	assert.For(ctx, "length").That(r.Uint32()).Equals(uint32(buf.Len()))
+	js := make([]byte, r.Uint32())
+	assert.For(ctx, "JSON chunk type").That(r.Uint32()).Equals(uint32(0x4E4F534A))
+	r.Data(js)
+	bin := make([]byte, r.Uint32())
+	assert.For(ctx, "BIN chunk type").That(r.Uint32()).Equals(uint32(0x004E4942))
+	r.Data(bin)
+	if !assert.For(ctx, "Read").ThatError(r.Error()).Succeeded() {
+		return
+	}
+	assert.For(ctx, "JSON chunk alignment").That(len(js) % 4).Equals(0)
+
+	var doc struct {
+		Meshes []struct {
+			Primitives []struct {
+				Attributes map[string]int
+				Indices    int
+				Mode       int
+			}
+		}
+		Accessors []struct {
+			BufferView    int
+			ComponentType int
+			Count         int
+			Type          string
+			Min, Max      []float32
+		}
+		BufferViews []struct {
+			ByteOffset, ByteLength int
+		}
+		Buffers []struct {
+			ByteLength int
+		}
+	}
+	if err := json.Unmarshal(js, &doc); !assert.For(ctx, "Unmarshal").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "Buffer length").That(doc.Buffers[0].ByteLength).Equals(len(bin))
+
+	prim := doc.Meshes[0].Primitives[0]
+	assert.For(ctx, "Mode").That(prim.Mode).Equals(5)
+	assert.For(ctx, "Attributes").ThatMap(prim.Attributes).IsLength(4)
+	for _, name := range []string{"POSITION", "NORMAL", "TEXCOORD_0", "COLOR_0"} {
+		_, ok := prim.Attributes[name]
+		assert.For(ctx, "Has %s attribute", name).That(ok).Equals(true)
+	}
+
+	pos := doc.Accessors[prim.Attributes["POSITION"]]
+	assert.For(ctx, "Position type").That(pos.Type).Equals("VEC3")
+	assert.For(ctx, "Position count").That(pos.Count).Equals(4)
+	assert.For(ctx, "Position min").ThatSlice(pos.Min).Equals([]float32{0, 0, 0})
+	assert.For(ctx, "Position max").ThatSlice(pos.Max).Equals([]float32{1, 1, 0})
+
+	color := doc.Accessors[prim.Attributes["COLOR_0"]]
+	assert.For(ctx, "Color type").That(color.Type).Equals("VEC4")
+	view := doc.BufferViews[color.BufferView]
+	colors := endian.Reader(bytes.NewReader(bin[view.ByteOffset:]), device.LittleEndian)
+	got := make([]float32, 8)
+	for i := range got {
+		got[i] = colors.Float32()
+	}
+	assert.For(ctx, "Colors").ThatSlice(got).Equals([]float32{1, 0, 0, 1, 0, 1, 0, 1})
+
+	indices := doc.Accessors[prim.Indices]
+	assert.For(ctx, "Index type").That(indices.ComponentType).Equals(5125)
+	view = doc.BufferViews[indices.BufferView]
+	assert.For(ctx, "Index data").ThatSlice(bin[view.ByteOffset : view.ByteOffset+view.ByteLength]).
+		Equals([]byte{0, 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 3, 0, 0, 0})
+}
+
+func TestMeshWriteOBJRestart(t *testing.T) {
+	ctx := log.Testing(t)
+
+	// Two triangle strips separated by a 16-bit primitive restart index, with
+	// BGRA colors.
+	m := newTestQuad()
+	m.VertexBuffer.Streams[3].Format = fmts.BGRA_U8_NORM
+	m.IndexBuffer.Indices = []uint32{0, 1, 2, 0xffff, 1, 2, 3}
+
+	buf := &bytes.Buffer{}
+	err := m.WriteOBJ(buf)
+	if !assert.For(ctx, "WriteOBJ").ThatError(err).Succeeded() {
+		return
+	}
+	lines := strings.Split(buf.String(), "\n")
+	assert.For(ctx, "Vertices").ThatSlice(lines[:4]).Equals([]string{
+		"v 0 0 0 0 0 1",
+		"v 0 1 0 0 1 0",
+		"v 1 0 0 1 0 0",
+		"v 1 1 0 1 1 1",
+	})
+	assert.For(ctx, "Faces").ThatSlice(lines[12:]).Equals([]string{
+		"f 1/1/1 2/2/2 3/3/3",
+		"f 2/2/2 3/3/3 4/4/4",
+		"",
+	})
+
+	m.IndexBuffer.Indices = []uint32{0, 1, 2, 0xfffe}
+	err = m.WriteOBJ(&bytes.Buffer{})
+	assert.For(ctx, "WriteOBJ out of bounds").ThatError(err).Failed()
+}
+
+func TestMeshWriteGLBRestart(t *testing.T) {
+	ctx := log.Testing(t)
+
+	// The only texture coordinate set has the index 1, and the strip is split
+	// by an 8-bit primitive restart index.
+	m := newTestQuad()
+	m.VertexBuffer.Streams[2].Semantic.Index = 1
+	m.IndexBuffer.Indices = []uint32{0, 1, 2, 0xff, 1, 2, 3}
+
+	buf := &bytes.Buffer{}
+	err := m.WriteGLB(buf)
+	if !assert.For(ctx, "WriteGLB").ThatError(err).Succeeded() {
+		return
+	}
+	data := buf.Bytes()
+	js := data[20 : 20+binary.LittleEndian.Uint32(data[12:])]
+
+	var doc struct {
+		Meshes []struct {
+			Primitives []struct {
+				Attributes map[string]int
+				Indices    int
+			}
+		}
+		Accessors []struct {
+			Count int
+		}
+	}
+	if err := json.Unmarshal(js, &doc); !assert.For(ctx, "Unmarshal").ThatError(err).Succeeded() {
+		return
+	}
+	prims := doc.Meshes[0].Primitives
+	if !assert.For(ctx, "Primitives").ThatSlice(prims).IsLength(2) {
+		return
+	}
+	for i, prim := range prims {
+		_, ok := prim.Attributes["TEXCOORD_0"]
+		assert.For(ctx, "Primitive %d has TEXCOORD_0", i).That(ok).Equals(true)
+		assert.For(ctx, "Primitive %d index count", i).That(doc.Accessors[prim.Indices].Count).Equals(3)
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "context"
+
+// RedundantCallChecker is the interface implemented by APIs that can detect
+// calls that have no effect on the state, such as re-binding an object that
+// is already bound.
+type RedundantCallChecker interface {
+	// RedundantCall is called before cmd is mutated on s. If cmd may have no
+	// effect, RedundantCall returns a description of the call and a function
+	// to call after cmd has been mutated on s, which returns true if cmd did
+	// not change the state. If cmd has an effect, unchanged is nil.
+	RedundantCall(ctx context.Context, id CmdID, cmd Cmd, s *GlobalState) (reason string, unchanged func() bool)
+}
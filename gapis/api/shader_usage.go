@@ -0,0 +1,34 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "context"
+
+// ShaderUsageProvider is the interface implemented by APIs that can attribute
+// their draw calls to the programs or pipelines they use.
+type ShaderUsageProvider interface {
+	// DrawPrograms returns the program or pipeline resource used by each of
+	// the draw calls performed by the command cmd, in order. The state s is the
+	// state before cmd is mutated, with the reads of cmd applied.
+	DrawPrograms(ctx context.Context, id CmdID, cmd Cmd, s *GlobalState) []Resource
+
+	// LinkedProgram returns the program or pipeline resource whose stages are
+	// replaced by the command cmd, or nil if cmd does not link one.
+	LinkedProgram(ctx context.Context, id CmdID, cmd Cmd, s *GlobalState) Resource
+
+	// ProgramStages returns the shader stages linked into the program or
+	// pipeline resource p in the state s.
+	ProgramStages(ctx context.Context, p Resource, s *GlobalState) ([]*Stage, error)
+}
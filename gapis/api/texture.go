@@ -275,3 +275,93 @@ func NewTexture(t interface{}) *Texture {
 		panic(fmt.Errorf("%T is not a Texture type", t))
 	}
 }
+
+// ImageTexture returns the texture as an image.Texture holding every
+// mip-level, array layer and cube-map face. get is called to load the data of
+// each image.
+func (t *Texture) ImageTexture(get func(*image.Info) ([]byte, error)) (*image.Texture, error) {
+	// cube returns the faces of l in the +X, -X, +Y, -Y, +Z, -Z order used by
+	// image.Texture.
+	cube := func(l *CubemapLevel) []*image.Info {
+		return []*image.Info{l.PositiveX, l.NegativeX, l.PositiveY, l.NegativeY, l.PositiveZ, l.NegativeZ}
+	}
+
+	// levels is indexed by level, then by layer and face.
+	var levels [][]*image.Info
+	add := func(level int, imgs ...*image.Info) {
+		for len(levels) <= level {
+			levels = append(levels, nil)
+		}
+		levels[level] = append(levels[level], imgs...)
+	}
+	out := &image.Texture{Faces: 1}
+	switch t := protoutil.OneOf(t.Type).(type) {
+	case *Texture1D:
+		for l, i := range t.Levels {
+			add(l, i)
+		}
+	case *Texture2D:
+		for l, i := range t.Levels {
+			add(l, i)
+		}
+	case *Texture3D:
+		for l, i := range t.Levels {
+			add(l, i)
+		}
+	case *Cubemap:
+		out.Faces = 6
+		for l, faces := range t.Levels {
+			add(l, cube(faces)...)
+		}
+	case *Texture1DArray:
+		out.Layers = uint32(len(t.Layers))
+		for _, layer := range t.Layers {
+			for l, i := range layer.Levels {
+				add(l, i)
+			}
+		}
+	case *Texture2DArray:
+		out.Layers = uint32(len(t.Layers))
+		for _, layer := range t.Layers {
+			for l, i := range layer.Levels {
+				add(l, i)
+			}
+		}
+	case *CubemapArray:
+		out.Layers, out.Faces = uint32(len(t.Layers)), 6
+		for _, layer := range t.Layers {
+			for l, faces := range layer.Levels {
+				add(l, cube(faces)...)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported texture type %T", t)
+	}
+	if len(levels) == 0 || len(levels[0]) == 0 || levels[0][0] == nil {
+		return nil, fmt.Errorf("Texture has no images")
+	}
+
+	base := levels[0][0]
+	out.Format, out.Width, out.Height, out.Depth = base.Format, base.Width, base.Height, base.Depth
+	out.Levels = make([][]*image.Data, len(levels))
+	for l, infos := range levels {
+		out.Levels[l] = make([]*image.Data, len(infos))
+		for i, info := range infos {
+			if info == nil {
+				return nil, fmt.Errorf("Texture is missing image %d of mip-level %d", i, l)
+			}
+			data, err := get(info)
+			if err != nil {
+				return nil, err
+			}
+			out.Levels[l][i] = &image.Data{
+				Format: info.Format,
+				Width:  info.Width,
+				Height: info.Height,
+				Depth:  info.Depth,
+				Bytes:  data,
+			}
+		}
+	}
+	return out, out.Check()
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+func TestCubemapArrayImageTexture(t *testing.T) {
+	ctx := log.Testing(t)
+
+	// Each image's data is a single RGBA texel holding the image's index.
+	data := map[*image.Info][]byte{}
+	info := func(size uint32) *image.Info {
+		i := &image.Info{Format: image.RGBA_U8_NORM, Width: size, Height: size, Depth: 1}
+		data[i] = make([]byte, size*size*4)
+		data[i][0] = byte(len(data))
+		return i
+	}
+	level := func(size uint32) *api.CubemapLevel {
+		return &api.CubemapLevel{
+			NegativeX: info(size), PositiveX: info(size),
+			NegativeY: info(size), PositiveY: info(size),
+			NegativeZ: info(size), PositiveZ: info(size),
+		}
+	}
+	cubes := &api.CubemapArray{Layers: []*api.Cubemap{
+		{Levels: []*api.CubemapLevel{level(2), level(1)}},
+		{Levels: []*api.CubemapLevel{level(2), level(1)}},
+	}}
+
+	tex, err := api.NewTexture(cubes).ImageTexture(func(i *image.Info) ([]byte, error) {
+		return data[i], nil
+	})
+	if !assert.For(ctx, "ImageTexture").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "Width").That(tex.Width).Equals(uint32(2))
+	assert.For(ctx, "Layers").That(tex.Layers).Equals(uint32(2))
+	assert.For(ctx, "Faces").That(tex.Faces).Equals(uint32(6))
+	assert.For(ctx, "Levels").That(len(tex.Levels)).Equals(2)
+
+	for layer, cube := range cubes.Layers {
+		for l, faces := range cube.Levels {
+			for face, expected := range []*image.Info{
+				faces.PositiveX, faces.NegativeX,
+				faces.PositiveY, faces.NegativeY,
+				faces.PositiveZ, faces.NegativeZ,
+			} {
+				got := tex.Image(l, layer, face).Bytes
+				assert.For(ctx, "Layer %d level %d face %d", layer, l, face).
+					That(got).DeepEquals(data[expected])
+			}
+		}
+	}
+}
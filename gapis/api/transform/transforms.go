@@ -27,6 +27,17 @@ type Transforms []Transformer
 // Transform sequentially transforms the commands by each of the transformers in
 // the list, before writing the final output to the output command Writer.
 func (l Transforms) Transform(ctx context.Context, cmds []api.Cmd, out Writer) {
+	l.TransformEach(ctx, func(ctx context.Context, cb func(context.Context, api.CmdID, api.Cmd) error) error {
+		return api.ForeachCmd(ctx, cmds, cb)
+	}, out)
+}
+
+// TransformEach is like Transform, but the commands are passed to the
+// transformers by foreach, which calls cb with each command in order. This
+// allows the commands to be decoded as they are transformed, for instance with
+// capture.Capture.ForeachCmd. TransformEach returns the error returned by
+// foreach, after flushing the transformers.
+func (l Transforms) TransformEach(ctx context.Context, foreach func(context.Context, func(context.Context, api.CmdID, api.Cmd) error) error, out Writer) error {
 	chain := out
 	for i := len(l) - 1; i >= 0; i-- {
 		s := out.State()
@@ -35,7 +46,7 @@ func (l Transforms) Transform(ctx context.Context, cmds []api.Cmd, out Writer) {
 		}
 		chain = TransformWriter{s, l[i], chain}
 	}
-	api.ForeachCmd(ctx, cmds, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+	err := foreach(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
 		chain.MutateAndWrite(ctx, id, cmd)
 		return nil
 	})
@@ -43,6 +54,7 @@ func (l Transforms) Transform(ctx context.Context, cmds []api.Cmd, out Writer) {
 		chain = p.O
 		p.T.Flush(ctx, chain)
 	}
+	return err
 }
 
 // Add is a convenience function for appending the list of Transformers t to the
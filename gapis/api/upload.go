@@ -0,0 +1,32 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "context"
+
+// Upload is an amount of data moved to a resource by a command.
+type Upload struct {
+	Kind  string // The kind of the resource, such as "Buffer" or "VkImage".
+	Name  string // The name of the resource used for display.
+	Bytes uint64 // The number of bytes moved to the resource.
+}
+
+// UploadProvider is the interface implemented by APIs that can attribute the
+// data moved by commands to the resources they write.
+type UploadProvider interface {
+	// Uploads returns the data moved by the command cmd. The state s is the
+	// state before cmd is mutated, with the reads of cmd applied.
+	Uploads(ctx context.Context, id CmdID, cmd Cmd, s *GlobalState) []Upload
+}
@@ -0,0 +1,47 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// remappable is the interface implemented by the handle types that are
+// remapped on replay.
+type remappable interface {
+	remap(api.Cmd, *api.GlobalState) (key interface{}, remap bool)
+}
+
+// CmdHandles implements the api.CmdHandlesProvider interface.
+func (API) CmdHandles(ctx context.Context, cmd api.Cmd, s *api.GlobalState) []interface{} {
+	out := []interface{}{}
+	add := func(p *api.Property) {
+		if p == nil {
+			return
+		}
+		if v, ok := p.Get().(remappable); ok {
+			if key, remap := v.remap(cmd, s); remap {
+				out = append(out, key)
+			}
+		}
+	}
+	for _, p := range cmd.CmdParams() {
+		add(p)
+	}
+	add(cmd.CmdResult())
+	return out
+}
@@ -0,0 +1,171 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+// Interface compliance test
+var (
+	_ = api.CmdStatsProvider(API{})
+)
+
+// CmdStats implements the api.CmdStatsProvider interface. The commands
+// recorded to command buffers are counted when the command buffers are
+// submitted. Writes to mapped memory are counted as buffer uploads when they
+// are flushed.
+func (API) CmdStats(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState) api.CmdStats {
+	out := api.CmdStats{}
+	st := GetState(s)
+
+	switch cmd := cmd.(type) {
+	case *VkFlushMappedMemoryRanges:
+		count := uint64(cmd.MemoryRangeCount())
+		rngs, err := cmd.PMemoryRanges().Slice(0, count, s.MemoryLayout).Read(ctx, cmd, s, nil)
+		if err != nil {
+			log.W(ctx, "Failed to read the memory ranges flushed by %v: %v", cmd, err)
+			return out
+		}
+		for _, rng := range rngs {
+			out.BufferUploadBytes += mappedRangeSize(st, rng)
+		}
+
+	case *VkQueueSubmit:
+		// The graphics pipeline and index buffer bound in each command buffer.
+		pipelines := map[VkCommandBuffer]GraphicsPipelineObjectʳ{}
+		indexBuffers := map[VkCommandBuffer]VkCmdBindIndexBufferArgsʳ{}
+
+		err := foreachSubmittedCommand(ctx, cmd, s, func(cb CommandBufferObjectʳ, cr CommandReferenceʳ) {
+			cmds, handle := cb.BufferCommands(), cb.VulkanHandle()
+			switch cr.Type() {
+			case CommandType_cmd_vkCmdBindPipeline:
+				args := cmds.VkCmdBindPipeline().Get(cr.MapIndex())
+				if args.PipelineBindPoint() == VkPipelineBindPoint_VK_PIPELINE_BIND_POINT_GRAPHICS {
+					pipelines[handle] = st.GraphicsPipelines().Get(args.Pipeline())
+				}
+				out.ProgramSwitches++
+			case CommandType_cmd_vkCmdBeginRenderPass:
+				out.RenderPasses++
+			case CommandType_cmd_vkCmdBindIndexBuffer:
+				indexBuffers[handle] = cmds.VkCmdBindIndexBuffer().Get(cr.MapIndex())
+				out.StateChanges++
+			case CommandType_cmd_vkCmdSetViewport,
+				CommandType_cmd_vkCmdSetScissor,
+				CommandType_cmd_vkCmdSetLineWidth,
+				CommandType_cmd_vkCmdSetDepthBias,
+				CommandType_cmd_vkCmdSetBlendConstants,
+				CommandType_cmd_vkCmdSetDepthBounds,
+				CommandType_cmd_vkCmdSetStencilCompareMask,
+				CommandType_cmd_vkCmdSetStencilWriteMask,
+				CommandType_cmd_vkCmdSetStencilReference,
+				CommandType_cmd_vkCmdBindDescriptorSets,
+				CommandType_cmd_vkCmdBindVertexBuffers,
+				CommandType_cmd_vkCmdPushConstants:
+				out.StateChanges++
+
+			case CommandType_cmd_vkCmdDraw:
+				p := pipelines[handle]
+				if p.IsNil() {
+					break
+				}
+				args := cmds.VkCmdDraw().Get(cr.MapIndex())
+				out.AddDraw(translateTopology(p.InputAssemblyState().Topology()), args.VertexCount(), nil)
+			case CommandType_cmd_vkCmdDrawIndexed:
+				p, ib := pipelines[handle], indexBuffers[handle]
+				if p.IsNil() || ib.IsNil() {
+					break
+				}
+				buf := st.Buffers().Get(ib.Buffer())
+				if buf.IsNil() {
+					break
+				}
+				args := cmds.VkCmdDrawIndexed().Get(cr.MapIndex())
+				indices, err := getIndicesData(ctx, s, cmd.Thread(), buf, ib.Offset(), ib.IndexType(),
+					args.IndexCount(), args.FirstIndex(), args.VertexOffset())
+				if err != nil {
+					log.W(ctx, "Failed to read the indices of a draw submitted by %v: %v", cmd, err)
+					break
+				}
+				out.AddDraw(translateTopology(p.InputAssemblyState().Topology()), args.IndexCount(), indices)
+
+			case CommandType_cmd_vkCmdUpdateBuffer:
+				args := cmds.VkCmdUpdateBuffer().Get(cr.MapIndex())
+				out.BufferUploadBytes += uint64(args.DataSize())
+			case CommandType_cmd_vkCmdCopyBuffer:
+				args := cmds.VkCmdCopyBuffer().Get(cr.MapIndex())
+				for _, region := range args.CopyRegions().All() {
+					out.BufferUploadBytes += uint64(region.Size())
+				}
+			case CommandType_cmd_vkCmdCopyBufferToImage:
+				args := cmds.VkCmdCopyBufferToImage().Get(cr.MapIndex())
+				if bytes, ok := bufferToImageBytes(ctx, cmd, s, args); ok {
+					out.TextureUploadBytes += bytes
+				}
+			}
+		})
+		if err != nil {
+			log.W(ctx, "Failed to read the command buffers submitted by %v: %v", cmd, err)
+		}
+	}
+	return out
+}
+
+// mappedRangeSize returns the number of bytes of the flushed mapped memory
+// range rng, resolving VK_WHOLE_SIZE with the mapping of the memory.
+func mappedRangeSize(st *State, rng VkMappedMemoryRange) uint64 {
+	size := uint64(rng.Size())
+	if size != vkWholeSize {
+		return size
+	}
+	mem := st.DeviceMemories().Get(rng.Memory())
+	if mem.IsNil() {
+		return 0
+	}
+	end := uint64(mem.MappedOffset()) + uint64(mem.MappedSize())
+	if end <= uint64(rng.Offset()) {
+		return 0
+	}
+	return end - uint64(rng.Offset())
+}
+
+// bufferToImageBytes returns the number of bytes copied by the recorded
+// vkCmdCopyBufferToImage with the arguments args, executed by the command
+// cmd. Depth and stencil aspects are estimated with the element size of the
+// image format.
+func bufferToImageBytes(ctx context.Context, cmd api.Cmd, s *api.GlobalState, args VkCmdCopyBufferToImageArgsʳ) (uint64, bool) {
+	img := GetState(s).Images().Get(args.DstImage())
+	if img.IsNil() {
+		return 0, false
+	}
+	info, err := subGetElementAndTexelBlockSize(ctx, nil, api.CmdNoID, nil, s, GetState(s), cmd.Thread(), nil, img.Info().Fmt())
+	if err != nil {
+		return 0, false
+	}
+	blockWidth := uint64(info.TexelBlockSize().Width())
+	blockHeight := uint64(info.TexelBlockSize().Height())
+	bytes := uint64(0)
+	for _, region := range args.Regions().All() {
+		extent := region.ImageExtent()
+		width := (uint64(extent.Width()) + blockWidth - 1) / blockWidth
+		height := (uint64(extent.Height()) + blockHeight - 1) / blockHeight
+		layers := uint64(region.ImageSubresource().LayerCount())
+		bytes += width * height * uint64(extent.Depth()) * layers * uint64(info.ElementSize())
+	}
+	return bytes, true
+}
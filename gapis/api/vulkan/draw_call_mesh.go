@@ -56,23 +56,7 @@ func drawCallMesh(ctx context.Context, dc *VkQueueSubmit, p *path.Mesh, r *path.
 	if lastDrawInfo.GraphicsPipeline().IsNil() {
 		return nil, fmt.Errorf("Cannot find last used graphics pipeline")
 	}
-	drawPrimitive := func() api.DrawPrimitive {
-		switch lastDrawInfo.GraphicsPipeline().InputAssemblyState().Topology() {
-		case VkPrimitiveTopology_VK_PRIMITIVE_TOPOLOGY_POINT_LIST:
-			return api.DrawPrimitive_Points
-		case VkPrimitiveTopology_VK_PRIMITIVE_TOPOLOGY_LINE_LIST:
-			return api.DrawPrimitive_Lines
-		case VkPrimitiveTopology_VK_PRIMITIVE_TOPOLOGY_LINE_STRIP:
-			return api.DrawPrimitive_LineStrip
-		case VkPrimitiveTopology_VK_PRIMITIVE_TOPOLOGY_TRIANGLE_LIST:
-			return api.DrawPrimitive_Triangles
-		case VkPrimitiveTopology_VK_PRIMITIVE_TOPOLOGY_TRIANGLE_STRIP:
-			return api.DrawPrimitive_TriangleStrip
-		case VkPrimitiveTopology_VK_PRIMITIVE_TOPOLOGY_TRIANGLE_FAN:
-			return api.DrawPrimitive_TriangleFan
-		}
-		return api.DrawPrimitive_Points
-	}()
+	drawPrimitive := translateTopology(lastDrawInfo.GraphicsPipeline().InputAssemblyState().Topology())
 
 	// Index buffer
 	ib := &api.IndexBuffer{}
@@ -114,7 +98,9 @@ func drawCallMesh(ctx context.Context, dc *VkQueueSubmit, p *path.Mesh, r *path.
 
 		var indices []uint32
 		if !noData {
-			indices, err = getIndicesData(ctx, s, dc.Thread(), lastDrawInfo.BoundIndexBuffer(), p.IndexCount(), p.FirstIndex(), p.VertexOffset())
+			bound := lastDrawInfo.BoundIndexBuffer()
+			indices, err = getIndicesData(ctx, s, dc.Thread(), bound.BoundBuffer().Buffer(), bound.BoundBuffer().Offset(), bound.Type(),
+				p.IndexCount(), p.FirstIndex(), p.VertexOffset())
 			if err != nil {
 				return nil, err
 			}
@@ -174,8 +160,27 @@ func drawCallMesh(ctx context.Context, dc *VkQueueSubmit, p *path.Mesh, r *path.
 	return mesh, nil
 }
 
-func getIndicesData(ctx context.Context, s *api.GlobalState, thread uint64, boundIndexBuffer BoundIndexBufferʳ, indexCount, firstIndex uint32, vertexOffset int32) ([]uint32, error) {
-	backingMem := boundIndexBuffer.BoundBuffer().Buffer().Memory()
+// translateTopology returns the draw primitive of the primitive topology t.
+func translateTopology(t VkPrimitiveTopology) api.DrawPrimitive {
+	switch t {
+	case VkPrimitiveTopology_VK_PRIMITIVE_TOPOLOGY_POINT_LIST:
+		return api.DrawPrimitive_Points
+	case VkPrimitiveTopology_VK_PRIMITIVE_TOPOLOGY_LINE_LIST:
+		return api.DrawPrimitive_Lines
+	case VkPrimitiveTopology_VK_PRIMITIVE_TOPOLOGY_LINE_STRIP:
+		return api.DrawPrimitive_LineStrip
+	case VkPrimitiveTopology_VK_PRIMITIVE_TOPOLOGY_TRIANGLE_LIST:
+		return api.DrawPrimitive_Triangles
+	case VkPrimitiveTopology_VK_PRIMITIVE_TOPOLOGY_TRIANGLE_STRIP:
+		return api.DrawPrimitive_TriangleStrip
+	case VkPrimitiveTopology_VK_PRIMITIVE_TOPOLOGY_TRIANGLE_FAN:
+		return api.DrawPrimitive_TriangleFan
+	}
+	return api.DrawPrimitive_Points
+}
+
+func getIndicesData(ctx context.Context, s *api.GlobalState, thread uint64, buffer BufferObjectʳ, offset VkDeviceSize, indexType VkIndexType, indexCount, firstIndex uint32, vertexOffset int32) ([]uint32, error) {
+	backingMem := buffer.Memory()
 	if backingMem.IsNil() {
 		return []uint32{}, nil
 	}
@@ -185,8 +190,8 @@ func getIndicesData(ctx context.Context, s *api.GlobalState, thread uint64, boun
 		size := uint64(indexCount) * sizeOfIndex
 
 		backingMemoryPieces, err := subGetBufferBoundMemoryPiecesInRange(
-			ctx, nil, api.CmdNoID, nil, s, nil, thread, nil, boundIndexBuffer.BoundBuffer().Buffer(),
-			boundIndexBuffer.BoundBuffer().Offset()+VkDeviceSize(uint64(firstIndex)*sizeOfIndex),
+			ctx, nil, api.CmdNoID, nil, s, nil, thread, nil, buffer,
+			offset+VkDeviceSize(uint64(firstIndex)*sizeOfIndex),
 			VkDeviceSize(size))
 		if err != nil {
 			return []uint32{}, err
@@ -228,7 +233,7 @@ func getIndicesData(ctx context.Context, s *api.GlobalState, thread uint64, boun
 		return indices, nil
 	}
 
-	switch boundIndexBuffer.Type() {
+	switch indexType {
 	case VkIndexType_VK_INDEX_TYPE_UINT16:
 		return extractIndices(2)
 	case VkIndexType_VK_INDEX_TYPE_UINT32:
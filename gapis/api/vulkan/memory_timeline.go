@@ -0,0 +1,55 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"strconv"
+
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// Interface compliance test
+var (
+	_ = api.MemoryTimelineProvider(API{})
+)
+
+// ChangesMemoryObjects implements the api.MemoryTimelineProvider interface.
+func (API) ChangesMemoryObjects(cmd api.Cmd) bool {
+	switch cmd.(type) {
+	case *VkAllocateMemory, *VkFreeMemory, *VkDestroyDevice:
+		return true
+	default:
+		return false
+	}
+}
+
+// MemoryObjects implements the api.MemoryTimelineProvider interface, listing
+// the device memory allocations.
+func (API) MemoryObjects(st *api.GlobalState) map[interface{}]*api.MemoryObject {
+	apiPath := path.NewAPI(id.ID(ID))
+	out := map[interface{}]*api.MemoryObject{}
+	for handle, info := range GetState(st).DeviceMemories().All() {
+		out[info] = &api.MemoryObject{
+			API:    apiPath,
+			Kind:   "VkDeviceMemory",
+			Handle: uint64(handle),
+			Name:   strconv.FormatUint(uint64(handle), 10),
+			Size:   uint64(info.AllocationSize()),
+		}
+	}
+	return out
+}
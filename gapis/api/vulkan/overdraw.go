@@ -55,8 +55,14 @@ func (s *stencilOverdraw) add(ctx context.Context, extraCommands uint64, after [
 		return
 	}
 	for lastSubmit := int64(after[0]); lastSubmit >= 0; lastSubmit-- {
-		switch (c.Commands[lastSubmit]).(type) {
-		case *VkQueueSubmit:
+		cmd, release, err := c.Cmd(ctx, api.CmdID(lastSubmit))
+		if err != nil {
+			res(nil, err)
+			return
+		}
+		_, isSubmit := cmd.(*VkQueueSubmit)
+		release()
+		if isSubmit {
 			id := api.CmdID(uint64(lastSubmit) + extraCommands)
 			s.rewrite[id] = res
 			s.lastSubIdx[id] = api.SubCmdIdx(after[1:])
@@ -0,0 +1,91 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// RedundantCall implements the api.RedundantCallChecker interface.
+func (API) RedundantCall(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState) (string, func() bool) {
+	switch cmd := cmd.(type) {
+	case *VkCmdBindDescriptorSets:
+		if redundantDescriptorSetsBind(ctx, cmd, s) {
+			return fmt.Sprintf("the descriptor sets from set %v are already bound with the same layout and dynamic offsets",
+				cmd.FirstSet()), func() bool { return true }
+		}
+	}
+	return "", nil
+}
+
+// redundantDescriptorSetsBind returns true if the command buffer of cmd has
+// already recorded a binding of the same descriptor sets, layout and dynamic
+// offsets since the last pipeline binding.
+func redundantDescriptorSetsBind(ctx context.Context, cmd *VkCmdBindDescriptorSets, s *api.GlobalState) bool {
+	cb, ok := GetState(s).CommandBuffers().Lookup(cmd.CommandBuffer())
+	if !ok {
+		return false
+	}
+
+	cmd.Extras().Observations().ApplyReads(s.Memory.ApplicationPool())
+	sets, err := cmd.PDescriptorSets().Slice(0, uint64(cmd.DescriptorSetCount()), s.MemoryLayout).Read(ctx, cmd, s, nil)
+	if err != nil {
+		return false
+	}
+	offsets, err := cmd.PDynamicOffsets().Slice(0, uint64(cmd.DynamicOffsetCount()), s.MemoryLayout).Read(ctx, cmd, s, nil)
+	if err != nil {
+		return false
+	}
+	first, last := cmd.FirstSet(), cmd.FirstSet()+cmd.DescriptorSetCount()
+
+	for k := cb.CommandReferences().Len() - 1; k >= 0; k-- {
+		cr := cb.CommandReferences().Get(uint32(k))
+		switch cr.Type() {
+		case CommandType_cmd_vkCmdBindPipeline:
+			// A pipeline with an incompatible layout may disturb the bound
+			// descriptor sets.
+			return false
+		case CommandType_cmd_vkCmdBindDescriptorSets:
+			args := cb.BufferCommands().VkCmdBindDescriptorSets().Get(cr.MapIndex())
+			if args.PipelineBindPoint() != cmd.PipelineBindPoint() {
+				continue
+			}
+			argsFirst := args.FirstSet()
+			argsLast := argsFirst + uint32(args.DescriptorSets().Len())
+			if argsLast <= first || argsFirst >= last {
+				continue // Binds other sets.
+			}
+			if args.Layout() != cmd.Layout() || argsFirst != first || argsLast != last ||
+				args.DynamicOffsets().Len() != len(offsets) {
+				return false
+			}
+			for i, set := range sets {
+				if args.DescriptorSets().Get(uint32(i)) != set {
+					return false
+				}
+			}
+			for i, offset := range offsets {
+				if args.DynamicOffsets().Get(uint32(i)) != offset {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
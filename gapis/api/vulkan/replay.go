@@ -514,7 +514,9 @@ func (a API) Replay(
 
 	optimize := !config.DisableDeadCodeElimination
 
-	cmds := capture.Commands
+	// The commands to replay are cmds followed, if replayCapture is true, by
+	// the commands of the capture, which are decoded as they are replayed.
+	cmds, replayCapture := []api.Cmd{}, true
 
 	transforms := transform.Transforms{}
 	transforms.Add(&makeAttachementReadable{})
@@ -554,7 +556,7 @@ func (a API) Replay(
 				dceInfo.ft = ft
 				dceInfo.dce = dependencygraph.NewDCE(ctx, dceInfo.ft)
 			}
-			cmds = []api.Cmd{}
+			cmds, replayCapture = []api.Cmd{}, false
 			numInitialCmdWithOpt = dceInfo.ft.NumInitialCommands
 			initCmdExpandedWithOpt = true
 			return numInitialCmdWithOpt, nil
@@ -679,7 +681,11 @@ func (a API) Replay(
 	transforms.Add(&destroyResourcesAtEOS{})
 
 	if config.DebugReplay {
-		log.I(ctx, "Replaying %d commands using transform chain:", len(cmds))
+		numCmds := uint64(len(cmds))
+		if replayCapture {
+			numCmds += capture.NumCommands()
+		}
+		log.I(ctx, "Replaying %d commands using transform chain:", numCmds)
 		for i, t := range transforms {
 			log.I(ctx, "(%d) %#v", i, t)
 		}
@@ -708,8 +714,15 @@ func (a API) Replay(
 		transforms.Add(replay.NewMappingPrinter(ctx, "mappings.txt"))
 	}
 
-	transforms.Transform(ctx, cmds, out)
-	return nil
+	return transforms.TransformEach(ctx, func(ctx context.Context, cb func(context.Context, api.CmdID, api.Cmd) error) error {
+		if err := api.ForeachCmd(ctx, cmds, cb); err != nil || !replayCapture {
+			return err
+		}
+		offset := api.CmdID(len(cmds))
+		return capture.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+			return cb(ctx, offset+id, cmd)
+		})
+	}, out)
 }
 
 func (a API) QueryFramebufferAttachment(
@@ -354,25 +354,25 @@ func getImageFormatFromVulkanFormat(vkfmt VkFormat) (*image.Format, error) {
 	case VkFormat_VK_FORMAT_BC5_SNORM_BLOCK:
 		return image.NewRGTC2_BC5_RG_S8_NORM("VK_FORMAT_BC5_SNORM_BLOCK"), nil
 	case VkFormat_VK_FORMAT_BC6H_UFLOAT_BLOCK:
-		return nil, &unsupportedVulkanFormatError{Format: vkfmt}
+		return image.NewBPTC_RGB_UF16("VK_FORMAT_BC6H_UFLOAT_BLOCK"), nil
 	case VkFormat_VK_FORMAT_BC6H_SFLOAT_BLOCK:
-		return nil, &unsupportedVulkanFormatError{Format: vkfmt}
+		return image.NewBPTC_RGB_SF16("VK_FORMAT_BC6H_SFLOAT_BLOCK"), nil
 	case VkFormat_VK_FORMAT_BC7_UNORM_BLOCK:
-		return nil, &unsupportedVulkanFormatError{Format: vkfmt}
+		return image.NewBPTC_RGBA_U8_NORM("VK_FORMAT_BC7_UNORM_BLOCK"), nil
 	case VkFormat_VK_FORMAT_BC7_SRGB_BLOCK:
-		return nil, &unsupportedVulkanFormatError{Format: vkfmt}
+		return image.NewBPTC_SRGBA_U8_NORM("VK_FORMAT_BC7_SRGB_BLOCK"), nil
 	case VkFormat_VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK:
 		return image.NewETC2_RGB_U8_NORM("VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK"), nil
 	case VkFormat_VK_FORMAT_ETC2_R8G8B8_SRGB_BLOCK:
-		return image.NewETC2_RGB_U8_NORM("VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK"), nil
+		return image.NewETC2_SRGB_U8_NORM("VK_FORMAT_ETC2_R8G8B8_SRGB_BLOCK"), nil
 	case VkFormat_VK_FORMAT_ETC2_R8G8B8A1_UNORM_BLOCK:
 		return image.NewETC2_RGBA_U8U8U8U1_NORM("VK_FORMAT_ETC2_R8G8B8A1_UNORM_BLOCK"), nil
 	case VkFormat_VK_FORMAT_ETC2_R8G8B8A1_SRGB_BLOCK:
-		return image.NewETC2_RGBA_U8U8U8U1_NORM("VK_FORMAT_ETC2_R8G8B8A1_UNORM_BLOCK"), nil
+		return image.NewETC2_SRGBA_U8U8U8U1_NORM("VK_FORMAT_ETC2_R8G8B8A1_SRGB_BLOCK"), nil
 	case VkFormat_VK_FORMAT_ETC2_R8G8B8A8_UNORM_BLOCK:
-		return image.NewETC2_SRGBA_U8_NORM("VK_FORMAT_ETC2_R8G8B8A8_UNORM_BLOCK"), nil
+		return image.NewETC2_RGBA_U8_NORM("VK_FORMAT_ETC2_R8G8B8A8_UNORM_BLOCK"), nil
 	case VkFormat_VK_FORMAT_ETC2_R8G8B8A8_SRGB_BLOCK:
-		return image.NewETC2_SRGBA_U8_NORM("VK_FORMAT_ETC2_R8G8B8A8_UNORM_BLOCK"), nil
+		return image.NewETC2_SRGBA_U8_NORM("VK_FORMAT_ETC2_R8G8B8A8_SRGB_BLOCK"), nil
 	case VkFormat_VK_FORMAT_EAC_R11_UNORM_BLOCK:
 		return image.NewETC2_R_U11_NORM("VK_FORMAT_EAC_R11_UNORM_BLOCK"), nil
 	case VkFormat_VK_FORMAT_EAC_R11_SNORM_BLOCK:
@@ -437,6 +437,22 @@ func getImageFormatFromVulkanFormat(vkfmt VkFormat) (*image.Format, error) {
 		return astc.NewRGBA_12x12("VK_FORMAT_ASTC_12x12_UNORM_BLOCK"), nil
 	case VkFormat_VK_FORMAT_ASTC_12x12_SRGB_BLOCK:
 		return astc.NewRGBA_12x12("VK_FORMAT_ASTC_12x12_SRGB_BLOCK"), nil
+	case VkFormat_VK_FORMAT_PVRTC1_2BPP_UNORM_BLOCK_IMG:
+		return image.NewPVRTC("VK_FORMAT_PVRTC1_2BPP_UNORM_BLOCK_IMG", 2, 1, true, false), nil
+	case VkFormat_VK_FORMAT_PVRTC1_2BPP_SRGB_BLOCK_IMG:
+		return image.NewPVRTC("VK_FORMAT_PVRTC1_2BPP_SRGB_BLOCK_IMG", 2, 1, true, true), nil
+	case VkFormat_VK_FORMAT_PVRTC1_4BPP_UNORM_BLOCK_IMG:
+		return image.NewPVRTC("VK_FORMAT_PVRTC1_4BPP_UNORM_BLOCK_IMG", 4, 1, true, false), nil
+	case VkFormat_VK_FORMAT_PVRTC1_4BPP_SRGB_BLOCK_IMG:
+		return image.NewPVRTC("VK_FORMAT_PVRTC1_4BPP_SRGB_BLOCK_IMG", 4, 1, true, true), nil
+	case VkFormat_VK_FORMAT_PVRTC2_2BPP_UNORM_BLOCK_IMG:
+		return image.NewPVRTC("VK_FORMAT_PVRTC2_2BPP_UNORM_BLOCK_IMG", 2, 2, true, false), nil
+	case VkFormat_VK_FORMAT_PVRTC2_2BPP_SRGB_BLOCK_IMG:
+		return image.NewPVRTC("VK_FORMAT_PVRTC2_2BPP_SRGB_BLOCK_IMG", 2, 2, true, true), nil
+	case VkFormat_VK_FORMAT_PVRTC2_4BPP_UNORM_BLOCK_IMG:
+		return image.NewPVRTC("VK_FORMAT_PVRTC2_4BPP_UNORM_BLOCK_IMG", 4, 2, true, false), nil
+	case VkFormat_VK_FORMAT_PVRTC2_4BPP_SRGB_BLOCK_IMG:
+		return image.NewPVRTC("VK_FORMAT_PVRTC2_4BPP_SRGB_BLOCK_IMG", 4, 2, true, true), nil
 	case VkFormat_VK_FORMAT_D32_SFLOAT_S8_UINT:
 		return image.NewUncompressed("VK_FORMAT_D32_SFLOAT_S8_UINT", fmts.DS_F32U8), nil
 	case VkFormat_VK_FORMAT_D32_SFLOAT:
@@ -826,10 +842,16 @@ func (shader ShaderModuleObjectʳ) SetResourceData(
 	}
 	for j := index; j >= 0; j-- {
 		i := resource.Accesses[j].Indices[0] // TODO: Subcommands
-		if cmd, ok := c.Commands[i].(*VkCreateShaderModule); ok {
+		cmd, release, err := c.Cmd(ctx, api.CmdID(i))
+		if err != nil {
+			return err
+		}
+		if cmd, ok := cmd.(*VkCreateShaderModule); ok {
 			edits(uint64(i), cmd.Replace(ctx, c, data))
+			release()
 			return nil
 		}
+		release()
 	}
 	return fmt.Errorf("No command to set data in")
 }
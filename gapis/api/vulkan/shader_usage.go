@@ -0,0 +1,104 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+// Interface compliance test
+var (
+	_ = api.ShaderUsageProvider(API{})
+)
+
+// DrawPrograms implements the api.ShaderUsageProvider interface. The draw
+// calls recorded to command buffers are attributed to the vkQueueSubmit that
+// executes them, and to the last graphics pipeline bound in their command
+// buffer.
+func (API) DrawPrograms(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState) []api.Resource {
+	submit, ok := cmd.(*VkQueueSubmit)
+	if !ok {
+		return nil
+	}
+	st := GetState(s)
+	out := []api.Resource{}
+	bound := map[VkCommandBuffer]VkPipeline{}
+	err := foreachSubmittedCommand(ctx, submit, s, func(cb CommandBufferObjectʳ, cr CommandReferenceʳ) {
+		switch cr.Type() {
+		case CommandType_cmd_vkCmdBindPipeline:
+			args := cb.BufferCommands().VkCmdBindPipeline().Get(cr.MapIndex())
+			if args.PipelineBindPoint() == VkPipelineBindPoint_VK_PIPELINE_BIND_POINT_GRAPHICS {
+				bound[cb.VulkanHandle()] = args.Pipeline()
+			}
+		case CommandType_cmd_vkCmdDraw,
+			CommandType_cmd_vkCmdDrawIndexed,
+			CommandType_cmd_vkCmdDrawIndirect,
+			CommandType_cmd_vkCmdDrawIndexedIndirect:
+			pipeline, ok := bound[cb.VulkanHandle()]
+			if !ok {
+				return
+			}
+			if p, ok := st.GraphicsPipelines().Lookup(pipeline); ok {
+				out = append(out, p)
+			}
+		}
+	})
+	if err != nil {
+		log.W(ctx, "Failed to read the command buffers submitted by %v: %v", cmd, err)
+	}
+	return out
+}
+
+// LinkedProgram implements the api.ShaderUsageProvider interface. Pipelines
+// cannot be relinked.
+func (API) LinkedProgram(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState) api.Resource {
+	return nil
+}
+
+// ProgramStages implements the api.ShaderUsageProvider interface.
+func (API) ProgramStages(ctx context.Context, r api.Resource, s *api.GlobalState) ([]*api.Stage, error) {
+	var stages []StageData
+	switch p := r.(type) {
+	case GraphicsPipelineObjectʳ:
+		for i := 0; i < p.Stages().Len(); i++ {
+			stages = append(stages, p.Stages().Get(uint32(i)))
+		}
+	case ComputePipelineObjectʳ:
+		stages = append(stages, p.Stage())
+	default:
+		return nil, fmt.Errorf("%v is not a pipeline", r.ResourceHandle())
+	}
+
+	out := make([]*api.Stage, 0, len(stages))
+	for _, stage := range stages {
+		if stage.Module().IsNil() {
+			continue
+		}
+		ty, err := stageType(stage.Stage())
+		if err != nil {
+			return nil, err
+		}
+		data, err := stage.Module().ResourceData(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &api.Stage{Type: ty, Shader: data.GetShader()})
+	}
+	return out, nil
+}
@@ -0,0 +1,67 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// foreachSubmittedCommand calls f with each command recorded to the command
+// buffers submitted by the vkQueueSubmit cmd, in execution order, along with
+// the command buffer holding the command. The commands of secondary command
+// buffers are passed after the vkCmdExecuteCommands that executes them.
+// s is the state before cmd, to which the reads of cmd must have been applied.
+func foreachSubmittedCommand(ctx context.Context, cmd *VkQueueSubmit, s *api.GlobalState,
+	f func(cb CommandBufferObjectʳ, cr CommandReferenceʳ)) error {
+
+	st := GetState(s)
+	l := s.MemoryLayout
+
+	var record func(handle VkCommandBuffer)
+	record = func(handle VkCommandBuffer) {
+		cb, ok := st.CommandBuffers().Lookup(handle)
+		if !ok {
+			return
+		}
+		for k := 0; k < cb.CommandReferences().Len(); k++ {
+			cr := cb.CommandReferences().Get(uint32(k))
+			f(cb, cr)
+			if cr.Type() == CommandType_cmd_vkCmdExecuteCommands {
+				args := cb.BufferCommands().VkCmdExecuteCommands().Get(cr.MapIndex())
+				for i := uint32(0); i < uint32(args.CommandBuffers().Len()); i++ {
+					record(args.CommandBuffers().Get(i))
+				}
+			}
+		}
+	}
+
+	submits, err := cmd.PSubmits().Slice(0, uint64(cmd.SubmitCount()), l).Read(ctx, cmd, s, nil)
+	if err != nil {
+		return err
+	}
+	for _, submit := range submits {
+		count := uint64(submit.CommandBufferCount())
+		handles, err := submit.PCommandBuffers().Slice(0, count, l).Read(ctx, cmd, s, nil)
+		if err != nil {
+			return err
+		}
+		for _, handle := range handles {
+			record(handle)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+// Interface compliance test
+var (
+	_ = api.UploadProvider(API{})
+)
+
+// Uploads implements the api.UploadProvider interface. The transfer commands
+// recorded to command buffers are attributed to the vkQueueSubmit that
+// executes them, and writes to mapped memory to the flushes.
+func (API) Uploads(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState) []api.Upload {
+	upload := func(kind string, handle uint64, bytes uint64) api.Upload {
+		return api.Upload{Kind: kind, Name: strconv.FormatUint(handle, 10), Bytes: bytes}
+	}
+
+	switch cmd := cmd.(type) {
+	case *VkQueueSubmit:
+		out := []api.Upload{}
+		err := foreachSubmittedCommand(ctx, cmd, s, func(cb CommandBufferObjectʳ, cr CommandReferenceʳ) {
+			cmds := cb.BufferCommands()
+			switch cr.Type() {
+			case CommandType_cmd_vkCmdUpdateBuffer:
+				args := cmds.VkCmdUpdateBuffer().Get(cr.MapIndex())
+				out = append(out, upload("VkBuffer", uint64(args.DstBuffer()), uint64(args.DataSize())))
+
+			case CommandType_cmd_vkCmdCopyBuffer:
+				args := cmds.VkCmdCopyBuffer().Get(cr.MapIndex())
+				bytes := uint64(0)
+				for _, region := range args.CopyRegions().All() {
+					bytes += uint64(region.Size())
+				}
+				out = append(out, upload("VkBuffer", uint64(args.DstBuffer()), bytes))
+
+			case CommandType_cmd_vkCmdCopyBufferToImage:
+				args := cmds.VkCmdCopyBufferToImage().Get(cr.MapIndex())
+				if bytes, ok := bufferToImageBytes(ctx, cmd, s, args); ok {
+					out = append(out, upload("VkImage", uint64(args.DstImage()), bytes))
+				}
+			}
+		})
+		if err != nil {
+			log.W(ctx, "Failed to read the command buffers submitted by %v: %v", cmd, err)
+		}
+		return out
+
+	case *VkFlushMappedMemoryRanges:
+		count := uint64(cmd.MemoryRangeCount())
+		rngs, err := cmd.PMemoryRanges().Slice(0, count, s.MemoryLayout).Read(ctx, cmd, s, nil)
+		if err != nil {
+			log.W(ctx, "Failed to read the memory ranges flushed by %v: %v", cmd, err)
+			return nil
+		}
+		out := []api.Upload{}
+		for _, rng := range rngs {
+			if size := mappedRangeSize(GetState(s), rng); size > 0 {
+				out = append(out, upload("VkDeviceMemory", uint64(rng.Memory()), size))
+			}
+		}
+		return out
+	}
+	return nil
+}
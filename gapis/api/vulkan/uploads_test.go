@@ -0,0 +1,129 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+)
+
+func TestUploads(t *testing.T) {
+	ctx := log.Testing(t)
+	s := api.NewStateWithEmptyAllocator(device.Little32)
+	a := s.Arena
+	st := GetState(s)
+
+	buffer, image, mem := VkBuffer(1), VkImage(2), VkDeviceMemory(3)
+	handle := VkCommandBuffer(4)
+
+	img := MakeImageObjectʳ(a)
+	img.Info().SetFmt(VkFormat_VK_FORMAT_R8G8B8A8_UNORM)
+	st.Images().Add(image, img)
+
+	deviceMemory := MakeDeviceMemoryObjectʳ(a)
+	deviceMemory.SetMappedOffset(0)
+	deviceMemory.SetMappedSize(64)
+	st.DeviceMemories().Add(mem, deviceMemory)
+
+	// Record a command buffer with a vkCmdUpdateBuffer, a vkCmdCopyBuffer and
+	// a vkCmdCopyBufferToImage.
+	update := MakeVkCmdUpdateBufferArgsʳ(a)
+	update.SetDstBuffer(buffer)
+	update.SetDataSize(16)
+
+	copyBuffer := MakeVkCmdCopyBufferArgsʳ(a)
+	copyBuffer.SetDstBuffer(buffer)
+	copyBuffer.CopyRegions().Add(0, NewVkBufferCopy(a, 0, 0, 8))
+	copyBuffer.CopyRegions().Add(1, NewVkBufferCopy(a, 8, 8, 4))
+
+	copyImage := MakeVkCmdCopyBufferToImageArgsʳ(a)
+	copyImage.SetDstImage(image)
+	copyImage.Regions().Add(0, NewVkBufferImageCopy(a,
+		0, // bufferOffset
+		0, // bufferRowLength
+		0, // bufferImageHeight
+		NewVkImageSubresourceLayers(a, // imageSubresource
+			VkImageAspectFlags(VkImageAspectFlagBits_VK_IMAGE_ASPECT_COLOR_BIT), // aspectMask
+			0, // mipLevel
+			0, // baseArrayLayer
+			2, // layerCount
+		),
+		MakeVkOffset3D(a),         // imageOffset
+		NewVkExtent3D(a, 4, 4, 1), // imageExtent
+	))
+
+	cb := MakeCommandBufferObjectʳ(a)
+	cb.BufferCommands().VkCmdUpdateBuffer().Add(0, update)
+	cb.BufferCommands().VkCmdCopyBuffer().Add(0, copyBuffer)
+	cb.BufferCommands().VkCmdCopyBufferToImage().Add(0, copyImage)
+	for i, ty := range []CommandType{
+		CommandType_cmd_vkCmdUpdateBuffer,
+		CommandType_cmd_vkCmdCopyBuffer,
+		CommandType_cmd_vkCmdCopyBufferToImage,
+	} {
+		cr := MakeCommandReferenceʳ(a)
+		cr.SetType(ty)
+		cb.CommandReferences().Add(uint32(i), cr)
+	}
+	st.CommandBuffers().Add(handle, cb)
+
+	handleData := s.AllocDataOrPanic(ctx, handle)
+	defer handleData.Free()
+	submitInfo := NewVkSubmitInfo(a,
+		VkStructureType_VK_STRUCTURE_TYPE_SUBMIT_INFO, // sType
+		0, // pNext
+		0, // waitSemaphoreCount
+		0, // pWaitSemaphores
+		0, // pWaitDstStageMask
+		1, // commandBufferCount
+		NewVkCommandBufferᶜᵖ(handleData.Ptr()), // pCommandBuffers
+		0, // signalSemaphoreCount
+		0, // pSignalSemaphores
+	)
+	submitData := s.AllocDataOrPanic(ctx, submitInfo)
+	defer submitData.Free()
+
+	rangesData := s.AllocDataOrPanic(ctx,
+		NewVkMappedMemoryRange(a, VkStructureType_VK_STRUCTURE_TYPE_MAPPED_MEMORY_RANGE, 0, mem, 0, 32),
+		NewVkMappedMemoryRange(a, VkStructureType_VK_STRUCTURE_TYPE_MAPPED_MEMORY_RANGE, 0, mem, 16, VkDeviceSize(vkWholeSize)),
+	)
+	defer rangesData.Free()
+
+	builder := CommandBuilder{Thread: 0, Arena: a}
+	for _, test := range []struct {
+		cmd      api.Cmd
+		expected []api.Upload
+	}{
+		{builder.VkQueueSubmit(0, 1, submitData.Ptr(), 0, VkResult_VK_SUCCESS).
+			AddRead(submitData.Data()).AddRead(handleData.Data()), []api.Upload{
+			{Kind: "VkBuffer", Name: "1", Bytes: 16},
+			{Kind: "VkBuffer", Name: "1", Bytes: 12},
+			{Kind: "VkImage", Name: "2", Bytes: 4 * 4 * 2 * 4},
+		}},
+		{builder.VkFlushMappedMemoryRanges(0, 2, rangesData.Ptr(), VkResult_VK_SUCCESS).
+			AddRead(rangesData.Data()), []api.Upload{
+			{Kind: "VkDeviceMemory", Name: "3", Bytes: 32},
+			{Kind: "VkDeviceMemory", Name: "3", Bytes: 48},
+		}},
+	} {
+		test.cmd.Extras().Observations().ApplyReads(s.Memory.ApplicationPool())
+		got := API{}.Uploads(ctx, api.CmdNoID, test.cmd, s)
+		assert.For(ctx, "%v uploads", test.cmd).ThatSlice(got).Equals(test.expected)
+	}
+}
@@ -19,6 +19,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/google/gapid/core/app/analytics"
@@ -49,7 +52,12 @@ var (
 const (
 	// CurrentCaptureVersion is incremented on breaking changes to the capture format.
 	// NB: Also update equally named field in spy_base.cpp
-	CurrentCaptureVersion int32 = 3
+	CurrentCaptureVersion int32 = 4
+
+	// MinCaptureVersion is the oldest capture format version that can still be
+	// read. Version 4 added the optional api.CmdTiming extras and the start time
+	// of the header, which are simply missing from version 3 captures.
+	MinCaptureVersion int32 = 3
 )
 
 type ErrUnsupportedVersion struct{ Version int32 }
@@ -59,13 +67,24 @@ func (e ErrUnsupportedVersion) Error() string {
 }
 
 type Capture struct {
-	Name         string
-	Header       *Header
+	Name   string
+	Header *Header
+	// Commands is the list of all the commands of the capture. It is nil for
+	// streamed captures, whose commands are accessed with ForeachCmd, Cmd or
+	// AllCommands, and for subsets of captures.
 	Commands     []api.Cmd
 	APIs         []api.API
 	Observed     interval.U64RangeList
 	InitialState *InitialState
 	Arena        arena.Arena
+
+	// subsetOf holds the commands of captures built from a subset of the
+	// commands of another capture, which are read from the other capture.
+	// Such captures have no Commands and must only be used for exporting.
+	subsetOf *cmdSubset
+
+	// stream decodes the commands on demand for streamed captures.
+	stream *cmdStream
 }
 
 type InitialState struct {
@@ -186,7 +205,8 @@ func (c *Capture) Service(ctx context.Context, p *path.Capture) *service.Capture
 		Name:         c.Name,
 		Device:       c.Header.Device,
 		ABI:          c.Header.ABI,
-		NumCommands:  uint64(len(c.Commands)),
+		NumCommands:  c.NumCommands(),
+		StartTime:    c.Header.StartTime,
 		APIs:         apis,
 		Observations: observations,
 	}
@@ -219,14 +239,57 @@ func ResolveFromPath(ctx context.Context, p *path.Capture) (*Capture, error) {
 
 // Import imports the capture by name and data, and stores it in the database.
 func Import(ctx context.Context, name string, data []byte) (*path.Capture, error) {
+	return importRecord(ctx, &Record{Name: name}, data)
+}
+
+// ImportInMemory is like Import, but the commands of the capture are always
+// held in memory instead of being streamed, regardless of StreamingThreshold.
+func ImportInMemory(ctx context.Context, name string, data []byte) (*path.Capture, error) {
+	return importRecord(ctx, &Record{Name: name, InMemory: true}, data)
+}
+
+// ImportFile imports the capture stored in the file at filename, and stores it
+// in the database. Captures larger than StreamingThreshold are not copied into
+// the database, their commands are streamed from the file instead. The file
+// must then be kept unchanged while the capture is in use.
+func ImportFile(ctx context.Context, name, filename string) (*path.Capture, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+	if StreamingThreshold <= 0 || info.Size() <= StreamingThreshold {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		return Import(ctx, name, data)
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	dataID, err := hashFile(abs)
+	if err != nil {
+		return nil, err
+	}
+	return addRecord(ctx, &Record{Name: name, Data: dataID[:], Path: abs})
+}
+
+// importRecord stores the capture data and the record r referencing it in the
+// database.
+func importRecord(ctx context.Context, r *Record, data []byte) (*path.Capture, error) {
 	dataID, err := database.Store(ctx, data)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to store capture data: %v", err)
 	}
-	id, err := database.Store(ctx, &Record{
-		Name: name,
-		Data: dataID[:],
-	})
+	r.Data = dataID[:]
+	return addRecord(ctx, r)
+}
+
+// addRecord stores the record r in the database and adds it to the list of
+// imported captures.
+func addRecord(ctx context.Context, r *Record) (*path.Capture, error) {
+	id, err := database.Store(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -241,6 +304,7 @@ func Import(ctx context.Context, name string, data []byte) (*path.Capture, error
 // Export encodes the given capture and associated resources
 // and writes it to the supplied io.Writer in the pack file format,
 // producing output suitable for use with Import or opening in the trace editor.
+// See Capture.Export for details.
 func Export(ctx context.Context, p *path.Capture, w io.Writer) error {
 	c, err := ResolveFromPath(ctx, p)
 	if err != nil {
@@ -251,6 +315,8 @@ func Export(ctx context.Context, p *path.Capture, w io.Writer) error {
 
 // Export encodes the given capture and associated resources
 // and writes it to the supplied io.Writer in the .gfxtrace format.
+// The written data always ends with a pack index chunk, which is skipped by
+// readers that do not support indices.
 func (c *Capture) Export(ctx context.Context, w io.Writer) error {
 	writer, err := pack.NewWriter(w)
 	if err != nil {
@@ -262,7 +328,10 @@ func (c *Capture) Export(ctx context.Context, w io.Writer) error {
 	// which protoconv functions need to handle resources.
 	ctx = id.PutRemapper(ctx, e)
 
-	return e.encode(ctx)
+	if err := e.encode(ctx); err != nil {
+		return err
+	}
+	return writer.WriteIndex(ctx)
 }
 
 func toProto(ctx context.Context, c *Capture) (*Record, error) {
@@ -286,25 +355,51 @@ func fromProto(ctx context.Context, r *Record) (out *Capture, err error) {
 
 	var dataID id.ID
 	copy(dataID[:], r.Data)
-	data, err := database.Resolve(ctx, dataID)
-	if err != nil {
-		return nil, fmt.Errorf("Unable to load capture data: %v", err)
+	var from io.ReadSeeker
+	var size int64
+	if r.Path != "" {
+		f, err := openFile(r.Path, dataID)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to load capture data: %v", err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		from, size = f, info.Size()
+	} else {
+		data, err := database.Resolve(ctx, dataID)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to load capture data: %v", err)
+		}
+		buf := data.([]byte)
+		from, size = bytes.NewReader(buf), int64(len(buf))
 	}
 
 	stopTiming := analytics.SendTiming("capture", "deserialize")
 	defer func() {
-		size := len(r.Data)
 		count := 0
 		if out != nil {
-			count = len(out.Commands)
+			count = int(out.NumCommands())
 		}
 		stopTiming(analytics.Size(size), analytics.Count(count))
 	}()
 
-	a := arena.New()
+	if !r.InMemory && StreamingThreshold > 0 && size > StreamingThreshold {
+		// The file, if any, is kept open to decode the commands on demand.
+		c, err := stream(ctx, r.Name, from)
+		if err != nil {
+			return nil, readError(ctx, err)
+		}
+		return c, nil
+	}
+	if f, ok := from.(*os.File); ok {
+		defer f.Close()
+	}
 
-	// Bind the arena used to for all allocations for this capture.
-	ctx = arena.Put(ctx, a)
+	// The arena used for all allocations for this capture.
+	a := arena.New()
 
 	d := newDecoder(a)
 
@@ -312,43 +407,8 @@ func fromProto(ctx context.Context, r *Record) (out *Capture, err error) {
 	// which protoconv functions need to handle resources.
 	ctx = id.PutRemapper(ctx, d)
 
-	if err := pack.Read(ctx, bytes.NewReader(data.([]byte)), d, false); err != nil {
-		switch err := errors.Cause(err).(type) {
-		case pack.ErrUnsupportedVersion:
-			log.E(ctx, "%v", err)
-			switch {
-			case err.Version.Major > pack.MaxMajorVersion:
-				return nil, &service.ErrUnsupportedVersion{
-					Reason:        messages.ErrFileTooNew(),
-					SuggestUpdate: true,
-				}
-			case err.Version.Major < pack.MinMajorVersion:
-				return nil, &service.ErrUnsupportedVersion{
-					Reason: messages.ErrFileTooOld(),
-				}
-			default:
-				return nil, &service.ErrUnsupportedVersion{
-					Reason: messages.ErrFileCannotBeRead(),
-				}
-			}
-		case ErrUnsupportedVersion:
-			switch {
-			case err.Version > CurrentCaptureVersion:
-				return nil, &service.ErrUnsupportedVersion{
-					Reason:        messages.ErrFileTooNew(),
-					SuggestUpdate: true,
-				}
-			case err.Version < CurrentCaptureVersion:
-				return nil, &service.ErrUnsupportedVersion{
-					Reason: messages.ErrFileTooOld(),
-				}
-			default:
-				return nil, &service.ErrUnsupportedVersion{
-					Reason: messages.ErrFileCannotBeRead(),
-				}
-			}
-		}
-		return nil, err
+	if err := pack.Read(ctx, from, d, false); err != nil {
+		return nil, readError(ctx, err)
 	}
 	d.flush(ctx)
 	if d.header == nil {
@@ -357,11 +417,86 @@ func fromProto(ctx context.Context, r *Record) (out *Capture, err error) {
 	return d.builder.build(r.Name, d.header), nil
 }
 
+// hashFile returns the identifier of the content of the file at filename.
+func hashFile(filename string) (id.ID, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return id.ID{}, err
+	}
+	defer f.Close()
+	return id.Hash(func(w io.Writer) error {
+		_, err := io.Copy(w, f)
+		return err
+	})
+}
+
+// openFile opens the capture file at filename, checking that its content has
+// the identifier dataID.
+func openFile(filename string, dataID id.ID) (*os.File, error) {
+	got, err := hashFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if got != dataID {
+		return nil, fmt.Errorf("Capture file '%v' has changed since it was imported", filename)
+	}
+	return os.Open(filename)
+}
+
+// readError returns the error to report for the error err returned when
+// reading the capture data.
+func readError(ctx context.Context, err error) error {
+	switch err := errors.Cause(err).(type) {
+	case pack.ErrUnsupportedVersion:
+		log.E(ctx, "%v", err)
+		switch {
+		case err.Version.Major > pack.MaxMajorVersion:
+			return &service.ErrUnsupportedVersion{
+				Reason:        messages.ErrFileTooNew(),
+				SuggestUpdate: true,
+			}
+		case err.Version.Major < pack.MinMajorVersion:
+			return &service.ErrUnsupportedVersion{
+				Reason: messages.ErrFileTooOld(),
+			}
+		default:
+			return &service.ErrUnsupportedVersion{
+				Reason: messages.ErrFileCannotBeRead(),
+			}
+		}
+	case pack.ErrUnknownCodec:
+		log.E(ctx, "%v", err)
+		return &service.ErrUnsupportedVersion{
+			Reason:        messages.ErrFileCannotBeRead(),
+			SuggestUpdate: true,
+		}
+	case ErrUnsupportedVersion:
+		switch {
+		case err.Version > CurrentCaptureVersion:
+			return &service.ErrUnsupportedVersion{
+				Reason:        messages.ErrFileTooNew(),
+				SuggestUpdate: true,
+			}
+		case err.Version < MinCaptureVersion:
+			return &service.ErrUnsupportedVersion{
+				Reason: messages.ErrFileTooOld(),
+			}
+		default:
+			return &service.ErrUnsupportedVersion{
+				Reason: messages.ErrFileCannotBeRead(),
+			}
+		}
+	}
+	return err
+}
+
 type builder struct {
 	apis         []api.API
 	seenAPIs     map[api.ID]struct{}
 	observed     interval.U64RangeList
 	cmds         []api.Cmd
+	nextCmdID    api.CmdID
+	dropCmds     bool // If true, commands are only counted, not kept.
 	resIDs       []id.ID
 	initialState *InitialState
 	arena        arena.Arena
@@ -388,8 +523,11 @@ func (b *builder) addCmd(ctx context.Context, cmd api.Cmd) api.CmdID {
 			b.addObservation(ctx, &observations.Writes[i])
 		}
 	}
-	id := api.CmdID(len(b.cmds))
-	b.cmds = append(b.cmds, cmd)
+	id := b.nextCmdID
+	b.nextCmdID++
+	if !b.dropCmds {
+		b.cmds = append(b.cmds, cmd)
+	}
 	return id
 }
 
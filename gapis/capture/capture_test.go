@@ -16,6 +16,10 @@ package capture_test
 
 import (
 	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/gapid/core/assert"
@@ -26,6 +30,9 @@ import (
 	"github.com/google/gapid/gapis/api/test"
 	"github.com/google/gapid/gapis/capture"
 	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/memory"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
 )
 
 func TestCaptureExportImport(t *testing.T) {
@@ -57,3 +64,248 @@ func TestCaptureExportImport(t *testing.T) {
 
 	assert.For(ctx, "got").That(ic.Commands).CustomDeepEquals(cmds, test.Cmds.IgnoreArena)
 }
+
+func TestCaptureStreamed(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	defer func(old int64) { capture.StreamingThreshold = old }(capture.StreamingThreshold)
+	capture.StreamingThreshold = 1
+
+	header := &capture.Header{ABI: device.WindowsX86_64}
+	cmds := []api.Cmd{}
+	for i := 0; i < 5000; i++ {
+		cmds = append(cmds, test.Cmds.A, test.Cmds.B)
+	}
+	p, err := capture.New(ctx, arena.New(), "test", header, cmds)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	err = capture.Export(capture.Put(ctx, p), p, buf)
+	if !assert.For(ctx, "capture.Export").ThatError(err).Succeeded() {
+		return
+	}
+
+	ip, err := capture.Import(ctx, "imported", buf.Bytes())
+	if !assert.For(ctx, "capture.Import").ThatError(err).Succeeded() {
+		return
+	}
+
+	ic, err := capture.Resolve(capture.Put(ctx, ip))
+	if !assert.For(ctx, "capture.Resolve").ThatError(err).Succeeded() {
+		return
+	}
+
+	assert.For(ctx, "streamed").That(ic.IsStreamed()).Equals(true)
+	assert.For(ctx, "count").That(ic.NumCommands()).Equals(uint64(len(cmds)))
+
+	names := []string{}
+	err = ic.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		names = append(names, cmd.CmdName())
+		return nil
+	})
+	assert.For(ctx, "ForeachCmd").ThatError(err).Succeeded()
+	expected := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		expected[i] = cmd.CmdName()
+	}
+	assert.For(ctx, "names").ThatSlice(names).Equals(expected)
+
+	last, release, err := ic.Cmd(ctx, api.CmdID(len(cmds)-1))
+	if assert.For(ctx, "Cmd").ThatError(err).Succeeded() {
+		assert.For(ctx, "last").That(last).CustomDeepEquals(cmds[len(cmds)-1], test.Cmds.IgnoreArena)
+		release()
+	}
+
+	kept, err := ic.KeepCmd(ctx, 1)
+	if assert.For(ctx, "KeepCmd").ThatError(err).Succeeded() {
+		assert.For(ctx, "kept").That(kept).CustomDeepEquals(cmds[1], test.Cmds.IgnoreArena)
+	}
+
+	all, err := ic.AllCommands(ctx)
+	assert.For(ctx, "AllCommands").ThatError(err).Succeeded()
+	assert.For(ctx, "all").That(all).CustomDeepEquals(cmds, test.Cmds.IgnoreArena)
+
+	// Captures imported in memory are never streamed.
+	mp, err := capture.ImportInMemory(ctx, "in memory", buf.Bytes())
+	if !assert.For(ctx, "capture.ImportInMemory").ThatError(err).Succeeded() {
+		return
+	}
+	mc, err := capture.Resolve(capture.Put(ctx, mp))
+	if !assert.For(ctx, "capture.Resolve").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "in memory streamed").That(mc.IsStreamed()).Equals(false)
+	assert.For(ctx, "in memory count").That(mc.NumCommands()).Equals(uint64(len(cmds)))
+
+	// Captures imported from files are streamed from the file.
+	dir, err := ioutil.TempDir("", "capture")
+	if !assert.For(ctx, "TempDir").ThatError(err).Succeeded() {
+		return
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "capture.gfxtrace")
+	err = ioutil.WriteFile(file, buf.Bytes(), 0666)
+	if !assert.For(ctx, "WriteFile").ThatError(err).Succeeded() {
+		return
+	}
+	fp, err := capture.ImportFile(ctx, "file", file)
+	if !assert.For(ctx, "capture.ImportFile").ThatError(err).Succeeded() {
+		return
+	}
+	fc, err := capture.Resolve(capture.Put(ctx, fp))
+	if !assert.For(ctx, "capture.Resolve file").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "file streamed").That(fc.IsStreamed()).Equals(true)
+
+	// Streamed captures are exported without decoding all their commands.
+	exported := &bytes.Buffer{}
+	err = fc.Export(ctx, exported)
+	if !assert.For(ctx, "Export file").ThatError(err).Succeeded() {
+		return
+	}
+	rp, err := capture.ImportInMemory(ctx, "exported", exported.Bytes())
+	if !assert.For(ctx, "capture.ImportInMemory exported").ThatError(err).Succeeded() {
+		return
+	}
+	rc, err := capture.Resolve(capture.Put(ctx, rp))
+	if assert.For(ctx, "capture.Resolve exported").ThatError(err).Succeeded() {
+		assert.For(ctx, "exported").That(rc.Commands).CustomDeepEquals(cmds, test.Cmds.IgnoreArena)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	header := &capture.Header{ABI: device.WindowsX86_64}
+	cmds := []api.Cmd{}
+	for i := 0; i < 10; i++ {
+		cmds = append(cmds, test.Cmds.A, test.Cmds.B)
+	}
+	p, err := capture.New(ctx, arena.New(), "test", header, cmds)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	err = capture.Export(capture.Put(ctx, p), p, buf)
+	if !assert.For(ctx, "capture.Export").ThatError(err).Succeeded() {
+		return
+	}
+	data := buf.Bytes()
+
+	v, err := capture.Validate(ctx, "intact", data, true)
+	if assert.For(ctx, "Validate intact").ThatError(err).Succeeded() {
+		assert.For(ctx, "intact issues").ThatSlice(v.Issues).IsEmpty()
+		assert.For(ctx, "intact commands").That(v.IntactCommands).Equals(uint64(len(cmds)))
+		assert.For(ctx, "intact salvaged").That(v.Salvaged).IsNil()
+	}
+
+	v, err = capture.Validate(ctx, "truncated", data[:len(data)/2], true)
+	if !assert.For(ctx, "Validate truncated").ThatError(err).Succeeded() {
+		return
+	}
+	if assert.For(ctx, "truncated issues").ThatSlice(v.Issues).IsLength(1) {
+		assert.For(ctx, "issue kind").That(v.Issues[0].Kind).Equals(service.CaptureIssueKind_TruncatedCapture)
+	}
+	assert.For(ctx, "truncated commands").That(v.IntactCommands < uint64(len(cmds))).Equals(true)
+	if !assert.For(ctx, "truncated salvaged").That(v.Salvaged).IsNotNil() {
+		return
+	}
+
+	sc, err := capture.ResolveFromPath(ctx, v.Salvaged)
+	if !assert.For(ctx, "Resolve salvaged").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "salvaged").That(sc.Commands).CustomDeepEquals(cmds[:v.IntactCommands], test.Cmds.IgnoreArena)
+}
+
+func TestSplitMerge(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	a := arena.New()
+	cb := test.CommandBuilder{Arena: a}
+	header := &capture.Header{ABI: device.WindowsX86_64}
+	onThread := func(thread uint64, cmd api.Cmd) api.Cmd {
+		cmd.SetThread(thread)
+		return cmd
+	}
+	cmds := []api.Cmd{
+		onThread(1, cb.CmdAdd(1, 2)),
+		onThread(2, cb.CmdVoid()),
+		onThread(1, cb.CmdAdd(3, 4)),
+		onThread(2, cb.CmdVoid()),
+	}
+	p, err := capture.New(ctx, a, "test", header, cmds)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+
+	type cmdInfo struct {
+		Name   string
+		Thread uint64
+	}
+	infos := func(p *path.Capture) []cmdInfo {
+		c, err := capture.ResolveFromPath(ctx, p)
+		if !assert.For(ctx, "capture.ResolveFromPath").ThatError(err).Succeeded() {
+			return nil
+		}
+		out := []cmdInfo{}
+		for _, cmd := range c.Commands {
+			out = append(out, cmdInfo{cmd.CmdName(), cmd.Thread()})
+		}
+		return out
+	}
+
+	parts, err := capture.Split(capture.Put(ctx, p), p, service.SplitMode_ByThread)
+	if !assert.For(ctx, "capture.Split").ThatError(err).Succeeded() {
+		return
+	}
+	if !assert.For(ctx, "parts").ThatSlice(parts).IsLength(2) {
+		return
+	}
+	assert.For(ctx, "part 0").ThatSlice(infos(parts[0])).Equals([]cmdInfo{
+		{"cmdAdd", 1}, {"cmdAdd", 1},
+	})
+	assert.For(ctx, "part 1").ThatSlice(infos(parts[1])).Equals([]cmdInfo{
+		{"cmdVoid", 2}, {"cmdVoid", 2},
+	})
+
+	// The second part starts with the state after the first command.
+	c1, err := capture.ResolveFromPath(ctx, parts[1])
+	if !assert.For(ctx, "capture.ResolveFromPath").ThatError(err).Succeeded() {
+		return
+	}
+	s := c1.NewState(ctx)
+	got, err := test.GetState(s).Ints().Read(ctx, nil, s, nil)
+	if assert.For(ctx, "initial state read").ThatError(err).Succeeded() {
+		assert.For(ctx, "initial state").ThatSlice(got).Equals([]memory.Int{3})
+	}
+
+	// Merging the parts back gives the commands of each part in turn.
+	merged, err := capture.Merge(ctx, "merged", parts)
+	if !assert.For(ctx, "capture.Merge").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "merged").ThatSlice(infos(merged)).Equals([]cmdInfo{
+		{"cmdAdd", 1}, {"cmdAdd", 1}, {"cmdVoid", 2}, {"cmdVoid", 2},
+	})
+
+	// Threads that collide with an earlier capture are remapped, without
+	// modifying the merged captures.
+	twice, err := capture.Merge(ctx, "twice", []*path.Capture{parts[0], parts[0]})
+	if !assert.For(ctx, "capture.Merge twice").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "twice").ThatSlice(infos(twice)).Equals([]cmdInfo{
+		{"cmdAdd", 1}, {"cmdAdd", 1}, {"cmdAdd", 2}, {"cmdAdd", 2},
+	})
+	assert.For(ctx, "part 0 after merge").ThatSlice(infos(parts[0])).Equals([]cmdInfo{
+		{"cmdAdd", 1}, {"cmdAdd", 1},
+	})
+}
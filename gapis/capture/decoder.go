@@ -21,12 +21,18 @@ type decoder struct {
 	header  *Header
 	builder *builder
 	groups  map[uint64]interface{}
+	arena   arena.Arena // The arena used to decode objects.
+
+	// skipResources is true when decoding a part of a capture whose resources
+	// have already been stored and indexed by the builder.
+	skipResources bool
 }
 
 func newDecoder(a arena.Arena) *decoder {
 	return &decoder{
 		builder: newBuilder(a),
 		groups:  map[uint64]interface{}{},
+		arena:   a,
 	}
 }
 
@@ -147,6 +153,9 @@ func (d *decoder) add(ctx context.Context, child, parent interface{}) error {
 }
 
 func (d *decoder) unmarshal(ctx context.Context, in proto.Message) (interface{}, error) {
+	// Bind the arena used for all allocations of the decoded objects.
+	ctx = arena.Put(ctx, d.arena)
+
 	obj, err := protoconv.ToObject(ctx, in)
 	if err != nil {
 		if e, ok := err.(protoconv.ErrNoConverterRegistered); ok && e.Object == in {
@@ -166,12 +175,15 @@ func (d *decoder) decode(ctx context.Context, in proto.Message) (interface{}, er
 	switch obj := obj.(type) {
 	case *Header:
 		d.header = obj
-		if d.header.Version != CurrentCaptureVersion {
+		if v := d.header.Version; v < MinCaptureVersion || v > CurrentCaptureVersion {
 			return nil, ErrUnsupportedVersion{Version: d.header.Version}
 		}
 		return in, nil
 
 	case *Resource:
+		if d.skipResources {
+			return in, nil
+		}
 		if err := d.builder.addRes(ctx, obj.Index, obj.Data); err != nil {
 			return nil, err
 		}
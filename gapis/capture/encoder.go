@@ -14,7 +14,7 @@ import (
 type encoder struct {
 	c      *Capture
 	w      *pack.Writer
-	cmdIDs map[api.Cmd]uint64
+	cmdIDs map[api.CmdID]uint64 // The group identifiers of the started commands.
 	resIDs map[id.ID]int64
 }
 
@@ -22,7 +22,7 @@ func newEncoder(c *Capture, w *pack.Writer) *encoder {
 	return &encoder{
 		c:      c,
 		w:      w,
-		cmdIDs: map[api.Cmd]uint64{},
+		cmdIDs: map[api.CmdID]uint64{},
 		resIDs: map[id.ID]int64{id.ID{}: 0},
 	}
 }
@@ -40,19 +40,25 @@ func (e *encoder) encode(ctx context.Context) error {
 		}
 	}
 
-	for _, cmd := range e.c.Commands {
-		cmdID, err := e.startCmd(ctx, cmd)
+	return e.foreachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		cmdID, err := e.startCmd(ctx, id, cmd)
 		if err != nil {
 			return err
 		}
 		if err := e.extras(ctx, cmd, cmdID); err != nil {
 			return err
 		}
-		if err := e.endCmd(ctx, cmd); err != nil {
-			return err
-		}
+		return e.endCmd(ctx, id)
+	})
+}
+
+// foreachCmd calls cb with each command to encode, until cb returns an error.
+// The identifiers passed to cb are those used by the callers of the commands.
+func (e *encoder) foreachCmd(ctx context.Context, cb func(context.Context, api.CmdID, api.Cmd) error) error {
+	if s := e.c.subsetOf; s != nil {
+		return s.foreachCmd(ctx, cb)
 	}
-	return nil
+	return e.c.ForeachCmd(ctx, cb)
 }
 
 func (e *encoder) initialState(ctx context.Context) (err error) {
@@ -100,8 +106,8 @@ func (e *encoder) childObject(ctx context.Context, obj interface{}, parentID uin
 	return nil
 }
 
-func (e *encoder) startCmd(ctx context.Context, cmd api.Cmd) (uint64, error) {
-	if cmdID, ok := e.cmdIDs[cmd]; ok {
+func (e *encoder) startCmd(ctx context.Context, id api.CmdID, cmd api.Cmd) (uint64, error) {
+	if cmdID, ok := e.cmdIDs[id]; ok {
 		return cmdID, nil
 	}
 	cmdProto, err := protoconv.ToProto(ctx, cmd)
@@ -110,8 +116,8 @@ func (e *encoder) startCmd(ctx context.Context, cmd api.Cmd) (uint64, error) {
 	}
 
 	var cmdID uint64
-	if id := cmd.Caller(); id != api.CmdNoID {
-		parentID, err := e.startCmd(ctx, e.c.Commands[id])
+	if caller := cmd.Caller(); e.encodes(caller) {
+		parentID, err := e.startCaller(ctx, caller)
 		if err != nil {
 			return 0, err
 		}
@@ -125,19 +131,50 @@ func (e *encoder) startCmd(ctx context.Context, cmd api.Cmd) (uint64, error) {
 			return 0, err
 		}
 	}
-	e.cmdIDs[cmd] = cmdID
+	e.cmdIDs[id] = cmdID
 	return cmdID, nil
 }
 
-func (e *encoder) endCmd(ctx context.Context, cmd api.Cmd) error {
-	id, ok := e.cmdIDs[cmd]
+// startCaller starts the command with the identifier id, which calls a
+// command being started, and returns its group identifier.
+func (e *encoder) startCaller(ctx context.Context, id api.CmdID) (uint64, error) {
+	if cmdID, ok := e.cmdIDs[id]; ok {
+		return cmdID, nil
+	}
+	from := e.c
+	if s := e.c.subsetOf; s != nil {
+		from = s.from
+	}
+	cmd, release, err := from.Cmd(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	return e.startCmd(ctx, id, cmd)
+}
+
+// encodes returns true if the command with the identifier id is one of the
+// commands being encoded.
+func (e *encoder) encodes(id api.CmdID) bool {
+	switch {
+	case id == api.CmdNoID:
+		return false
+	case e.c.subsetOf != nil:
+		return e.c.subsetOf.contains(id)
+	default:
+		return uint64(id) < e.c.NumCommands()
+	}
+}
+
+func (e *encoder) endCmd(ctx context.Context, id api.CmdID) error {
+	cmdID, ok := e.cmdIDs[id]
 	if !ok {
 		panic("Attempting to end command that was not in cmdIDs")
 	}
-	if err := e.w.EndGroup(ctx, id); err != nil {
+	if err := e.w.EndGroup(ctx, cmdID); err != nil {
 		return err
 	}
-	delete(e.cmdIDs, cmd)
+	delete(e.cmdIDs, id)
 	return nil
 }
 
@@ -0,0 +1,346 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/app/status"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/data/pack"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/math/interval"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// Split divides the commands of the given capture into one new capture per
+// context or thread, as selected by mode. See Capture.Split for details.
+// The new captures are stored in the database.
+func Split(ctx context.Context, p *path.Capture, mode service.SplitMode) ([]*path.Capture, error) {
+	c, err := ResolveFromPath(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := c.Split(Put(ctx, p), mode)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*path.Capture, len(parts))
+	for i, part := range parts {
+		if out[i], err = reimport(ctx, part); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Split divides the commands of the capture into one capture per context or
+// thread, as selected by mode.
+// Each of the returned captures starts with the state as it was before the
+// first command of its context or thread, serialized as its initial state.
+// When splitting by context, commands that are not executed on any context are
+// added to all the captures whose context has been seen.
+// The returned captures must only be used for exporting.
+func (c *Capture) Split(ctx context.Context, mode service.SplitMode) ([]*Capture, error) {
+	ctx = status.Start(ctx, "Splitting capture '%v'", c.Name)
+	defer status.Finish(ctx)
+
+	type part struct {
+		name  string
+		first api.CmdID
+		ids   []api.CmdID
+		state *InitialState
+	}
+
+	// Find the part of each command. A nil key means the command belongs to
+	// all the parts seen so far.
+	keys := make([]interface{}, c.NumCommands())
+	parts := map[interface{}]*part{}
+	order := []*part{}
+	s := c.NewState(ctx)
+	err := c.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if err := cmd.Mutate(ctx, id, s, nil); err != nil {
+			log.W(ctx, "Split: Cmd [%v]%v - %v", id, cmd, err)
+		}
+
+		var key interface{}
+		var name string
+		switch mode {
+		case service.SplitMode_ByThread:
+			key = cmd.Thread()
+			name = fmt.Sprintf("%v (thread %v)", c.Name, cmd.Thread())
+		case service.SplitMode_ByContext:
+			if a := cmd.API(); a != nil {
+				if context := a.Context(s, cmd.Thread()); context != nil {
+					key = context.ID()
+					name = fmt.Sprintf("%v (context %v)", c.Name, len(order))
+				}
+			}
+		default:
+			return fmt.Errorf("Unknown split mode %v", mode)
+		}
+
+		keys[id] = key
+		if key != nil {
+			if _, ok := parts[key]; !ok {
+				p := &part{name: name, first: id}
+				parts[key] = p
+				order = append(order, p)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Mutate again to snapshot the state before the first command of each
+	// part, and assign the commands to the parts.
+	a := arena.New()
+	s = c.NewState(ctx)
+	started := []*part{}
+	err = c.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		key := keys[id]
+		if key != nil {
+			p := parts[key]
+			if p.first == id {
+				state, err := snapshot(ctx, s, a)
+				if err != nil {
+					return err
+				}
+				p.state = state
+				started = append(started, p)
+			}
+			p.ids = append(p.ids, id)
+		} else {
+			for _, p := range started {
+				p.ids = append(p.ids, id)
+			}
+		}
+		if err := cmd.Mutate(ctx, id, s, nil); err != nil {
+			log.W(ctx, "Split: Cmd [%v]%v - %v", id, cmd, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*Capture, len(order))
+	for i, p := range order {
+		if out[i], err = c.subset(ctx, p.name, p.ids, p.state, a); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Merge combines the given captures into a single new capture named name,
+// which is stored in the database. See MergeCaptures for details.
+func Merge(ctx context.Context, name string, captures []*path.Capture) (*path.Capture, error) {
+	cs := make([]*Capture, len(captures))
+	for i, p := range captures {
+		c, err := ResolveFromPath(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		cs[i] = c
+	}
+	merged, err := MergeCaptures(ctx, name, cs...)
+	if err != nil {
+		return nil, err
+	}
+	return reimport(ctx, merged)
+}
+
+// MergeCaptures combines the commands of the captures cs into a single capture
+// named name. The captures must be of independent contexts, such as those
+// produced by Split, and are appended one after another.
+// As API states cannot be combined, the initial state of each capture is
+// replaced with the commands built by the API state rebuilders.
+// Thread identifiers already used by an earlier capture are remapped to new
+// identifiers. Captures that use the same context or API object handles as an
+// earlier capture cannot be combined and are rejected.
+// The returned capture must only be used for exporting.
+func MergeCaptures(ctx context.Context, name string, cs ...*Capture) (*Capture, error) {
+	if len(cs) == 0 {
+		return nil, fmt.Errorf("No captures to merge")
+	}
+	header := cs[0].Header
+	for _, c := range cs[1:] {
+		if !proto.Equal(c.Header.ABI, header.ABI) {
+			return nil, fmt.Errorf("Cannot merge capture '%v' with ABI %v into capture with ABI %v",
+				c.Name, c.Header.ABI, header.ABI)
+		}
+	}
+
+	// Find the threads, contexts and handles of each capture, and reject
+	// the captures that would collide.
+	owners := map[interface{}]*Capture{}
+	threads := make([][]uint64, len(cs))
+	nextThread := uint64(0)
+	for i, c := range cs {
+		used, err := c.handles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range used.handles {
+			if owner, ok := owners[h]; ok {
+				return nil, fmt.Errorf("Cannot merge capture '%v': %v is also used by capture '%v'",
+					c.Name, h, owner.Name)
+			}
+			owners[h] = c
+		}
+		threads[i] = used.threads
+		for _, t := range used.threads {
+			if t >= nextThread {
+				nextThread = t + 1
+			}
+		}
+	}
+
+	// Copy the commands of each capture into the arena of the merged capture,
+	// so that their threads can be remapped without modifying the source
+	// captures.
+	a := arena.New()
+	b := newBuilder(a)
+	seenThreads := map[uint64]struct{}{}
+	for i, c := range cs {
+		remap := map[uint64]uint64{}
+		for _, t := range threads[i] {
+			if _, seen := seenThreads[t]; seen {
+				remap[t] = nextThread
+				nextThread++
+			} else {
+				remap[t] = t
+			}
+		}
+		for _, t := range remap {
+			seenThreads[t] = struct{}{}
+		}
+		setThread := func(cmd api.Cmd) {
+			if t, ok := remap[cmd.Thread()]; ok {
+				cmd.SetThread(t)
+			}
+		}
+
+		initialCmds, ranges := c.BuildInitialCommands(ctx)
+		for _, r := range ranges {
+			interval.Merge(&b.observed, r.Span(), true)
+		}
+		for _, cmd := range initialCmds {
+			setThread(cmd)
+			b.addCmd(ctx, cmd)
+		}
+
+		cmds, err := c.copyCommands(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+		// The callers are identified by their index in cmds.
+		first := b.nextCmdID
+		for _, cmd := range cmds {
+			if caller := cmd.Caller(); caller != api.CmdNoID {
+				cmd.SetCaller(first + caller)
+			}
+			setThread(cmd)
+			b.addCmd(ctx, cmd)
+		}
+	}
+	return b.build(name, header), nil
+}
+
+// usedHandles holds the threads, and the contexts and API object handles used
+// by the commands of a capture.
+type usedHandles struct {
+	threads []uint64
+	handles []interface{}
+}
+
+// contextHandle is the key of a context in usedHandles.handles.
+type contextHandle api.ContextID
+
+func (c contextHandle) String() string { return fmt.Sprintf("context %v", id.ID(c)) }
+
+// handles returns the threads, contexts and API object handles used by the
+// commands of the capture, in order of first use.
+func (c *Capture) handles(ctx context.Context) (usedHandles, error) {
+	out := usedHandles{}
+	seen := map[interface{}]struct{}{}
+	seenThreads := map[uint64]struct{}{}
+	add := func(h interface{}) {
+		if _, ok := seen[h]; !ok {
+			seen[h] = struct{}{}
+			out.handles = append(out.handles, h)
+		}
+	}
+	s := c.NewState(ctx)
+	err := c.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if _, ok := seenThreads[cmd.Thread()]; !ok {
+			seenThreads[cmd.Thread()] = struct{}{}
+			out.threads = append(out.threads, cmd.Thread())
+		}
+		if a := cmd.API(); a != nil {
+			if hp, ok := a.(api.CmdHandlesProvider); ok {
+				for _, h := range hp.CmdHandles(ctx, cmd, s) {
+					add(h)
+				}
+			}
+		}
+		if err := cmd.Mutate(ctx, id, s, nil); err != nil {
+			log.W(ctx, "Split: Cmd [%v]%v - %v", id, cmd, err)
+		}
+		if a := cmd.API(); a != nil {
+			if context := a.Context(s, cmd.Thread()); context != nil {
+				add(contextHandle(context.ID()))
+			}
+		}
+		return nil
+	})
+	return out, err
+}
+
+// copyCommands returns a copy of all the commands of the capture, allocated
+// in the arena a. The returned commands can be modified without affecting the
+// capture.
+func (c *Capture) copyCommands(ctx context.Context, a arena.Arena) ([]api.Cmd, error) {
+	buf := bytes.Buffer{}
+	if err := c.Export(ctx, &buf); err != nil {
+		return nil, err
+	}
+	d := newDecoder(a)
+	ctx = id.PutRemapper(ctx, d)
+	if err := pack.Read(ctx, bytes.NewReader(buf.Bytes()), d, false); err != nil {
+		return nil, err
+	}
+	d.flush(ctx)
+	return d.builder.cmds, nil
+}
+
+// reimport exports the capture c and imports it as a new capture, so that the
+// stored capture is independent of the captures c was built from.
+func reimport(ctx context.Context, c *Capture) (*path.Capture, error) {
+	buf := bytes.Buffer{}
+	if err := c.Export(ctx, &buf); err != nil {
+		return nil, err
+	}
+	return Import(ctx, c.Name, buf.Bytes())
+}
@@ -0,0 +1,444 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/data/pack"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/gapis/api"
+)
+
+// StreamingThreshold is the size in bytes of the capture data above which
+// the commands of a capture are not all held in memory, but decoded in chunks
+// on demand. A value of 0 or less disables streaming. Captures imported with
+// ImportInMemory are never streamed.
+// Streaming does not bound the memory of a capture once AllCommands has been
+// called, see AllCommands for details.
+var StreamingThreshold int64 = 512 << 20
+
+const (
+	// cmdsPerChunk is the minimum number of commands in each chunk of a
+	// streamed capture.
+	cmdsPerChunk = 4096
+
+	// maxCachedChunks is the number of decoded chunks of a streamed capture
+	// that are kept in memory.
+	maxCachedChunks = 16
+)
+
+// cmdChunk is a range of root objects and groups of the capture data, which
+// holds a range of commands of a streamed capture.
+type cmdChunk struct {
+	root  int       // The index of the first root of the chunk in the pack file.
+	roots int       // The number of roots of the chunk.
+	first api.CmdID // The identifier of the first command of the chunk.
+	count uint64    // The number of commands in the chunk.
+}
+
+// decodedChunk holds the commands of a decoded cmdChunk.
+type decodedChunk struct {
+	index   int
+	cmds    []api.Cmd
+	arena   arena.Arena
+	refs    int  // Number of users of the commands.
+	evicted bool // True once the chunk has been removed from the cache.
+	kept    bool // True if the commands are kept for the lifetime of the capture.
+}
+
+// cmdStream decodes the commands of a streamed capture on demand.
+type cmdStream struct {
+	resIDs []id.ID
+	chunks []cmdChunk
+	count  uint64
+
+	readMutex sync.Mutex   // Locks reader, which is not safe for concurrent use.
+	reader    *pack.Reader // The reader of the capture data.
+
+	mutex  sync.Mutex
+	lru    *list.List            // *decodedChunk, most recently used at the front.
+	cached map[int]*list.Element // Elements of lru by chunk index.
+	kept   map[int]*decodedChunk // The chunks kept by KeepCmd.
+	all    []api.Cmd             // The commands returned by AllCommands.
+}
+
+// indexer is a decoder that splits the commands of the capture into chunks
+// of roots of the pack file while reading the capture data, without keeping
+// the commands.
+type indexer struct {
+	*decoder
+	persistent arena.Arena // The arena for objects that outlive the chunks.
+	chunks     []cmdChunk
+	root       int       // The index of the next root.
+	start      int       // The first root of the current chunk.
+	first      api.CmdID // The first command of the current chunk.
+	keptState  bool      // True once the initial state is persistent.
+}
+
+func (i *indexer) BeginGroup(ctx context.Context, msg proto.Message, id uint64) error {
+	i.beginRoot()
+	return i.decoder.BeginGroup(ctx, msg, id)
+}
+
+func (i *indexer) Object(ctx context.Context, msg proto.Message) error {
+	i.beginRoot()
+	return i.decoder.Object(ctx, msg)
+}
+
+// beginRoot is called at the start of each root of the pack file. Chunks can
+// only start with roots that are read while no groups are open, so that the
+// commands of the chunk are all read with the roots of the chunk.
+func (i *indexer) beginRoot() {
+	if len(i.groups) == 0 {
+		i.keepInitialState()
+		next := i.builder.nextCmdID
+		switch {
+		case next == i.first:
+			// No commands since the end of the last chunk. Start the chunk
+			// here to skip the preceding roots when decoding the chunk.
+			i.start = i.root
+		case uint64(next-i.first) >= cmdsPerChunk:
+			i.endChunk(i.root)
+			i.start, i.first = i.root, next
+			// The commands of the chunk are no longer needed.
+			i.arena.Dispose()
+			i.arena = arena.New()
+		}
+	}
+	i.root++
+}
+
+// endChunk adds the chunk that ends before the root end to the index.
+func (i *indexer) endChunk(end int) {
+	i.chunks = append(i.chunks, cmdChunk{
+		root:  i.start,
+		roots: end - i.start,
+		first: i.first,
+		count: uint64(i.builder.nextCmdID - i.first),
+	})
+}
+
+// keepInitialState clones the initial state, if any, into the persistent
+// arena so that it outlives the arena it was decoded with.
+func (i *indexer) keepInitialState() {
+	if i.keptState || i.builder.initialState == nil {
+		return
+	}
+	for a, s := range i.builder.initialState.APIs {
+		i.builder.initialState.APIs[a] = s.Clone(i.persistent)
+	}
+	i.keptState = true
+}
+
+// stream returns a streamed capture for the capture data read from from,
+// which is indexed without holding the commands in memory. The commands are
+// read from from when they are decoded, so from must be kept unchanged for the
+// lifetime of the capture.
+func stream(ctx context.Context, name string, from io.ReadSeeker) (*Capture, error) {
+	r, err := pack.NewReader(ctx, from, false)
+	if err != nil {
+		return nil, err
+	}
+
+	a := arena.New()
+	i := &indexer{decoder: newDecoder(arena.New()), persistent: a}
+	i.builder.dropCmds = true
+
+	// The decoder implements the ID Remapper interface,
+	// which protoconv functions need to handle resources.
+	ctx = id.PutRemapper(ctx, i)
+
+	if r.Count() > 0 {
+		err = r.ReadRange(ctx, 0, r.Count(), i)
+	}
+	if err == nil {
+		i.flush(ctx)
+		if i.builder.nextCmdID > i.first {
+			i.endChunk(r.Count())
+		}
+		i.keepInitialState()
+	}
+	i.arena.Dispose()
+	if err != nil {
+		a.Dispose()
+		return nil, err
+	}
+	if i.header == nil {
+		a.Dispose()
+		return nil, log.Err(ctx, nil, "Capture was missing header chunk")
+	}
+
+	b := i.builder
+	b.arena = a
+	out := b.build(name, i.header)
+	out.Commands = nil
+	out.stream = &cmdStream{
+		resIDs: b.resIDs,
+		chunks: i.chunks,
+		count:  uint64(b.nextCmdID),
+		reader: r,
+		lru:    list.New(),
+		cached: map[int]*list.Element{},
+		kept:   map[int]*decodedChunk{},
+	}
+	log.I(ctx, "Streaming %v commands of capture '%v' in %v chunks", b.nextCmdID, name, len(i.chunks))
+	return out, nil
+}
+
+// decode decodes the commands of the chunk with the given index using a new
+// arena.
+func (s *cmdStream) decode(ctx context.Context, index int) (*decodedChunk, error) {
+	chunk := s.chunks[index]
+	a := arena.New()
+	d := newDecoder(a)
+	d.skipResources = true
+	d.builder.resIDs = s.resIDs
+	d.builder.nextCmdID = chunk.first
+	ctx = id.PutRemapper(ctx, d)
+
+	s.readMutex.Lock()
+	err := s.reader.ReadRange(ctx, chunk.root, chunk.roots, d)
+	s.readMutex.Unlock()
+	if err != nil {
+		a.Dispose()
+		return nil, err
+	}
+	d.flush(ctx)
+	if got := uint64(len(d.builder.cmds)); got != chunk.count {
+		a.Dispose()
+		return nil, fmt.Errorf("Decoded %v commands for chunk %v, expected %v", got, index, chunk.count)
+	}
+	return &decodedChunk{index: index, cmds: d.builder.cmds, arena: a}, nil
+}
+
+// acquire returns the decoded chunk with the given index, decoding it if it
+// is not cached. The chunk must be released with release once its commands
+// are no longer used.
+func (s *cmdStream) acquire(ctx context.Context, index int) (*decodedChunk, error) {
+	s.mutex.Lock()
+	if e, ok := s.cached[index]; ok {
+		s.lru.MoveToFront(e)
+		c := e.Value.(*decodedChunk)
+		c.refs++
+		s.mutex.Unlock()
+		return c, nil
+	}
+	s.mutex.Unlock()
+
+	c, err := s.decode(ctx, index)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if e, ok := s.cached[index]; ok {
+		// Decoded by another user in the meantime.
+		c.arena.Dispose()
+		s.lru.MoveToFront(e)
+		c = e.Value.(*decodedChunk)
+	} else {
+		s.cached[index] = s.lru.PushFront(c)
+		s.evictLocked()
+	}
+	c.refs++
+	return c, nil
+}
+
+// release marks the end of a use of the chunk c returned by acquire.
+func (s *cmdStream) release(c *decodedChunk) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	c.refs--
+	if c.evicted && c.refs == 0 && !c.kept {
+		c.arena.Dispose()
+	}
+}
+
+// evictLocked removes the least recently used chunks until the cache is
+// within its size limit. Evicted chunks are freed once they are released.
+// evictLocked must be called with a locked mutex.
+func (s *cmdStream) evictLocked() {
+	for s.lru.Len() > maxCachedChunks {
+		e := s.lru.Back()
+		c := e.Value.(*decodedChunk)
+		s.lru.Remove(e)
+		delete(s.cached, c.index)
+		c.evicted = true
+		if c.refs == 0 && !c.kept {
+			c.arena.Dispose()
+		}
+	}
+}
+
+// chunkOf returns the index of the chunk holding the command with identifier
+// id.
+func (s *cmdStream) chunkOf(id api.CmdID) (int, error) {
+	if uint64(id) >= s.count {
+		return 0, fmt.Errorf("Command %v out of range [0..%v]", id, s.count-1)
+	}
+	i := sort.Search(len(s.chunks), func(i int) bool { return s.chunks[i].first > id }) - 1
+	if i < 0 {
+		return 0, fmt.Errorf("Command %v is not in any chunk", id)
+	}
+	return i, nil
+}
+
+// IsStreamed returns true if the commands of the capture are decoded on
+// demand rather than held in memory.
+func (c *Capture) IsStreamed() bool {
+	return c.stream != nil
+}
+
+// NumCommands returns the number of commands in the capture.
+func (c *Capture) NumCommands() uint64 {
+	if c.stream != nil {
+		return c.stream.count
+	}
+	return uint64(len(c.Commands))
+}
+
+// ForeachCmd calls cb with each command of the capture, in order, until cb
+// returns an error. If cb returns api.Break, then ForeachCmd stops and returns
+// nil.
+// For streamed captures the commands are decoded in chunks, and the commands
+// passed to cb must not be used once cb has returned.
+func (c *Capture) ForeachCmd(ctx context.Context, cb func(context.Context, api.CmdID, api.Cmd) error) error {
+	if c.stream == nil {
+		return api.ForeachCmd(ctx, c.Commands, cb)
+	}
+	for i := range c.stream.chunks {
+		chunk, err := c.stream.acquire(ctx, i)
+		if err != nil {
+			return err
+		}
+		first, stop := c.stream.chunks[i].first, false
+		err = api.ForeachCmd(ctx, chunk.cmds, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+			err := cb(ctx, first+id, cmd)
+			stop = err == api.Break
+			return err
+		})
+		c.stream.release(chunk)
+		if err != nil || stop {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cmd returns the command of the capture with the identifier id, and a
+// function to call once the command is no longer used.
+// For streamed captures the command is decoded on demand, and it is freed once
+// release has been called and its chunk is evicted from the cache of decoded
+// commands. The command must not be used after calling release.
+func (c *Capture) Cmd(ctx context.Context, id api.CmdID) (cmd api.Cmd, release func(), err error) {
+	if c.stream == nil {
+		if uint64(id) >= uint64(len(c.Commands)) {
+			return nil, nil, fmt.Errorf("Command %v out of range [0..%v]", id, len(c.Commands)-1)
+		}
+		return c.Commands[id], func() {}, nil
+	}
+	i, err := c.stream.chunkOf(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	chunk, err := c.stream.acquire(ctx, i)
+	if err != nil {
+		return nil, nil, err
+	}
+	return chunk.cmds[id-c.stream.chunks[i].first], func() { c.stream.release(chunk) }, nil
+}
+
+// KeepCmd returns the command of the capture with the identifier id, which
+// stays valid for the lifetime of the capture.
+// For streamed captures only the chunk holding the command is decoded, and the
+// commands of the chunk are then kept in memory for the lifetime of the
+// capture.
+func (c *Capture) KeepCmd(ctx context.Context, id api.CmdID) (api.Cmd, error) {
+	if c.stream == nil {
+		if uint64(id) >= uint64(len(c.Commands)) {
+			return nil, fmt.Errorf("Command %v out of range [0..%v]", id, len(c.Commands)-1)
+		}
+		return c.Commands[id], nil
+	}
+	s := c.stream
+	i, err := s.chunkOf(id)
+	if err != nil {
+		return nil, err
+	}
+	first := s.chunks[i].first
+
+	s.mutex.Lock()
+	chunk, ok := s.kept[i]
+	all := s.all
+	s.mutex.Unlock()
+	switch {
+	case all != nil:
+		return all[id], nil
+	case ok:
+		return chunk.cmds[id-first], nil
+	}
+
+	chunk, err = s.acquire(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	chunk.kept = true
+	s.kept[i] = chunk
+	s.mutex.Unlock()
+	s.release(chunk)
+	return chunk.cmds[id-first], nil
+}
+
+// AllCommands returns the list of all the commands of the capture.
+// For streamed captures this decodes all the commands and keeps them in memory
+// for the lifetime of the capture, so once AllCommands has been called the
+// capture uses as much memory as if it was not streamed. Streaming only bounds
+// the memory used by captures that are exclusively accessed with ForeachCmd,
+// Cmd and KeepCmd, as commands resolved by path, replays, dependency graphs, footprints, trims, splits and exports
+// are. Prefer ForeachCmd, Cmd and KeepCmd over AllCommands.
+func (c *Capture) AllCommands(ctx context.Context) ([]api.Cmd, error) {
+	if c.stream == nil {
+		return c.Commands, nil
+	}
+	s := c.stream
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.all != nil {
+		return s.all, nil
+	}
+	log.W(ctx, "Decoding all %v commands of streamed capture '%v'", s.count, c.Name)
+	all := make([]api.Cmd, 0, s.count)
+	for i := range s.chunks {
+		chunk, err := s.decode(ctx, i)
+		if err != nil {
+			return nil, err
+		}
+		// The chunk's arena is never disposed as the commands are kept.
+		all = append(all, chunk.cmds...)
+	}
+	s.all = all
+	return all, nil
+}
@@ -0,0 +1,188 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/google/gapid/core/app/status"
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/memory"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// ExportFrames encodes count frames of the given capture, starting with the
+// frame first, and writes it to the supplied io.Writer in the .gfxtrace format.
+// See Capture.ExportFrames for details.
+func ExportFrames(ctx context.Context, p *path.Capture, first, count uint64, w io.Writer) error {
+	c, err := ResolveFromPath(ctx, p)
+	if err != nil {
+		return err
+	}
+	return c.ExportFrames(Put(ctx, p), first, count, w)
+}
+
+// ExportFrames encodes count frames of the capture, starting with the frame
+// first, and writes it to the supplied io.Writer in the .gfxtrace format.
+// If count is 0 then all the frames from first to the end of the capture are
+// written.
+// The state at the start of frame first is serialized as the initial state of
+// the written capture, which is turned back into commands by the API state
+// rebuilders when the capture is replayed.
+func (c *Capture) ExportFrames(ctx context.Context, first, count uint64, w io.Writer) error {
+	t, err := c.trim(ctx, first, count)
+	if err != nil {
+		return err
+	}
+	return t.Export(ctx, w)
+}
+
+// trim returns a capture holding only count frames of c, starting with the
+// frame first. The returned capture must only be used for exporting.
+func (c *Capture) trim(ctx context.Context, first, count uint64) (*Capture, error) {
+	ctx = status.Start(ctx, "Trimming capture '%v'", c.Name)
+	defer status.Finish(ctx)
+
+	last := uint64(math.MaxUint64)
+	if count > 0 {
+		last = first + count - 1
+	}
+
+	// Mutate up to the end of the requested frames, snapshotting the state at
+	// the start of frame first. Commands after frame first still need to be
+	// mutated as their flags may depend on the state.
+	a := arena.New()
+	s := c.NewState(ctx)
+	initialState := c.InitialState
+	start, end := api.CmdID(0), api.CmdID(c.NumCommands())
+	frame := uint64(0)
+	err := c.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if err := cmd.Mutate(ctx, id, s, nil); err != nil {
+			log.W(ctx, "Trim: Cmd [%v]%v - %v", id, cmd, err)
+		}
+		if !cmd.CmdFlags(ctx, id, s).IsEndOfFrame() {
+			return nil
+		}
+		if frame == last {
+			end = id + 1
+			return api.Break
+		}
+		frame++
+		if frame == first {
+			start = id + 1
+			var err error
+			initialState, err = snapshot(ctx, s, a)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if first > 0 && (frame < first || start == end) {
+		return nil, fmt.Errorf("Capture has only %v frames, frame %v requested", frame, first)
+	}
+
+	ids := make([]api.CmdID, 0, end-start)
+	for id := start; id < end; id++ {
+		ids = append(ids, id)
+	}
+	return c.subset(ctx, c.Name, ids, initialState, a)
+}
+
+// cmdSubset is the list of the commands of a capture built from a subset of
+// the commands of another capture.
+type cmdSubset struct {
+	from *Capture    // The capture the commands are taken from.
+	ids  []api.CmdID // The identifiers of the commands in from, in order.
+}
+
+// foreachCmd calls cb with each command of the subset, and its identifier in
+// the capture the commands are taken from, until cb returns an error.
+func (s *cmdSubset) foreachCmd(ctx context.Context, cb func(context.Context, api.CmdID, api.Cmd) error) error {
+	if len(s.ids) == 0 {
+		return nil
+	}
+	i := 0
+	return s.from.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if id != s.ids[i] {
+			return nil
+		}
+		if err := cb(ctx, id, cmd); err != nil {
+			return err
+		}
+		if i++; i == len(s.ids) {
+			return api.Break
+		}
+		return nil
+	})
+}
+
+// contains returns true if the command with identifier id in the capture the
+// commands are taken from is part of the subset.
+func (s *cmdSubset) contains(id api.CmdID) bool {
+	i := sort.Search(len(s.ids), func(i int) bool { return s.ids[i] >= id })
+	return i < len(s.ids) && s.ids[i] == id
+}
+
+// subset returns a capture named name holding the commands of c with the given
+// ids, in increasing order, starting with the given initial state.
+// The commands of the returned capture are read from c, so it must only be
+// used for exporting.
+func (c *Capture) subset(ctx context.Context, name string, ids []api.CmdID, initialState *InitialState, a arena.Arena) (*Capture, error) {
+	return &Capture{
+		Name:         name,
+		Header:       c.Header,
+		APIs:         c.APIs,
+		Observed:     c.Observed,
+		InitialState: initialState,
+		Arena:        a,
+		subsetOf:     &cmdSubset{from: c, ids: ids},
+	}, nil
+}
+
+// snapshot returns an InitialState holding a copy of the API states and all
+// the written memory of s. The API states are cloned using the arena a.
+func snapshot(ctx context.Context, s *api.GlobalState, a arena.Arena) (*InitialState, error) {
+	out := &InitialState{APIs: map[api.API]api.State{}}
+	for _, st := range s.APIs {
+		out.APIs[st.API()] = st.Clone(a)
+	}
+	for i := memory.PoolID(0); i < s.Memory.NextPoolID(); i++ {
+		pool, err := s.Memory.Get(i)
+		if err != nil {
+			continue // Pool was never created.
+		}
+		all := pool.Slice(memory.Range{Base: 0, Size: math.MaxUint64})
+		for _, rng := range all.ValidRanges() {
+			if err := task.StopReason(ctx); err != nil {
+				return nil, err
+			}
+			resID, err := all.Slice(rng).ResourceID(ctx)
+			if err != nil {
+				return nil, err
+			}
+			out.Memory = append(out.Memory, api.CmdObservation{Pool: i, Range: rng, ID: resID})
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/test"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/memory"
+)
+
+// frameEnd wraps a command so that it ends a frame. frameEnd commands cannot
+// be encoded, so they must not be exported.
+type frameEnd struct{ api.Cmd }
+
+func (f frameEnd) CmdFlags(ctx context.Context, id api.CmdID, s *api.GlobalState) api.CmdFlags {
+	return f.Cmd.CmdFlags(ctx, id, s) | api.EndOfFrame
+}
+
+func newTrimCapture(ctx context.Context, a arena.Arena, cmds []api.Cmd) *Capture {
+	b := newBuilder(a)
+	for _, cmd := range cmds {
+		b.addCmd(ctx, cmd)
+	}
+	return b.build("test", &Header{ABI: device.WindowsX86_64})
+}
+
+func TestTrim(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	a := arena.New()
+	cb := test.CommandBuilder{Arena: a}
+	cmds := []api.Cmd{
+		cb.CmdAdd(1, 2), frameEnd{cb.CmdVoid()}, // Frame 0
+		cb.CmdAdd(3, 4), cb.CmdVoid(), frameEnd{cb.CmdVoid()}, // Frame 1
+		cb.CmdAdd(5, 6), frameEnd{cb.CmdVoid()}, // Frame 2
+	}
+	c := newTrimCapture(ctx, a, cmds)
+
+	trimmed, err := c.trim(ctx, 1, 1)
+	if !assert.For(ctx, "trim").ThatError(err).Succeeded() {
+		return
+	}
+
+	// Only the commands of frame 1 are kept.
+	assert.For(ctx, "commands").ThatSlice(trimmed.subsetOf.ids).Equals([]api.CmdID{2, 3, 4})
+
+	// The initial state is the state at the end of frame 0.
+	s := trimmed.NewState(ctx)
+	got, err := test.GetState(s).Ints().Read(ctx, nil, s, nil)
+	if assert.For(ctx, "initial state read").ThatError(err).Succeeded() {
+		assert.For(ctx, "initial state").ThatSlice(got).Equals([]memory.Int{3})
+	}
+
+	// Requesting frames beyond the end of the capture fails.
+	_, err = c.trim(ctx, 3, 1)
+	assert.For(ctx, "trim beyond end").ThatError(err).Failed()
+}
+
+func TestExportFrames(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	a := arena.New()
+	cb := test.CommandBuilder{Arena: a}
+	c := newTrimCapture(ctx, a, []api.Cmd{
+		cb.CmdAdd(1, 2), frameEnd{cb.CmdVoid()}, // Frame 0
+		cb.CmdAdd(3, 4), cb.CmdVoid(), // Unfinished frame 1
+	})
+
+	buf := &bytes.Buffer{}
+	err := c.ExportFrames(ctx, 1, 0, buf)
+	if !assert.For(ctx, "ExportFrames").ThatError(err).Succeeded() {
+		return
+	}
+
+	ip, err := Import(ctx, "trimmed", buf.Bytes())
+	if !assert.For(ctx, "Import").ThatError(err).Succeeded() {
+		return
+	}
+	ic, err := ResolveFromPath(ctx, ip)
+	if !assert.For(ctx, "ResolveFromPath").ThatError(err).Succeeded() {
+		return
+	}
+
+	names := []string{}
+	for _, cmd := range ic.Commands {
+		names = append(names, cmd.CmdName())
+	}
+	assert.For(ctx, "names").ThatSlice(names).Equals([]string{"cmdAdd", "cmdVoid"})
+
+	s := ic.NewState(ctx)
+	got, err := test.GetState(s).Ints().Read(ctx, nil, s, nil)
+	if assert.For(ctx, "initial state read").ThatError(err).Succeeded() {
+		assert.For(ctx, "initial state").ThatSlice(got).Equals([]memory.Int{3})
+	}
+}
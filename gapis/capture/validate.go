@@ -0,0 +1,264 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/app/status"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/data/pack"
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/gapis/service"
+	"github.com/pkg/errors"
+)
+
+// Validate checks the integrity of the capture data, returning the problems
+// found. Unlike loading the capture, Validate carries on past the problems
+// that do not prevent reading the rest of the data: observations of missing
+// resources, objects of unknown APIs and unsupported capture versions are
+// reported and skipped.
+// If salvage is true and the capture is damaged, then the intact commands are
+// imported as a new capture named name, which is returned in the Salvaged
+// field.
+func Validate(ctx context.Context, name string, data []byte, salvage bool) (*service.CaptureValidation, error) {
+	ctx = status.Start(ctx, "Validating capture '%v'", name)
+	defer status.Finish(ctx)
+
+	a := arena.New()
+	defer a.Dispose()
+
+	v := &validator{
+		decoder: newDecoder(a),
+		unknown: map[string]int{},
+		pending: map[*cmdGroup][]*service.CaptureIssue{},
+	}
+	v.builder.dropCmds = !salvage
+
+	// The validator implements the ID Remapper interface,
+	// which protoconv functions need to handle resources.
+	ctx = id.PutRemapper(ctx, v)
+
+	err := pack.Read(ctx, bytes.NewReader(data), v, false)
+	if err := task.StopReason(ctx); err != nil {
+		return nil, err
+	}
+
+	damaged := true
+	switch cause := errors.Cause(err).(type) {
+	case nil:
+		damaged = len(v.groups) > 0 || v.end < int64(len(data))
+		if damaged {
+			i := v.issue(service.CaptureIssueKind_TruncatedCapture,
+				"Capture is truncated after %v of %v bytes, with %v incomplete commands or groups",
+				v.end, len(data), len(v.groups))
+			i.Offset = uint64(v.end)
+		}
+	case pack.ErrUnsupportedVersion, pack.ErrUnknownCodec:
+		v.issue(service.CaptureIssueKind_UnsupportedCaptureVersion, "%v", cause)
+		v.badVersion = true
+	default:
+		var i *service.CaptureIssue
+		if cause == pack.ErrIncorrectMagic {
+			i = v.issue(service.CaptureIssueKind_CorruptCapture, "Not a capture file: %v", cause)
+		} else {
+			i = v.issue(service.CaptureIssueKind_CorruptCapture, "Capture data could not be decoded: %v", err)
+		}
+		i.Offset = uint64(v.end)
+	}
+	if v.header == nil {
+		v.issue(service.CaptureIssueKind_MissingHeader, "Capture has no header")
+	}
+	v.unknownIssues()
+
+	out := &service.CaptureValidation{
+		Size:              uint64(len(data)),
+		IntactSize:        uint64(v.end),
+		IntactCommands:    uint64(v.builder.nextCmdID),
+		LastIntactCommand: v.lastCmd,
+		Issues:            v.issues,
+	}
+	if v.header != nil {
+		out.Version = v.header.Version
+	}
+
+	if salvage && (damaged || v.missingRes) && v.header != nil && !v.badVersion {
+		c := v.builder.build(name, v.header)
+		if out.Salvaged, err = reimport(ctx, c); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// validator is a decoder that records the problems found in the capture data
+// instead of failing on them.
+type validator struct {
+	*decoder
+	end        int64  // The offset of the end of the last chunk that left no open groups.
+	lastCmd    string // The name of the last complete command.
+	issues     []*service.CaptureIssue
+	missing    []int64        // Indices of the missing resources of the object being decoded.
+	missingRes bool           // True if any resource is missing.
+	badVersion bool           // True if the capture version is not supported.
+	unknown    map[string]int // The number of objects of unknown types by package.
+
+	// pending holds the issues of commands that are not complete, whose
+	// indices are not yet known.
+	pending map[*cmdGroup][]*service.CaptureIssue
+}
+
+// issue adds and returns a new issue of the given kind.
+func (v *validator) issue(kind service.CaptureIssueKind, msg string, args ...interface{}) *service.CaptureIssue {
+	i := &service.CaptureIssue{
+		Kind:    kind,
+		Message: fmt.Sprintf(msg, args...),
+	}
+	v.issues = append(v.issues, i)
+	return i
+}
+
+// unknownIssues adds an issue for each package of unknown types.
+func (v *validator) unknownIssues() {
+	pkgs := make([]string, 0, len(v.unknown))
+	for pkg := range v.unknown {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		v.issue(service.CaptureIssueKind_UnknownAPI,
+			"%v objects of the unknown API or package '%v' were skipped", v.unknown[pkg], pkg)
+	}
+}
+
+// Checkpoint implements the pack.Checkpointer interface.
+func (v *validator) Checkpoint(ctx context.Context, cp pack.Checkpoint) error {
+	v.end = cp.Offset
+	return nil
+}
+
+// RemapIndex remaps resource index to ID. Unlike the decoder, the validator
+// records the indices of missing resources, which are remapped to the empty
+// resource.
+func (v *validator) RemapIndex(ctx context.Context, index int64) (id.ID, error) {
+	resID, err := v.decoder.RemapIndex(ctx, index)
+	if err != nil {
+		v.missing = append(v.missing, index)
+		v.missingRes = true
+		return id.ID{}, nil
+	}
+	return resID, nil
+}
+
+func (v *validator) BeginGroup(ctx context.Context, msg proto.Message, id uint64) error {
+	obj, err := v.decode(ctx, msg, nil)
+	if err != nil {
+		return err
+	}
+	v.groups[id] = obj
+	return nil
+}
+
+func (v *validator) BeginChildGroup(ctx context.Context, msg proto.Message, id, parentID uint64) error {
+	parent := v.groups[parentID]
+	obj, err := v.decode(ctx, msg, parent)
+	if err != nil {
+		return err
+	}
+	v.groups[id] = obj
+	return v.add(ctx, obj, parent)
+}
+
+func (v *validator) EndGroup(ctx context.Context, id uint64) error {
+	obj := v.groups[id]
+	if err := v.decoder.EndGroup(ctx, id); err != nil {
+		return err
+	}
+	if g, ok := obj.(*cmdGroup); ok {
+		v.lastCmd = g.cmd.CmdName()
+		for _, i := range v.pending[g] {
+			i.Command = uint64(v.builder.nextCmdID - 1)
+			i.CommandComplete = true
+		}
+		delete(v.pending, g)
+	}
+	return nil
+}
+
+func (v *validator) Object(ctx context.Context, msg proto.Message) error {
+	_, err := v.decode(ctx, msg, nil)
+	return err
+}
+
+func (v *validator) ChildObject(ctx context.Context, msg proto.Message, parentID uint64) error {
+	parent := v.groups[parentID]
+	obj, err := v.decode(ctx, msg, parent)
+	if err != nil {
+		return err
+	}
+	return v.add(ctx, obj, parent)
+}
+
+// add adds the decoded child to its parent, skipping objects of unknown types.
+func (v *validator) add(ctx context.Context, child, parent interface{}) error {
+	if _, ok := child.(*pack.Dynamic); ok {
+		return nil
+	}
+	return v.decoder.add(ctx, child, parent)
+}
+
+// decode decodes the message msg, a child of parent, recording unsupported
+// capture versions, unknown types and missing resources.
+func (v *validator) decode(ctx context.Context, msg proto.Message, parent interface{}) (interface{}, error) {
+	if dyn, ok := msg.(*pack.Dynamic); ok {
+		pkg := dyn.TypeName()
+		if i := strings.Index(pkg, "."); i >= 0 {
+			pkg = pkg[:i]
+		}
+		v.unknown[pkg]++
+		return msg, nil
+	}
+
+	obj, err := v.decoder.decode(ctx, msg)
+	if e, ok := err.(ErrUnsupportedVersion); ok {
+		v.issue(service.CaptureIssueKind_UnsupportedCaptureVersion,
+			"Capture format version %v is not supported, expected versions %v to %v",
+			e.Version, MinCaptureVersion, CurrentCaptureVersion)
+		v.badVersion = true
+		return msg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, index := range v.missing {
+		i := v.issue(service.CaptureIssueKind_MissingResource, "Resource %v is missing", index)
+		switch parent := parent.(type) {
+		case *cmdGroup:
+			i.CommandName = parent.cmd.CmdName()
+			v.pending[parent] = append(v.pending[parent], i)
+		case *InitialState:
+			i.Message += " from the initial state"
+		}
+	}
+	v.missing = v.missing[:0]
+	return obj, nil
+}
@@ -207,9 +207,10 @@ func (c *client) ImportCapture(ctx context.Context, name string, data []byte) (*
 	return res.GetCapture(), nil
 }
 
-func (c *client) ExportCapture(ctx context.Context, p *path.Capture) ([]byte, error) {
+func (c *client) ExportCapture(ctx context.Context, p *path.Capture, frames *service.FrameRange) ([]byte, error) {
 	res, err := c.client.ExportCapture(ctx, &service.ExportCaptureRequest{
 		Capture: p,
+		Frames:  frames,
 	})
 	if err != nil {
 		return nil, err
@@ -220,6 +221,34 @@ func (c *client) ExportCapture(ctx context.Context, p *path.Capture) ([]byte, er
 	return res.GetData(), nil
 }
 
+func (c *client) SplitCapture(ctx context.Context, p *path.Capture, mode service.SplitMode) ([]*path.Capture, error) {
+	res, err := c.client.SplitCapture(ctx, &service.SplitCaptureRequest{
+		Capture: p,
+		Mode:    mode,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetCaptures().List, nil
+}
+
+func (c *client) MergeCaptures(ctx context.Context, name string, captures []*path.Capture) (*path.Capture, error) {
+	res, err := c.client.MergeCaptures(ctx, &service.MergeCapturesRequest{
+		Name:     name,
+		Captures: captures,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetCapture(), nil
+}
+
 func (c *client) LoadCapture(ctx context.Context, path string) (*path.Capture, error) {
 	res, err := c.client.LoadCapture(ctx, &service.LoadCaptureRequest{
 		Path: path,
@@ -247,6 +276,20 @@ func (c *client) SaveCapture(ctx context.Context, capture *path.Capture, path st
 	return nil
 }
 
+func (c *client) ValidateCapture(ctx context.Context, path string, salvage bool) (*service.CaptureValidation, error) {
+	res, err := c.client.ValidateCapture(ctx, &service.ValidateCaptureRequest{
+		Path:    path,
+		Salvage: salvage,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetValidation(), nil
+}
+
 func (c *client) GetDevices(ctx context.Context) ([]*path.Device, error) {
 	res, err := c.client.GetDevices(ctx, &service.GetDevicesRequest{})
 	if err != nil {
@@ -317,6 +360,21 @@ func (c *client) Find(ctx context.Context, req *service.FindRequest, handler ser
 	return event.Feed(ctx, event.AsHandler(ctx, h), grpcutil.ToProducer(stream))
 }
 
+func (c *client) DiffCaptures(ctx context.Context, a, b *path.Capture, r *path.ResolveConfig) (*service.CaptureDiff, error) {
+	res, err := c.client.DiffCaptures(ctx, &service.DiffCapturesRequest{
+		A:      a,
+		B:      b,
+		Config: r,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetDiff(), nil
+}
+
 func (c *client) EnableCrashReporting(ctx context.Context, enable bool) error {
 	_, err := c.client.EnableCrashReporting(ctx, &service.EnableCrashReportingRequest{
 		Enable: enable,
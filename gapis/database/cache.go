@@ -0,0 +1,106 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/core/data/id"
+)
+
+// Cache is the interface to a content-addressed byte store that a Database
+// can use to hold encoded records and resolved values beyond the lifetime of
+// the process.
+// As entries are keyed by the hash of their content, an entry for a given id
+// never changes once written.
+type Cache interface {
+	// Get returns the data stored for id.
+	// If the cache has no entry for id then ok is false.
+	Get(ctx context.Context, id id.ID) (data []byte, ok bool, err error)
+	// Put stores data for id. Putting an id that is already in the cache is a
+	// no-op.
+	Put(ctx context.Context, id id.ID, data []byte) error
+	// Contains returns true if the cache is known to hold an entry for id.
+	// Contains is called for every database lookup miss, so it must only
+	// consult an in-memory index and never perform any I/O. Caches that cannot
+	// answer cheaply should return false.
+	Contains(ctx context.Context, id id.ID) bool
+}
+
+// DependentCache is the interface implemented by caches that evict entries.
+// Resolved values can refer to other entries by their id, so a resolved value
+// must not outlive the entries it refers to.
+type DependentCache interface {
+	Cache
+	// PutDependent stores data for id, like Put, along with the ids of the
+	// entries that data refers to. The entry for id is evicted along with any
+	// of the entries deps. If any of deps is not held by the cache then data
+	// is not stored.
+	PutDependent(ctx context.Context, id id.ID, data []byte, deps []id.ID) error
+}
+
+// NewCached builds a new in memory database that is backed by the cache c.
+// Records stored in the database are written through to c, and the results of
+// resolving Resolvables are looked up in c before they are built.
+func NewCached(ctx context.Context, c Cache) Database {
+	m := newMemory(ctx)
+	m.cache = c
+	return m
+}
+
+// NewOnDisk builds a new database backed by a disk cache in the directory dir.
+// See NewDiskCache for details on limit.
+func NewOnDisk(ctx context.Context, dir string, limit int64) (Database, error) {
+	c, err := NewDiskCache(ctx, dir, limit)
+	if err != nil {
+		return nil, err
+	}
+	return NewCached(ctx, c), nil
+}
+
+// recordSeparator separates the record type from the record data in a cache
+// entry. Record types are proto message names or blob, so can never contain
+// this byte.
+const recordSeparator = 0
+
+// encodeEntry returns the cache entry for the record data of type ty.
+func encodeEntry(ty recordType, data []byte) []byte {
+	out := make([]byte, 0, len(ty)+1+len(data))
+	out = append(out, ty...)
+	out = append(out, recordSeparator)
+	return append(out, data...)
+}
+
+// decodeEntry returns the record held by the cache entry data.
+func decodeEntry(data []byte) (*record, error) {
+	i := bytes.IndexByte(data, recordSeparator)
+	if i < 0 {
+		return nil, fmt.Errorf("Corrupt cache entry: missing record type")
+	}
+	return &record{ty: recordType(data[:i]), data: data[i+1:]}, nil
+}
+
+// IsRecordEntry returns true if the cache entry data holds an encoded record
+// whose content hashes to id. Entries that are not records, such as resolved
+// values, cannot be verified from their content alone and return false.
+func IsRecordEntry(id id.ID, data []byte) bool {
+	r, err := decodeEntry(data)
+	if err != nil {
+		return false
+	}
+	return generateID(r.ty, r.data) == id
+}
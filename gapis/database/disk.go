@@ -0,0 +1,399 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"container/list"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+)
+
+// tmpDirName is the name of the cache sub-directory used to hold partially
+// written entries.
+const tmpDirName = "tmp"
+
+// depsDirName is the name of the cache sub-directory holding the ids of the
+// entries each dependent entry refers to.
+const depsDirName = "deps"
+
+// NewDiskCache returns a Cache that holds each entry as a file under dir.
+// Once the total size of the entries exceeds limit bytes, the least recently
+// used entries are evicted. A limit of 0 or less means the cache is unbounded.
+// Entries are written to a temporary file and then renamed into place, so a
+// crash part way through a write never leaves a partial entry in the cache.
+// Entries stored with PutDependent are evicted along with the entries they
+// depend on.
+func NewDiskCache(ctx context.Context, dir string, limit int64) (DependentCache, error) {
+	c := &diskCache{
+		dir:        dir,
+		limit:      limit,
+		lru:        list.New(),
+		entries:    map[id.ID]*list.Element{},
+		deps:       map[id.ID][]id.ID{},
+		dependents: map[id.ID][]id.ID{},
+	}
+	if err := c.open(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type diskCache struct {
+	dir     string
+	limit   int64
+	mutex   sync.Mutex
+	size    int64                   // Total size of all the entries in bytes.
+	lru     *list.List              // *diskEntry, most recently used at the front.
+	entries map[id.ID]*list.Element // Elements of lru.
+	// deps maps each dependent entry to the entries it depends on, and
+	// dependents maps entries to the entries that depend on them.
+	deps       map[id.ID][]id.ID
+	dependents map[id.ID][]id.ID
+}
+
+type diskEntry struct {
+	id      id.ID
+	size    int64
+	modTime time.Time
+}
+
+// open prepares the cache directory and builds the LRU list from the entries
+// left by a previous session.
+func (c *diskCache) open(ctx context.Context) error {
+	// Anything in the temporary directory was abandoned mid-write.
+	tmp := filepath.Join(c.dir, tmpDirName)
+	if err := os.RemoveAll(tmp); err != nil {
+		return log.Errf(ctx, err, "Failed to clear '%v'", tmp)
+	}
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return log.Errf(ctx, err, "Failed to create '%v'", tmp)
+	}
+
+	found := []*diskEntry{}
+	err := filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path == tmp || path == filepath.Join(c.dir, depsDirName) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(c.dir, path)
+		if err != nil {
+			return err
+		}
+		entryID, err := id.Parse(filepath.Dir(rel) + filepath.Base(rel))
+		if err != nil {
+			log.W(ctx, "Ignoring unexpected file in database cache: %v", path)
+			return nil
+		}
+		found = append(found, &diskEntry{entryID, info.Size(), info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return log.Errf(ctx, err, "Failed to scan '%v'", c.dir)
+	}
+
+	// File modification times are bumped on each Get, so they give us the
+	// order of use from the last session.
+	sort.Slice(found, func(i, j int) bool { return found[i].modTime.Before(found[j].modTime) })
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, e := range found {
+		c.entries[e.id] = c.lru.PushFront(e)
+		c.size += e.size
+	}
+	if err := c.openDepsLocked(ctx); err != nil {
+		return err
+	}
+	c.evictLocked(ctx)
+
+	log.I(ctx, "Opened database cache '%v' with %d entries (%d bytes)", c.dir, c.lru.Len(), c.size)
+	return nil
+}
+
+// openDepsLocked loads the dependencies of the entries stored with
+// PutDependent. Dependent entries whose dependencies are missing are evicted.
+// openDepsLocked must be called with a locked mutex.
+func (c *diskCache) openDepsLocked(ctx context.Context) error {
+	dir := filepath.Join(c.dir, depsDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return log.Errf(ctx, err, "Failed to create '%v'", dir)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return log.Errf(ctx, err, "Failed to scan '%v'", dir)
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.Name())
+		entryID, err := id.Parse(f.Name())
+		data, rerr := ioutil.ReadFile(path)
+		if err != nil || rerr != nil || len(data)%len(entryID) != 0 {
+			log.W(ctx, "Ignoring unexpected file in database cache: %v", path)
+			os.Remove(path)
+			continue
+		}
+		deps := make([]id.ID, len(data)/len(entryID))
+		for i := range deps {
+			copy(deps[i][:], data[i*len(entryID):])
+		}
+		if _, ok := c.entries[entryID]; !ok {
+			os.Remove(path)
+			continue
+		}
+		c.addDepsLocked(entryID, deps)
+		for _, dep := range deps {
+			if _, ok := c.entries[dep]; !ok {
+				c.removeLocked(ctx, entryID)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// addDepsLocked records that the entry id depends on the entries deps.
+// addDepsLocked must be called with a locked mutex.
+func (c *diskCache) addDepsLocked(id id.ID, deps []id.ID) {
+	c.deps[id] = deps
+	for _, dep := range deps {
+		c.dependents[dep] = append(c.dependents[dep], id)
+	}
+}
+
+// depsPath returns the path of the file holding the dependencies of the entry
+// with the given id.
+func (c *diskCache) depsPath(id id.ID) string {
+	return filepath.Join(c.dir, depsDirName, id.String())
+}
+
+// path returns the file path for the entry with the given id.
+// Entries are sharded into sub-directories by the first byte of the id to
+// keep directory sizes manageable.
+func (c *diskCache) path(id id.ID) string {
+	s := id.String()
+	return filepath.Join(c.dir, s[:2], s[2:])
+}
+
+// Implements Cache
+func (c *diskCache) Get(ctx context.Context, id id.ID) ([]byte, bool, error) {
+	c.mutex.Lock()
+	e, ok := c.entries[id]
+	if ok {
+		c.lru.MoveToFront(e)
+	}
+	c.mutex.Unlock()
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	path := c.path(id)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Removed behind our back. Treat as a miss.
+			c.mutex.Lock()
+			c.removeLocked(ctx, id)
+			c.mutex.Unlock()
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	// Persist the recency of use for the next session.
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	return data, true, nil
+}
+
+// Implements Cache
+func (c *diskCache) Contains(ctx context.Context, id id.ID) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, got := c.entries[id]
+	return got
+}
+
+// Implements Cache
+func (c *diskCache) Put(ctx context.Context, id id.ID, data []byte) error {
+	c.mutex.Lock()
+	_, got := c.entries[id]
+	c.mutex.Unlock()
+	if got {
+		return nil
+	}
+
+	if err := c.write(c.path(id), data); err != nil {
+		return log.Errf(ctx, err, "Failed to write database cache entry %v", id)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, got := c.entries[id]; !got {
+		c.entries[id] = c.lru.PushFront(&diskEntry{id: id, size: int64(len(data))})
+		c.size += int64(len(data))
+		c.evictLocked(ctx)
+	}
+	return nil
+}
+
+// Implements DependentCache
+func (c *diskCache) PutDependent(ctx context.Context, id id.ID, data []byte, deps []id.ID) error {
+	if !c.containsAll(deps) {
+		return nil
+	}
+
+	encoded := []byte{}
+	for _, dep := range deps {
+		encoded = append(encoded, dep[:]...)
+	}
+	if err := c.write(c.depsPath(id), encoded); err != nil {
+		return log.Errf(ctx, err, "Failed to write database cache dependencies of %v", id)
+	}
+	if err := c.Put(ctx, id, data); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, got := c.entries[id]; !got {
+		// Evicted as soon as it was written.
+		os.Remove(c.depsPath(id))
+		return nil
+	}
+	if _, got := c.deps[id]; got {
+		return nil
+	}
+	c.addDepsLocked(id, deps)
+	for _, dep := range deps {
+		if _, got := c.entries[dep]; !got {
+			// A dependency was evicted to make room for the entry.
+			c.removeLocked(ctx, id)
+			break
+		}
+	}
+	return nil
+}
+
+// containsAll returns true if the cache holds all the entries ids.
+func (c *diskCache) containsAll(ids []id.ID) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, id := range ids {
+		if _, got := c.entries[id]; !got {
+			return false
+		}
+	}
+	return true
+}
+
+// write writes data to a temporary file which is synced and then renamed to
+// path.
+func (c *diskCache) write(path string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Join(c.dir, tmpDirName), "")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// evictLocked removes the least recently used entries until the cache is
+// within its size limit. evictLocked must be called with a locked mutex.
+func (c *diskCache) evictLocked(ctx context.Context) {
+	if c.limit <= 0 {
+		return
+	}
+	for c.size > c.limit && c.lru.Len() > 0 {
+		c.removeLocked(ctx, c.lru.Back().Value.(*diskEntry).id)
+	}
+}
+
+// removeLocked removes the entry with the given id, along with all the
+// entries that depend on it. removeLocked must be called with a locked mutex.
+func (c *diskCache) removeLocked(ctx context.Context, entryID id.ID) {
+	pending := []id.ID{entryID}
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if e, got := c.entries[id]; got {
+			if err := os.Remove(c.path(id)); err != nil && !os.IsNotExist(err) {
+				log.W(ctx, "Failed to evict database cache entry: %v", err)
+			}
+			c.lru.Remove(e)
+			delete(c.entries, id)
+			c.size -= e.Value.(*diskEntry).size
+		}
+
+		pending = append(pending, c.dependents[id]...)
+		delete(c.dependents, id)
+
+		if deps, got := c.deps[id]; got {
+			os.Remove(c.depsPath(id))
+			delete(c.deps, id)
+			for _, dep := range deps {
+				c.dependents[dep] = removeID(c.dependents[dep], id)
+				if len(c.dependents[dep]) == 0 {
+					delete(c.dependents, dep)
+				}
+			}
+		}
+	}
+}
+
+// removeID returns ids without the elements equal to remove.
+func removeID(ids []id.ID, remove id.ID) []id.ID {
+	out := ids[:0]
+	for _, i := range ids {
+		if i != remove {
+			out = append(out, i)
+		}
+	}
+	return out
+}
@@ -0,0 +1,155 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/database"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "database")
+	if err != nil {
+		t.Fatalf("Couldn't create temporary directory: %v", err)
+	}
+	return dir
+}
+
+func TestDiskCachePutGet(t *testing.T) {
+	ctx := log.Testing(t)
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	c, err := database.NewDiskCache(ctx, dir, 0)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+
+	a, b := id.OfString("a"), id.OfString("b")
+	assert.For(ctx, "put").ThatError(c.Put(ctx, a, []byte("apple"))).Succeeded()
+
+	data, ok, err := c.Get(ctx, a)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "ok").That(ok).Equals(true)
+	assert.For(ctx, "data").ThatSlice(data).Equals([]byte("apple"))
+
+	_, ok, err = c.Get(ctx, b)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "ok").That(ok).Equals(false)
+}
+
+func TestDiskCacheReopen(t *testing.T) {
+	ctx := log.Testing(t)
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	a := id.OfString("a")
+	c, err := database.NewDiskCache(ctx, dir, 0)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "put").ThatError(c.Put(ctx, a, []byte("apple"))).Succeeded()
+
+	// Simulate a write that was interrupted by a crash.
+	abandoned := filepath.Join(dir, "tmp", "partial")
+	assert.For(ctx, "write").ThatError(ioutil.WriteFile(abandoned, []byte("app"), 0644)).Succeeded()
+
+	c, err = database.NewDiskCache(ctx, dir, 0)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+
+	data, ok, err := c.Get(ctx, a)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "ok").That(ok).Equals(true)
+	assert.For(ctx, "data").ThatSlice(data).Equals([]byte("apple"))
+
+	_, err = os.Stat(abandoned)
+	assert.For(ctx, "abandoned exists").That(os.IsNotExist(err)).Equals(true)
+}
+
+func TestDiskCacheEviction(t *testing.T) {
+	ctx := log.Testing(t)
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	c, err := database.NewDiskCache(ctx, dir, 10)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+
+	a, b, x := id.OfString("a"), id.OfString("b"), id.OfString("c")
+	assert.For(ctx, "put a").ThatError(c.Put(ctx, a, []byte("aaaa"))).Succeeded()
+	assert.For(ctx, "put b").ThatError(c.Put(ctx, b, []byte("bbbb"))).Succeeded()
+	_, _, err = c.Get(ctx, a) // Make b the least recently used.
+	assert.For(ctx, "get a").ThatError(err).Succeeded()
+	assert.For(ctx, "put c").ThatError(c.Put(ctx, x, []byte("cccc"))).Succeeded()
+
+	for _, test := range []struct {
+		name     string
+		id       id.ID
+		expected bool
+	}{
+		{"a", a, true},
+		{"b", b, false},
+		{"c", x, true},
+	} {
+		_, ok, err := c.Get(ctx, test.id)
+		assert.For(ctx, "err").ThatError(err).Succeeded()
+		assert.For(ctx, "%v present", test.name).That(ok).Equals(test.expected)
+		assert.For(ctx, "%v contained", test.name).That(c.Contains(ctx, test.id)).Equals(test.expected)
+	}
+}
+
+func TestDiskCacheEvictsDependents(t *testing.T) {
+	ctx := log.Testing(t)
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	c, err := database.NewDiskCache(ctx, dir, 12)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+
+	a, b, r, x := id.OfString("a"), id.OfString("b"), id.OfString("r"), id.OfString("x")
+	assert.For(ctx, "put a").ThatError(c.Put(ctx, a, []byte("aaaa"))).Succeeded()
+	assert.For(ctx, "put b").ThatError(c.Put(ctx, b, []byte("bbbb"))).Succeeded()
+	// r refers to a, and is stored last, so a is evicted before r.
+	assert.For(ctx, "put r").ThatError(c.PutDependent(ctx, r, []byte("rrrr"), []id.ID{a})).Succeeded()
+
+	// Reopening the cache keeps the dependency.
+	c, err = database.NewDiskCache(ctx, dir, 12)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+
+	_, _, err = c.Get(ctx, b) // Make a the least recently used.
+	assert.For(ctx, "get b").ThatError(err).Succeeded()
+	_, _, err = c.Get(ctx, r)
+	assert.For(ctx, "get r").ThatError(err).Succeeded()
+	assert.For(ctx, "put x").ThatError(c.Put(ctx, x, []byte("xxxx"))).Succeeded()
+
+	for _, test := range []struct {
+		name     string
+		id       id.ID
+		expected bool
+	}{
+		{"a", a, false},
+		{"b", b, true},
+		{"r", r, false},
+		{"x", x, true},
+	} {
+		assert.For(ctx, "%v contained", test.name).That(c.Contains(ctx, test.id)).Equals(test.expected)
+	}
+
+	// Values that refer to missing entries are not stored.
+	assert.For(ctx, "put r").ThatError(c.PutDependent(ctx, r, []byte("rrrr"), []id.ID{a})).Succeeded()
+	assert.For(ctx, "r contained").That(c.Contains(ctx, r)).Equals(false)
+}
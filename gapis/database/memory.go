@@ -27,10 +27,15 @@ import (
 	"github.com/google/gapid/core/data/id"
 	"github.com/google/gapid/core/data/protoconv"
 	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/log"
 )
 
 // NewInMemory builds a new in memory database.
 func NewInMemory(ctx context.Context) Database {
+	return newMemory(ctx)
+}
+
+func newMemory(ctx context.Context) *memory {
 	m := &memory{}
 	m.records = map[id.ID]*record{}
 	m.resolveCtx = Put(ctx, m)
@@ -64,6 +69,7 @@ type record struct {
 	object       interface{} // object is the deserialized object
 	resolveState *resolveState
 	created      callstack
+	deps         map[id.ID]struct{} // ids stored or resolved while resolving
 }
 
 type resolveState struct {
@@ -89,7 +95,10 @@ func (r *record) decode(ctx context.Context) (interface{}, error) {
 	}
 }
 
-func (r *record) resolve(ctx context.Context) error {
+// load decodes the record's data into an object, if this has not already been
+// done, and converts it from a proto to a Go object if there is a converter
+// registered for it.
+func (r *record) load(ctx context.Context) error {
 	// Decode the object if we don't have the object already.
 	if r.object == nil {
 		obj, err := r.decode(ctx)
@@ -115,6 +124,13 @@ func (r *record) resolve(ctx context.Context) error {
 			return err
 		}
 	}
+	return nil
+}
+
+func (r *record) resolve(ctx context.Context) error {
+	if err := r.load(ctx); err != nil {
+		return err
+	}
 
 	// Keep on resolving until the type no longer implements Resolvable.
 	for {
@@ -136,37 +152,51 @@ type memory struct {
 	mutex      sync.Mutex
 	records    map[id.ID]*record
 	resolveCtx context.Context
+	cache      Cache // Optional backing cache. May be nil.
 }
 
-// Implements Database
-func (d *memory) Store(ctx context.Context, val interface{}) (id.ID, error) {
-	var data []byte
-	var ty recordType
-
+// encode returns the encoded data and record type for val.
+func encode(ctx context.Context, val interface{}) ([]byte, recordType, error) {
 	switch val := val.(type) {
 	case nil:
 		panic(fmt.Errorf("Attemping to store nil in database"))
 	case []byte:
-		data, ty = val, blob
+		return val, blob, nil
 	default:
 		m, err := toProto(ctx, val)
 		if err != nil {
-			return id.ID{}, err
+			return nil, "", err
 		}
-		data, err = proto.Marshal(m)
+		data, err := proto.Marshal(m)
 		if err != nil {
-			return id.ID{}, err
+			return nil, "", err
 		}
-		ty = recordType(proto.MessageName(m))
+		return data, recordType(proto.MessageName(m)), nil
+	}
+}
+
+// Implements Database
+func (d *memory) Store(ctx context.Context, val interface{}) (id.ID, error) {
+	data, ty, err := encode(ctx, val)
+	if err != nil {
+		return id.ID{}, err
 	}
 
 	id := generateID(ty, data)
 
 	d.mutex.Lock()
-	defer d.mutex.Unlock()
-	if _, got := d.records[id]; !got {
+	_, got := d.records[id]
+	if !got {
 		d.records[id] = &record{data: data, ty: ty, object: val, created: getCallstack(4)}
 	}
+	d.addDepLocked(ctx, id)
+	d.mutex.Unlock()
+
+	if !got && d.cache != nil {
+		if err := d.cache.Put(ctx, id, encodeEntry(ty, data)); err != nil {
+			log.W(ctx, "Failed to write record %v to the database cache: %v", id, err)
+		}
+	}
 
 	return id, nil
 }
@@ -181,9 +211,14 @@ func (d *memory) Resolve(ctx context.Context, id id.ID) (interface{}, error) {
 // resolve function must be called with a locked mutex and returns with a locked
 // mutex.
 func (d *memory) resolveLocked(ctx context.Context, id id.ID) (interface{}, error) {
+	d.addDepLocked(ctx, id)
+
 	// Look up the record with the provided identifier.
 	r, got := d.records[id]
 	if !got {
+		r = d.fetchLocked(ctx, id)
+	}
+	if r == nil {
 		// Database doesn't recognise this identifier.
 		return nil, fmt.Errorf("Resource '%v' not found", id)
 	}
@@ -211,7 +246,7 @@ func (d *memory) resolveLocked(ctx context.Context, id id.ID) (interface{}, erro
 		ctx := rs.ctx
 		crash.Go(func() {
 			defer d.resolvePanicHandler(ctx)
-			err := r.resolve(ctx)
+			err := d.resolve(ctx, id, r)
 
 			// Signal that the resolvable has finished.
 			d.mutex.Lock()
@@ -255,10 +290,130 @@ func (d *memory) resolveLocked(ctx context.Context, id id.ID) (interface{}, erro
 	return r.object, nil // Done.
 }
 
+// addDepLocked records that the values resolved by the resolve chain of ctx
+// may refer to the record with the given identifier.
+// addDepLocked must be called with a locked mutex.
+func (d *memory) addDepLocked(ctx context.Context, dep id.ID) {
+	for c := getResolveChain(ctx); c != nil; c = c.parent {
+		if c.record.deps == nil {
+			c.record.deps = map[id.ID]struct{}{}
+		}
+		c.record.deps[dep] = struct{}{}
+	}
+}
+
+// fetchLocked attempts to load the record with the given identifier from the
+// cache, adding it to the database if found. If the database has no cache or
+// the cache does not hold the record then fetchLocked returns nil.
+// fetchLocked must be called with a locked mutex and returns with a locked
+// mutex.
+func (d *memory) fetchLocked(ctx context.Context, id id.ID) *record {
+	if d.cache == nil {
+		return nil
+	}
+	d.mutex.Unlock()
+	r := d.fetch(ctx, id)
+	d.mutex.Lock()
+	if r == nil {
+		return nil
+	}
+	if existing, got := d.records[id]; got {
+		return existing // Stored while the mutex was unlocked.
+	}
+	r.created = getCallstack(4)
+	d.records[id] = r
+	return r
+}
+
+// fetch returns the record with the given identifier from the cache, or nil
+// if the cache does not hold the record.
+func (d *memory) fetch(ctx context.Context, id id.ID) *record {
+	data, ok, err := d.cache.Get(ctx, id)
+	if err != nil {
+		log.W(ctx, "Failed to read record %v from the database cache: %v", id, err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	r, err := decodeEntry(data)
+	if err != nil {
+		log.W(ctx, "Failed to decode record %v from the database cache: %v", id, err)
+		return nil
+	}
+	return r
+}
+
+// resolve resolves the record r with the identifier id.
+// If the database has a cache and r is a Resolvable, then the cache is checked
+// for a previously resolved value before calling Resolve(), and the resolved
+// value is written to the cache afterwards.
+func (d *memory) resolve(ctx context.Context, id id.ID, r *record) error {
+	if d.cache == nil {
+		return r.resolve(ctx)
+	}
+	if err := r.load(ctx); err != nil {
+		return err
+	}
+	if _, ok := r.object.(Resolvable); !ok {
+		return r.resolve(ctx)
+	}
+
+	rid := resolvedID(id)
+	if cached := d.fetch(ctx, rid); cached != nil {
+		err := cached.resolve(ctx)
+		if err == nil {
+			r.object = cached.object
+			return nil
+		}
+		log.W(ctx, "Failed to resolve cached value %v: %v", rid, err)
+	}
+
+	if err := r.resolve(ctx); err != nil {
+		return err
+	}
+
+	if r.object == nil {
+		return nil
+	}
+
+	// Not all resolved values can be serialized (for example, those holding
+	// Go functions or large in-memory graphs). These simply don't get cached.
+	data, ty, err := encode(ctx, r.object)
+	if err != nil {
+		log.D(ctx, "Resolved value %T not cacheable: %v", r.object, err)
+		return nil
+	}
+	if err := d.putResolved(ctx, rid, encodeEntry(ty, data), r); err != nil {
+		log.W(ctx, "Failed to write resolved value %v to the database cache: %v", rid, err)
+	}
+	return nil
+}
+
+// putResolved writes the resolved value of r to the cache. If the cache
+// evicts entries, the resolved value is stored as depending on the records
+// that were stored or resolved while resolving r, as the resolved value may
+// refer to them.
+func (d *memory) putResolved(ctx context.Context, rid id.ID, data []byte, r *record) error {
+	dc, ok := d.cache.(DependentCache)
+	if !ok {
+		return d.cache.Put(ctx, rid, data)
+	}
+	d.mutex.Lock()
+	deps := make([]id.ID, 0, len(r.deps))
+	for dep := range r.deps {
+		deps = append(deps, dep)
+	}
+	d.mutex.Unlock()
+	return dc.PutDependent(ctx, rid, data, deps)
+}
+
 // Implements Database
 func (d *memory) Contains(ctx context.Context, id id.ID) (res bool) {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
-	_, got := d.records[id]
-	return got
+	if _, got := d.records[id]; got {
+		return true
+	}
+	return d.cache != nil && d.cache.Contains(ctx, id)
 }
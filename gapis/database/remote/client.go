@@ -0,0 +1,132 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/app/auth"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/net/grpcutil"
+	"github.com/google/gapid/gapis/database"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// chunkLimit is the maximum number of bytes of data sent in a single chunk.
+const chunkLimit = 1 * 1024 * 1024
+
+type client struct {
+	client     CacheClient
+	writeToken auth.Token
+}
+
+// Connect returns a database.Cache backed by the remote cache on the supplied
+// connection.
+// writeToken is presented to the server when writing resolved values. If
+// writeToken is auth.NoAuth then resolved values are not written, and only
+// records are shared.
+func Connect(ctx context.Context, conn *grpc.ClientConn, writeToken auth.Token) database.DependentCache {
+	return &client{NewCacheClient(conn), writeToken}
+}
+
+// Dial returns a database.Cache backed by the remote cache at address, along
+// with a function to close the connection.
+// See Connect for details on writeToken.
+func Dial(ctx context.Context, address string, writeToken auth.Token) (database.DependentCache, func(), error) {
+	conn, err := grpcutil.Dial(ctx, address, grpc.WithInsecure())
+	if err != nil {
+		return nil, nil, log.Errf(ctx, err, "Failed to connect to remote database cache at '%v'", address)
+	}
+	return Connect(ctx, conn, writeToken), func() { conn.Close() }, nil
+}
+
+// Implements database.Cache
+func (c *client) Get(ctx context.Context, id id.ID) ([]byte, bool, error) {
+	stream, err := c.client.Get(ctx, &GetRequest{Id: id[:], Version: app.Version.String()})
+	if err != nil {
+		return nil, false, err
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		return nil, false, err
+	}
+	if !first.Found {
+		return nil, false, nil
+	}
+	buf := bytes.NewBuffer(first.Data)
+	for {
+		chunk, err := stream.Recv()
+		switch {
+		case errors.Cause(err) == io.EOF:
+			return buf.Bytes(), true, nil
+		case err != nil:
+			return nil, false, err
+		default:
+			buf.Write(chunk.Data)
+		}
+	}
+}
+
+// Implements database.Cache
+func (c *client) Put(ctx context.Context, id id.ID, data []byte) error {
+	return c.PutDependent(ctx, id, data, nil)
+}
+
+// Implements database.DependentCache
+func (c *client) PutDependent(ctx context.Context, id id.ID, data []byte, deps []id.ID) error {
+	chunk := &PutChunk{Id: id[:]}
+	for i := range deps {
+		chunk.Deps = append(chunk.Deps, deps[i][:])
+	}
+	if !database.IsRecordEntry(id, data) {
+		if c.writeToken == auth.NoAuth {
+			return nil // Not trusted to share resolved values.
+		}
+		chunk.Resolved, chunk.Version = true, app.Version.String()
+		ctx = auth.WithToken(ctx, c.writeToken)
+	}
+	stream, err := c.client.Put(ctx)
+	if err != nil {
+		return err
+	}
+	for {
+		n := len(data)
+		if n > chunkLimit {
+			n = chunkLimit
+		}
+		chunk.Data, data = data[:n], data[n:]
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			break
+		}
+		chunk = &PutChunk{}
+	}
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+// Implements database.Cache
+// The remote cache cannot be queried without a round-trip, so Contains always
+// returns false. Get should be used to find entries held remotely.
+func (c *client) Contains(ctx context.Context, id id.ID) bool {
+	return false
+}
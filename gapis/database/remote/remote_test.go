@@ -0,0 +1,110 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/gapid/core/app/auth"
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/fault"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/net/grpcutil"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/database/remote"
+	"google.golang.org/grpc"
+)
+
+func TestRemoteCache(t *testing.T) {
+	ctx := log.Testing(t)
+
+	dir, err := ioutil.TempDir("", "remote")
+	if err != nil {
+		t.Fatalf("Couldn't create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	shared, err := database.NewDiskCache(ctx, dir, 0)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+
+	const token = auth.Token("secret")
+
+	var srv *grpc.Server
+	go grpcutil.ServeWithListener(
+		ctx,
+		grpcutil.NewPipeListener("pipe:remotecache"),
+		func(ctx context.Context, listener net.Listener, server *grpc.Server) error {
+			srv = server
+			return remote.Serve(ctx, server, shared, token)
+		},
+	)
+
+	largeData := make([]byte, 3*1024*1024+17) // Spans several chunks.
+	for i := range largeData {
+		largeData[i] = byte(i * 7)
+	}
+	resolved, resolvedData := id.OfString("resolved"), []byte("resolved")
+
+	ok := fault.Const("ok")
+	err = grpcutil.Client(ctx, "pipe:remotecache", func(ctx context.Context, cc *grpc.ClientConn) error {
+		untrusted := remote.Connect(ctx, cc, auth.NoAuth)
+		trusted := remote.Connect(ctx, cc, token)
+
+		// Records can be shared by any client.
+		large, err := database.NewCached(ctx, untrusted).Store(ctx, largeData)
+		assert.For(ctx, "store large").ThatError(err).Succeeded()
+		got, err := database.NewCached(ctx, trusted).Resolve(ctx, large)
+		assert.For(ctx, "resolve large").ThatError(err).Succeeded()
+		assert.For(ctx, "large data").ThatSlice(got).Equals(largeData)
+
+		// Records must match their id.
+		stream, err := remote.NewCacheClient(cc).Put(ctx)
+		assert.For(ctx, "forged put").ThatError(err).Succeeded()
+		forged := id.OfString("forged")
+		assert.For(ctx, "forged send").ThatError(stream.Send(&remote.PutChunk{Id: forged[:], Data: []byte("forged")})).Succeeded()
+		_, err = stream.CloseAndRecv()
+		assert.For(ctx, "forged put").ThatError(err).Failed()
+
+		// Resolved values are only written by trusted clients.
+		assert.For(ctx, "untrusted put").ThatError(untrusted.Put(ctx, resolved, resolvedData)).Succeeded()
+		_, found, err := untrusted.Get(ctx, resolved)
+		assert.For(ctx, "untrusted get err").ThatError(err).Succeeded()
+		assert.For(ctx, "untrusted get found").That(found).Equals(false)
+
+		wrong := remote.Connect(ctx, cc, auth.Token("wrong"))
+		assert.For(ctx, "wrong token put").ThatError(wrong.Put(ctx, resolved, resolvedData)).Failed()
+
+		assert.For(ctx, "trusted put").ThatError(trusted.Put(ctx, resolved, resolvedData)).Succeeded()
+		data, found, err := untrusted.Get(ctx, resolved)
+		assert.For(ctx, "trusted get err").ThatError(err).Succeeded()
+		assert.For(ctx, "trusted get found").That(found).Equals(true)
+		assert.For(ctx, "trusted get data").ThatSlice(data).Equals(resolvedData)
+		return ok
+	}, grpc.WithDialer(grpcutil.GetDialer(ctx)), grpc.WithTimeout(1*time.Second), grpc.WithInsecure())
+	assert.For(ctx, "err").ThatError(err).Equals(ok)
+
+	// Resolved values are namespaced by version in the shared cache.
+	_, found, err := shared.Get(ctx, resolved)
+	assert.For(ctx, "shared err").ThatError(err).Succeeded()
+	assert.For(ctx, "shared found").That(found).Equals(false)
+
+	srv.GracefulStop()
+}
@@ -0,0 +1,151 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"io"
+
+	"github.com/google/gapid/core/app/auth"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/database"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+type server struct {
+	cache      database.Cache
+	writeToken auth.Token
+}
+
+// Serve exposes the cache c over the grpc server.
+// Records can be written by any client, as they are verified against their
+// id. Resolved values can only be written by clients that present writeToken.
+// If writeToken is auth.NoAuth then resolved values are not accepted at all.
+func Serve(ctx context.Context, grpcServer *grpc.Server, c database.Cache, writeToken auth.Token) error {
+	RegisterCacheServer(grpcServer, &server{c, writeToken})
+	return nil
+}
+
+// resolvedKey returns the key used to store the resolved value with the given
+// id written by the given gapis version.
+func resolvedKey(version string, in id.ID) id.ID {
+	return id.OfBytes([]byte("resolved:"), []byte(version), []byte{0}, in[:])
+}
+
+func toID(ctx context.Context, data []byte) (id.ID, error) {
+	out := id.ID{}
+	if len(data) != len(out) {
+		return out, log.Errf(ctx, nil, "Invalid id length %d", len(data))
+	}
+	copy(out[:], data)
+	return out, nil
+}
+
+// Get fetches an entry from the underlying cache.
+// See CacheServer for more information.
+func (s *server) Get(req *GetRequest, stream Cache_GetServer) error {
+	ctx := stream.Context()
+	id, err := toID(ctx, req.Id)
+	if err != nil {
+		return err
+	}
+	data, found, err := s.cache.Get(ctx, id)
+	if err == nil && !found && req.Version != "" {
+		data, found, err = s.cache.Get(ctx, resolvedKey(req.Version, id))
+	}
+	if err != nil {
+		return log.Err(ctx, err, "Cache get")
+	}
+	chunk := &GetChunk{Found: found}
+	for {
+		n := len(data)
+		if n > chunkLimit {
+			n = chunkLimit
+		}
+		chunk.Data, data = data[:n], data[n:]
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			return nil
+		}
+		chunk = &GetChunk{}
+	}
+}
+
+// put writes data to the underlying cache. If the cache evicts entries, data
+// is stored as depending on the entries deps.
+func (s *server) put(ctx context.Context, key id.ID, data []byte, deps [][]byte) error {
+	dc, ok := s.cache.(database.DependentCache)
+	if !ok || len(deps) == 0 {
+		return s.cache.Put(ctx, key, data)
+	}
+	ids := make([]id.ID, len(deps))
+	for i, dep := range deps {
+		id, err := toID(ctx, dep)
+		if err != nil {
+			return err
+		}
+		ids[i] = id
+	}
+	return dc.PutDependent(ctx, key, data, ids)
+}
+
+// Put adds an entry to the underlying cache.
+// See CacheServer for more information.
+func (s *server) Put(stream Cache_PutServer) error {
+	ctx := stream.Context()
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	id, err := toID(ctx, first.Id)
+	if err != nil {
+		return err
+	}
+	key := id
+	if first.Resolved {
+		if s.writeToken == auth.NoAuth {
+			return log.Err(ctx, nil, "Server does not accept resolved values")
+		}
+		if err := auth.Check(ctx, s.writeToken); err != nil {
+			return log.Err(ctx, err, "Resolved value write rejected")
+		}
+		if first.Version == "" {
+			return log.Err(ctx, nil, "Resolved value written without a version")
+		}
+		key = resolvedKey(first.Version, id)
+	}
+	data := first.Data
+	for {
+		chunk, err := stream.Recv()
+		switch {
+		case errors.Cause(err) == io.EOF:
+			if !first.Resolved && !database.IsRecordEntry(id, data) {
+				return log.Errf(ctx, nil, "Entry data does not match id %v", id)
+			}
+			if err := s.put(ctx, key, data, first.Deps); err != nil {
+				return log.Err(ctx, err, "Cache put")
+			}
+			return stream.SendAndClose(&PutResponse{})
+		case err != nil:
+			return err
+		default:
+			data = append(data, chunk.Data...)
+		}
+	}
+}
@@ -17,6 +17,7 @@ package database
 import (
 	"context"
 
+	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/data/id"
 )
 
@@ -36,6 +37,11 @@ type Resolvable interface {
 
 // resolvedID returns the identifier of a resolved object given the identifier
 // of the Resolvable.
+// Resolved values are persisted in caches that outlive the process, and the
+// value produced by a Resolvable can change between builds of gapis even when
+// the Resolvable itself does not. The identifier is therefore salted with the
+// application version so that values resolved by a different build are never
+// used.
 func resolvedID(in id.ID) id.ID {
-	return id.OfBytes(resolvablePrefix, in[:])
+	return id.OfBytes(resolvablePrefix, []byte(app.Version.String()), []byte{0}, in[:])
 }
@@ -0,0 +1,131 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/gapid/core/app/crash"
+	"github.com/google/gapid/core/context/keys"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+)
+
+// maxPendingTierWrites is the maximum number of writes to the slower tiers
+// that can be in flight at any time. Writes beyond this are dropped.
+const maxPendingTierWrites = 64
+
+// NewTieredCache returns a Cache that consults each of the caches in order.
+// Entries found in a later cache are copied into the earlier caches, so a
+// fast local cache can sit in front of a slower shared one.
+// Put writes the entry to the first cache before returning. The writes to the
+// remaining caches are made in the background and are best-effort: failures
+// are logged, and writes are dropped if too many are already in flight.
+func NewTieredCache(caches ...Cache) Cache {
+	return &tiered{
+		caches:  caches,
+		pending: make(chan struct{}, maxPendingTierWrites),
+	}
+}
+
+type tiered struct {
+	caches  []Cache
+	pending chan struct{} // Semaphore of in-flight background writes.
+}
+
+// Interface compliance test
+var (
+	_ = DependentCache(&tiered{})
+)
+
+// Implements Cache
+func (t *tiered) Get(ctx context.Context, id id.ID) ([]byte, bool, error) {
+	var firstErr error
+	for i, c := range t.caches {
+		data, ok, err := c.Get(ctx, id)
+		if err != nil {
+			// Carry on to the next tier. A failing tier should only cost us
+			// the cached value, not the resolve.
+			log.W(ctx, "Failed to read %v from database cache tier %d: %v", id, i, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if !ok {
+			continue
+		}
+		for j := 0; j < i; j++ {
+			if err := t.caches[j].Put(ctx, id, data); err != nil {
+				log.W(ctx, "Failed to write %v to database cache tier %d: %v", id, j, err)
+			}
+		}
+		return data, true, nil
+	}
+	return nil, false, firstErr
+}
+
+// Implements Cache
+func (t *tiered) Put(ctx context.Context, id id.ID, data []byte) error {
+	return t.put(ctx, id, data, nil)
+}
+
+// Implements DependentCache
+func (t *tiered) PutDependent(ctx context.Context, id id.ID, data []byte, deps []id.ID) error {
+	return t.put(ctx, id, data, deps)
+}
+
+func (t *tiered) put(ctx context.Context, id id.ID, data []byte, deps []id.ID) error {
+	if len(t.caches) == 0 {
+		return nil
+	}
+	if len(t.caches) > 1 {
+		select {
+		case t.pending <- struct{}{}:
+			// The background write must outlive the request that caused it.
+			ctx := keys.Clone(context.Background(), ctx)
+			crash.Go(func() {
+				defer func() { <-t.pending }()
+				for i, c := range t.caches[1:] {
+					if err := putTier(ctx, c, id, data, deps); err != nil {
+						log.W(ctx, "Failed to write %v to database cache tier %d: %v", id, i+1, err)
+					}
+				}
+			})
+		default:
+			log.D(ctx, "Too many pending database cache writes. Dropping write of %v", id)
+		}
+	}
+	return putTier(ctx, t.caches[0], id, data, deps)
+}
+
+// putTier writes data to the cache c, along with the ids of the entries data
+// refers to if deps is not nil and c evicts entries.
+func putTier(ctx context.Context, c Cache, id id.ID, data []byte, deps []id.ID) error {
+	if dc, ok := c.(DependentCache); ok && deps != nil {
+		return dc.PutDependent(ctx, id, data, deps)
+	}
+	return c.Put(ctx, id, data)
+}
+
+// Implements Cache
+func (t *tiered) Contains(ctx context.Context, id id.ID) bool {
+	for _, c := range t.caches {
+		if c.Contains(ctx, id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/database"
+)
+
+// slowCache is a Cache whose Put blocks until released, and then fails.
+type slowCache struct {
+	put     chan id.ID
+	release chan struct{}
+}
+
+func (c *slowCache) Get(ctx context.Context, id id.ID) ([]byte, bool, error) {
+	return nil, false, nil
+}
+
+func (c *slowCache) Put(ctx context.Context, id id.ID, data []byte) error {
+	<-c.release
+	c.put <- id
+	return fmt.Errorf("Unavailable")
+}
+
+func (c *slowCache) Contains(ctx context.Context, id id.ID) bool {
+	return false
+}
+
+func TestTieredCachePut(t *testing.T) {
+	ctx := log.Testing(t)
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	local, err := database.NewDiskCache(ctx, dir, 0)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	remote := &slowCache{put: make(chan id.ID, 1), release: make(chan struct{})}
+	c := database.NewTieredCache(local, remote)
+
+	// Put must not wait for, or fail because of, the slower tier.
+	a := id.OfString("a")
+	assert.For(ctx, "put").ThatError(c.Put(ctx, a, []byte("apple"))).Succeeded()
+	assert.For(ctx, "contains").That(c.Contains(ctx, a)).Equals(true)
+
+	data, ok, err := local.Get(ctx, a)
+	assert.For(ctx, "local err").ThatError(err).Succeeded()
+	assert.For(ctx, "local ok").That(ok).Equals(true)
+	assert.For(ctx, "local data").ThatSlice(data).Equals([]byte("apple"))
+
+	close(remote.release)
+	select {
+	case got := <-remote.put:
+		assert.For(ctx, "remote put").That(got).Equals(a)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timeout waiting for the write to the remote tier")
+	}
+}
@@ -71,4 +71,5 @@ type Issue struct {
 	Command  api.CmdID        // The command that reported the issue.
 	Severity service.Severity // The severity of the issue.
 	Error    error            // The issue's error.
+	Known    bool             // True if the API whitelists the issue as known.
 }
@@ -0,0 +1,149 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// Bandwidth resolves and returns the analysis of the data moved by the
+// commands of the capture from the path p.
+func Bandwidth(ctx context.Context, p *path.Bandwidth, r *path.ResolveConfig) (*service.Bandwidth, error) {
+	obj, err := database.Build(ctx, &BandwidthResolvable{Path: p, Config: r})
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*service.Bandwidth), nil
+}
+
+// bandwidthResourceKey identifies a resource of the bandwidth analysis.
+type bandwidthResourceKey struct {
+	kind string
+	name string
+}
+
+// Resolve implements the database.Resolver interface.
+func (r *BandwidthResolvable) Resolve(ctx context.Context) (interface{}, error) {
+	p := r.Path
+	c, err := capture.ResolveFromPath(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := Events(ctx, &path.Events{
+		Capture:     p.Capture,
+		LastInFrame: true,
+	}, r.Config)
+	if err != nil {
+		return nil, err
+	}
+	frameEnds := map[api.CmdID]struct{}{}
+	for _, e := range events.List {
+		frameEnds[api.CmdID(e.Command.Indices[0])] = struct{}{}
+	}
+
+	out := &service.Bandwidth{}
+	resources := map[bandwidthResourceKey]*service.ResourceBandwidth{}
+	frame := &service.FrameBandwidth{}
+	endFrame := func() {
+		cmds := frame.Commands
+		sort.SliceStable(cmds, func(i, j int) bool {
+			if cmds[i].Bytes != cmds[j].Bytes {
+				return cmds[i].Bytes > cmds[j].Bytes
+			}
+			return cmds[i].ObservedBytes > cmds[j].ObservedBytes
+		})
+		if p.Top > 0 && len(cmds) > int(p.Top) {
+			frame.Commands = cmds[:p.Top]
+		}
+		out.Frames = append(out.Frames, frame)
+		frame = &service.FrameBandwidth{Frame: uint64(len(out.Frames))}
+	}
+
+	s := c.NewState(ctx)
+	err = c.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		observed := uint64(0)
+		if o := cmd.Extras().Observations(); o != nil {
+			for _, read := range o.Reads {
+				observed += read.Range.Size
+			}
+		}
+
+		bytes := uint64(0)
+		if up, ok := cmd.API().(api.UploadProvider); ok {
+			cmd.Extras().Observations().ApplyReads(s.Memory.ApplicationPool())
+			for _, u := range up.Uploads(ctx, id, cmd, s) {
+				bytes += u.Bytes
+				key := bandwidthResourceKey{u.Kind, u.Name}
+				res, ok := resources[key]
+				if !ok {
+					res = &service.ResourceBandwidth{Kind: u.Kind, Name: u.Name}
+					resources[key] = res
+				}
+				res.Bytes += u.Bytes
+				res.Uploads++
+			}
+		}
+
+		if err := cmd.Mutate(ctx, id, s, nil /* no builder, just mutate */); err != nil {
+			log.W(ctx, "Command %v %v: %v", id, cmd, err)
+		}
+
+		frame.Bytes += bytes
+		frame.ObservedBytes += observed
+		if bytes > 0 || observed > 0 {
+			frame.Commands = append(frame.Commands, &service.CommandBandwidth{
+				Command:       p.Capture.Command(uint64(id)),
+				Name:          cmd.CmdName(),
+				Bytes:         bytes,
+				ObservedBytes: observed,
+			})
+		}
+
+		// As with Stats, any commands after the last frame boundary belong to
+		// the last frame.
+		if _, ok := frameEnds[id]; ok && len(out.Frames) < len(events.List)-1 {
+			endFrame()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	endFrame()
+
+	for _, res := range resources {
+		out.Resources = append(out.Resources, res)
+	}
+	sort.Slice(out.Resources, func(i, j int) bool {
+		a, b := out.Resources[i], out.Resources[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return out, nil
+}
@@ -249,7 +249,7 @@ func (r *CommandTreeResolvable) Resolve(ctx context.Context) (interface{}, error
 
 	// Walk the list of unfiltered commands to build the groups.
 	s := c.NewState(ctx)
-	api.ForeachCmd(ctx, c.Commands, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+	c.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
 		cmd.Mutate(ctx, id, s, nil)
 		if filter(id, cmd, s) {
 			for _, g := range groupers {
@@ -264,11 +264,11 @@ func (r *CommandTreeResolvable) Resolve(ctx context.Context) (interface{}, error
 		path: p,
 		root: api.CmdIDGroup{
 			Name:  "root",
-			Range: api.CmdIDRange{End: api.CmdID(len(c.Commands))},
+			Range: api.CmdIDRange{End: api.CmdID(c.NumCommands())},
 		},
 	}
 	for _, g := range groupers {
-		for _, l := range g.Build(api.CmdID(len(c.Commands))) {
+		for _, l := range g.Build(api.CmdID(c.NumCommands())) {
 			if group, err := out.root.AddGroup(l.Start, l.End, l.Name); err == nil {
 				group.UserData = l.UserData
 			}
@@ -288,14 +288,14 @@ func (r *CommandTreeResolvable) Resolve(ctx context.Context) (interface{}, error
 			return nil, log.Errf(ctx, err, "Couldn't get events")
 		}
 		if p.GroupByFrame {
-			addFrameGroups(ctx, events, p, out, api.CmdID(len(c.Commands)))
+			addFrameGroups(ctx, events, p, out, api.CmdID(c.NumCommands()))
 		}
 		if p.GroupByTransformFeedback {
-			addFrameEventGroups(ctx, events, p, out, api.CmdID(len(c.Commands)),
+			addFrameEventGroups(ctx, events, p, out, api.CmdID(c.NumCommands()),
 				service.EventKind_TransformFeedback, "Transform Feedback")
 		}
 		if p.GroupByDrawCall {
-			addFrameEventGroups(ctx, events, p, out, api.CmdID(len(c.Commands)),
+			addFrameEventGroups(ctx, events, p, out, api.CmdID(c.NumCommands()),
 				service.EventKind_DrawCall, "Draw")
 		}
 	}
@@ -304,7 +304,7 @@ func (r *CommandTreeResolvable) Resolve(ctx context.Context) (interface{}, error
 
 	// Now we have all the groups, we finally need to add the filtered commands.
 	s = c.NewState(ctx)
-	api.ForeachCmd(ctx, c.Commands, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+	c.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
 		cmd.Mutate(ctx, id, s, nil)
 
 		if !filter(id, cmd, s) {
@@ -38,7 +38,7 @@ func Commands(ctx context.Context, p *path.Commands, r *path.ResolveConfig) (*se
 	if len(p.From) > 1 || len(p.To) > 1 {
 		return nil, fmt.Errorf("Subcommands currently not supported for Commands") // TODO: Subcommands
 	}
-	count := uint64(len(c.Commands))
+	count := c.NumCommands()
 	if count == 0 {
 		return nil, fmt.Errorf("No commands in capture")
 	}
@@ -47,10 +47,10 @@ func Commands(ctx context.Context, p *path.Commands, r *path.ResolveConfig) (*se
 	if cmdIdxFrom > cmdIdxTo {
 		cmdIdxFrom, cmdIdxTo = cmdIdxTo, cmdIdxFrom
 	}
-	count = cmdIdxTo - cmdIdxFrom
+	count = cmdIdxTo - cmdIdxFrom + 1
 	paths := make([]*path.Command, count)
 	for i := uint64(0); i < count; i++ {
-		paths[i] = p.Capture.Command(i)
+		paths[i] = p.Capture.Command(cmdIdxFrom + i)
 	}
 	return &service.Commands{List: paths}, nil
 }
@@ -61,7 +61,7 @@ func Cmds(ctx context.Context, p *path.Capture) ([]api.Cmd, error) {
 	if err != nil {
 		return nil, err
 	}
-	return c.Commands, nil
+	return c.AllCommands(ctx)
 }
 
 // NCmds resolves and returns the command list from the path p, ensuring
@@ -124,11 +124,16 @@ func Cmd(ctx context.Context, p *path.Command, r *path.ResolveConfig) (api.Cmd,
 			return nil, &service.ErrDataUnavailable{Reason: messages.ErrMessage("Not a valid subcommand")}
 		}
 	}
-	cmds, err := NCmds(ctx, p.Capture, cmdIdx+1)
+	c, err := capture.ResolveFromPath(ctx, p.Capture)
 	if err != nil {
 		return nil, err
 	}
-	return cmds[cmdIdx], nil
+	// Only decode the requested command, instead of all the commands of
+	// streamed captures.
+	if count := c.NumCommands(); cmdIdx >= count {
+		return nil, errPathOOB(cmdIdx, "Index", 0, count-1, p)
+	}
+	return c.KeepCmd(ctx, api.CmdID(cmdIdx))
 }
 
 // Parameter resolves and returns the parameter from the path p.
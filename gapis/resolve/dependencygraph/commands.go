@@ -0,0 +1,73 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencygraph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+)
+
+// cmdList is the list of commands a graph or footprint is built for: the
+// generated commands which build the initial state, followed by the commands
+// of the capture. The commands of the capture are not held by the list, they
+// are read from the capture when needed.
+type cmdList struct {
+	initialCmds []api.Cmd
+	capture     *capture.Capture
+}
+
+// count returns the number of commands in the list.
+func (l cmdList) count() int {
+	n := len(l.initialCmds)
+	if l.capture != nil {
+		n += int(l.capture.NumCommands())
+	}
+	return n
+}
+
+// foreach calls cb with the index and command of each command of the list, in
+// order, until cb returns an error. If cb returns api.Break, then foreach stops
+// and returns nil. The commands passed to cb must not be used once cb has
+// returned.
+func (l cmdList) foreach(ctx context.Context, cb func(context.Context, api.CmdID, api.Cmd) error) error {
+	stop := false
+	err := api.ForeachCmd(ctx, l.initialCmds, func(ctx context.Context, index api.CmdID, cmd api.Cmd) error {
+		err := cb(ctx, index, cmd)
+		stop = err == api.Break
+		return err
+	})
+	if err != nil || stop || l.capture == nil {
+		return err
+	}
+	offset := api.CmdID(len(l.initialCmds))
+	return l.capture.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		return cb(ctx, offset+id, cmd)
+	})
+}
+
+// cmd returns the command of the list with the given index, and the function
+// to call once the command is no longer used.
+func (l cmdList) cmd(ctx context.Context, index int) (api.Cmd, func(), error) {
+	if index < len(l.initialCmds) {
+		return l.initialCmds[index], func() {}, nil
+	}
+	if l.capture == nil {
+		return nil, nil, fmt.Errorf("Command %v out of range [0..%v]", index, len(l.initialCmds)-1)
+	}
+	return l.capture.Cmd(ctx, api.CmdID(index-len(l.initialCmds)))
+}
@@ -111,15 +111,46 @@ func (t *DCE) Flush(ctx context.Context, out transform.Writer) {
 		log.E(ctx, "DCE: Cannot backpropagate through def-use chain from behavior index: %v, "+
 			"with length of behavior list: %v.", t.endBehaviorIndex, len(t.footprint.Behaviors))
 		log.W(ctx, "DCE: Fallback to disable DCE.")
-		for i := api.CmdID(0); i <= t.endCmdIndex; i++ {
-			out.MutateAndWrite(ctx, i, t.footprint.Commands[int(i)])
+		err := t.footprint.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+			if id > t.endCmdIndex {
+				return api.Break
+			}
+			out.MutateAndWrite(ctx, id, cmd)
+			return nil
+		})
+		if err != nil {
+			log.E(ctx, "DCE: %v", err)
 		}
 		return
 	}
 	t0 := dCECounter.Start()
 	livenessBoard, aliveCmds := t.BackPropagate(ctx)
 	dCECounter.Stop(t0)
-	flushedCommands := &CommandIndicesSet{}
+
+	// Find the alive and dead commands, which are then read in a single pass
+	// over the commands.
+	alive, dead := map[uint64]struct{}{}, map[uint64]struct{}{}
+	last := uint64(0)
+	for bi := uint64(0); bi <= t.endBehaviorIndex; bi++ {
+		bh := t.footprint.Behaviors[bi]
+		fci := bh.Owner
+		if len(fci) != 1 {
+			continue
+		}
+		if _, ok := alive[fci[0]]; ok {
+			continue
+		}
+		if livenessBoard[bi] {
+			alive[fci[0]] = struct{}{}
+		} else if !aliveCmds.Contains(fci) {
+			dead[fci[0]] = struct{}{}
+		} else {
+			continue
+		}
+		if fci[0] > last {
+			last = fci[0]
+		}
+	}
 
 	numCmd, numDead, numDeadDraws, numLive, numLiveDraws := 0, 0, 0, 0, 0
 	deadMem, liveMem := uint64(0), uint64(0)
@@ -130,43 +161,41 @@ func (t *DCE) Flush(ctx context.Context, out transform.Writer) {
 	// Do not use for expected state!
 	s := out.State()
 
-	for bi := uint64(0); bi <= t.endBehaviorIndex; bi++ {
-		bh := t.footprint.Behaviors[bi]
-		fci := bh.Owner
-
-		if livenessBoard[bi] && len(fci) == 1 && !flushedCommands.Contains(fci) {
-			flushedCommands.Insert(fci)
-			aliveCmd := t.footprint.Commands[fci[0]]
-
+	err := t.footprint.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if uint64(id) > last {
+			return api.Break
+		}
+		if _, ok := alive[uint64(id)]; ok {
 			// Logging the DCE result of alive commands
 			numCmd++
 			numLive++
-			if aliveCmd.CmdFlags(ctx, api.CmdNoID, s).IsDrawCall() {
+			if cmd.CmdFlags(ctx, api.CmdNoID, s).IsDrawCall() {
 				numLiveDraws++
 			}
-			if e := aliveCmd.Extras(); e != nil && e.Observations() != nil {
+			if e := cmd.Extras(); e != nil && e.Observations() != nil {
 				for _, r := range e.Observations().Reads {
 					liveMem += r.Range.Size
 				}
 			}
 
-			out.MutateAndWrite(ctx, api.CmdID(fci[0]), aliveCmd)
-		} else {
-			if len(fci) == 1 && !aliveCmds.Contains(fci) {
-				// logging the DCE result of dead commands
-				deadCmd := t.footprint.Commands[fci[0]]
-				numCmd++
-				numDead++
-				if deadCmd.CmdFlags(ctx, api.CmdNoID, s).IsDrawCall() {
-					numDeadDraws++
-				}
-				if e := deadCmd.Extras(); e != nil && e.Observations() != nil {
-					for _, r := range e.Observations().Reads {
-						deadMem += r.Range.Size
-					}
+			out.MutateAndWrite(ctx, id, cmd)
+		} else if _, ok := dead[uint64(id)]; ok {
+			// logging the DCE result of dead commands
+			numCmd++
+			numDead++
+			if cmd.CmdFlags(ctx, api.CmdNoID, s).IsDrawCall() {
+				numDeadDraws++
+			}
+			if e := cmd.Extras(); e != nil && e.Observations() != nil {
+				for _, r := range e.Observations().Reads {
+					deadMem += r.Range.Size
 				}
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		log.E(ctx, "DCE: %v", err)
 	}
 	dCECmdDeadCounter.Add(int64(numDead))
 	dCECmdLiveCounter.Add(int64(numLive))
@@ -80,24 +80,57 @@ func (t *DeadCodeElimination) Transform(ctx context.Context, id api.CmdID, c api
 
 func (t *DeadCodeElimination) Flush(ctx context.Context, out transform.Writer) {
 	if t.KeepAllAlive {
-		api.ForeachCmd(ctx, t.depGraph.Commands, func(ctx context.Context, index api.CmdID, cmd api.Cmd) error {
+		err := t.depGraph.ForeachCmd(ctx, func(ctx context.Context, index api.CmdID, cmd api.Cmd) error {
 			out.MutateAndWrite(ctx, t.depGraph.GetCmdID(int(index)), cmd)
 			return nil
 		})
+		if err != nil {
+			log.E(ctx, "DCE: %v", err)
+		}
 		return
 	}
 	t0 := deadCodeEliminationCounter.Start()
 	isLive := t.propagateLiveness(ctx)
 	deadCodeEliminationCounter.Stop(t0)
-	api.ForeachCmd(ctx, t.depGraph.Commands[:len(isLive)], func(ctx context.Context, index api.CmdID, cmd api.Cmd) error {
+
+	num, numDead, numLive := len(isLive), 0, 0
+	deadMem, liveMem := uint64(0), uint64(0)
+	err := t.depGraph.ForeachCmd(ctx, func(ctx context.Context, index api.CmdID, cmd api.Cmd) error {
+		if int(index) >= num {
+			return api.Break
+		}
+		mem := uint64(0)
+		if e := cmd.Extras(); e != nil && e.Observations() != nil {
+			for _, r := range e.Observations().Reads {
+				mem += r.Range.Size
+			}
+		}
 		id := t.depGraph.GetCmdID(int(index))
 		if isLive[index] {
+			numLive++
+			liveMem += mem
 			out.MutateAndWrite(ctx, id, cmd)
-		} else if debugDCE {
-			log.I(ctx, "Dropped %v %v", id, cmd)
+		} else {
+			numDead++
+			deadMem += mem
+			if debugDCE {
+				log.I(ctx, "Dropped %v %v", id, cmd)
+			}
 		}
 		return nil
 	})
+	if err != nil {
+		log.E(ctx, "DCE: %v", err)
+	}
+
+	// Report statistics
+	deadCodeEliminationCmdDeadCounter.Add(int64(numDead))
+	deadCodeEliminationCmdLiveCounter.Add(int64(numLive))
+	deadCodeEliminationDataDeadCounter.Add(int64(deadMem))
+	deadCodeEliminationDataLiveCounter.Add(int64(liveMem))
+	log.D(ctx, "DCE: dead: %v%% %v cmds %v MB, live: %v%% %v cmds %v MB",
+		100*numDead/num, numDead, deadMem/1024/1024,
+		100*numLive/num, numLive, liveMem/1024/1024)
 }
 
 // See https://en.wikipedia.org/wiki/Live_variable_analysis
@@ -147,39 +180,14 @@ func (t *DeadCodeElimination) propagateLiveness(ctx context.Context) []bool {
 		}
 		// Debug output
 		if config.DebugDeadCodeElimination && t.requests.Contains(id) {
-			log.I(ctx, "DCE: Requested cmd %v: %v", id, t.depGraph.Commands[i])
+			if cmd, release, err := t.depGraph.GetCmd(ctx, i); err == nil {
+				log.I(ctx, "DCE: Requested cmd %v: %v", id, cmd)
+				release()
+			}
 			t.depGraph.Print(ctx, &b)
 		}
 	}
 
-	{
-		// Collect and report statistics
-		num, numDead, numLive := len(isLive), 0, 0
-		deadMem, liveMem := uint64(0), uint64(0)
-		for i := 0; i < num; i++ {
-			cmd := t.depGraph.Commands[i]
-			mem := uint64(0)
-			if e := cmd.Extras(); e != nil && e.Observations() != nil {
-				for _, r := range e.Observations().Reads {
-					mem += r.Range.Size
-				}
-			}
-			if !isLive[i] {
-				numDead++
-				deadMem += mem
-			} else {
-				numLive++
-				liveMem += mem
-			}
-		}
-		deadCodeEliminationCmdDeadCounter.Add(int64(numDead))
-		deadCodeEliminationCmdLiveCounter.Add(int64(numLive))
-		deadCodeEliminationDataDeadCounter.Add(int64(deadMem))
-		deadCodeEliminationDataLiveCounter.Add(int64(liveMem))
-		log.D(ctx, "DCE: dead: %v%% %v cmds %v MB, live: %v%% %v cmds %v MB",
-			100*numDead/num, numDead, deadMem/1024/1024,
-			100*numLive/num, numLive, liveMem/1024/1024)
-	}
 	return isLive
 }
 
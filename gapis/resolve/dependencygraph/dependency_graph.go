@@ -37,16 +37,38 @@ import (
 var dependencyGraphBuildCounter = benchmark.Duration("dependencyGraph.build")
 
 type DependencyGraph struct {
-	// Number of generated commands in 'Commands' which build the initial state.
+	// Number of generated commands in the command list which build the
+	// initial state.
 	NumInitialCommands int
 
-	Commands   []api.Cmd             // Command list which this graph was build for.
+	cmds       cmdList               // Command list which this graph was build for.
 	Behaviours []CmdBehaviour        // State reads/writes for each command (graph edges).
 	Roots      map[StateAddress]bool // State to mark live at requested commands.
 	addressMap addressMapping        // Remap state keys to integers for performance.
 }
 
-// GetCmdID returns the CmdID for given element in the Commands slice.
+// NumCommands returns the number of commands of the command list which this
+// graph was built for, including the initial commands.
+func (g *DependencyGraph) NumCommands() int {
+	return g.cmds.count()
+}
+
+// ForeachCmd calls cb with the index and command of each command of the
+// command list which this graph was built for, in order, until cb returns an
+// error. The commands of the capture are decoded on demand, so the commands
+// passed to cb must not be used once cb has returned.
+func (g *DependencyGraph) ForeachCmd(ctx context.Context, cb func(context.Context, api.CmdID, api.Cmd) error) error {
+	return g.cmds.foreach(ctx, cb)
+}
+
+// GetCmd returns the command with the given index in the command list which
+// this graph was built for, and the function to call once the command is no
+// longer used.
+func (g *DependencyGraph) GetCmd(ctx context.Context, cmdIndex int) (api.Cmd, func(), error) {
+	return g.cmds.cmd(ctx, cmdIndex)
+}
+
+// GetCmdID returns the CmdID for given element in the command list.
 func (g *DependencyGraph) GetCmdID(cmdIndex int) api.CmdID {
 	if cmdIndex < g.NumInitialCommands {
 		return api.CmdID(0).Derived()
@@ -169,19 +191,15 @@ func (r *DependencyGraphResolvable) Resolve(ctx context.Context) (interface{}, e
 	if err != nil {
 		return nil, err
 	}
-	cmds := c.Commands
 	behaviourProviders := map[api.API]BehaviourProvider{}
 
 	initCmds, ranges, err := initialcmds.InitialCommands(ctx, r.Capture)
 
-	if len(initCmds) > 0 {
-		cmds = append(initCmds, cmds...)
-	}
-
+	cmds := cmdList{initialCmds: initCmds, capture: c}
 	g := &DependencyGraph{
 		NumInitialCommands: len(initCmds),
-		Commands:           cmds,
-		Behaviours:         make([]CmdBehaviour, len(cmds)),
+		cmds:               cmds,
+		Behaviours:         make([]CmdBehaviour, cmds.count()),
 		Roots:              map[StateAddress]bool{},
 		addressMap: addressMapping{
 			address: map[StateKey]StateAddress{nil: NullStateAddress},
@@ -193,7 +211,7 @@ func (r *DependencyGraphResolvable) Resolve(ctx context.Context) (interface{}, e
 	s := c.NewUninitializedState(ctx, ranges)
 
 	dependencyGraphBuildCounter.Time(func() {
-		api.ForeachCmd(ctx, cmds, func(ctx context.Context, index api.CmdID, cmd api.Cmd) error {
+		err = cmds.foreach(ctx, func(ctx context.Context, index api.CmdID, cmd api.Cmd) error {
 			a := cmd.API()
 			id := g.GetCmdID(int(index))
 			if _, ok := behaviourProviders[a]; !ok {
@@ -218,5 +236,8 @@ func (r *DependencyGraphResolvable) Resolve(ctx context.Context) (interface{}, e
 			return nil
 		})
 	})
+	if err != nil {
+		return nil, err
+	}
 	return g, nil
 }
@@ -33,7 +33,7 @@ var footprintBuildCounter = benchmark.Duration("footprint.build")
 // Footprint contains a list of command and a list of Behaviors which
 // describes the side effect of executing the commands in that list.
 type Footprint struct {
-	Commands           []api.Cmd
+	cmds               cmdList
 	NumInitialCommands int
 	Behaviors          []*Behavior
 	BehaviorIndices    map[*Behavior]uint64
@@ -44,25 +44,35 @@ type Footprint struct {
 // returns a pointer to that Footprint.
 func NewEmptyFootprint(ctx context.Context) *Footprint {
 	return &Footprint{
-		Commands:         []api.Cmd{},
 		Behaviors:        []*Behavior{},
 		BehaviorIndices:  map[*Behavior]uint64{},
 		cmdIdxToBehavior: api.SubCmdIdxTrie{},
 	}
 }
 
-// NewFootprint takes a list of commands and creates a new Footprint with
-// that list of commands, and returns a pointer to that Footprint.
-func NewFootprint(ctx context.Context, cmds []api.Cmd, numInitialCommands int) *Footprint {
+// NewFootprint creates a new Footprint for the list of commands made of the
+// initial commands followed by the commands of the capture c, and returns a
+// pointer to that Footprint. The commands of the capture are read from c when
+// needed.
+func NewFootprint(ctx context.Context, initialCmds []api.Cmd, c *capture.Capture) *Footprint {
+	cmds := cmdList{initialCmds: initialCmds, capture: c}
 	return &Footprint{
-		Commands:           cmds,
-		NumInitialCommands: numInitialCommands,
-		Behaviors:          make([]*Behavior, 0, len(cmds)),
+		cmds:               cmds,
+		NumInitialCommands: len(initialCmds),
+		Behaviors:          make([]*Behavior, 0, cmds.count()),
 		BehaviorIndices:    map[*Behavior]uint64{},
 		cmdIdxToBehavior:   api.SubCmdIdxTrie{},
 	}
 }
 
+// ForeachCmd calls cb with the index and command of each command of the
+// footprint, in order, until cb returns an error. The commands of the capture
+// are decoded on demand, so the commands passed to cb must not be used once
+// cb has returned.
+func (f *Footprint) ForeachCmd(ctx context.Context, cb func(context.Context, api.CmdID, api.Cmd) error) error {
+	return f.cmds.foreach(ctx, cb)
+}
+
 // Behavior contains a set of read and write operations as side effect of
 // executing the command to whom it belongs. Behavior also contains a
 // reference to the back-propagation machine which should be used to process
@@ -218,26 +228,20 @@ func (r *FootprintResolvable) Resolve(ctx context.Context) (interface{}, error)
 	if err != nil {
 		return nil, err
 	}
-	cmds := c.Commands
 	// If the capture contains initial state, prepend the commands to build the state.
-
 	initialCmds, ranges, err := initialcmds.InitialCommands(ctx, r.Capture)
 	if err != nil {
 		return nil, err
 	}
-	numInitialCmds := len(initialCmds)
-	if len(initialCmds) > 0 {
-		cmds = append(initialCmds, cmds...)
-	}
 
 	builders := map[api.API]FootprintBuilder{}
 
-	ft := NewFootprint(ctx, cmds, numInitialCmds)
+	ft := NewFootprint(ctx, initialCmds, c)
 
 	s := c.NewUninitializedState(ctx, ranges)
 	t0 := footprintBuildCounter.Start()
 	defer footprintBuildCounter.Stop(t0)
-	api.ForeachCmd(ctx, cmds, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+	err = ft.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
 		a := cmd.API()
 		if _, ok := builders[cmd.API()]; !ok {
 			if bp, ok := cmd.API().(FootprintBuilderProvider); ok {
@@ -265,5 +269,8 @@ func (r *FootprintResolvable) Resolve(ctx context.Context) (interface{}, error)
 		builders[a].BuildFootprint(ctx, s, ft, id, cmd)
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 	return ft, nil
 }
@@ -0,0 +1,139 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencygraph
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// Resource describes a resource holding state of the dependency graph, such
+// as a texture holding texel data.
+type Resource struct {
+	Object interface{} // Uniquely identifies the resource.
+	Kind   string      // The kind of the resource, such as "Texture".
+	Name   string      // The name of the resource used for display.
+	Size   uint64      // The estimated size in bytes of the resource contents.
+}
+
+// UnusedResource is a resource whose contents are written, but never read by
+// the live commands.
+type UnusedResource struct {
+	Resource
+	Created api.CmdID // The first command that wrote the resource contents.
+}
+
+// ResourceKeyProvider is the interface implemented by behaviour providers that
+// can identify the resources holding the state of their keys.
+type ResourceKeyProvider interface {
+	// ResourceOfKey returns the resource whose contents hold the state of key,
+	// or false if the state is not held by a resource.
+	ResourceOfKey(key StateKey) (Resource, bool)
+}
+
+// GetResourceKeyProvider returns the ResourceKeyProvider of the behaviour
+// provider of the API a, or nil if the unused resources of a cannot be found.
+func GetResourceKeyProvider(ctx context.Context, a api.API) ResourceKeyProvider {
+	if bp, ok := a.(DependencyGraphBehaviourProvider); ok {
+		p, _ := bp.GetDependencyGraphBehaviourProvider(ctx).(ResourceKeyProvider)
+		return p
+	}
+	return nil
+}
+
+// UnusedResources returns the resources whose contents are written by the
+// commands of the graph, but never reach the framebuffers requested at the
+// given commands. The resources are listed in the order they were first
+// written.
+func (g *DependencyGraph) UnusedResources(ctx context.Context, requests []api.CmdID) ([]UnusedResource, error) {
+	dce := NewDeadCodeElimination(ctx, g)
+	for _, id := range requests {
+		dce.Request(id)
+	}
+	isLive := dce.propagateLiveness(ctx)
+
+	// Only the API of each command is needed, so the commands are not kept.
+	apis := make([]api.API, 0, g.NumCommands())
+	err := g.ForeachCmd(ctx, func(ctx context.Context, index api.CmdID, cmd api.Cmd) error {
+		apis = append(apis, cmd.API())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	providers := map[api.API]ResourceKeyProvider{}
+	resourceOf := func(i int, key StateKey) (Resource, bool) {
+		a := apis[i]
+		p, ok := providers[a]
+		if !ok {
+			p = GetResourceKeyProvider(ctx, a)
+			providers[a] = p
+		}
+		if p == nil {
+			return Resource{}, false
+		}
+		return p.ResourceOfKey(key)
+	}
+	return g.unusedResources(isLive, resourceOf), nil
+}
+
+// unusedResources returns the resources written by the commands of the graph
+// and never read or modified by the commands flagged in isLive. resourceOf
+// returns the resource holding the state of key, accessed by the i'th command.
+func (g *DependencyGraph) unusedResources(isLive []bool, resourceOf func(i int, key StateKey) (Resource, bool)) []UnusedResource {
+	written := []*UnusedResource{}
+	byObject := map[interface{}]*UnusedResource{}
+	used := map[interface{}]struct{}{}
+
+	for i, b := range g.Behaviours {
+		if b.Aborted {
+			continue
+		}
+		live := i < len(isLive) && isLive[i]
+		for _, list := range [][]StateAddress{b.Writes, b.Modifies} {
+			for _, address := range list {
+				r, ok := resourceOf(i, g.addressMap.key[address])
+				if !ok {
+					continue
+				}
+				if _, ok := byObject[r.Object]; !ok {
+					u := &UnusedResource{Resource: r, Created: g.GetCmdID(i)}
+					byObject[r.Object] = u
+					written = append(written, u)
+				}
+			}
+		}
+		if !live {
+			continue
+		}
+		for _, list := range [][]StateAddress{b.Reads, b.Modifies} {
+			for _, address := range list {
+				if r, ok := resourceOf(i, g.addressMap.key[address]); ok {
+					used[r.Object] = struct{}{}
+				}
+			}
+		}
+	}
+
+	out := []UnusedResource{}
+	for _, u := range written {
+		if _, ok := used[u.Object]; !ok {
+			out = append(out, *u)
+		}
+	}
+	return out
+}
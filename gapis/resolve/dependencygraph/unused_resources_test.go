@@ -0,0 +1,68 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencygraph
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+type testResourceKey struct{ name string }
+
+func (testResourceKey) Parent() StateKey { return nil }
+
+type testStateKey struct{ name string }
+
+func (testStateKey) Parent() StateKey { return nil }
+
+func TestUnusedResources(t *testing.T) {
+	ctx := log.Testing(t)
+
+	g := &DependencyGraph{
+		Behaviours: make([]CmdBehaviour, 5),
+		Roots:      map[StateAddress]bool{},
+		addressMap: addressMapping{
+			address: map[StateKey]StateAddress{nil: NullStateAddress},
+			key:     map[StateAddress]StateKey{NullStateAddress: nil},
+			parent:  map[StateAddress]StateAddress{NullStateAddress: NullStateAddress},
+		},
+	}
+	texA, texB, texC := testResourceKey{"A"}, testResourceKey{"B"}, testResourceKey{"C"}
+	other := testStateKey{"other"}
+
+	g.Behaviours[0].Write(g, texA)
+	g.Behaviours[1].Modify(g, texB)
+	g.Behaviours[2].Write(g, texC)
+	g.Behaviours[2].Write(g, other)
+	g.Behaviours[3].Read(g, texA) // Live read of A.
+	g.Behaviours[4].Read(g, texC) // Dead read of C.
+	isLive := []bool{true, false, false, true, false}
+
+	resourceOf := func(i int, key StateKey) (Resource, bool) {
+		if k, ok := key.(testResourceKey); ok {
+			return Resource{Object: k, Kind: "Texture", Name: k.name}, true
+		}
+		return Resource{}, false
+	}
+
+	got := g.unusedResources(isLive, resourceOf)
+	assert.For(ctx, "unused").ThatSlice(got).Equals([]UnusedResource{
+		{Resource: Resource{Object: texB, Kind: "Texture", Name: "B"}, Created: api.CmdID(1)},
+		{Resource: Resource{Object: texC, Kind: "Texture", Name: "C"}, Created: api.CmdID(2)},
+	})
+}
@@ -0,0 +1,479 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/google/gapid/core/data/compare"
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/box"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// maxLCSCells is the largest product of the lengths of two command lists that
+// are aligned with a longest common subsequence. This bounds the time taken to
+// align the lists. Larger lists are aligned by position.
+const maxLCSCells = 1 << 24
+
+// CaptureDiff resolves and returns the differences between the captures a and
+// b.
+func CaptureDiff(ctx context.Context, a, b *path.Capture, r *path.ResolveConfig) (*service.CaptureDiff, error) {
+	obj, err := database.Build(ctx, &CaptureDiffResolvable{A: a, B: b, Config: r})
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*service.CaptureDiff), nil
+}
+
+// diffCapture holds the information about a single capture needed to diff it
+// against another.
+type diffCapture struct {
+	path      *path.Capture
+	cmds      []api.Cmd
+	frames    [][]cmdSegment // The draw call segments of each frame.
+	draws     []uint64       // Draw calls per frame.
+	triangles []uint64       // Triangles drawn per frame.
+}
+
+// cmdSegment is a range of commands ending with a draw call, or the end of the
+// frame. start is inclusive, end is exclusive.
+type cmdSegment struct {
+	start, end uint64
+	draw       bool // True if the last command of the segment is a draw call.
+}
+
+func newDiffCapture(ctx context.Context, p *path.Capture, r *path.ResolveConfig) (*diffCapture, error) {
+	ctx = setupContext(ctx, p, r)
+
+	cmds, err := Cmds(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := Stats(ctx, &path.Stats{Capture: p, DrawCall: true, Triangles: true}, r)
+	if err != nil {
+		return nil, err
+	}
+	events, err := Events(ctx, &path.Events{Capture: p, DrawCalls: true, LastInFrame: true}, r)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &diffCapture{
+		path:      p,
+		cmds:      cmds,
+		draws:     stats.DrawCalls,
+		triangles: stats.Triangles,
+	}
+
+	// Split the commands into frames, and each frame into draw call segments.
+	// As with Stats, any commands after the last frame boundary belong to the
+	// last frame.
+	frame := []cmdSegment{}
+	start := uint64(0)
+	for _, e := range events.List {
+		end := e.Command.Indices[0] + 1
+		if end > start {
+			frame = append(frame, cmdSegment{start, end, e.Kind == service.EventKind_DrawCall})
+			start = end
+		}
+		if e.Kind == service.EventKind_LastInFrame {
+			out.frames = append(out.frames, frame)
+			frame = []cmdSegment{}
+		}
+	}
+	if start < uint64(len(cmds)) {
+		frame = append(frame, cmdSegment{start, uint64(len(cmds)), false})
+	}
+	if len(frame) > 0 {
+		if len(out.frames) == 0 {
+			out.frames = append(out.frames, frame)
+		} else {
+			last := len(out.frames) - 1
+			out.frames[last] = append(out.frames[last], frame...)
+		}
+	}
+	return out, nil
+}
+
+func (c *diffCapture) frameDraws(i int) uint64 {
+	if i < len(c.draws) {
+		return c.draws[i]
+	}
+	return 0
+}
+
+func (c *diffCapture) frameTriangles(i int) uint64 {
+	if i < len(c.triangles) {
+		return c.triangles[i]
+	}
+	return 0
+}
+
+// Resolve implements the database.Resolver interface.
+func (r *CaptureDiffResolvable) Resolve(ctx context.Context) (interface{}, error) {
+	a, err := newDiffCapture(ctx, r.A, r.Config)
+	if err != nil {
+		return nil, err
+	}
+	b, err := newDiffCapture(ctx, r.B, r.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &service.CaptureDiff{}
+
+	numFrames := len(a.frames)
+	if len(b.frames) > numFrames {
+		numFrames = len(b.frames)
+	}
+	for i := 0; i < numFrames; i++ {
+		if err := task.StopReason(ctx); err != nil {
+			return nil, err
+		}
+		f := &service.FrameDiff{
+			Frame:      uint64(i),
+			DrawCallsA: a.frameDraws(i),
+			DrawCallsB: b.frameDraws(i),
+			TrianglesA: a.frameTriangles(i),
+			TrianglesB: b.frameTriangles(i),
+		}
+		f.DrawCallsDelta = int64(f.DrawCallsB) - int64(f.DrawCallsA)
+		f.TrianglesDelta = int64(f.TrianglesB) - int64(f.TrianglesA)
+		switch {
+		case i >= len(b.frames):
+			f.Kind = service.DiffKind_Removed
+		case i >= len(a.frames):
+			f.Kind = service.DiffKind_Added
+		default:
+			f.Commands = diffFrame(a, b, a.frames[i], b.frames[i])
+			if len(f.Commands) > 0 || f.DrawCallsA != f.DrawCallsB || f.TrianglesA != f.TrianglesB {
+				f.Kind = service.DiffKind_Changed
+			}
+		}
+		out.Frames = append(out.Frames, f)
+	}
+
+	resources, err := diffResources(ctx, a, b, r.Config)
+	if err != nil {
+		return nil, err
+	}
+	out.Resources = resources
+
+	return out, nil
+}
+
+// diffFrame returns the differences between the commands of the frames fa and
+// fb. The draw call segments of the frames are aligned by the names of their
+// commands, and the commands of each pair of aligned segments are then aligned
+// by name. Segments left unaligned between two aligned pairs are paired by
+// position, so a draw call whose commands changed is still diffed against the
+// draw call it replaced.
+func diffFrame(a, b *diffCapture, fa, fb []cmdSegment) []*service.CommandDiff {
+	cmds := []*service.CommandDiff{}
+	i, j := 0, 0
+	pairUnaligned := func(endA, endB int) {
+		for i < endA || j < endB {
+			var sa, sb cmdSegment
+			if i < endA {
+				sa, i = fa[i], i+1
+			}
+			if j < endB {
+				sb, j = fb[j], j+1
+			}
+			cmds = append(cmds, diffSegment(a, b, sa, sb)...)
+		}
+	}
+	for _, m := range alignNames(a.segmentKeys(fa), b.segmentKeys(fb)) {
+		pairUnaligned(int(m.a), int(m.b))
+		cmds = append(cmds, diffSegment(a, b, fa[i], fb[j])...)
+		i, j = i+1, j+1
+	}
+	pairUnaligned(len(fa), len(fb))
+	return cmds
+}
+
+// segmentKeys returns a key for each of the segments f that is equal for
+// segments with the same command names.
+func (c *diffCapture) segmentKeys(f []cmdSegment) []string {
+	out := make([]string, len(f))
+	for i, s := range f {
+		names := make([]string, 0, s.end-s.start)
+		for _, cmd := range c.cmds[s.start:s.end] {
+			names = append(names, cmd.CmdName())
+		}
+		out[i] = strings.Join(names, "\n")
+	}
+	return out
+}
+
+// diffSegment returns the differences between the commands of the segments
+// sa and sb.
+func diffSegment(a, b *diffCapture, sa, sb cmdSegment) []*service.CommandDiff {
+	out := []*service.CommandDiff{}
+	removed := func(i uint64) {
+		out = append(out, &service.CommandDiff{
+			Kind: service.DiffKind_Removed,
+			A:    a.path.Command(i),
+			Name: a.cmds[i].CmdName(),
+		})
+	}
+	added := func(i uint64) {
+		out = append(out, &service.CommandDiff{
+			Kind: service.DiffKind_Added,
+			B:    b.path.Command(i),
+			Name: b.cmds[i].CmdName(),
+		})
+	}
+	matched := func(i, j uint64) {
+		if params := diffParameters(a.cmds[i], b.cmds[j]); len(params) > 0 {
+			out = append(out, &service.CommandDiff{
+				Kind:       service.DiffKind_Changed,
+				A:          a.path.Command(i),
+				B:          b.path.Command(j),
+				Name:       a.cmds[i].CmdName(),
+				Parameters: params,
+			})
+		}
+	}
+
+	i, j := sa.start, sb.start
+	for _, m := range alignCmds(a.cmds[sa.start:sa.end], b.cmds[sb.start:sb.end]) {
+		for ; i < sa.start+m.a; i++ {
+			removed(i)
+		}
+		for ; j < sb.start+m.b; j++ {
+			added(j)
+		}
+		matched(i, j)
+		i, j = i+1, j+1
+	}
+	for ; i < sa.end; i++ {
+		removed(i)
+	}
+	for ; j < sb.end; j++ {
+		added(j)
+	}
+	return out
+}
+
+// cmdMatch is a pair of relative command indices that have been aligned.
+type cmdMatch struct {
+	a, b uint64
+}
+
+// alignCmds returns the longest common subsequence of the command names in a
+// and b, as a list of matched index pairs. If the lists are too large to align
+// this way, only their common prefix and suffix are matched.
+func alignCmds(a, b []api.Cmd) []cmdMatch {
+	names := func(cmds []api.Cmd) []string {
+		out := make([]string, len(cmds))
+		for i, c := range cmds {
+			out[i] = c.CmdName()
+		}
+		return out
+	}
+	return alignNames(names(a), names(b))
+}
+
+// alignNames returns the longest common subsequence of a and b as a list of
+// matched index pairs. See alignCmds for details.
+func alignNames(a, b []string) []cmdMatch {
+	out := []cmdMatch{}
+
+	// Common prefixes and suffixes are part of the LCS, and trimming them
+	// first makes the typical, mostly identical lists cheap to align.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		out = append(out, cmdMatch{uint64(prefix), uint64(prefix)})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	n, m := len(a)-prefix-suffix, len(b)-prefix-suffix
+	if n*m <= maxLCSCells {
+		lcs(a[prefix:prefix+n], b[prefix:prefix+m], uint64(prefix), uint64(prefix), &out)
+	}
+	for i := 0; i < suffix; i++ {
+		out = append(out, cmdMatch{uint64(prefix + n + i), uint64(prefix + m + i)})
+	}
+	return out
+}
+
+// lcs appends the longest common subsequence of a and b to out, offsetting the
+// indices by offA and offB.
+// lcs uses Hirschberg's algorithm, which takes O(len(a)*len(b)) time, but only
+// O(len(b)) space.
+func lcs(a, b []string, offA, offB uint64, out *[]cmdMatch) {
+	switch {
+	case len(a) == 0 || len(b) == 0:
+		return
+	case len(a) == 1:
+		for j := range b {
+			if a[0] == b[j] {
+				*out = append(*out, cmdMatch{offA, offB + uint64(j)})
+				return
+			}
+		}
+		return
+	}
+
+	// Split a in half, and find the split of b that maximizes the sum of the
+	// LCS lengths of the two halves.
+	mid := len(a) / 2
+	fwd := lcsLengths(a[:mid], b, false)
+	bwd := lcsLengths(a[mid:], b, true)
+	split, best := 0, -1
+	for j := 0; j <= len(b); j++ {
+		if l := fwd[j] + bwd[len(b)-j]; l > best {
+			split, best = j, l
+		}
+	}
+	lcs(a[:mid], b[:split], offA, offB, out)
+	lcs(a[mid:], b[split:], offA+uint64(mid), offB+uint64(split), out)
+}
+
+// lcsLengths returns the lengths of the LCS of a and each prefix of b, indexed
+// by the length of the prefix. If reverse is true, then a and b are both
+// walked backwards, giving the LCS lengths of a and each suffix of b.
+func lcsLengths(a, b []string, reverse bool) []int {
+	prev, curr := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		ai := a[i]
+		if reverse {
+			ai = a[len(a)-1-i]
+		}
+		for j := range b {
+			bj := b[j]
+			if reverse {
+				bj = b[len(b)-1-j]
+			}
+			switch {
+			case ai == bj:
+				curr[j+1] = prev[j] + 1
+			case prev[j+1] >= curr[j]:
+				curr[j+1] = prev[j+1]
+			default:
+				curr[j+1] = curr[j]
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev
+}
+
+// diffParameters returns the parameters that differ between the commands a and
+// b, which are expected to have the same name.
+func diffParameters(a, b api.Cmd) []*service.ParameterDiff {
+	out := []*service.ParameterDiff{}
+	pb := b.CmdParams()
+	for _, p := range a.CmdParams() {
+		q := pb.Find(p.Name)
+		if q == nil {
+			continue
+		}
+		va, vb := p.Get(), q.Get()
+		if len(compare.Diff(va, vb, 1)) > 0 {
+			out = append(out, &service.ParameterDiff{
+				Name: p.Name,
+				A:    box.NewValue(va),
+				B:    box.NewValue(vb),
+			})
+		}
+	}
+	return out
+}
+
+// resourceKey is used to match the resources of two captures.
+type resourceKey struct {
+	ty     api.ResourceType
+	handle string
+}
+
+// diffResources returns the resources that are only in one of a or b, or whose
+// data differs at the end of the captures.
+func diffResources(ctx context.Context, a, b *diffCapture, r *path.ResolveConfig) ([]*service.ResourceDiff, error) {
+	resourcesOf := func(c *diffCapture) (map[resourceKey]*service.Resource, error) {
+		res, err := Resources(ctx, c.path, r)
+		if err != nil {
+			return nil, err
+		}
+		out := map[resourceKey]*service.Resource{}
+		for _, t := range res.Types {
+			for _, r := range t.Resources {
+				out[resourceKey{t.Type, r.Handle}] = r
+			}
+		}
+		return out, nil
+	}
+	dataOf := func(c *diffCapture, res *service.Resource) interface{} {
+		if len(c.cmds) == 0 {
+			return nil
+		}
+		after := c.path.Command(uint64(len(c.cmds) - 1))
+		data, err := ResourceData(setupContext(ctx, c.path, r), after.ResourceAfter(res.ID), r)
+		if err != nil {
+			return err.Error()
+		}
+		return data
+	}
+
+	ra, err := resourcesOf(a)
+	if err != nil {
+		return nil, err
+	}
+	rb, err := resourcesOf(b)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []*service.ResourceDiff{}
+	for k, resA := range ra {
+		resB, ok := rb[k]
+		switch {
+		case !ok:
+			out = append(out, &service.ResourceDiff{
+				Kind: service.DiffKind_Removed, Type: k.ty, Handle: k.handle, A: resA.ID,
+			})
+		case !compare.DeepEqual(dataOf(a, resA), dataOf(b, resB)):
+			out = append(out, &service.ResourceDiff{
+				Kind: service.DiffKind_Changed, Type: k.ty, Handle: k.handle, A: resA.ID, B: resB.ID,
+			})
+		}
+	}
+	for k, resB := range rb {
+		if _, ok := ra[k]; !ok {
+			out = append(out, &service.ResourceDiff{
+				Kind: service.DiffKind_Added, Type: k.ty, Handle: k.handle, B: resB.ID,
+			})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Type != out[j].Type {
+			return out[i].Type < out[j].Type
+		}
+		return out[i].Handle < out[j].Handle
+	})
+	return out, nil
+}
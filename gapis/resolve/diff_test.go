@@ -0,0 +1,181 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/test"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// lcsLength returns the length of the longest common subsequence of a and b
+// using the textbook quadratic-space algorithm.
+func lcsLength(a, b []string) int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := range a {
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				lengths[i+1][j+1] = lengths[i][j] + 1
+			case lengths[i][j+1] >= lengths[i+1][j]:
+				lengths[i+1][j+1] = lengths[i][j+1]
+			default:
+				lengths[i+1][j+1] = lengths[i+1][j]
+			}
+		}
+	}
+	return lengths[len(a)][len(b)]
+}
+
+func TestAlignNames(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct{ a, b string }{
+		{"", ""},
+		{"abc", ""},
+		{"", "abc"},
+		{"abc", "abc"},
+		{"abc", "xyz"},
+		{"abcbdab", "bdcaba"},
+		{"xaybz", "ab"},
+		{"aaaa", "aa"},
+		{"abcdefgh", "abxdeygh"},
+		{"thequickbrownfox", "thequackbrownbox"},
+		{"gladraw gladraw swap", "gladraw draw swap"},
+	} {
+		a, b := strings.Split(test.a, ""), strings.Split(test.b, "")
+		got := alignNames(a, b)
+		assert.For(ctx, "alignNames(%v, %v) length", test.a, test.b).That(len(got)).Equals(lcsLength(a, b))
+		for i, m := range got {
+			if !assert.For(ctx, "alignNames(%v, %v)[%d] names", test.a, test.b, i).
+				ThatString(a[m.a]).Equals(b[m.b]) {
+				break
+			}
+			if i > 0 && (m.a <= got[i-1].a || m.b <= got[i-1].b) {
+				t.Errorf("alignNames(%v, %v) matches not in order: %v", test.a, test.b, got)
+				break
+			}
+		}
+	}
+}
+
+func TestAlignNamesTooLarge(t *testing.T) {
+	ctx := log.Testing(t)
+	// Lists that are too large for the LCS are matched by their common prefix
+	// and suffix only.
+	n := 1 << 13
+	a, b := make([]string, n), make([]string, n)
+	for i := range a {
+		a[i], b[i] = "a", "b"
+	}
+	a[0], b[0] = "x", "x"
+	a[n-1], b[n-1] = "y", "y"
+	got := alignNames(a, b)
+	assert.For(ctx, "matches").ThatSlice(got).Equals([]cmdMatch{{0, 0}, {uint64(n - 1), uint64(n - 1)}})
+}
+
+func TestDiffSegment(t *testing.T) {
+	ctx := log.Testing(t)
+	cb := test.CommandBuilder{Arena: arena.New()}
+	mix := func(id uint64, u8 uint8) api.Cmd {
+		return cb.CmdTypeMix(id, u8, 20, 30, 40, 50, 60, 70, 80, 90, 100, true, test.Voidᵖ(0x12345678), 2)
+	}
+	a := &diffCapture{
+		path: &path.Capture{},
+		cmds: []api.Cmd{mix(0, 10), cb.CmdVoid(), mix(1, 10)},
+	}
+	b := &diffCapture{
+		path: &path.Capture{},
+		cmds: []api.Cmd{mix(0, 10), mix(1, 11), cb.CmdVoid()},
+	}
+	got := diffSegment(a, b, cmdSegment{0, 3, false}, cmdSegment{0, 3, false})
+	checkCommandDiffs(ctx, got, []cmdDiff{
+		{service.DiffKind_Removed, "cmdVoid", []uint64{1}, nil, nil},
+		{service.DiffKind_Changed, "cmdTypeMix", []uint64{2}, []uint64{1}, []string{"U8"}},
+		{service.DiffKind_Added, "cmdVoid", nil, []uint64{2}, []string{}},
+	})
+}
+
+func TestDiffFrame(t *testing.T) {
+	ctx := log.Testing(t)
+	cb := test.CommandBuilder{Arena: arena.New()}
+	mix := func(id uint64) api.Cmd {
+		return cb.CmdTypeMix(id, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100, true, test.Voidᵖ(0x12345678), 2)
+	}
+	a := &diffCapture{
+		path: &path.Capture{},
+		cmds: []api.Cmd{cb.CmdVoid(), mix(0), cb.CmdVoidU8(1), mix(1)},
+	}
+	b := &diffCapture{
+		path: &path.Capture{},
+		cmds: []api.Cmd{cb.CmdVoidU32(7), mix(2), cb.CmdVoid(), mix(0), cb.CmdVoidU8(2), mix(1)},
+	}
+	// B has an extra draw call at the start of the frame. The draw calls of A
+	// should be diffed against the last two draw calls of B, not the first two.
+	got := diffFrame(a, b,
+		[]cmdSegment{{0, 2, true}, {2, 4, true}},
+		[]cmdSegment{{0, 2, true}, {2, 4, true}, {4, 6, true}})
+	checkCommandDiffs(ctx, got, []cmdDiff{
+		{service.DiffKind_Added, "cmdVoidU32", nil, []uint64{0}, []string{}},
+		{service.DiffKind_Added, "cmdTypeMix", nil, []uint64{1}, []string{}},
+		{service.DiffKind_Changed, "cmdVoidU8", []uint64{2}, []uint64{4}, nil},
+	})
+}
+
+// cmdDiff is the expected value of a service.CommandDiff.
+type cmdDiff struct {
+	kind   service.DiffKind
+	name   string
+	a, b   []uint64
+	params []string // The names of the changed parameters. Not checked if nil.
+}
+
+func checkCommandDiffs(ctx context.Context, got []*service.CommandDiff, expected []cmdDiff) {
+	if !assert.For(ctx, "diffs").ThatSlice(got).IsLength(len(expected)) {
+		return
+	}
+	for i, d := range got {
+		e := expected[i]
+		assert.For(ctx, "diff %d kind", i).That(d.Kind).Equals(e.kind)
+		assert.For(ctx, "diff %d name", i).ThatString(d.Name).Equals(e.name)
+		if e.a != nil {
+			assert.For(ctx, "diff %d A", i).ThatSlice(d.A.Indices).Equals(e.a)
+		} else {
+			assert.For(ctx, "diff %d A", i).That(d.A).IsNil()
+		}
+		if e.b != nil {
+			assert.For(ctx, "diff %d B", i).ThatSlice(d.B.Indices).Equals(e.b)
+		} else {
+			assert.For(ctx, "diff %d B", i).That(d.B).IsNil()
+		}
+		if e.params != nil {
+			params := []string{}
+			for _, p := range d.Parameters {
+				params = append(params, p.Name)
+			}
+			assert.For(ctx, "diff %d parameters", i).ThatSlice(params).Equals(e.params)
+		}
+	}
+}
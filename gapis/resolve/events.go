@@ -67,7 +67,7 @@ func Events(ctx context.Context, p *path.Events, r *path.ResolveConfig) (*servic
 	s := c.NewState(ctx)
 	lastCmd := api.CmdID(0)
 	var pending []service.EventKind
-	api.ForeachCmd(ctx, c.Commands, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+	c.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
 		cmd.Mutate(ctx, id, s, nil)
 
 		// TODO: Add event generation to the API files.
@@ -77,6 +77,15 @@ func Find(ctx context.Context, req *service.FindRequest, h service.FindHandler)
 		pred = func(s string) bool { return strings.Contains(strings.ToLower(s), text) }
 	}
 
+	var query *cmdQuery
+	if req.Query != "" {
+		q, err := compileQuery(ctx, req.Query)
+		if err != nil {
+			return log.Err(ctx, err, "Couldn't parse query")
+		}
+		query = q
+	}
+
 	switch from := protoutil.OneOf(req.From).(type) {
 	case nil:
 		return fault.Const("FindRequest.From cannot be nil")
@@ -99,26 +108,50 @@ func Find(ctx context.Context, req *service.FindRequest, h service.FindHandler)
 			return err
 		}
 
+		cmdPred := func(id api.CmdID) bool {
+			cmd, release, err := c.Cmd(ctx, id)
+			if err != nil {
+				return false
+			}
+			defer release()
+			return pred(fmt.Sprint(cmd))
+		}
+		groupPred := pred
+
+		if query != nil {
+			// Queries are evaluated in a single pass over the capture, as
+			// they may depend on the state built by all the prior commands.
+			matches, err := queryMatches(ctx, c, query)
+			if err != nil {
+				return err
+			}
+			cmdPred = func(id api.CmdID) bool {
+				_, ok := matches[id]
+				return ok
+			}
+			groupPred = func(string) bool { return false }
+		}
+
 		nodePred := func(item api.SpanItem) bool {
 			switch item := item.(type) {
 			case api.CmdIDGroup:
-				return pred(item.Name)
+				return groupPred(item.Name)
 			case api.SubCmdIdx:
 				if len(item) > 1 {
 					if idx, found := translateIDForDisplay(item, snc); found {
-						return pred(fmt.Sprint(c.Commands[idx]))
+						return cmdPred(idx)
 					}
 					return false
 				}
-				return pred(fmt.Sprint(c.Commands[item[0]]))
+				return cmdPred(api.CmdID(item[0]))
 			case api.SubCmdRoot:
 				if len(item.Id) > 1 {
 					if idx, found := translateIDForDisplay(item.Id, snc); found {
-						return pred(fmt.Sprint(c.Commands[idx]))
+						return cmdPred(idx)
 					}
 					return false
 				}
-				return pred(fmt.Sprint(c.Commands[item.Id[0]]))
+				return cmdPred(api.CmdID(item.Id[0]))
 			default:
 				return false
 			}
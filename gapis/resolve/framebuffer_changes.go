@@ -96,6 +96,10 @@ func (r *FramebufferChangesResolvable) Resolve(ctx context.Context) (interface{}
 		}
 	}
 
-	sync.MutateWithSubcommands(ctx, r.Capture, c.Commands, postCmdAndSubCmd, nil, postCmdAndSubCmd)
+	cmds, err := c.AllCommands(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sync.MutateWithSubcommands(ctx, r.Capture, cmds, postCmdAndSubCmd, nil, postCmdAndSubCmd)
 	return out, nil
 }
@@ -17,9 +17,11 @@ package resolve
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
 	"github.com/google/gapid/gapis/messages"
 	"github.com/google/gapid/gapis/service"
 	"github.com/google/gapid/gapis/service/path"
@@ -34,6 +36,13 @@ func Metrics(ctx context.Context, p *path.Metrics, r *path.ResolveConfig) (*api.
 		}
 		res.MemoryBreakdown = breakdown
 	}
+	if p.MemoryTimeline {
+		timeline, err := memoryTimeline(ctx, p.Command.Capture)
+		if err != nil {
+			return nil, log.Errf(ctx, err, "Failed to get memory timeline")
+		}
+		res.MemoryTimeline = timeline
+	}
 	return &res, nil
 }
 
@@ -57,3 +66,148 @@ func memoryBreakdown(ctx context.Context, c *path.Command, r *path.ResolveConfig
 	return nil, fmt.Errorf("Memory breakdown not supported for API %v", a.Name())
 
 }
+
+// memoryObjectKey identifies a memory object in the memory timeline.
+type memoryObjectKey struct {
+	api    api.ID
+	object interface{}
+}
+
+// memoryTimeline returns the timeline of the memory objects of the capture p.
+func memoryTimeline(ctx context.Context, p *path.Capture) (*api.MemoryTimeline, error) {
+	c, err := capture.ResolveFromPath(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	supported := false
+	for _, a := range c.APIs {
+		if _, ok := a.(api.MemoryTimelineProvider); ok {
+			supported = true
+		}
+	}
+	if !supported {
+		return nil, fmt.Errorf("Memory timeline not supported for the APIs of the capture")
+	}
+
+	return buildMemoryTimeline(ctx, c.APIs, c.NewState(ctx), c.ForeachCmd)
+}
+
+// buildMemoryTimeline returns the timeline of the memory objects of the APIs
+// apis, by mutating s with the commands passed by foreach and comparing the
+// memory objects listed by the APIs after each command that may change them.
+func buildMemoryTimeline(ctx context.Context, apis []api.API, s *api.GlobalState,
+	foreach func(context.Context, func(context.Context, api.CmdID, api.Cmd) error) error) (*api.MemoryTimeline, error) {
+
+	out := &api.MemoryTimeline{}
+	live := map[memoryObjectKey]*api.MemoryObject{}
+	total := uint64(0)
+	event := func(id api.CmdID, kind api.MemoryEventKind, o *api.MemoryObject) {
+		out.Events = append(out.Events, &api.MemoryEvent{
+			Command:   uint64(id),
+			Kind:      kind,
+			Object:    o,
+			TotalSize: total,
+		})
+	}
+
+	// The objects of the initial state are live from the start.
+	for _, a := range apis {
+		if mt, ok := a.(api.MemoryTimelineProvider); ok {
+			for obj, o := range mt.MemoryObjects(s) {
+				live[memoryObjectKey{a.ID(), obj}] = o
+				total += o.Size
+			}
+		}
+	}
+	out.PeakSize = total
+
+	err := foreach(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if err := cmd.Mutate(ctx, id, s, nil /* no builder, just mutate */); err != nil {
+			log.W(ctx, "Command %v %v: %v", id, cmd, err)
+		}
+		a := cmd.API()
+		mt, ok := a.(api.MemoryTimelineProvider)
+		if !ok || !mt.ChangesMemoryObjects(cmd) {
+			return nil
+		}
+
+		objects := mt.MemoryObjects(s)
+		keys := make([]memoryObjectKey, 0, len(objects))
+		for obj := range objects {
+			keys = append(keys, memoryObjectKey{a.ID(), obj})
+		}
+		sortMemoryObjectKeys(keys, func(key memoryObjectKey) *api.MemoryObject { return objects[key.object] })
+
+		for _, key := range keys {
+			o := objects[key.object]
+			old, ok := live[key]
+			switch {
+			case !ok:
+				o.Allocated = uint64(id)
+				live[key] = o
+				total += o.Size
+				event(id, api.MemoryEventKind_MemoryAllocated, o)
+			case old.Size != o.Size:
+				o.Allocated = old.Allocated
+				live[key] = o
+				total = total - old.Size + o.Size
+				event(id, api.MemoryEventKind_MemoryResized, o)
+			case old.Name != o.Name:
+				// The object was renamed, e.g. after the destruction of the
+				// context it was named after.
+				o.Allocated = old.Allocated
+				live[key] = o
+			}
+		}
+
+		freed := []memoryObjectKey{}
+		for key := range live {
+			if _, ok := objects[key.object]; !ok && key.api == a.ID() {
+				freed = append(freed, key)
+			}
+		}
+		sortMemoryObjectKeys(freed, func(key memoryObjectKey) *api.MemoryObject { return live[key] })
+		for _, key := range freed {
+			o := live[key]
+			delete(live, key)
+			total -= o.Size
+			event(id, api.MemoryEventKind_MemoryFreed, o)
+		}
+
+		if total > out.PeakSize {
+			out.PeakSize, out.PeakCommand = total, uint64(id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range live {
+		out.Leaked = append(out.Leaked, o)
+	}
+	sort.Slice(out.Leaked, func(i, j int) bool {
+		a, b := out.Leaked[i], out.Leaked[j]
+		if a.Allocated != b.Allocated {
+			return a.Allocated < b.Allocated
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return out, nil
+}
+
+// sortMemoryObjectKeys sorts the keys by the kind and name of their objects,
+// returned by get, so that the events of a command are in a stable order.
+func sortMemoryObjectKeys(keys []memoryObjectKey, get func(memoryObjectKey) *api.MemoryObject) {
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := get(keys[i]), get(keys[j])
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+}
@@ -0,0 +1,129 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/test"
+	"github.com/google/gapid/gapis/replay/builder"
+)
+
+// memoryObject is an object owning memory of a memoryAPI.
+type memoryObject struct {
+	name string
+	size uint64
+}
+
+// memoryAPI is an API whose memory objects are changed by memoryCmds.
+type memoryAPI struct {
+	api.API
+	objects map[uint64]*memoryObject
+}
+
+func (a *memoryAPI) ChangesMemoryObjects(cmd api.Cmd) bool {
+	_, ok := cmd.(memoryCmd)
+	return ok
+}
+
+func (a *memoryAPI) MemoryObjects(*api.GlobalState) map[interface{}]*api.MemoryObject {
+	out := map[interface{}]*api.MemoryObject{}
+	for handle, o := range a.objects {
+		out[o] = &api.MemoryObject{Kind: "Buffer", Handle: handle, Name: o.name, Size: o.size}
+	}
+	return out
+}
+
+// memoryCmd sets the name and size of the object with the given handle,
+// creating the object if needed. A size of 0 frees the object.
+type memoryCmd struct {
+	api.Cmd
+	a      *memoryAPI
+	handle uint64
+	name   string
+	size   uint64
+}
+
+func (c memoryCmd) API() api.API { return c.a }
+
+func (c memoryCmd) Mutate(context.Context, api.CmdID, *api.GlobalState, *builder.Builder) error {
+	o, ok := c.a.objects[c.handle]
+	switch {
+	case c.size == 0:
+		delete(c.a.objects, c.handle)
+	case !ok:
+		c.a.objects[c.handle] = &memoryObject{c.name, c.size}
+	default:
+		o.name, o.size = c.name, c.size
+	}
+	return nil
+}
+
+func TestMemoryTimeline(t *testing.T) {
+	ctx := log.Testing(t)
+
+	a := arena.New()
+	defer a.Dispose()
+
+	cb := test.CommandBuilder{Arena: a}
+	m := &memoryAPI{API: test.API{}, objects: map[uint64]*memoryObject{}}
+	cmd := func(handle uint64, name string, size uint64) api.Cmd {
+		return memoryCmd{cb.CmdVoid(), m, handle, name, size}
+	}
+	cmds := []api.Cmd{
+		cmd(1, "A", 16), // Allocates A.
+		cmd(2, "B", 32), // Allocates B, at the peak size.
+		cmd(1, "A", 8),  // Resizes A.
+		cmd(2, "B", 0),  // Frees B.
+		cb.CmdVoid(),    // Does not change memory objects.
+		cmd(1, "C", 8),  // Renames A, which is still the same object.
+		cmd(3, "D", 4),  // Allocates D, never freed.
+	}
+
+	s := api.NewStateWithEmptyAllocator(device.Little32)
+	foreach := func(ctx context.Context, cb func(context.Context, api.CmdID, api.Cmd) error) error {
+		return api.ForeachCmd(ctx, cmds, cb)
+	}
+	got, err := buildMemoryTimeline(ctx, []api.API{m}, s, foreach)
+	if !assert.For(ctx, "buildMemoryTimeline").ThatError(err).Succeeded() {
+		return
+	}
+
+	object := func(handle uint64, name string, size, allocated uint64) *api.MemoryObject {
+		return &api.MemoryObject{Kind: "Buffer", Handle: handle, Name: name, Size: size, Allocated: allocated}
+	}
+	assert.For(ctx, "Events").ThatSlice(got.Events).DeepEquals([]*api.MemoryEvent{
+		{Command: 0, Kind: api.MemoryEventKind_MemoryAllocated, Object: object(1, "A", 16, 0), TotalSize: 16},
+		{Command: 1, Kind: api.MemoryEventKind_MemoryAllocated, Object: object(2, "B", 32, 1), TotalSize: 48},
+		{Command: 2, Kind: api.MemoryEventKind_MemoryResized, Object: object(1, "A", 8, 0), TotalSize: 40},
+		{Command: 3, Kind: api.MemoryEventKind_MemoryFreed, Object: object(2, "B", 32, 1), TotalSize: 8},
+		{Command: 6, Kind: api.MemoryEventKind_MemoryAllocated, Object: object(3, "D", 4, 6), TotalSize: 12},
+	})
+	assert.For(ctx, "PeakSize").That(got.PeakSize).Equals(uint64(48))
+	assert.For(ctx, "PeakCommand").That(got.PeakCommand).Equals(uint64(1))
+
+	// Leaks point at the command that allocated the objects, not at the last
+	// command that resized them.
+	assert.For(ctx, "Leaked").ThatSlice(got.Leaked).DeepEquals([]*api.MemoryObject{
+		object(1, "C", 8, 0),
+		object(3, "D", 4, 6),
+	})
+}
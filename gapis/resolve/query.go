@@ -0,0 +1,522 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/google/gapid/core/data/dictionary"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/test/robot/search"
+	"github.com/google/gapid/test/robot/search/script"
+)
+
+// queryRE splits a query into the command name pattern and the expression.
+var queryRE = regexp.MustCompile(`(?s)^\s*(\S*?)\s*(?:\bwhere\b(.*))?$`)
+
+// cmdQuery is a compiled command query, of the form:
+//
+//	<name> [where <expression>]
+//
+// name is a regular expression that must match the whole command name, and
+// can be omitted to match all the commands.
+// The expression uses the syntax of the test/robot/search/script package.
+// Names in the expression refer to the parameters of the command, except for:
+//
+//	result - the result of the command.
+//	cmd    - the command, with the members name, index and thread.
+//	state  - the state of the command's API after the command.
+//
+// Members and subscripts give access to the fields of structures and state
+// objects, and to the elements of maps, arrays and slices. Names are matched
+// case-insensitively if there is no exact match.
+// Values that cannot be resolved for a command, such as the parameters of
+// other commands, compare unequal to everything: any comparison involving them
+// is false, and so is its negation with not, != or the reversed relational
+// operator.
+type cmdQuery struct {
+	name      *regexp.Regexp // nil matches all the commands.
+	expr      queryExpr
+	usesState bool
+}
+
+// queryEnv is the environment in which a query expression is evaluated.
+type queryEnv struct {
+	ctx   context.Context
+	id    api.CmdID
+	cmd   api.Cmd
+	state *api.GlobalState
+}
+
+// queryExpr evaluates part of a query expression, returning nil if the value
+// cannot be resolved. Boolean expressions that depend on a value that cannot
+// be resolved also evaluate to nil.
+type queryExpr func(env *queryEnv) interface{}
+
+// queryCmd is the value of the name cmd in query expressions.
+type queryCmd struct {
+	Name   string
+	Index  uint64
+	Thread uint64
+}
+
+// compileQuery parses and compiles the command query text.
+func compileQuery(ctx context.Context, text string) (*cmdQuery, error) {
+	parts := queryRE.FindStringSubmatch(text)
+	if parts == nil {
+		return nil, fmt.Errorf("Expected '<command> [where <expression>]', got '%v'", text)
+	}
+	q := &cmdQuery{}
+	if name := parts[1]; name != "" {
+		re, err := regexp.Compile("^(?:" + name + ")$")
+		if err != nil {
+			return nil, err
+		}
+		q.name = re
+	}
+	b, err := script.Parse(ctx, strings.TrimSpace(parts[2]))
+	if err != nil {
+		return nil, err
+	}
+	if q.expr, err = q.compile(b.Expression()); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// match returns true if the command cmd with the identifier id matches the
+// query. s is the global state after the command, and is only used if the
+// query refers to the state.
+func (q *cmdQuery) match(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState) bool {
+	if q.name != nil && !q.name.MatchString(cmd.CmdName()) {
+		return false
+	}
+	matched, _ := q.expr(&queryEnv{ctx, id, cmd, s}).(bool)
+	return matched
+}
+
+// queryMatches returns the identifiers of the commands of the capture c that
+// match the query q.
+func queryMatches(ctx context.Context, c *capture.Capture, q *cmdQuery) (map[api.CmdID]struct{}, error) {
+	var s *api.GlobalState
+	if q.usesState {
+		s = c.NewState(ctx)
+	}
+	out := map[api.CmdID]struct{}{}
+	err := c.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if s != nil {
+			if err := cmd.Mutate(ctx, id, s, nil); err != nil && !api.IsErrCmdAborted(err) {
+				log.W(ctx, "Query: Cmd [%v]%v - %v", id, cmd, err)
+			}
+		}
+		if q.match(ctx, id, cmd, s) {
+			out[id] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (q *cmdQuery) compile(expr *search.Expression) (queryExpr, error) {
+	constant := func(v interface{}) queryExpr {
+		return func(*queryEnv) interface{} { return v }
+	}
+	if expr == nil {
+		return constant(true), nil
+	}
+	switch e := expr.Is.(type) {
+	case nil:
+		return constant(true), nil
+	case *search.Expression_Boolean:
+		return constant(e.Boolean), nil
+	case *search.Expression_String_:
+		return constant(e.String_), nil
+	case *search.Expression_Signed:
+		return constant(e.Signed), nil
+	case *search.Expression_Unsigned:
+		return constant(e.Unsigned), nil
+	case *search.Expression_Double:
+		return constant(e.Double), nil
+	case *search.Expression_Name:
+		return q.compileName(e.Name), nil
+	case *search.Expression_And:
+		return q.compileBinary(e.And, func(lhs, rhs queryExpr, env *queryEnv) interface{} {
+			return queryAnd(lhs(env), rhs(env))
+		})
+	case *search.Expression_Or:
+		return q.compileBinary(e.Or, func(lhs, rhs queryExpr, env *queryEnv) interface{} {
+			return queryOr(lhs(env), rhs(env))
+		})
+	case *search.Expression_Equal:
+		return q.compileBinary(e.Equal, func(lhs, rhs queryExpr, env *queryEnv) interface{} {
+			a, b := lhs(env), rhs(env)
+			if a == nil || b == nil {
+				return nil
+			}
+			return queryEqual(a, b)
+		})
+	case *search.Expression_Greater:
+		return q.compileBinary(e.Greater, func(lhs, rhs queryExpr, env *queryEnv) interface{} {
+			a, b := lhs(env), rhs(env)
+			if a == nil || b == nil {
+				return nil
+			}
+			c, ok := queryCompare(a, b)
+			return ok && c > 0
+		})
+	case *search.Expression_GreaterOrEqual:
+		return q.compileBinary(e.GreaterOrEqual, func(lhs, rhs queryExpr, env *queryEnv) interface{} {
+			a, b := lhs(env), rhs(env)
+			if a == nil || b == nil {
+				return nil
+			}
+			c, ok := queryCompare(a, b)
+			return ok && c >= 0
+		})
+	case *search.Expression_Not:
+		rhs, err := q.compile(e.Not)
+		if err != nil {
+			return nil, err
+		}
+		return func(env *queryEnv) interface{} {
+			if b, ok := rhs(env).(bool); ok {
+				return !b
+			}
+			return nil
+		}, nil
+	case *search.Expression_Member:
+		obj, err := q.compile(e.Member.Object)
+		if err != nil {
+			return nil, err
+		}
+		name := e.Member.Name
+		return func(env *queryEnv) interface{} { return queryMember(obj(env), name) }, nil
+	case *search.Expression_Subscript:
+		container, err := q.compile(e.Subscript.Container)
+		if err != nil {
+			return nil, err
+		}
+		key, err := q.compile(e.Subscript.Key)
+		if err != nil {
+			return nil, err
+		}
+		return func(env *queryEnv) interface{} {
+			return querySubscript(container(env), key(env))
+		}, nil
+	case *search.Expression_Regex:
+		val, err := q.compile(e.Regex.Value)
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(e.Regex.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return func(env *queryEnv) interface{} {
+			v := val(env)
+			if v == nil {
+				return nil
+			}
+			return re.MatchString(fmt.Sprint(v))
+		}, nil
+	default:
+		return nil, fmt.Errorf("Unsupported query expression %T", e)
+	}
+}
+
+func (q *cmdQuery) compileBinary(expr *search.Binary, f func(lhs, rhs queryExpr, env *queryEnv) interface{}) (queryExpr, error) {
+	lhs, err := q.compile(expr.Lhs)
+	if err != nil {
+		return nil, err
+	}
+	rhs, err := q.compile(expr.Rhs)
+	if err != nil {
+		return nil, err
+	}
+	return func(env *queryEnv) interface{} { return f(lhs, rhs, env) }, nil
+}
+
+func (q *cmdQuery) compileName(name string) queryExpr {
+	switch name {
+	case "result":
+		return func(env *queryEnv) interface{} {
+			if r := env.cmd.CmdResult(); r != nil {
+				return r.Get()
+			}
+			return nil
+		}
+	case "cmd":
+		return func(env *queryEnv) interface{} {
+			return queryCmd{env.cmd.CmdName(), uint64(env.id), env.cmd.Thread()}
+		}
+	case "state":
+		q.usesState = true
+		return func(env *queryEnv) interface{} {
+			a := env.cmd.API()
+			if a == nil || env.state == nil {
+				return nil
+			}
+			if s := env.state.APIs[a.ID()]; s != nil {
+				return s
+			}
+			return nil
+		}
+	default:
+		return func(env *queryEnv) interface{} {
+			if p := queryProperty(env.cmd.CmdParams(), name); p != nil {
+				return p.Get()
+			}
+			return nil
+		}
+	}
+}
+
+// queryProperty returns the property with the given name, matched
+// case-insensitively if there is no exact match.
+func queryProperty(l api.Properties, name string) *api.Property {
+	if p := l.Find(name); p != nil {
+		return p
+	}
+	for _, p := range l {
+		if strings.EqualFold(p.Name, name) {
+			return p
+		}
+	}
+	return nil
+}
+
+// queryMember returns the member of v with the given name, or nil if there
+// is no such member.
+func queryMember(v interface{}, name string) interface{} {
+	r := reflect.ValueOf(v)
+	for r.IsValid() && !isNil(r) {
+		if pp, ok := r.Interface().(api.PropertyProvider); ok {
+			if p := queryProperty(pp.Properties(), name); p != nil {
+				return p.Get()
+			}
+		}
+		switch r.Kind() {
+		case reflect.Struct:
+			f := r.FieldByName(name)
+			if !f.IsValid() {
+				f = r.FieldByNameFunc(func(n string) bool { return strings.EqualFold(n, name) })
+			}
+			if !f.IsValid() || !f.CanInterface() {
+				return nil
+			}
+			return f.Interface()
+		case reflect.Interface, reflect.Ptr:
+			r = r.Elem()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// querySubscript returns the element of the map, array or slice v with the
+// given key, or nil if there is no such element.
+func querySubscript(v, key interface{}) interface{} {
+	if v == nil || key == nil {
+		return nil
+	}
+	if d := dictionary.From(v); d != nil {
+		k, ok := convert(reflect.ValueOf(key), d.KeyTy())
+		if !ok {
+			return nil
+		}
+		if val, ok := d.Lookup(k.Interface()); ok {
+			return val
+		}
+		return nil
+	}
+	r := reflect.ValueOf(v)
+	switch r.Kind() {
+	case reflect.Array, reflect.Slice, reflect.String:
+		i := int64(-1)
+		switch n := queryNumber(key).(type) {
+		case int64:
+			i = n
+		case uint64:
+			if n < uint64(r.Len()) {
+				i = int64(n)
+			}
+		}
+		if i < 0 || i >= int64(r.Len()) {
+			return nil
+		}
+		return r.Index(int(i)).Interface()
+	default:
+		return nil
+	}
+}
+
+// queryAnd returns the conjunction of a and b, which is false if either is
+// false, and nil if neither is false and either is not a bool.
+func queryAnd(a, b interface{}) interface{} {
+	x, okA := a.(bool)
+	y, okB := b.(bool)
+	switch {
+	case okA && !x, okB && !y:
+		return false
+	case okA && okB:
+		return true
+	default:
+		return nil
+	}
+}
+
+// queryOr returns the disjunction of a and b, which is true if either is true,
+// and nil if neither is true and either is not a bool.
+func queryOr(a, b interface{}) interface{} {
+	x, okA := a.(bool)
+	y, okB := b.(bool)
+	switch {
+	case okA && x, okB && y:
+		return true
+	case okA && okB:
+		return false
+	default:
+		return nil
+	}
+}
+
+// queryNumber returns v as an int64, uint64 or float64, or nil if v is not a
+// number.
+func queryNumber(v interface{}) interface{} {
+	r := reflect.ValueOf(v)
+	switch r.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return r.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return r.Uint()
+	case reflect.Float32, reflect.Float64:
+		return r.Float()
+	default:
+		return nil
+	}
+}
+
+// queryCompare returns -1, 0 or 1 if a is less than, equal to or greater than
+// b, and whether a and b can be compared. Numbers of any type can be compared
+// to each other, and strings to strings.
+func queryCompare(a, b interface{}) (int, bool) {
+	x, y := queryNumber(a), queryNumber(b)
+	if x == nil || y == nil {
+		sa, okA := a.(string)
+		sb, okB := b.(string)
+		if !okA || !okB {
+			return 0, false
+		}
+		return strings.Compare(sa, sb), true
+	}
+	switch x := x.(type) {
+	case int64:
+		switch y := y.(type) {
+		case int64:
+			return compareInt64(x, y), true
+		case uint64:
+			if x < 0 {
+				return -1, true
+			}
+			return compareUint64(uint64(x), y), true
+		case float64:
+			return compareFloat64(float64(x), y), true
+		}
+	case uint64:
+		switch y := y.(type) {
+		case int64:
+			if y < 0 {
+				return 1, true
+			}
+			return compareUint64(x, uint64(y)), true
+		case uint64:
+			return compareUint64(x, y), true
+		case float64:
+			return compareFloat64(float64(x), y), true
+		}
+	case float64:
+		switch y := y.(type) {
+		case int64:
+			return compareFloat64(x, float64(y)), true
+		case uint64:
+			return compareFloat64(x, float64(y)), true
+		case float64:
+			return compareFloat64(x, y), true
+		}
+	}
+	return 0, false
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// queryEqual returns true if a and b are equal. a and b must not be nil. Numbers of any type are
+// compared by value, and values compared to strings are compared by their
+// string representation, so that enums can be compared to their names.
+func queryEqual(a, b interface{}) bool {
+	if c, ok := queryCompare(a, b); ok {
+		return c == 0
+	}
+	sa, okA := a.(string)
+	sb, okB := b.(string)
+	switch {
+	case okA && !okB:
+		return sa == fmt.Sprint(b)
+	case okB && !okA:
+		return fmt.Sprint(a) == sb
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+)
+
+func TestQuery(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	p := newPathTest(ctx)
+	c, err := capture.ResolveFromPath(ctx, p)
+	if !assert.For(ctx, "ResolveFromPath").ThatError(err).Succeeded() {
+		return
+	}
+
+	for _, test := range []struct {
+		query    string
+		expected []api.CmdID
+	}{
+		{`cmdTypeMix`, []api.CmdID{0, 1}},
+		{`cmd.*`, []api.CmdID{0, 1}},
+		{`cmdTypeMix where`, []api.CmdID{0, 1}},
+		{`cmdTypeMix where U8 > 12`, []api.CmdID{1}},
+		{`where S32 >= 60 and Bool == true`, []api.CmdID{0}},
+		{`where u32 < 52 or F64 > 104.5`, []api.CmdID{0, 1}},
+		{`where result == 3`, []api.CmdID{1}},
+		{`where cmd.index == 2`, []api.CmdID{2}},
+		{`where cmd.name ?= "State"`, []api.CmdID{2}},
+		{`where not (U8 == 10)`, []api.CmdID{1}},
+		{`where state.Str == "aaa"`, []api.CmdID{2}},
+		{`primeState where state.Ref.Strings["123"] == 123`, []api.CmdID{2}},
+		{`where doesnotexist == 0`, []api.CmdID{}},
+		{`where U8 < 10`, []api.CmdID{}},
+		{`where U8 < 15`, []api.CmdID{0}},
+		{`where U8 <= 10`, []api.CmdID{0}},
+		{`where U8 <= 15`, []api.CmdID{0, 1}},
+		{`where U8 > 15`, []api.CmdID{}},
+		{`where U8 >= 15`, []api.CmdID{1}},
+		{`where 15 > U8`, []api.CmdID{0}},
+		{`where U8 != 10`, []api.CmdID{1}},
+		{`where not (U8 < 15)`, []api.CmdID{1}},
+		{`where doesnotexist != 0`, []api.CmdID{}},
+		{`where doesnotexist < 0`, []api.CmdID{}},
+		{`where not doesnotexist`, []api.CmdID{}},
+		{`where U8 == 10 or doesnotexist == 0`, []api.CmdID{0}},
+		{`where not (U8 == 10 and doesnotexist == 0)`, []api.CmdID{1}},
+	} {
+		q, err := compileQuery(ctx, test.query)
+		if !assert.For(ctx, "compileQuery(%v)", test.query).ThatError(err).Succeeded() {
+			continue
+		}
+		matches, err := queryMatches(ctx, c, q)
+		if !assert.For(ctx, "queryMatches(%v)", test.query).ThatError(err).Succeeded() {
+			continue
+		}
+		got := []api.CmdID{}
+		for id := api.CmdID(0); id < 3; id++ {
+			if _, ok := matches[id]; ok {
+				got = append(got, id)
+			}
+		}
+		assert.For(ctx, "queryMatches(%v)", test.query).ThatSlice(got).Equals(test.expected)
+	}
+
+	for _, query := range []string{`cmdTypeMix where U8 >`, `cmd( where U8 > 1`} {
+		_, err := compileQuery(ctx, query)
+		assert.For(ctx, "compileQuery(%v)", query).ThatError(err).Failed()
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/google/gapid/gapis/database"
 	"github.com/google/gapid/gapis/messages"
 	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/resolve/dependencygraph"
 	"github.com/google/gapid/gapis/service"
 	"github.com/google/gapid/gapis/service/path"
 	"github.com/google/gapid/gapis/stringtable"
@@ -62,7 +63,7 @@ func (r *ReportResolvable) Resolve(ctx context.Context) (interface{}, error) {
 		return nil, err
 	}
 
-	defer analytics.SendTiming("resolve", "report")(analytics.Size(len(c.Commands)))
+	defer analytics.SendTiming("resolve", "report")(analytics.Size(int(c.NumCommands())))
 
 	sd, err := SyncData(ctx, r.Path.Capture)
 	if err != nil {
@@ -89,6 +90,17 @@ func (r *ReportResolvable) Resolve(ctx context.Context) (interface{}, error) {
 
 	issues := map[api.CmdID][]replay.Issue{}
 
+	unused := map[api.CmdID][]dependencygraph.UnusedResource{}
+	if r.Path.UnusedResources {
+		resources, err := UnusedResources(ctx, r.Path.Capture, r.Config)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range resources {
+			unused[u.Created] = append(unused[u.Created], u)
+		}
+	}
+
 	if r.Path.Device != nil {
 		// Request is for a replay report too.
 		intent := replay.Intent{
@@ -123,7 +135,7 @@ func (r *ReportResolvable) Resolve(ctx context.Context) (interface{}, error) {
 
 	// Gather report items from the state mutator, and collect together all the
 	// APIs in use.
-	api.ForeachCmd(ctx, c.Commands, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+	c.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
 		items, currentCmd = items[:0], uint64(id)
 
 		if as := cmd.Extras().Aborted(); as != nil && as.IsAssert {
@@ -131,11 +143,27 @@ func (r *ReportResolvable) Resolve(ctx context.Context) (interface{}, error) {
 				messages.ErrTraceAssert(as.Reason)))
 		}
 
+		var reason string
+		var unchanged func() bool
+		if r.Path.Lint {
+			if rc, ok := cmd.API().(api.RedundantCallChecker); ok {
+				reason, unchanged = rc.RedundantCall(ctx, id, cmd, state)
+			}
+		}
+
 		if err := cmd.Mutate(ctx, id, state, nil /* no builder, just mutate */); err != nil {
 			if !api.IsErrCmdAborted(err) {
 				items = append(items, r.newReportItem(log.Error, uint64(id),
 					messages.ErrInternalError(err.Error())))
 			}
+		} else if unchanged != nil && unchanged() {
+			items = append(items, r.newReportItem(log.Warning, uint64(id),
+				messages.WarnRedundantCall(reason)))
+		}
+
+		for _, u := range unused[id] {
+			items = append(items, r.newReportItem(log.Warning, uint64(id),
+				messages.WarnUnusedResource(u.Name, u.Size)))
 		}
 
 		if filter(id, cmd, state) {
@@ -146,8 +174,12 @@ func (r *ReportResolvable) Resolve(ctx context.Context) (interface{}, error) {
 			for _, issue := range issues[id] {
 				item := r.newReportItem(log.Severity(issue.Severity), uint64(issue.Command),
 					messages.ErrReplayDriver(issue.Error.Error()))
-				if int(issue.Command) < len(c.Commands) {
-					item.Tags = append(item.Tags, getCommandNameTag(c.Commands[issue.Command]))
+				if cmd, release, err := c.Cmd(ctx, issue.Command); err == nil {
+					item.Tags = append(item.Tags, getCommandNameTag(cmd))
+					release()
+				}
+				if issue.Known {
+					item.Tags = append(item.Tags, messages.TagKnownIssue())
 				}
 				builder.Add(ctx, item)
 			}
@@ -155,6 +187,27 @@ func (r *ReportResolvable) Resolve(ctx context.Context) (interface{}, error) {
 		return nil
 	})
 
+	// The resources of the initial state were not created by a command of the
+	// capture.
+	for id, resources := range unused {
+		if id.IsReal() {
+			continue
+		}
+		for _, u := range resources {
+			builder.Add(ctx, r.newReportItem(log.Warning, uint64(api.CmdNoID),
+				messages.WarnUnusedResource(u.Name, u.Size)))
+		}
+	}
+
+	if r.Path.UnusedResources {
+		for _, a := range c.APIs {
+			if dependencygraph.GetResourceKeyProvider(ctx, a) == nil {
+				builder.Add(ctx, r.newReportItem(log.Warning, uint64(api.CmdNoID),
+					messages.WarnUnusedResourcesUnsupported(a.Name())))
+			}
+		}
+	}
+
 	return builder.Build(), nil
 }
 
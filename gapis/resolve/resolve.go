@@ -356,6 +356,10 @@ func ResolveInternal(ctx context.Context, p path.Node, r *path.ResolveConfig) (i
 		return Thumbnail(ctx, p, r)
 	case *path.Stats:
 		return Stats(ctx, p, r)
+	case *path.Bandwidth:
+		return Bandwidth(ctx, p, r)
+	case *path.Shaders:
+		return Shaders(ctx, p, r)
 	default:
 		return nil, fmt.Errorf("Unknown path type %T", p)
 	}
@@ -82,7 +82,7 @@ func (r *ResourcesResolvable) Resolve(ctx context.Context) (interface{}, error)
 		return nil
 	})
 
-	api.ForeachCmd(ctx, c.Commands, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+	c.ForeachCmd(ctx, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
 		currentCmdResourceCount = 0
 		currentCmdIndex = uint64(id)
 		cmd.Mutate(ctx, id, state, nil)
@@ -0,0 +1,237 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/resolve/initialcmds"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+	"github.com/google/gapid/gapis/shadertools"
+)
+
+// Shaders resolves and returns the complexity and interface analysis of the
+// shaders of the capture from the path p.
+func Shaders(ctx context.Context, p *path.Shaders, r *path.ResolveConfig) (*service.ShaderReport, error) {
+	obj, err := database.Build(ctx, &ShadersResolvable{Path: p, Config: r})
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*service.ShaderReport), nil
+}
+
+// shaderKey identifies the shaders with the same source.
+type shaderKey struct {
+	ty     api.ShaderType
+	source string
+}
+
+// shaderProgram is a link of a program or pipeline used by draw calls.
+type shaderProgram struct {
+	resource api.Resource
+	draws    uint64
+	stages   []*api.Stage
+}
+
+// Resolve implements the database.Resolver interface.
+func (r *ShadersResolvable) Resolve(ctx context.Context) (interface{}, error) {
+	ctx = setupContext(ctx, r.Path.Capture, r.Config)
+
+	c, err := capture.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	initialCmds, ranges, err := initialcmds.InitialCommands(ctx, r.Path.Capture)
+	if err != nil {
+		return nil, err
+	}
+	state := c.NewUninitializedState(ctx, ranges)
+
+	// The shader resources in creation order, with the last source they had.
+	shaderResources := []api.Resource{}
+	sources := map[api.Resource]*api.Shader{}
+	state.OnResourceCreated = func(r api.Resource) {
+		if r.ResourceType(ctx) == api.ResourceType_ShaderResource {
+			shaderResources = append(shaderResources, r)
+			sources[r] = nil
+		}
+	}
+	accessed := map[api.Resource]struct{}{}
+	state.OnResourceAccessed = func(r api.Resource) {
+		if _, ok := sources[r]; ok {
+			accessed[r] = struct{}{}
+		}
+	}
+
+	// The programs in the order they were first drawn with after each link.
+	programs := []*shaderProgram{}
+	linked := map[api.Resource]*shaderProgram{}
+	mutate := func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if up, ok := cmd.API().(api.ShaderUsageProvider); ok {
+			cmd.Extras().Observations().ApplyReads(state.Memory.ApplicationPool())
+			for _, res := range up.DrawPrograms(ctx, id, cmd, state) {
+				prog, ok := linked[res]
+				if !ok {
+					// The stages are taken at the first draw after the program
+					// or pipeline is linked.
+					stages, err := up.ProgramStages(ctx, res, state)
+					if err != nil {
+						log.W(ctx, "Shaders: %v stages at [%v]%v - %v", res.ResourceHandle(), id, cmd, err)
+					}
+					prog = &shaderProgram{resource: res, stages: stages}
+					linked[res] = prog
+					programs = append(programs, prog)
+				}
+				prog.draws++
+			}
+			if res := up.LinkedProgram(ctx, id, cmd, state); res != nil {
+				delete(linked, res)
+			}
+		}
+
+		if err := cmd.Mutate(ctx, id, state, nil /* no builder, just mutate */); err != nil {
+			log.W(ctx, "Shaders: [%v]%v - %v", id, cmd, err)
+		}
+
+		for res := range accessed {
+			data, err := res.ResourceData(ctx, state)
+			if err != nil {
+				continue
+			}
+			if shader := data.GetShader(); shader != nil && shader.Source != "" {
+				sources[res] = shader
+			}
+		}
+		accessed = map[api.Resource]struct{}{}
+		return nil
+	}
+
+	if err := api.ForeachCmd(ctx, initialCmds, mutate); err != nil {
+		return nil, err
+	}
+	if err := c.ForeachCmd(ctx, mutate); err != nil {
+		return nil, err
+	}
+
+	out := &service.ShaderReport{}
+	shaders := map[shaderKey]*service.ShaderCost{}
+	programSets := map[shaderKey]map[api.Resource]struct{}{}
+	get := func(s *api.Shader) (shaderKey, *service.ShaderCost) {
+		key := shaderKey{s.Type, s.Source}
+		cost, ok := shaders[key]
+		if !ok {
+			cost = &service.ShaderCost{Type: s.Type}
+			shaders[key] = cost
+			programSets[key] = map[api.Resource]struct{}{}
+			out.Shaders = append(out.Shaders, cost)
+			analyzeShader(s, cost)
+		}
+		return key, cost
+	}
+	for _, res := range shaderResources {
+		if s := sources[res]; s != nil {
+			_, cost := get(s)
+			cost.Handles = append(cost.Handles, res.ResourceHandle())
+		}
+	}
+	for _, prog := range programs {
+		for _, stage := range prog.stages {
+			if stage.Shader == nil || stage.Shader.Source == "" {
+				continue
+			}
+			key, cost := get(stage.Shader)
+			cost.Stage = stage.Type
+			cost.Draws += prog.draws
+			if _, ok := programSets[key][prog.resource]; !ok {
+				programSets[key][prog.resource] = struct{}{}
+				cost.Programs++
+			}
+		}
+	}
+
+	weight := func(s *service.ShaderCost) uint64 { return uint64(s.Instructions) * s.Draws }
+	sort.SliceStable(out.Shaders, func(i, j int) bool {
+		a, b := out.Shaders[i], out.Shaders[j]
+		if weight(a) != weight(b) {
+			return weight(a) > weight(b)
+		}
+		return a.Instructions > b.Instructions
+	})
+	return out, nil
+}
+
+// glslShaderTypes maps the GLSL shader types to the shadertools types.
+var glslShaderTypes = map[api.ShaderType]shadertools.ShaderType{
+	api.ShaderType_Vertex:         shadertools.TypeVertex,
+	api.ShaderType_Geometry:       shadertools.TypeGeometry,
+	api.ShaderType_TessControl:    shadertools.TypeTessControl,
+	api.ShaderType_TessEvaluation: shadertools.TypeTessEvaluation,
+	api.ShaderType_Fragment:       shadertools.TypeFragment,
+	api.ShaderType_Compute:        shadertools.TypeCompute,
+}
+
+// analyzeShader fills the complexity and interface of cost from the shader s.
+// GLSL shaders are compiled to SPIR-V to be analyzed.
+func analyzeShader(s *api.Shader, cost *service.ShaderCost) {
+	var words []uint32
+	switch s.Type {
+	case api.ShaderType_Spirv:
+		words = shadertools.AssembleSpirvText(s.Source)
+	default:
+		ty, ok := glslShaderTypes[s.Type]
+		if !ok {
+			cost.Error = fmt.Sprintf("Unsupported shader type %v", s.Type)
+			return
+		}
+		code, err := shadertools.ConvertGlsl(s.Source, &shadertools.ConvertOptions{
+			ShaderType:  ty,
+			Disassemble: true,
+			Relaxed:     true,
+		})
+		if err != nil {
+			cost.Error = err.Error()
+			return
+		}
+		words = shadertools.AssembleSpirvText(code.DisassemblyString)
+	}
+	if words == nil {
+		cost.Error = "Failed to assemble the SPIR-V of the shader"
+		return
+	}
+
+	complexity, err := shadertools.SpirvComplexity(words)
+	if err != nil {
+		cost.Error = err.Error()
+		return
+	}
+	cost.Instructions = complexity.Instructions
+	cost.TextureSamples = complexity.TextureSamples
+	cost.Branches = complexity.Branches
+
+	iface, err := shadertools.ParseInterface(words, "main")
+	if err != nil {
+		cost.Error = err.Error()
+		return
+	}
+	cost.Inputs = iface.Inputs
+	cost.Uniforms = iface.Uniforms
+}
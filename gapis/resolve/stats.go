@@ -27,16 +27,19 @@ import (
 // Stats resolves and returns the stats list from the path p.
 func Stats(ctx context.Context, p *path.Stats, r *path.ResolveConfig) (*service.Stats, error) {
 	stats := &service.Stats{}
-	if p.DrawCall {
-		err := drawCallStats(ctx, p.Capture, stats, r)
+	if p.DrawCall || p.Triangles || p.PerFrame {
+		err := drawCallStats(ctx, p.Capture, p.Triangles, p.PerFrame, stats, r)
 		if err != nil {
 			return nil, err
 		}
 	}
+	if !p.DrawCall {
+		stats.DrawCalls = nil
+	}
 	return stats, nil
 }
 
-func drawCallStats(ctx context.Context, capt *path.Capture, stats *service.Stats, r *path.ResolveConfig) error {
+func drawCallStats(ctx context.Context, capt *path.Capture, triangles, perFrame bool, stats *service.Stats, r *path.ResolveConfig) error {
 	d, err := SyncData(ctx, capt)
 	if err != nil {
 		return err
@@ -61,8 +64,8 @@ func drawCallStats(ctx context.Context, capt *path.Capture, stats *service.Stats
 		return err
 	}
 
-	drawsPerFrame := make([]uint64, len(events.List))
-	drawsSinceLastFrame := uint64(0)
+	frames := make([]*service.FrameStats, len(events.List))
+	frame := &service.FrameStats{}
 
 	processed := map[sync.SyncNodeIdx]struct{}{}
 
@@ -100,7 +103,7 @@ func drawCallStats(ctx context.Context, capt *path.Capture, stats *service.Stats
 				if (len(idx) == 1 && cmdflags.IsDrawCall()) ||
 					(len(idx) > 1 && cmdflags.IsExecutedDraw()) {
 
-					drawsSinceLastFrame += 1
+					frame.DrawCalls++
 				}
 			}
 		}
@@ -120,6 +123,22 @@ func drawCallStats(ctx context.Context, capt *path.Capture, stats *service.Stats
 
 	processCmd := func(idx uint64) error {
 		cmd := cmds[idx]
+		if perFrame {
+			frame.Commands++
+		}
+		if triangles || perFrame {
+			if p, ok := cmd.API().(api.CmdStatsProvider); ok {
+				cmd.Extras().Observations().ApplyReads(st.Memory.ApplicationPool())
+				cs := p.CmdStats(ctx, api.CmdID(idx), cmd, st)
+				frame.StateChanges += cs.StateChanges
+				frame.BufferUploadBytes += cs.BufferUploadBytes
+				frame.TextureUploadBytes += cs.TextureUploadBytes
+				frame.ProgramSwitches += cs.ProgramSwitches
+				frame.RenderPasses += cs.RenderPasses
+				frame.Triangles += cs.Triangles
+				frame.Vertices += cs.Vertices
+			}
+		}
 		err := cmd.Mutate(ctx, api.CmdID(idx), st, nil)
 		if err != nil {
 			return err
@@ -130,7 +149,7 @@ func drawCallStats(ctx context.Context, capt *path.Capture, stats *service.Stats
 		// assume its a synchronous command (e.g. glDraw)
 		if _, ok := d.CmdSyncNodes[api.CmdID(idx)]; !ok {
 			if flags[idx].IsDrawCall() {
-				drawsSinceLastFrame += 1
+				frame.DrawCalls++
 			}
 		}
 		return nil
@@ -160,10 +179,22 @@ func drawCallStats(ctx context.Context, capt *path.Capture, stats *service.Stats
 				return err
 			}
 		}
-		drawsPerFrame[i] = drawsSinceLastFrame
-		drawsSinceLastFrame = 0
+		frames[i] = frame
+		frame = &service.FrameStats{}
 	}
 
-	stats.DrawCalls = drawsPerFrame
+	stats.DrawCalls = make([]uint64, len(frames))
+	for i, f := range frames {
+		stats.DrawCalls[i] = f.DrawCalls
+	}
+	if triangles {
+		stats.Triangles = make([]uint64, len(frames))
+		for i, f := range frames {
+			stats.Triangles[i] = f.Triangles
+		}
+	}
+	if perFrame {
+		stats.Frames = frames
+	}
 	return nil
 }
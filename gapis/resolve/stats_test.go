@@ -0,0 +1,91 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/protoconv"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/test"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// frameEnd wraps a command so that it ends a frame.
+type frameEnd struct{ api.Cmd }
+
+func (f frameEnd) CmdFlags(ctx context.Context, id api.CmdID, s *api.GlobalState) api.CmdFlags {
+	return f.Cmd.CmdFlags(ctx, id, s) | api.EndOfFrame
+}
+
+func init() {
+	// Captures are encoded when they are stored, so frameEnd is encoded as the
+	// command it wraps. Decoding gives back the wrapped command.
+	protoconv.Register(
+		func(ctx context.Context, in frameEnd) (proto.Message, error) {
+			return protoconv.ToProto(ctx, in.Cmd)
+		},
+		func(ctx context.Context, in proto.Message) (frameEnd, error) {
+			return frameEnd{}, fmt.Errorf("frameEnd cannot be decoded")
+		},
+	)
+}
+
+func TestPerFrameStats(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	a := arena.New()
+	cb := test.CommandBuilder{Arena: a}
+	h := &capture.Header{ABI: device.WindowsX86_64}
+	cmds := []api.Cmd{
+		cb.CmdAdd(1, 2), cb.CmdVoid(), frameEnd{cb.CmdVoid()}, // Frame 0
+		cb.CmdVoid(), frameEnd{cb.CmdVoid()}, // Frame 1
+		cb.CmdAdd(3, 4), // Unfinished frame, counted in frame 1
+	}
+	p, err := capture.New(ctx, a, "test", h, cmds)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+	ctx = capture.Put(ctx, p)
+
+	stats, err := Stats(ctx, &path.Stats{Capture: p, DrawCall: true, PerFrame: true}, nil)
+	if !assert.For(ctx, "Stats").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "DrawCalls").ThatSlice(stats.DrawCalls).Equals([]uint64{0, 0})
+	assert.For(ctx, "Frames").ThatSlice(stats.Frames).DeepEquals([]*service.FrameStats{
+		{Commands: 3},
+		{Commands: 3},
+	})
+
+	// Per-frame statistics are only returned when requested.
+	stats, err = Stats(ctx, &path.Stats{Capture: p, DrawCall: true}, nil)
+	if assert.For(ctx, "Stats").ThatError(err).Succeeded() {
+		assert.For(ctx, "Frames").ThatSlice(stats.Frames).IsEmpty()
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/resolve/dependencygraph"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// UnusedResources returns the resources of the capture p whose contents are
+// written, but never reach a draw call, a dispatch or the framebuffer at the
+// end of a frame.
+func UnusedResources(ctx context.Context, p *path.Capture, r *path.ResolveConfig) ([]dependencygraph.UnusedResource, error) {
+	ctx = setupContext(ctx, p, r)
+
+	events, err := Events(ctx, &path.Events{
+		Capture:     p,
+		DrawCalls:   true,
+		LastInFrame: true,
+	}, r)
+	if err != nil {
+		return nil, err
+	}
+	requests := make([]api.CmdID, len(events.List))
+	for i, e := range events.List {
+		requests[i] = api.CmdID(e.Command.Indices[0])
+	}
+
+	g, err := dependencygraph.GetDependencyGraph(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return g.UnusedResources(ctx, requests)
+}
@@ -260,13 +260,35 @@ func (s *grpcServer) ImportCapture(ctx xctx.Context, req *service.ImportCaptureR
 
 func (s *grpcServer) ExportCapture(ctx xctx.Context, req *service.ExportCaptureRequest) (*service.ExportCaptureResponse, error) {
 	defer s.inRPC()()
-	data, err := s.handler.ExportCapture(s.bindCtx(ctx), req.Capture)
+	data, err := s.handler.ExportCapture(s.bindCtx(ctx), req.Capture, req.Frames)
 	if err := service.NewError(err); err != nil {
 		return &service.ExportCaptureResponse{Res: &service.ExportCaptureResponse_Error{Error: err}}, nil
 	}
 	return &service.ExportCaptureResponse{Res: &service.ExportCaptureResponse_Data{Data: data}}, nil
 }
 
+func (s *grpcServer) SplitCapture(ctx xctx.Context, req *service.SplitCaptureRequest) (*service.SplitCaptureResponse, error) {
+	defer s.inRPC()()
+	captures, err := s.handler.SplitCapture(s.bindCtx(ctx), req.Capture, req.Mode)
+	if err := service.NewError(err); err != nil {
+		return &service.SplitCaptureResponse{Res: &service.SplitCaptureResponse_Error{Error: err}}, nil
+	}
+	return &service.SplitCaptureResponse{
+		Res: &service.SplitCaptureResponse_Captures{
+			Captures: &service.Captures{List: captures},
+		},
+	}, nil
+}
+
+func (s *grpcServer) MergeCaptures(ctx xctx.Context, req *service.MergeCapturesRequest) (*service.MergeCapturesResponse, error) {
+	defer s.inRPC()()
+	capture, err := s.handler.MergeCaptures(s.bindCtx(ctx), req.Name, req.Captures)
+	if err := service.NewError(err); err != nil {
+		return &service.MergeCapturesResponse{Res: &service.MergeCapturesResponse_Error{Error: err}}, nil
+	}
+	return &service.MergeCapturesResponse{Res: &service.MergeCapturesResponse_Capture{Capture: capture}}, nil
+}
+
 func (s *grpcServer) LoadCapture(ctx xctx.Context, req *service.LoadCaptureRequest) (*service.LoadCaptureResponse, error) {
 	defer s.inRPC()()
 	capture, err := s.handler.LoadCapture(s.bindCtx(ctx), req.Path)
@@ -285,6 +307,15 @@ func (s *grpcServer) SaveCapture(ctx xctx.Context, req *service.SaveCaptureReque
 	return &service.SaveCaptureResponse{}, nil
 }
 
+func (s *grpcServer) ValidateCapture(ctx xctx.Context, req *service.ValidateCaptureRequest) (*service.ValidateCaptureResponse, error) {
+	defer s.inRPC()()
+	validation, err := s.handler.ValidateCapture(s.bindCtx(ctx), req.Path, req.Salvage)
+	if err := service.NewError(err); err != nil {
+		return &service.ValidateCaptureResponse{Res: &service.ValidateCaptureResponse_Error{Error: err}}, nil
+	}
+	return &service.ValidateCaptureResponse{Res: &service.ValidateCaptureResponse_Validation{Validation: validation}}, nil
+}
+
 func (s *grpcServer) GetDevices(ctx xctx.Context, req *service.GetDevicesRequest) (*service.GetDevicesResponse, error) {
 	defer s.inRPC()()
 	devices, err := s.handler.GetDevices(s.bindCtx(ctx))
@@ -340,6 +371,15 @@ func (s *grpcServer) Find(req *service.FindRequest, server service.Gapid_FindSer
 	return s.handler.Find(s.bindCtx(ctx), req, server.Send)
 }
 
+func (s *grpcServer) DiffCaptures(ctx xctx.Context, req *service.DiffCapturesRequest) (*service.DiffCapturesResponse, error) {
+	defer s.inRPC()()
+	diff, err := s.handler.DiffCaptures(s.bindCtx(ctx), req.A, req.B, req.Config)
+	if err := service.NewError(err); err != nil {
+		return &service.DiffCapturesResponse{Res: &service.DiffCapturesResponse_Error{Error: err}}, nil
+	}
+	return &service.DiffCapturesResponse{Res: &service.DiffCapturesResponse_Diff{Diff: diff}}, nil
+}
+
 func (s *grpcServer) EnableCrashReporting(ctx xctx.Context, req *service.EnableCrashReportingRequest) (*service.EnableCrashReportingResponse, error) {
 	defer s.inRPC()()
 	err := s.handler.EnableCrashReporting(s.bindCtx(ctx), req.Enable)
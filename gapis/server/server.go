@@ -183,17 +183,37 @@ func (s *server) ImportCapture(ctx context.Context, name string, data []uint8) (
 	return p, nil
 }
 
-func (s *server) ExportCapture(ctx context.Context, c *path.Capture) ([]byte, error) {
+func (s *server) ExportCapture(ctx context.Context, c *path.Capture, frames *service.FrameRange) ([]byte, error) {
 	ctx = log.Enter(ctx, "ExportCapture")
 	ctx = status.Start(ctx, "ExportCapture")
 	defer status.Finish(ctx)
 	b := bytes.Buffer{}
+	if frames != nil {
+		if err := capture.ExportFrames(ctx, c, frames.First, frames.Count, &b); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
 	if err := capture.Export(ctx, c, &b); err != nil {
 		return nil, err
 	}
 	return b.Bytes(), nil
 }
 
+func (s *server) SplitCapture(ctx context.Context, c *path.Capture, mode service.SplitMode) ([]*path.Capture, error) {
+	ctx = log.Enter(ctx, "SplitCapture")
+	ctx = status.Start(ctx, "SplitCapture")
+	defer status.Finish(ctx)
+	return capture.Split(ctx, c, mode)
+}
+
+func (s *server) MergeCaptures(ctx context.Context, name string, captures []*path.Capture) (*path.Capture, error) {
+	ctx = log.Enter(ctx, "MergeCaptures")
+	ctx = status.Start(ctx, "MergeCaptures")
+	defer status.Finish(ctx)
+	return capture.Merge(ctx, name, captures)
+}
+
 func (s *server) LoadCapture(ctx context.Context, path string) (*path.Capture, error) {
 	ctx = log.Enter(ctx, "LoadCapture")
 	ctx = status.Start(ctx, "LoadCapture")
@@ -202,11 +222,7 @@ func (s *server) LoadCapture(ctx context.Context, path string) (*path.Capture, e
 		return nil, fmt.Errorf("Server not configured to allow reading of local files")
 	}
 	name := filepath.Base(path)
-	in, err := ioutil.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	p, err := capture.Import(ctx, name, in)
+	p, err := capture.ImportFile(ctx, name, path)
 	if err != nil {
 		return nil, err
 	}
@@ -232,6 +248,20 @@ func (s *server) SaveCapture(ctx context.Context, c *path.Capture, path string)
 	return capture.Export(ctx, c, f)
 }
 
+func (s *server) ValidateCapture(ctx context.Context, path string, salvage bool) (*service.CaptureValidation, error) {
+	ctx = log.Enter(ctx, "ValidateCapture")
+	ctx = status.Start(ctx, "ValidateCapture")
+	defer status.Finish(ctx)
+	if !s.enableLocalFiles {
+		return nil, fmt.Errorf("Server not configured to allow reading of local files")
+	}
+	in, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return capture.Validate(ctx, filepath.Base(path), in, salvage)
+}
+
 func (s *server) GetDevices(ctx context.Context) ([]*path.Device, error) {
 	ctx = log.Enter(ctx, "GetDevices")
 	ctx = status.Start(ctx, "GetDevices")
@@ -353,6 +383,13 @@ func (s *server) Find(ctx context.Context, req *service.FindRequest, handler ser
 	return resolve.Find(ctx, req, handler)
 }
 
+func (s *server) DiffCaptures(ctx context.Context, a, b *path.Capture, c *path.ResolveConfig) (*service.CaptureDiff, error) {
+	ctx = log.Enter(ctx, "DiffCaptures")
+	ctx = status.Start(ctx, "DiffCaptures")
+	defer status.Finish(ctx)
+	return resolve.CaptureDiff(ctx, a, b, c)
+}
+
 func (s *server) BeginCPUProfile(ctx context.Context) error {
 	ctx = log.Enter(ctx, "BeginCPUProfile")
 	ctx = status.Start(ctx, "BeginCPUProfile")
@@ -476,6 +513,7 @@ func optionsToTraceOptions(opts *service.TraceOptions) tracer.TraceOptions {
 		DeferStart:            opts.DeferStart,
 		NoBuffer:              opts.NoBuffer,
 		HideUnknownExtensions: opts.HideUnknownExtensions,
+		RecordTiming:          opts.RecordTiming,
 	}
 }
 
@@ -60,6 +60,7 @@ func printIndices(index []uint64) string {
 func (n *API) Path() *Any                       { return &Any{Path: &Any_API{n}} }
 func (n *ArrayIndex) Path() *Any                { return &Any{Path: &Any_ArrayIndex{n}} }
 func (n *As) Path() *Any                        { return &Any{Path: &Any_As{n}} }
+func (n *Bandwidth) Path() *Any                 { return &Any{Path: &Any_Bandwidth{n}} }
 func (n *Blob) Path() *Any                      { return &Any{Path: &Any_Blob{n}} }
 func (n *Capture) Path() *Any                   { return &Any{Path: &Any_Capture{n}} }
 func (n *ConstantSet) Path() *Any               { return &Any{Path: &Any_ConstantSet{n}} }
@@ -87,6 +88,7 @@ func (n *ResourceData) Path() *Any              { return &Any{Path: &Any_Resourc
 func (n *MultiResourceData) Path() *Any         { return &Any{Path: &Any_MultiResourceData{n}} }
 func (n *Resources) Path() *Any                 { return &Any{Path: &Any_Resources{n}} }
 func (n *Result) Path() *Any                    { return &Any{Path: &Any_Result{n}} }
+func (n *Shaders) Path() *Any                   { return &Any{Path: &Any_Shaders{n}} }
 func (n *Slice) Path() *Any                     { return &Any{Path: &Any_Slice{n}} }
 func (n *State) Path() *Any                     { return &Any{Path: &Any_State{n}} }
 func (n *StateTree) Path() *Any                 { return &Any{Path: &Any_StateTree{n}} }
@@ -98,6 +100,7 @@ func (n *Thumbnail) Path() *Any                 { return &Any{Path: &Any_Thumbna
 func (n API) Parent() Node                       { return nil }
 func (n ArrayIndex) Parent() Node                { return oneOfNode(n.Array) }
 func (n As) Parent() Node                        { return oneOfNode(n.From) }
+func (n Bandwidth) Parent() Node                 { return n.Capture }
 func (n Blob) Parent() Node                      { return nil }
 func (n Capture) Parent() Node                   { return nil }
 func (n ConstantSet) Parent() Node               { return n.API }
@@ -125,6 +128,7 @@ func (n ResourceData) Parent() Node              { return n.After }
 func (n MultiResourceData) Parent() Node         { return n.After }
 func (n Resources) Parent() Node                 { return n.Capture }
 func (n Result) Parent() Node                    { return n.Command }
+func (n Shaders) Parent() Node                   { return n.Capture }
 func (n Slice) Parent() Node                     { return oneOfNode(n.Array) }
 func (n State) Parent() Node                     { return n.After }
 func (n StateTree) Parent() Node                 { return n.State }
@@ -134,6 +138,7 @@ func (n Stats) Parent() Node                     { return n.Capture }
 func (n Thumbnail) Parent() Node                 { return oneOfNode(n.Object) }
 
 func (n *API) SetParent(p Node)                       {}
+func (n *Bandwidth) SetParent(p Node)                 { n.Capture, _ = p.(*Capture) }
 func (n *Blob) SetParent(p Node)                      {}
 func (n *Capture) SetParent(p Node)                   {}
 func (n *ConstantSet) SetParent(p Node)               { n.API, _ = p.(*API) }
@@ -158,6 +163,7 @@ func (n *ResourceData) SetParent(p Node)              { n.After, _ = p.(*Command
 func (n *MultiResourceData) SetParent(p Node)         { n.After, _ = p.(*Command) }
 func (n *Resources) SetParent(p Node)                 { n.Capture, _ = p.(*Capture) }
 func (n *Result) SetParent(p Node)                    { n.Command, _ = p.(*Command) }
+func (n *Shaders) SetParent(p Node)                   { n.Capture, _ = p.(*Capture) }
 func (n *State) SetParent(p Node)                     { n.After, _ = p.(*Command) }
 func (n *StateTree) SetParent(p Node)                 { n.State, _ = p.(*State) }
 func (n *StateTreeNode) SetParent(p Node)             {}
@@ -177,6 +183,9 @@ func (n As) Format(f fmt.State, c rune) {
 	fmt.Fprintf(f, "%v.as<%v>", n.Parent(), protoutil.OneOf(n.To))
 }
 
+// Format implements fmt.Formatter to print the version.
+func (n Bandwidth) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.bandwidth", n.Parent()) }
+
 // Format implements fmt.Formatter to print the version.
 func (n Blob) Format(f fmt.State, c rune) { fmt.Fprintf(f, "blob<%x>", n.ID) }
 
@@ -263,6 +272,9 @@ func (n Resources) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.resources",
 // Format implements fmt.Formatter to print the version.
 func (n Result) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.result", n.Parent()) }
 
+// Format implements fmt.Formatter to print the version.
+func (n Shaders) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.shaders", n.Parent()) }
+
 // Format implements fmt.Formatter to print the version.
 func (n Slice) Format(f fmt.State, c rune) {
 	fmt.Fprintf(f, "%v[%v:%v]", n.Parent(), n.Start, n.End)
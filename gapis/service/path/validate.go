@@ -107,6 +107,11 @@ func (n *As) Validate() error {
 	)
 }
 
+// Validate checks the path is valid.
+func (n *Bandwidth) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
 // Validate checks the path is valid.
 func (n *Blob) Validate() error {
 	return checkIsValid(n, n.ID, "id")
@@ -277,6 +282,11 @@ func (n *Result) Validate() error {
 	return checkNotNilAndValidate(n, n.Command, "command")
 }
 
+// Validate checks the path is valid.
+func (n *Shaders) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
 // Validate checks the path is valid.
 func (n *Slice) Validate() error {
 	return checkNotNilAndValidate(n, protoutil.OneOf(n.Array), "array")
@@ -0,0 +1,200 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// commandNameTag is the identifier of the tag holding the name of the command
+// that raised a report item.
+const commandNameTag = "TAG_COMMAND_NAME"
+
+// knownIssueTag is the identifier of the tag of the report items whitelisted
+// by their API as known issues, such as known driver bugs.
+const knownIssueTag = "TAG_KNOWN_ISSUE"
+
+// anyCommand is the command name of suppressions that apply to all commands.
+const anyCommand = "*"
+
+// RawItems returns the items of the report with their messages and tags
+// resolved.
+func (r *Report) RawItems() []*ReportItemRaw {
+	out := make([]*ReportItemRaw, len(r.Items))
+	for i, item := range r.Items {
+		raw := &ReportItemRaw{Item: item, Message: r.Msg(item.Message)}
+		for _, t := range item.Tags {
+			raw.Tags = append(raw.Tags, r.Msg(t))
+		}
+		out[i] = raw
+	}
+	return out
+}
+
+// CommandName returns the name of the command that raised the item, or an
+// empty string if the item has no command name tag.
+func (i *ReportItemRaw) CommandName() string {
+	for _, t := range i.Tags {
+		if t.Identifier == commandNameTag {
+			if v, ok := t.Arguments["command"]; ok {
+				return fmt.Sprint(v.Unpack())
+			}
+		}
+	}
+	return ""
+}
+
+// IsKnownIssue returns true if the API that raised the item whitelists it as a
+// known issue.
+func (i *ReportItemRaw) IsKnownIssue() bool {
+	for _, t := range i.Tags {
+		if t.Identifier == knownIssueTag {
+			return true
+		}
+	}
+	return false
+}
+
+// reportSuppression is a single entry of a ReportBaseline.
+type reportSuppression struct {
+	message string // The message identifier.
+	command string // The command name, or anyCommand.
+}
+
+// ReportBaseline is a set of known report issues, keyed by the identifier of
+// their message and the name of the command that raised them. Issues in the
+// baseline are suppressed, so that only new issues are reported.
+//
+// Baselines are stored as text, with one suppression per line of the form:
+//
+//	<message identifier> [command name]
+//
+// A missing or '*' command name suppresses the message for all commands.
+// Empty lines and lines starting with '#' are ignored.
+//
+// Known issues, which the APIs already whitelist when finding replay issues,
+// are suppressed by all baselines.
+type ReportBaseline struct {
+	suppressions map[reportSuppression]struct{}
+}
+
+// NewReportBaseline returns a new, empty baseline.
+func NewReportBaseline() *ReportBaseline {
+	return &ReportBaseline{suppressions: map[reportSuppression]struct{}{}}
+}
+
+// ReadReportBaseline reads a baseline from its text representation.
+func ReadReportBaseline(r io.Reader) (*ReportBaseline, error) {
+	b := NewReportBaseline()
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		switch len(fields) {
+		case 1:
+			b.suppress(fields[0], anyCommand)
+		case 2:
+			b.suppress(fields[0], fields[1])
+		default:
+			return nil, fmt.Errorf("Invalid baseline suppression at line %v: '%v'", line, text)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *ReportBaseline) suppress(message, command string) {
+	b.suppressions[reportSuppression{message, command}] = struct{}{}
+}
+
+// Add adds a suppression of the item to the baseline.
+func (b *ReportBaseline) Add(item *ReportItemRaw) {
+	command := item.CommandName()
+	if command == "" {
+		command = anyCommand
+	}
+	b.suppress(item.Message.Identifier, command)
+}
+
+// Suppresses returns true if the item is in the baseline or is a known issue.
+func (b *ReportBaseline) Suppresses(item *ReportItemRaw) bool {
+	if item.IsKnownIssue() {
+		return true
+	}
+	id := item.Message.Identifier
+	if _, ok := b.suppressions[reportSuppression{id, anyCommand}]; ok {
+		return true
+	}
+	_, ok := b.suppressions[reportSuppression{id, item.CommandName()}]
+	return ok
+}
+
+// Filter returns a copy of the report r without the items suppressed by the
+// baseline. The groups of the copy only hold the remaining items, and groups
+// without remaining items are dropped.
+func (b *ReportBaseline) Filter(r *Report) *Report {
+	out := &Report{Strings: r.Strings, Values: r.Values}
+	indices := make(map[uint32]uint32, len(r.Items))
+	for i, raw := range r.RawItems() {
+		if !b.Suppresses(raw) {
+			indices[uint32(i)] = uint32(len(out.Items))
+			out.Items = append(out.Items, raw.Item)
+		}
+	}
+	for _, g := range r.Groups {
+		items := []uint32{}
+		for _, i := range g.Items {
+			if j, ok := indices[i]; ok {
+				items = append(items, j)
+			}
+		}
+		if len(items) > 0 {
+			out.Groups = append(out.Groups, &ReportGroup{Name: g.Name, Items: items, Tags: g.Tags})
+		}
+	}
+	return out
+}
+
+// Write writes the text representation of the baseline to w, sorted by
+// message identifier and command name.
+func (b *ReportBaseline) Write(w io.Writer) error {
+	lines := make([]string, 0, len(b.suppressions))
+	for s := range b.suppressions {
+		if s.command == anyCommand {
+			lines = append(lines, s.message)
+		} else {
+			lines = append(lines, s.message+" "+s.command)
+		}
+	}
+	sort.Strings(lines)
+	if _, err := fmt.Fprintln(w, "# Known issues suppressed by gapit report, as '<message identifier> [command name]'."); err != nil {
+		return err
+	}
+	for _, l := range lines {
+		if _, err := fmt.Fprintln(w, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
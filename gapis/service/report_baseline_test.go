@@ -0,0 +1,128 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/stringtable"
+)
+
+func item(message, command string) *service.ReportItemRaw {
+	out := &service.ReportItemRaw{
+		Item:    &service.ReportItem{Severity: service.Severity_ErrorLevel},
+		Message: &stringtable.Msg{Identifier: message},
+	}
+	if command != "" {
+		out.Tags = []*stringtable.Msg{{
+			Identifier: "TAG_COMMAND_NAME",
+			Arguments:  map[string]*stringtable.Value{"command": stringtable.ToValue(command)},
+		}}
+	}
+	return out
+}
+
+func TestReportBaseline(t *testing.T) {
+	ctx := log.Testing(t)
+
+	b, err := service.ReadReportBaseline(strings.NewReader(`
+# A comment
+ERR_INVALID_ENUM glTexParameteri
+ERR_INVALID_VALUE *
+ERR_TRACE_ASSERT
+`))
+	if !assert.For(ctx, "ReadReportBaseline").ThatError(err).Succeeded() {
+		return
+	}
+
+	for _, test := range []struct {
+		item     *service.ReportItemRaw
+		expected bool
+	}{
+		{item("ERR_INVALID_ENUM", "glTexParameteri"), true},
+		{item("ERR_INVALID_ENUM", "glBindTexture"), false},
+		{item("ERR_INVALID_ENUM", ""), false},
+		{item("ERR_INVALID_VALUE", "glUniform1i"), true},
+		{item("ERR_TRACE_ASSERT", ""), true},
+		{item("ERR_INVALID_OPERATION", "glTexParameteri"), false},
+	} {
+		assert.For(ctx, "Suppresses(%v, %v)", test.item.Message.Identifier, test.item.CommandName()).
+			That(b.Suppresses(test.item)).Equals(test.expected)
+	}
+
+	_, err = service.ReadReportBaseline(strings.NewReader("ERR_INVALID_ENUM glBindTexture extra\n"))
+	assert.For(ctx, "Invalid line").ThatError(err).Failed()
+
+	written := service.NewReportBaseline()
+	written.Add(item("ERR_INVALID_VALUE", "glUniform1i"))
+	written.Add(item("ERR_INVALID_ENUM", "glBindTexture"))
+	written.Add(item("ERR_TRACE_ASSERT", ""))
+	buf := &bytes.Buffer{}
+	if !assert.For(ctx, "Write").ThatError(written.Write(buf)).Succeeded() {
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.For(ctx, "Written").ThatSlice(lines[1:]).Equals([]string{
+		"ERR_INVALID_ENUM glBindTexture",
+		"ERR_INVALID_VALUE glUniform1i",
+		"ERR_TRACE_ASSERT",
+	})
+
+	read, err := service.ReadReportBaseline(buf)
+	if !assert.For(ctx, "Read written").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "Read written suppresses").That(read.Suppresses(item("ERR_INVALID_ENUM", "glBindTexture"))).Equals(true)
+}
+
+func TestReportBaselineKnownIssues(t *testing.T) {
+	ctx := log.Testing(t)
+	known := item("ERR_REPLAY_DRIVER", "glActiveTexture")
+	known.Tags = append(known.Tags, &stringtable.Msg{Identifier: "TAG_KNOWN_ISSUE"})
+	assert.For(ctx, "IsKnownIssue").That(known.IsKnownIssue()).Equals(true)
+	assert.For(ctx, "Suppresses known").That(service.NewReportBaseline().Suppresses(known)).Equals(true)
+	assert.For(ctx, "Suppresses unknown").That(service.NewReportBaseline().Suppresses(item("ERR_REPLAY_DRIVER", "glActiveTexture"))).Equals(false)
+}
+
+func TestReportBaselineFilter(t *testing.T) {
+	ctx := log.Testing(t)
+	builder := service.NewReportBuilder()
+	for _, i := range []*service.ReportItemRaw{
+		item("ERR_INVALID_ENUM", "glTexParameteri"),
+		item("ERR_INVALID_VALUE", "glUniform1i"),
+		item("ERR_INVALID_ENUM", "glBindTexture"),
+	} {
+		builder.Add(ctx, i)
+	}
+	b, err := service.ReadReportBaseline(strings.NewReader("ERR_INVALID_ENUM glTexParameteri\nERR_INVALID_VALUE\n"))
+	if !assert.For(ctx, "ReadReportBaseline").ThatError(err).Succeeded() {
+		return
+	}
+
+	filtered := b.Filter(builder.Build())
+	got := []string{}
+	for _, raw := range filtered.RawItems() {
+		got = append(got, raw.Message.Identifier+" "+raw.CommandName())
+	}
+	assert.For(ctx, "items").ThatSlice(got).Equals([]string{"ERR_INVALID_ENUM glBindTexture"})
+	if assert.For(ctx, "groups").ThatSlice(filtered.Groups).IsLength(1) {
+		assert.For(ctx, "group items").ThatSlice(filtered.Groups[0].Items).Equals([]uint32{0})
+	}
+}
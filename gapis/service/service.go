@@ -68,7 +68,16 @@ type Service interface {
 
 	// ExportCapture returns a capture's data that can be consumed by
 	// ImportCapture or LoadCapture.
-	ExportCapture(ctx context.Context, c *path.Capture) ([]byte, error)
+	// If frames is not nil then only the given range of frames is exported.
+	ExportCapture(ctx context.Context, c *path.Capture, frames *FrameRange) ([]byte, error)
+
+	// SplitCapture divides the commands of a capture into one new capture per
+	// context or thread, returning the new capture identifiers.
+	SplitCapture(ctx context.Context, c *path.Capture, mode SplitMode) ([]*path.Capture, error)
+
+	// MergeCaptures combines captures of independent contexts into a single
+	// new capture named name, returning the new capture identifier.
+	MergeCaptures(ctx context.Context, name string, captures []*path.Capture) (*path.Capture, error)
 
 	// LoadCapture imports capture data from a local file, returning the new
 	// capture identifier.
@@ -77,6 +86,11 @@ type Service interface {
 	// SaveCapture saves the capture to a local file.
 	SaveCapture(ctx context.Context, c *path.Capture, path string) error
 
+	// ValidateCapture checks the integrity of the local capture file at path.
+	// If salvage is true and the capture is damaged, then its intact commands
+	// are imported as a new capture.
+	ValidateCapture(ctx context.Context, path string, salvage bool) (*CaptureValidation, error)
+
 	// GetDevices returns the full list of replay devices avaliable to the server.
 	// These include local replay devices and any connected Android devices.
 	// This list may change over time, as devices are connected and disconnected.
@@ -141,6 +155,9 @@ type Service interface {
 	// Find performs a search using req, streaming the results to h.
 	Find(ctx context.Context, req *FindRequest, h FindHandler) error
 
+	// DiffCaptures returns the differences between the captures a and b.
+	DiffCaptures(ctx context.Context, a, b *path.Capture, c *path.ResolveConfig) (*CaptureDiff, error)
+
 	// EnableCrashReporting enables or disables crash reporting for this session.
 	EnableCrashReporting(ctx context.Context, enable bool) error
 
@@ -246,6 +263,10 @@ func NewValue(v interface{}) *Value {
 		return &Value{Val: &Value_StateTreeNode{v}}
 	case *Stats:
 		return &Value{Val: &Value_Stats{v}}
+	case *Bandwidth:
+		return &Value{Val: &Value_Bandwidth{v}}
+	case *ShaderReport:
+		return &Value{Val: &Value_ShaderReport{v}}
 	case *api.Command:
 		return &Value{Val: &Value_Command{v}}
 	case *api.Mesh:
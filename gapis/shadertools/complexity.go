@@ -0,0 +1,87 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shadertools
+
+import "fmt"
+
+// SPIR-V opcodes used by SpirvComplexity.
+const (
+	opLine                         = 8
+	opFunction                     = 54
+	opFunctionParameter            = 55
+	opFunctionEnd                  = 56
+	opImageSampleImplicitLod       = 87
+	opImageDrefGather              = 97
+	opLabel                        = 248
+	opBranchConditional            = 250
+	opSwitch                       = 251
+	opImageSparseSampleImplicitLod = 305
+	opImageSparseDrefGather        = 315
+	opNoLine                       = 317
+	spirvMagic                     = 0x07230203
+	spirvHeaderWords               = 5
+)
+
+// Complexity holds static cost estimates of a SPIR-V module.
+type Complexity struct {
+	// The number of instructions in the function bodies, excluding labels,
+	// parameters and debug line instructions.
+	Instructions uint32
+	// The number of image sample, fetch and gather instructions.
+	TextureSamples uint32
+	// The number of conditional branches and switches.
+	Branches uint32
+}
+
+// SpirvComplexity walks the instructions of the SPIR-V binary words and
+// returns the static cost estimates of the module.
+func SpirvComplexity(words []uint32) (Complexity, error) {
+	out := Complexity{}
+	if len(words) < spirvHeaderWords || words[0] != spirvMagic {
+		return out, fmt.Errorf("Invalid SPIR-V header")
+	}
+	inFunction := false
+	for i := spirvHeaderWords; i < len(words); {
+		count, opcode := int(words[i]>>16), words[i]&0xffff
+		if count == 0 || i+count > len(words) {
+			return out, fmt.Errorf("Invalid SPIR-V instruction at word %v", i)
+		}
+		i += count
+
+		switch {
+		case opcode == opFunction:
+			inFunction = true
+			continue
+		case opcode == opFunctionEnd:
+			inFunction = false
+			continue
+		case !inFunction:
+			continue
+		}
+
+		switch {
+		case opcode == opLabel, opcode == opFunctionParameter,
+			opcode == opLine, opcode == opNoLine:
+			continue
+		case opcode >= opImageSampleImplicitLod && opcode <= opImageDrefGather,
+			opcode >= opImageSparseSampleImplicitLod && opcode <= opImageSparseDrefGather:
+			out.TextureSamples++
+		case opcode == opBranchConditional, opcode == opSwitch:
+			out.Branches++
+		}
+		out.Instructions++
+	}
+	return out, nil
+}
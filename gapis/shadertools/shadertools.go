@@ -425,3 +425,100 @@ func ParseDescriptorSets(shader []uint32, entryPoint string) (DescriptorSets, er
 
 	return res, nil
 }
+
+// ShaderInterface is the interface of a shader entry point.
+type ShaderInterface struct {
+	Inputs   []string // The names of the user defined input variables.
+	Uniforms []string // The names of the descriptor bindings.
+}
+
+// ParseInterface determines the input variables and the descriptor bindings
+// used by the entry point of the shader.
+func ParseInterface(shader []uint32, entryPoint string) (ShaderInterface, error) {
+	res := ShaderInterface{}
+	spvReflectErr := func(res C.SpvReflectResult) error {
+		if res == C.SPV_REFLECT_RESULT_SUCCESS {
+			return nil
+		}
+		return fmt.Errorf("SPIRV-Reflect failed with error code %v", res)
+	}
+	module := C.SpvReflectShaderModule{}
+	cEntryPoint := C.CString(entryPoint)
+	defer C.free(unsafe.Pointer(cEntryPoint))
+
+	shaderPtr := unsafe.Pointer(nil)
+	if len(shader) > 0 {
+		shaderPtr = unsafe.Pointer(&shader[0])
+	}
+	if err := spvReflectErr(C.spvReflectCreateShaderModule(
+		C.size_t(len(shader)*4),
+		shaderPtr,
+		&module)); err != nil {
+		return res, err
+	}
+	defer C.spvReflectDestroyShaderModule(&module)
+
+	if C.spvReflectGetEntryPoint(&module, cEntryPoint) == nil {
+		return res, fmt.Errorf("Entry point %v not found", entryPoint)
+	}
+
+	inputCount := C.uint32_t(0)
+	if err := spvReflectErr(C.spvReflectEnumerateEntryPointInputVariables(
+		&module,
+		cEntryPoint,
+		&inputCount,
+		nil)); err != nil {
+		return res, err
+	}
+	inputs := make([]*C.SpvReflectInterfaceVariable, inputCount)
+	if inputCount > 0 {
+		if err := spvReflectErr(C.spvReflectEnumerateEntryPointInputVariables(
+			&module,
+			cEntryPoint,
+			&inputCount,
+			&inputs[0])); err != nil {
+			return res, err
+		}
+	}
+	for _, input := range inputs {
+		if input.decoration_flags&C.SPV_REFLECT_DECORATION_BUILT_IN != 0 {
+			continue
+		}
+		if name := C.GoString(input.name); name != "" {
+			res.Inputs = append(res.Inputs, name)
+		}
+	}
+
+	bindingCount := C.uint32_t(0)
+	if err := spvReflectErr(C.spvReflectEnumerateEntryPointDescriptorBindings(
+		&module,
+		cEntryPoint,
+		&bindingCount,
+		nil)); err != nil {
+		return res, err
+	}
+	bindings := make([]*C.SpvReflectDescriptorBinding, bindingCount)
+	if bindingCount > 0 {
+		if err := spvReflectErr(C.spvReflectEnumerateEntryPointDescriptorBindings(
+			&module,
+			cEntryPoint,
+			&bindingCount,
+			&bindings[0])); err != nil {
+			return res, err
+		}
+	}
+	for _, binding := range bindings {
+		name := C.GoString(binding.name)
+		if name == "" && binding.type_description != nil {
+			// Uniform blocks without an instance name are named by their type.
+			name = C.GoString(binding.type_description.type_name)
+		}
+		if name != "" {
+			res.Uniforms = append(res.Uniforms, name)
+		}
+	}
+
+	sort.Strings(res.Inputs)
+	sort.Strings(res.Uniforms)
+	return res, nil
+}
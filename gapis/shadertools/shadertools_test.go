@@ -548,6 +548,36 @@ func TestParseDescriptorSets(t *testing.T) {
 	}
 }
 
+func TestSpirvComplexity(t *testing.T) {
+	ctx := log.Testing(t)
+	spv := shadertools.AssembleSpirvText(sampleBranch_spv)
+
+	complexity, err := shadertools.SpirvComplexity(spv)
+	if assert.For(ctx, "err").ThatError(err).Succeeded() {
+		assert.For(ctx, "complexity").That(complexity).Equals(shadertools.Complexity{
+			Instructions:   12,
+			TextureSamples: 1,
+			Branches:       1,
+		})
+	}
+
+	_, err = shadertools.SpirvComplexity(spv[1:])
+	assert.For(ctx, "bad header").ThatError(err).Failed()
+
+	_, err = shadertools.SpirvComplexity(append(spv, 5<<16|1)) // OpUndef missing its operands.
+	assert.For(ctx, "truncated").ThatError(err).Failed()
+}
+
+func TestParseInterface(t *testing.T) {
+	ctx := log.Testing(t)
+	spv := shadertools.AssembleSpirvText(sampleBranch_spv)
+	iface, err := shadertools.ParseInterface(spv, "main")
+	if assert.For(ctx, "err").ThatError(err).Succeeded() {
+		assert.For(ctx, "inputs").ThatSlice(iface.Inputs).Equals([]string{"uv"})
+		assert.For(ctx, "uniforms").ThatSlice(iface.Uniforms).Equals([]string{"tex"})
+	}
+}
+
 var (
 	multientrypoint_spv = `
 ; SPIR-V
@@ -671,4 +701,62 @@ var (
                OpStore %8 %66
                OpReturn
                OpFunctionEnd`
+
+	sampleBranch_spv = `
+; SPIR-V
+; Version: 1.0
+; Generator: Khronos SPIR-V Tools Assembler; 0
+; Bound: 30
+; Schema: 0
+               OpCapability Shader
+          %1 = OpExtInstImport "GLSL.std.450"
+               OpMemoryModel Logical GLSL450
+               OpEntryPoint Fragment %main "main" %uv %color %gl_FragCoord
+               OpExecutionMode %main OriginUpperLeft
+               OpName %main "main"
+               OpName %uv "uv"
+               OpName %color "color"
+               OpName %tex "tex"
+               OpName %gl_FragCoord "gl_FragCoord"
+               OpDecorate %uv Location 0
+               OpDecorate %color Location 0
+               OpDecorate %tex DescriptorSet 0
+               OpDecorate %tex Binding 1
+               OpDecorate %gl_FragCoord BuiltIn FragCoord
+       %void = OpTypeVoid
+          %3 = OpTypeFunction %void
+      %float = OpTypeFloat 32
+    %v2float = OpTypeVector %float 2
+    %v4float = OpTypeVector %float 4
+%_ptr_Input_v2float = OpTypePointer Input %v2float
+%_ptr_Input_v4float = OpTypePointer Input %v4float
+%_ptr_Output_v4float = OpTypePointer Output %v4float
+         %uv = OpVariable %_ptr_Input_v2float Input
+%gl_FragCoord = OpVariable %_ptr_Input_v4float Input
+      %color = OpVariable %_ptr_Output_v4float Output
+         %10 = OpTypeImage %float 2D 0 0 0 1 Unknown
+         %11 = OpTypeSampledImage %10
+%_ptr_UniformConstant_11 = OpTypePointer UniformConstant %11
+        %tex = OpVariable %_ptr_UniformConstant_11 UniformConstant
+       %bool = OpTypeBool
+    %float_0 = OpConstant %float 0
+         %v0 = OpConstantComposite %v4float %float_0 %float_0 %float_0 %float_0
+       %main = OpFunction %void None %3
+         %20 = OpLabel
+         %21 = OpLoad %v2float %uv
+         %22 = OpCompositeExtract %float %21 0
+         %23 = OpFOrdGreaterThan %bool %22 %float_0
+               OpSelectionMerge %26 None
+               OpBranchConditional %23 %24 %25
+         %24 = OpLabel
+         %27 = OpLoad %11 %tex
+         %28 = OpImageSampleImplicitLod %v4float %27 %21
+               OpStore %color %28
+               OpBranch %26
+         %25 = OpLabel
+               OpStore %color %v0
+               OpBranch %26
+         %26 = OpLabel
+               OpReturn
+               OpFunctionEnd`
 )
@@ -67,6 +67,7 @@ type TraceOptions struct {
 	DeferStart            bool    // Should we record extra error state
 	NoBuffer              bool    // Disable buffering.
 	HideUnknownExtensions bool    // Hide unknown extensions from the application.
+	RecordTiming          bool    // Record the CPU time spent in each command.
 }
 
 // Tracer is an option interface that a bind.Device can implement.
@@ -134,6 +135,9 @@ func (o TraceOptions) GapiiOptions() gapii.Options {
 	if o.HideUnknownExtensions {
 		flags |= gapii.HideUnknownExtensions
 	}
+	if o.RecordTiming {
+		flags |= gapii.RecordTiming
+	}
 
 	return gapii.Options{
 		o.ObserveFrameFrequency,
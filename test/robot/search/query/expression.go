@@ -80,8 +80,8 @@ func exprEqual(lhs, rhs *search.Expression) *search.Expression {
 }
 
 func exprLess(lhs, rhs *search.Expression) *search.Expression {
-	return &search.Expression{Is: &search.Expression_GreaterOrEqual{
-		GreaterOrEqual: &search.Binary{
+	return &search.Expression{Is: &search.Expression_Greater{
+		Greater: &search.Binary{
 			Lhs: rhs,
 			Rhs: lhs,
 		},
@@ -89,8 +89,8 @@ func exprLess(lhs, rhs *search.Expression) *search.Expression {
 }
 
 func exprLessOrEqual(lhs, rhs *search.Expression) *search.Expression {
-	return &search.Expression{Is: &search.Expression_Greater{
-		Greater: &search.Binary{
+	return &search.Expression{Is: &search.Expression_GreaterOrEqual{
+		GreaterOrEqual: &search.Binary{
 			Lhs: rhs,
 			Rhs: lhs,
 		},
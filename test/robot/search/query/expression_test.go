@@ -0,0 +1,38 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/test/robot/search/query"
+)
+
+func TestCompare(t *testing.T) {
+	ctx := log.Testing(t)
+	a, b := query.Name("a"), query.Name("b")
+	for _, test := range []struct {
+		name   string
+		got    query.Builder
+		expect query.Builder
+	}{
+		{"a < b", a.Less(b), b.Greater(a)},
+		{"a <= b", a.LessOrEqual(b), b.GreaterOrEqual(a)},
+	} {
+		assert.For(ctx, test.name).That(test.got.Expression()).DeepEquals(test.expect.Expression())
+	}
+}
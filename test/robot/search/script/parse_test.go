@@ -0,0 +1,43 @@
+// Copyright (C) 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package script_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/test/robot/search/query"
+	"github.com/google/gapid/test/robot/search/script"
+)
+
+func TestParseCompare(t *testing.T) {
+	ctx := log.Testing(t)
+	a, b := query.Name("a"), query.Name("b")
+	for _, test := range []struct {
+		input  string
+		expect query.Builder
+	}{
+		{"a < b", a.Less(b)},
+		{"a <= b", a.LessOrEqual(b)},
+		{"a > b", a.Greater(b)},
+		{"a >= b", a.GreaterOrEqual(b)},
+	} {
+		got, err := script.Parse(ctx, test.input)
+		if assert.For(ctx, "Parse(%v)", test.input).ThatError(err).Succeeded() {
+			assert.For(ctx, test.input).That(got.Expression()).DeepEquals(test.expect.Expression())
+		}
+	}
+}